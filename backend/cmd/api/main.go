@@ -12,9 +12,12 @@ import (
 	"github.com/fariima/backend/internal/config"
 	"github.com/fariima/backend/internal/database"
 	"github.com/fariima/backend/internal/handlers"
+	"github.com/fariima/backend/internal/mailer"
 	"github.com/fariima/backend/internal/middleware"
 	"github.com/fariima/backend/internal/repositories"
+	"github.com/fariima/backend/internal/scanner"
 	"github.com/fariima/backend/internal/services"
+	"github.com/fariima/backend/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -56,32 +59,115 @@ func main() {
 	projectRepo := repositories.NewProjectRepository(db)
 	escrowRepo := repositories.NewEscrowRepository(db)
 	disputeRepo := repositories.NewDisputeRepository(db)
+	jurorRepo := repositories.NewJurorRepository(db)
+	proposalRepo := repositories.NewProposalRepository(db)
+	stakingRepo := repositories.NewStakingRepository(db)
+	uploadRepo := repositories.NewUploadRepository(db)
+	privateFileRepo := repositories.NewPrivateFileRepository(db)
+	pinRepo := repositories.NewPinRepository(db)
+	metricRepo := repositories.NewMetricRepository(db)
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	invoiceRepo := repositories.NewInvoiceRepository(db)
 	nftRepo := repositories.NewNFTRepository(db)
+	messageRepo := repositories.NewMessageRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	emailRepo := repositories.NewEmailRepository(db)
+	jobRepo := repositories.NewJobRepository(db)
+	indexerRepo := repositories.NewIndexerRepository(db)
+
+	// Initialize mailer
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		logger.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	mailRenderer, err := mailer.NewRenderer()
+	if err != nil {
+		logger.Fatalf("Failed to load email templates: %v", err)
+	}
+	store, err := storage.New(cfg, logger)
+	if err != nil {
+		logger.Fatalf("Failed to initialize storage: %v", err)
+	}
+	storageCache, err := storage.NewCache(cfg.IPFSCacheDir, int64(cfg.IPFSCacheMaxMB)<<20)
+	if err != nil {
+		logger.Fatalf("Failed to open IPFS cache: %v", err)
+	}
+	malwareScanner, err := scanner.New(cfg, logger)
+	if err != nil {
+		logger.Fatalf("Failed to initialize malware scanner: %v", err)
+	}
 	
 	// Initialize services
 	blockchainService := services.NewBlockchainService(cfg, logger)
+	jobQueue := services.NewJobQueue(cfg, jobRepo, logger)
 	authService := services.NewAuthService(cfg, userRepo, logger)
 	userService := services.NewUserService(userRepo, redisClient, logger)
-	projectService := services.NewProjectService(projectRepo, blockchainService, logger)
-	escrowService := services.NewEscrowService(escrowRepo, blockchainService, logger)
-	disputeService := services.NewDisputeService(disputeRepo, blockchainService, logger)
-	nftService := services.NewNFTService(nftRepo, blockchainService, logger)
-	ipfsService := services.NewIPFSService(cfg, logger)
-	searchService := services.NewSearchService(projectRepo, userRepo, redisClient, logger)
-	analyticsService := services.NewAnalyticsService(db, redisClient, logger)
 	wsService := services.NewWebSocketService(logger)
+	emailService := services.NewEmailService(cfg, mail, mailRenderer, emailRepo, userRepo, logger)
+	notificationService := services.NewNotificationService(cfg, notificationRepo, userRepo, emailService, logger)
+	notificationService.RegisterChannel(services.NewInAppChannel(wsService))
+	notificationService.RegisterChannel(services.NewEmailChannel(cfg, emailService))
+	notificationService.RegisterChannel(services.NewWebhookChannel(cfg, notificationRepo))
+	rankingService := services.NewRankingService(cfg, stakingRepo, logger)
+	projectService := services.NewProjectService(projectRepo, blockchainService, notificationService, rankingService, logger)
+	escrowService := services.NewEscrowService(escrowRepo, blockchainService, logger)
+	ipfsService := services.NewIPFSService(cfg, store, storageCache, malwareScanner, logger)
+	privateFileService, err := services.NewPrivateFileService(cfg, privateFileRepo, uploadRepo, pinRepo, userRepo, projectRepo, disputeRepo, jurorRepo, ipfsService, logger)
+	if err != nil {
+		logger.Fatalf("Failed to initialize private files: %v", err)
+	}
+	pinService := services.NewPinService(cfg, pinRepo, store, logger)
+	uploadService := services.NewUploadService(cfg, uploadRepo, ipfsService, privateFileService, logger)
+	disputeFeedService := services.NewDisputeFeedService(cfg, disputeRepo, wsService, logger)
+	disputeService := services.NewDisputeService(disputeRepo, userRepo, jurorRepo, projectRepo, escrowRepo, blockchainService, uploadService, privateFileService, notificationService, disputeFeedService, logger)
+	governanceService := services.NewGovernanceService(proposalRepo, userRepo, blockchainService, logger)
+	stakingService := services.NewStakingService(stakingRepo, rankingService, blockchainService, logger)
+	jurorService := services.NewJurorService(cfg, jurorRepo, disputeRepo, userRepo, blockchainService, privateFileService, notificationService, logger)
+	credentialService, err := services.NewCredentialService(cfg, nftRepo, userRepo, logger)
+	if err != nil {
+		logger.Fatalf("Failed to initialize credential service: %v", err)
+	}
+	nftService := services.NewNFTService(cfg, nftRepo, projectRepo, userRepo, blockchainService, notificationService, logger)
+	searchService := services.NewSearchService(projectRepo, userRepo, rankingService, redisClient, logger)
+	tokens, err := services.NewTokenRegistry(cfg)
+	if err != nil {
+		logger.Fatalf("Failed to load payment tokens: %v", err)
+	}
+	ledgerService := services.NewLedgerService(cfg, ledgerRepo, invoiceRepo, escrowRepo, tokens, logger)
+	accountingService := services.NewAccountingService(cfg, ledgerService, invoiceRepo, escrowRepo, projectRepo, userRepo, tokens, logger)
+	analyticsService := services.NewAnalyticsService(cfg, db, metricRepo, ledgerService, tokens, redisClient, logger)
+	messageService := services.NewMessageService(messageRepo, projectRepo, disputeRepo, ipfsService, uploadService, wsService, logger)
+	healthService := services.NewHealthService(cfg, db, redisClient, blockchainService, logger)
+
+	// Export runtime metrics
+	if err := database.RegisterPoolMetrics(db); err != nil {
+		logger.Fatalf("Failed to register database metrics: %v", err)
+	}
+	wsService.RegisterMetrics()
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, logger)
 	userHandler := handlers.NewUserHandler(userService, logger)
-	projectHandler := handlers.NewProjectHandler(projectService, logger)
+	projectHandler := handlers.NewProjectHandler(projectService, analyticsService, logger)
 	escrowHandler := handlers.NewEscrowHandler(escrowService, logger)
 	disputeHandler := handlers.NewDisputeHandler(disputeService, logger)
+	jurorHandler := handlers.NewJurorHandler(jurorService, logger)
+	disputeFeedHandler := handlers.NewDisputeFeedHandler(disputeFeedService, logger)
+	governanceHandler := handlers.NewGovernanceHandler(governanceService, logger)
+	stakingHandler := handlers.NewStakingHandler(stakingService, logger)
 	nftHandler := handlers.NewNFTHandler(nftService, logger)
-	ipfsHandler := handlers.NewIPFSHandler(ipfsService, logger)
+	credentialHandler := handlers.NewCredentialHandler(credentialService, logger)
+	ipfsHandler := handlers.NewIPFSHandler(ipfsService, uploadService, privateFileService, logger)
 	searchHandler := handlers.NewSearchHandler(searchService, logger)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, logger)
-	wsHandler := handlers.NewWebSocketHandler(wsService, logger)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService, logger)
+	accountingHandler := handlers.NewAccountingHandler(accountingService, logger)
+	wsHandler := handlers.NewWebSocketHandler(wsService, authService, logger)
+	messageHandler := handlers.NewMessageHandler(messageService, logger)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, logger)
+	emailHandler := handlers.NewEmailHandler(emailService, logger)
+	jobHandler := handlers.NewJobHandler(jobQueue, logger)
+	healthHandler := handlers.NewHealthHandler(healthService, logger)
 
 	// Setup router
 	router := setupRouter(cfg, 
@@ -90,17 +176,85 @@ func main() {
 		projectHandler,
 		escrowHandler,
 		disputeHandler,
+		jurorHandler,
+		disputeFeedHandler,
+		governanceHandler,
+		stakingHandler,
 		nftHandler,
+		credentialHandler,
 		ipfsHandler,
 		searchHandler,
 		analyticsHandler,
+		ledgerHandler,
+		accountingHandler,
 		wsHandler,
+		messageHandler,
+		notificationHandler,
+		emailHandler,
+		jobHandler,
+		healthHandler,
 		authService,
 	)
 
-	// Start blockchain indexer
-	indexer := services.NewBlockchainIndexer(cfg, blockchainService, escrowRepo, disputeRepo, nftRepo, logger)
-	go indexer.Start(context.Background())
+	// Background workers stop when the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	indexer := services.NewBlockchainIndexer(cfg, blockchainService, indexerRepo, escrowRepo, disputeRepo, projectRepo, disputeService, jurorService, disputeFeedService, governanceService, nftService, stakingService, ledgerService, notificationService, logger)
+
+	// Start job queue
+	jobQueue.Register(services.JobIndexChain, indexer.Index, services.JobOptions{MaxAttempts: 1})
+	jobQueue.Register(services.JobProcessEmails, emailService.ProcessOutbox, services.JobOptions{MaxAttempts: 1})
+	jobQueue.Register(services.JobSendDigests, notificationService.SendDigests, services.JobOptions{MaxAttempts: 1, Timeout: 30 * time.Minute})
+	jobQueue.Register(services.JobDisputeDeadlines, disputeService.ProcessDeadlines, services.JobOptions{MaxAttempts: 1})
+	jobQueue.Register(services.JobRefreshJurorPool, jurorService.RefreshPool, services.JobOptions{MaxAttempts: 3, Timeout: 30 * time.Minute})
+	jobQueue.Register(services.JobSyncProposals, governanceService.SyncProposals, services.JobOptions{MaxAttempts: 1})
+	jobQueue.Register(services.JobRefreshRanking, rankingService.RefreshBoosts, services.JobOptions{MaxAttempts: 3})
+	jobQueue.Register(services.JobVerifyPins, pinService.VerifyPins, services.JobOptions{MaxAttempts: 1, Timeout: 6 * time.Hour})
+	jobQueue.Register(services.JobUnpinOrphans, pinService.UnpinOrphans, services.JobOptions{MaxAttempts: 3, Timeout: time.Hour})
+	jobQueue.Register(services.JobRollupMetrics, analyticsService.RollupMetrics, services.JobOptions{MaxAttempts: 3, Timeout: time.Hour})
+	jobQueue.Register(services.JobReconcileLedger, ledgerService.ReconcileLedger, services.JobOptions{MaxAttempts: 1, Timeout: 30 * time.Minute})
+	if err := jobQueue.Schedule("prune-jobs", "@daily", services.JobPruneJobs); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("chain-index", fmt.Sprintf("@every %ds", cfg.IndexerIntervalSeconds), services.JobIndexChain); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("email-outbox", "@every 5s", services.JobProcessEmails); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("notification-digest", "0 8 * * *", services.JobSendDigests); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("dispute-deadlines", "@every 1m", services.JobDisputeDeadlines); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("juror-pool", "@hourly", services.JobRefreshJurorPool); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("proposal-sync", "@every 5m", services.JobSyncProposals); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("ranking-refresh", "@hourly", services.JobRefreshRanking); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("pin-verify", "@daily", services.JobVerifyPins); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("pin-gc", "@daily", services.JobUnpinOrphans); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("metrics-rollup", "@hourly", services.JobRollupMetrics); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	if err := jobQueue.Schedule("ledger-reconcile", "*/15 * * * *", services.JobReconcileLedger); err != nil {
+		logger.Fatalf("Failed to register job schedule: %v", err)
+	}
+	workersDone := make(chan struct{})
+	go func() {
+		defer close(workersDone)
+		jobQueue.Start(workerCtx)
+	}()
 
 	// Start HTTP server
 	srv := &http.Server{
@@ -124,6 +278,7 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -132,9 +287,27 @@ func main() {
 		logger.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Running jobs see their context cancelled. Any still running after the
+	// timeout are taken back by this host's next start.
+	select {
+	case <-workersDone:
+	case <-time.After(workerShutdownTimeout):
+		logger.Warn("Job workers did not stop in time")
+	}
+
 	logger.Info("Server exited")
 }
 
+// workerShutdownTimeout bounds how long shutdown waits for running jobs.
+const workerShutdownTimeout = 30 * time.Second
+
+// Request body caps for upload routes. multipartOverhead leaves room for form
+// fields and part headers around the file itself.
+const (
+	multipartOverhead  = 1 << 20
+	maxMessageBodySize = 10*10<<20 + multipartOverhead // ten 10 MB attachments
+)
+
 func setupRouter(
 	cfg *config.Config,
 	authHandler *handlers.AuthHandler,
@@ -142,11 +315,23 @@ func setupRouter(
 	projectHandler *handlers.ProjectHandler,
 	escrowHandler *handlers.EscrowHandler,
 	disputeHandler *handlers.DisputeHandler,
+	jurorHandler *handlers.JurorHandler,
+	disputeFeedHandler *handlers.DisputeFeedHandler,
+	governanceHandler *handlers.GovernanceHandler,
+	stakingHandler *handlers.StakingHandler,
 	nftHandler *handlers.NFTHandler,
+	credentialHandler *handlers.CredentialHandler,
 	ipfsHandler *handlers.IPFSHandler,
 	searchHandler *handlers.SearchHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
+	ledgerHandler *handlers.LedgerHandler,
+	accountingHandler *handlers.AccountingHandler,
 	wsHandler *handlers.WebSocketHandler,
+	messageHandler *handlers.MessageHandler,
+	notificationHandler *handlers.NotificationHandler,
+	emailHandler *handlers.EmailHandler,
+	jobHandler *handlers.JobHandler,
+	healthHandler *handlers.HealthHandler,
 	authService *services.AuthService,
 ) *gin.Engine {
 	if cfg.GinMode == "release" {
@@ -155,14 +340,21 @@ func setupRouter(
 
 	router := gin.New()
 	router.Use(gin.Recovery())
+
+	// Probes and metrics, registered before the middleware below so they
+	// are neither logged nor rate limited
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/health", healthHandler.Readiness)
+	router.GET("/readyz", healthHandler.Readiness)
+	router.GET("/metrics", middleware.MetricsAuth(cfg), healthHandler.Metrics)
+
+	router.Use(middleware.Metrics())
 	router.Use(middleware.Logger())
 	router.Use(middleware.CORS(cfg))
 	router.Use(middleware.RateLimit(cfg))
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-	})
+	// did:web document of the credential issuer
+	router.GET("/.well-known/did.json", credentialHandler.GetDIDDocument)
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -173,14 +365,54 @@ func setupRouter(
 			auth.POST("/nonce", authHandler.GetNonce)
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/email/verify", emailHandler.VerifyEmail)
 		}
 
+		// Email provider callbacks
+		v1.GET("/email/unsubscribe", emailHandler.Unsubscribe)
+		v1.POST("/email/unsubscribe", emailHandler.Unsubscribe)
+		v1.POST("/email/bounces", emailHandler.Bounce)
+
 		// Public search
 		v1.GET("/search/projects", searchHandler.SearchProjects)
 		v1.GET("/search/users", searchHandler.SearchUsers)
 
 		// Public analytics
 		v1.GET("/analytics/platform", analyticsHandler.GetPlatformStats)
+		v1.GET("/analytics/metrics", analyticsHandler.GetMetrics)
+
+		// Public juror reputation
+		v1.GET("/jurors/leaderboard", jurorHandler.GetLeaderboard)
+		v1.GET("/jurors/:address", jurorHandler.GetProfile)
+
+		// Public dispute feed
+		publicDisputes := v1.Group("/public/disputes")
+		{
+			publicDisputes.GET("", disputeFeedHandler.ListDisputes)
+			publicDisputes.GET("/stats", disputeFeedHandler.GetStats)
+			publicDisputes.GET("/feed.rss", disputeFeedHandler.GetRSS)
+			publicDisputes.GET("/feed.atom", disputeFeedHandler.GetAtom)
+			publicDisputes.GET("/:id", disputeFeedHandler.GetDispute)
+		}
+
+		// Public NFT metadata, usable as the ERC-721 tokenURI base
+		publicNFTs := v1.Group("/public/nfts")
+		{
+			publicNFTs.GET("/:tokenId", nftHandler.GetTokenMetadata)
+			publicNFTs.GET("/:tokenId/image.svg", nftHandler.GetImage)
+		}
+
+		// Public credential verification
+		v1.POST("/public/credentials/verify", credentialHandler.VerifyCredential)
+
+		// Public DAO governance
+		governance := v1.Group("/governance")
+		{
+			governance.GET("/proposals", governanceHandler.ListProposals)
+			governance.GET("/proposals/:id", governanceHandler.GetProposal)
+			governance.GET("/proposals/:id/votes", governanceHandler.GetProposalVotes)
+			governance.GET("/voters/:address/votes", governanceHandler.GetVoterHistory)
+		}
 
 		// Protected routes
 		protected := v1.Group("")
@@ -191,9 +423,19 @@ func setupRouter(
 			{
 				users.GET("/me", userHandler.GetCurrentUser)
 				users.PUT("/me", userHandler.UpdateProfile)
+				users.PUT("/me/encryption-key", ipfsHandler.SetEncryptionKey)
+				users.PUT("/me/email", emailHandler.ChangeEmail)
+				users.POST("/me/email/verification", emailHandler.SendVerification)
+				users.POST("/me/sessions/revoke", authHandler.RevokeSessions)
+				users.GET("/me/earnings", ledgerHandler.GetEarnings)
+				users.GET("/me/spend", ledgerHandler.GetSpend)
+				users.GET("/me/statement", accountingHandler.GetStatement)
+				users.GET("/me/invoices", accountingHandler.ListInvoices)
+				users.GET("/me/invoices/:number/pdf", accountingHandler.GetInvoicePDF)
 				users.GET("/:address", userHandler.GetUserByAddress)
 				users.GET("/:address/projects", userHandler.GetUserProjects)
-				users.GET("/:address/nfts", userHandler.GetUserNFTs)
+				users.GET("/:address/nfts", nftHandler.GetUserNFTs)
+				users.GET("/:address/staking", stakingHandler.GetStaking)
 				users.POST("/:address/follow", userHandler.FollowUser)
 				users.DELETE("/:address/follow", userHandler.UnfollowUser)
 			}
@@ -227,8 +469,29 @@ func setupRouter(
 				disputes.GET("/:id", disputeHandler.GetDispute)
 				disputes.GET("", disputeHandler.GetDisputes)
 				disputes.POST("/:id/vote", disputeHandler.Vote)
-				disputes.POST("/:id/evidence", disputeHandler.SubmitEvidence)
+				disputes.POST("/:id/evidence", middleware.MaxBodySize(services.MaxEvidenceSize+multipartOverhead), disputeHandler.SubmitEvidence)
+				disputes.GET("/:id/evidence", disputeHandler.GetEvidence)
+				disputes.GET("/:id/evidence/:evidenceId/content", disputeHandler.GetEvidenceContent)
 				disputes.GET("/:id/votes", disputeHandler.GetVotes)
+				disputes.GET("/:id/eligibility", disputeHandler.GetEligibility)
+				disputes.GET("/:id/finalize-tx", disputeHandler.GetFinalizeTransaction)
+				disputes.GET("/:id/jury", jurorHandler.GetJury)
+				disputes.POST("/:id/jury/accept", jurorHandler.Accept)
+				disputes.POST("/:id/jury/decline", jurorHandler.Decline)
+			}
+
+			// Juror routes
+			jury := protected.Group("/jury")
+			{
+				jury.GET("/assignments", jurorHandler.ListAssignments)
+				jury.GET("/rewards/claim-tx", jurorHandler.GetClaimTransaction)
+			}
+
+			// Verifiable credentials
+			credentials := protected.Group("/credentials")
+			{
+				credentials.GET("/nfts/:tokenId", credentialHandler.GetNFTCredential)
+				credentials.GET("/work-history", credentialHandler.GetWorkHistoryCredential)
 			}
 
 			// NFT routes
@@ -242,8 +505,42 @@ func setupRouter(
 			// IPFS routes
 			ipfs := protected.Group("/ipfs")
 			{
-				ipfs.POST("/upload", ipfsHandler.Upload)
+				ipfs.POST("/upload", middleware.MaxBodySize(services.MaxUploadSize+multipartOverhead), ipfsHandler.Upload)
 				ipfs.GET("/:hash", ipfsHandler.Get)
+				ipfs.GET("/:hash/key", ipfsHandler.GetKey)
+			}
+
+			uploads := protected.Group("/uploads")
+			{
+				uploads.GET("", ipfsHandler.ListUploads)
+				uploads.GET("/usage", ipfsHandler.GetUsage)
+			}
+
+			// Conversation routes
+			conversations := protected.Group("/conversations")
+			{
+				conversations.POST("", messageHandler.OpenConversation)
+				conversations.GET("", messageHandler.GetConversations)
+				conversations.GET("/:id", messageHandler.GetConversation)
+				conversations.GET("/:id/messages", messageHandler.GetMessages)
+				conversations.POST("/:id/messages", middleware.MaxBodySize(maxMessageBodySize), messageHandler.SendMessage)
+				conversations.POST("/:id/read", messageHandler.MarkRead)
+				conversations.GET("/:id/reads", messageHandler.GetReadReceipts)
+				conversations.POST("/:id/typing", messageHandler.Typing)
+			}
+
+			// Notification routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", notificationHandler.GetNotifications)
+				notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+				notifications.PUT("/:id/read", notificationHandler.MarkRead)
+				notifications.PUT("/:id/unread", notificationHandler.MarkUnread)
+				notifications.POST("/read-all", notificationHandler.MarkAllRead)
+				notifications.GET("/preferences", notificationHandler.GetPreferences)
+				notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+				notifications.PUT("/webhook", notificationHandler.SetWebhook)
+				notifications.DELETE("/webhook", notificationHandler.DeleteWebhook)
 			}
 
 			// Analytics
@@ -252,10 +549,25 @@ func setupRouter(
 				analytics.GET("/user/:address", analyticsHandler.GetUserStats)
 				analytics.GET("/project/:id", analyticsHandler.GetProjectStats)
 			}
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.Admin(cfg))
+			{
+				admin.GET("/jobs", jobHandler.ListJobs)
+				admin.GET("/jobs/stats", jobHandler.GetStats)
+				admin.GET("/jobs/:id", jobHandler.GetJob)
+				admin.POST("/jobs/:id/retry", jobHandler.RetryJob)
+				admin.GET("/schedules", jobHandler.ListSchedules)
+				admin.GET("/uploads/quarantine", ipfsHandler.ListQuarantined)
+				admin.GET("/uploads/:id/content", ipfsHandler.GetQuarantinedContent)
+				admin.POST("/uploads/:id/review", ipfsHandler.ReviewUpload)
+			}
 		}
 
 		// WebSocket
 		v1.GET("/ws", wsHandler.HandleConnection)
+		v1.GET("/ws/public", wsHandler.HandlePublicConnection)
 	}
 
 	return router
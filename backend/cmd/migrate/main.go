@@ -78,6 +78,34 @@ func migrateUp(db *gorm.DB) {
 		&models.Evidence{},
 		&models.Vote{},
 		&models.NFT{},
+		&models.Conversation{},
+		&models.Message{},
+		&models.MessageAttachment{},
+		&models.ConversationRead{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.NotificationWebhook{},
+		&models.EmailMessage{},
+		&models.EmailSuppression{},
+		&models.EmailToken{},
+		&models.Job{},
+		&models.JobSchedule{},
+		&models.IndexerCursor{},
+		&models.JurorPoolMember{},
+		&models.JurorDraw{},
+		&models.JurorAssignment{},
+		&models.JurorReward{},
+		&models.Proposal{},
+		&models.ProposalVote{},
+		&models.StakeAccount{},
+		&models.StakeEvent{},
+		&models.VestingSchedule{},
+		&models.Upload{},
+		&models.PrivateFile{},
+		&models.FileGrant{},
+		&models.DailyMetric{},
+		&models.LedgerEntry{},
+		&models.Invoice{},
 	}
 
 	for i, model := range tables {
@@ -91,6 +119,10 @@ func migrateUp(db *gorm.DB) {
 		}
 	}
 
+	if err := database.ClearEmptyEmails(db); err != nil {
+		fmt.Printf("❌ خطا: %v\n", err)
+	}
+
 	fmt.Println()
 	fmt.Println("✨ همه جداول با موفقیت ایجاد شدند!")
 	fmt.Println()
@@ -104,6 +136,34 @@ func migrateDown(db *gorm.DB) {
 	fmt.Println()
 
 	tables := []interface{}{
+		&models.Invoice{},
+		&models.LedgerEntry{},
+		&models.DailyMetric{},
+		&models.FileGrant{},
+		&models.PrivateFile{},
+		&models.Upload{},
+		&models.VestingSchedule{},
+		&models.StakeEvent{},
+		&models.StakeAccount{},
+		&models.ProposalVote{},
+		&models.Proposal{},
+		&models.JurorReward{},
+		&models.JurorAssignment{},
+		&models.JurorDraw{},
+		&models.JurorPoolMember{},
+		&models.IndexerCursor{},
+		&models.JobSchedule{},
+		&models.Job{},
+		&models.EmailToken{},
+		&models.EmailSuppression{},
+		&models.EmailMessage{},
+		&models.NotificationWebhook{},
+		&models.NotificationPreference{},
+		&models.Notification{},
+		&models.ConversationRead{},
+		&models.MessageAttachment{},
+		&models.Message{},
+		&models.Conversation{},
 		&models.NFT{},
 		&models.Vote{},
 		&models.Evidence{},
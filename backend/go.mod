@@ -9,9 +9,9 @@ require (
 	github.com/joho/godotenv v1.5.1
 	gorm.io/gorm v1.25.5
 	gorm.io/driver/postgres v1.5.4
+	gorm.io/datatypes v1.2.0
 	github.com/redis/go-redis/v9 v9.3.0
 	github.com/gorilla/websocket v1.5.1
-	github.com/ipfs/go-ipfs-api v0.7.0
 	github.com/sirupsen/logrus v1.9.3
 	github.com/swaggo/gin-swagger v1.6.0
 	github.com/swaggo/swag v1.16.2
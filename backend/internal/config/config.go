@@ -41,12 +41,26 @@ type Config struct {
 	FARITokenContract string
 	DAOContract      string
 	NFTContract      string
+	Stablecoins      []string // SYMBOL:address:decimals of accepted payment tokens
+	InvoicePrefix    string   // Invoice numbers are PREFIX-YEAR-SEQUENCE
 
 	// IPFS
 	IPFSAPIUrl     string
 	IPFSGatewayURL string
 	PinataAPIKey   string
 	PinataSecretKey string
+	KuboAPIURL     string
+	StorageDir     string
+	StorageBackends []string
+	StorageTimeoutSeconds int
+	StorageMaxRetries int
+	UploadQuotaMB  int
+	IPFSCacheDir   string
+	IPFSCacheMaxMB int
+	PinRetentionDays int
+	MalwareScanner string
+	ClamAVAddress  string
+	ScanTimeoutSeconds int
 
 	// JWT
 	JWTSecret          string
@@ -56,6 +70,7 @@ type Config struct {
 	IndexerStartBlock     int64
 	IndexerBatchSize      int
 	IndexerIntervalSeconds int
+	RPCMaxHeadAgeSeconds   int // Readiness fails once the RPC node's latest block is older
 
 	// Rate Limiting
 	RateLimitRequests     int
@@ -69,6 +84,40 @@ type Config struct {
 	// Logging
 	LogLevel  string
 	LogFormat string
+
+	// Monitoring
+	MetricsToken string // Bearer token required by /metrics; empty leaves it open, outside release mode
+
+	// Background jobs
+	JobWorkers int
+
+	// Disputes
+	JurorsPerDispute int
+
+	// Stake-weighted ranking
+	RankingMaxBoost        int // Percent; 0 disables the boost
+	RankingMinStake        int // FARI
+	RankingSaturationStake int // FARI; effective stake at which the boost is capped
+
+	// Verifiable credentials
+	CredentialSigningKey string // Hex secp256k1 private key
+	FileEncryptionKey    string // Hex 32-byte key that wraps private file keys
+
+	// Admin
+	AdminAddresses []string
+
+	// Email
+	AppBaseURL        string
+	PublicAPIURL      string
+	MailDriver        string // smtp, file, stdout
+	MailFrom          string
+	MailFileDir       string
+	MailMaxAttempts   int
+	MailWebhookSecret string
+	SMTPHost          string
+	SMTPPort          int
+	SMTPUsername      string
+	SMTPPassword      string
 }
 
 func Load() (*Config, error) {
@@ -107,12 +156,31 @@ func Load() (*Config, error) {
 		FARITokenContract: getEnv("FARI_TOKEN_CONTRACT", ""),
 		DAOContract:      getEnv("DAO_CONTRACT", ""),
 		NFTContract:      getEnv("NFT_CONTRACT", ""),
+		Stablecoins:      getEnvAsSlice("STABLECOINS", []string{
+			"USDC:0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359:6",
+			"USDC.e:0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174:6",
+			"USDT:0xc2132D05D31c914a87C6611C10748AEb04B58e8F:6",
+			"DAI:0x8f3Cf7ad23Cd3CaDbD9735AFf958023239c6A063:18",
+		}),
+		InvoicePrefix:    getEnv("INVOICE_PREFIX", "FAR"),
 
 		// IPFS
 		IPFSAPIUrl:     getEnv("IPFS_API_URL", "https://api.pinata.cloud"),
 		IPFSGatewayURL: getEnv("IPFS_GATEWAY_URL", "https://gateway.pinata.cloud/ipfs/"),
 		PinataAPIKey:   getEnv("PINATA_API_KEY", ""),
 		PinataSecretKey: getEnv("PINATA_SECRET_KEY", ""),
+		KuboAPIURL:     getEnv("KUBO_API_URL", "http://localhost:5001"),
+		StorageDir:     getEnv("STORAGE_DIR", "./tmp/ipfs"),
+		StorageBackends: getEnvAsSlice("STORAGE_BACKENDS", []string{"pinata"}),
+		StorageTimeoutSeconds: getEnvAsInt("STORAGE_TIMEOUT_SECONDS", 120),
+		StorageMaxRetries: getEnvAsInt("STORAGE_MAX_RETRIES", 3),
+		UploadQuotaMB:  getEnvAsInt("UPLOAD_QUOTA_MB", 1024),
+		IPFSCacheDir:   getEnv("IPFS_CACHE_DIR", "./tmp/ipfs-cache"),
+		IPFSCacheMaxMB: getEnvAsInt("IPFS_CACHE_MAX_MB", 1024),
+		PinRetentionDays: getEnvAsInt("PIN_RETENTION_DAYS", 14),
+		MalwareScanner: getEnv("MALWARE_SCANNER", "local"),
+		ClamAVAddress:  getEnv("CLAMAV_ADDRESS", "tcp://localhost:3310"),
+		ScanTimeoutSeconds: getEnvAsInt("SCAN_TIMEOUT_SECONDS", 300),
 
 		// JWT
 		JWTSecret:          getEnv("JWT_SECRET", "change-me-in-production"),
@@ -122,6 +190,7 @@ func Load() (*Config, error) {
 		IndexerStartBlock:     int64(getEnvAsInt("INDEXER_START_BLOCK", 0)),
 		IndexerBatchSize:      getEnvAsInt("INDEXER_BATCH_SIZE", 1000),
 		IndexerIntervalSeconds: getEnvAsInt("INDEXER_INTERVAL_SECONDS", 10),
+		RPCMaxHeadAgeSeconds:   getEnvAsInt("RPC_MAX_HEAD_AGE_SECONDS", 120),
 
 		// Rate Limiting
 		RateLimitRequests:     getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
@@ -135,6 +204,40 @@ func Load() (*Config, error) {
 		// Logging
 		LogLevel:  getEnv("LOG_LEVEL", "info"),
 		LogFormat: getEnv("LOG_FORMAT", "json"),
+
+		// Monitoring
+		MetricsToken: getEnv("METRICS_TOKEN", ""),
+
+		// Background jobs
+		JobWorkers: getEnvAsInt("JOB_WORKERS", 4),
+
+		// Disputes
+		JurorsPerDispute: getEnvAsInt("JURORS_PER_DISPUTE", 5),
+
+		// Stake-weighted ranking
+		RankingMaxBoost:        getEnvAsInt("RANKING_MAX_BOOST", 50),
+		RankingMinStake:        getEnvAsInt("RANKING_MIN_STAKE", 100),
+		RankingSaturationStake: getEnvAsInt("RANKING_SATURATION_STAKE", 10000),
+
+		// Verifiable credentials
+		CredentialSigningKey: getEnv("CREDENTIAL_SIGNING_KEY", ""),
+		FileEncryptionKey:    getEnv("FILE_ENCRYPTION_KEY", ""),
+
+		// Admin
+		AdminAddresses: getEnvAsSlice("ADMIN_ADDRESSES", []string{}),
+
+		// Email
+		AppBaseURL:        strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:3000"), "/"),
+		PublicAPIURL:      strings.TrimRight(getEnv("PUBLIC_API_URL", "http://localhost:8080"), "/"),
+		MailDriver:        getEnv("MAIL_DRIVER", "stdout"),
+		MailFrom:          getEnv("MAIL_FROM", "Fariima <no-reply@fariima.io>"),
+		MailFileDir:       getEnv("MAIL_FILE_DIR", "./tmp/mail"),
+		MailMaxAttempts:   getEnvAsInt("MAIL_MAX_ATTEMPTS", 5),
+		MailWebhookSecret: getEnv("MAIL_WEBHOOK_SECRET", ""),
+		SMTPHost:          getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:          getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:      getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:      getEnv("SMTP_PASSWORD", ""),
 	}
 
 	return cfg, cfg.Validate()
@@ -145,6 +248,22 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("JWT_SECRET must be set in production")
 	}
 
+	if c.CredentialSigningKey == "" && c.GinMode == "release" {
+		return fmt.Errorf("CREDENTIAL_SIGNING_KEY must be set in production")
+	}
+
+	if c.FileEncryptionKey == "" && c.GinMode == "release" {
+		return fmt.Errorf("FILE_ENCRYPTION_KEY must be set in production")
+	}
+
+	if c.MetricsToken == "" && c.GinMode == "release" {
+		return fmt.Errorf("METRICS_TOKEN must be set in production")
+	}
+
+	if c.MalwareScanner != "clamav" && c.GinMode == "release" {
+		return fmt.Errorf("MALWARE_SCANNER must be clamav in production")
+	}
+
 	if c.DBPassword == "" {
 		return fmt.Errorf("DB_PASSWORD is required")
 	}
@@ -175,6 +294,16 @@ func (c *Config) GetChainID() int64 {
 	return c.TestnetChainID
 }
 
+// IsAdmin reports whether the wallet address is listed in ADMIN_ADDRESSES.
+func (c *Config) IsAdmin(address string) bool {
+	for _, admin := range c.AdminAddresses {
+		if address != "" && strings.EqualFold(strings.TrimSpace(admin), address) {
+			return true
+		}
+	}
+	return false
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
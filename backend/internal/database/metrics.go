@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/fariima/backend/internal/metrics"
+	"gorm.io/gorm"
+)
+
+// RegisterPoolMetrics exports the connection pool statistics of db.
+func RegisterPoolMetrics(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	stat := func(fn func(sql.DBStats) float64) func() float64 {
+		return func() float64 { return fn(sqlDB.Stats()) }
+	}
+	metrics.NewGaugeFunc("fariima_db_connections_max_open", "Maximum number of open database connections.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	metrics.NewGaugeFunc("fariima_db_connections", "Open database connections by state.", metrics.Labels{"state": "in_use"},
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	metrics.NewGaugeFunc("fariima_db_connections", "Open database connections by state.", metrics.Labels{"state": "idle"},
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	metrics.NewCounterFunc("fariima_db_connection_waits_total", "Times a query waited for a free database connection.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	metrics.NewCounterFunc("fariima_db_connection_wait_seconds_total", "Time spent waiting for a free database connection.", nil,
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	metrics.NewCounterFunc("fariima_db_connections_closed_total", "Database connections closed by the pool, by reason.", metrics.Labels{"reason": "max_idle"},
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	metrics.NewCounterFunc("fariima_db_connections_closed_total", "Database connections closed by the pool, by reason.", metrics.Labels{"reason": "max_idle_time"},
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }))
+	metrics.NewCounterFunc("fariima_db_connections_closed_total", "Database connections closed by the pool, by reason.", metrics.Labels{"reason": "max_lifetime"},
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+	return nil
+}
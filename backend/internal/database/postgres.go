@@ -14,7 +14,8 @@ func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
 	dsn := cfg.GetDatabaseDSN()
 	
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 	
 	if err != nil {
@@ -34,7 +35,7 @@ func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
 }
 
 func RunMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Follow{},
 		&models.Project{},
@@ -46,5 +47,43 @@ func RunMigrations(db *gorm.DB) error {
 		&models.Evidence{},
 		&models.Vote{},
 		&models.NFT{},
-	)
+		&models.Conversation{},
+		&models.Message{},
+		&models.MessageAttachment{},
+		&models.ConversationRead{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.NotificationWebhook{},
+		&models.EmailMessage{},
+		&models.EmailSuppression{},
+		&models.EmailToken{},
+		&models.Job{},
+		&models.JobSchedule{},
+		&models.IndexerCursor{},
+		&models.JurorPoolMember{},
+		&models.JurorDraw{},
+		&models.JurorAssignment{},
+		&models.JurorReward{},
+		&models.Proposal{},
+		&models.ProposalVote{},
+		&models.StakeAccount{},
+		&models.StakeEvent{},
+		&models.VestingSchedule{},
+		&models.Upload{},
+		&models.PrivateFile{},
+		&models.FileGrant{},
+		&models.DailyMetric{},
+		&models.LedgerEntry{},
+		&models.Invoice{},
+	); err != nil {
+		return err
+	}
+	return ClearEmptyEmails(db)
+}
+
+// ClearEmptyEmails unsets the empty addresses wallet users were created with
+// before users.email became nullable, so they no longer collide on its
+// unique index.
+func ClearEmptyEmails(db *gorm.DB) error {
+	return db.Unscoped().Model(&models.User{}).Where("email = ''").Update("email", nil).Error
 }
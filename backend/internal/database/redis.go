@@ -15,7 +15,6 @@ func NewRedisClient(cfg *config.Config) *redis.Client {
 	})
 }
 
-func PingRedis(client *redis.Client) error {
-	ctx := context.Background()
+func PingRedis(ctx context.Context, client *redis.Client) error {
 	return client.Ping(ctx).Err()
 }
@@ -0,0 +1,146 @@
+// Package envelope implements the envelope encryption used for private
+// files. Each file is encrypted with its own random data key. The data key is
+// wrapped with the server's key, so the API can stream decrypted content, and
+// for every authorized user with the encryption public key from their wallet
+// (eth_getEncryptionPublicKey), so they can decrypt it client-side with
+// eth_decrypt.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// KeySize is the size of data keys and the server's key encryption key.
+const KeySize = 32
+
+// BoxVersion is the eth_decrypt scheme of keys wrapped for users.
+const BoxVersion = "x25519-xsalsa20-poly1305"
+
+var ErrInvalidKey = errors.New("invalid key")
+
+// GenerateKey returns a random data key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ParseKey decodes a hex key encryption key.
+func ParseKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("%w: expected %d hex-encoded bytes", ErrInvalidKey, KeySize)
+	}
+	return key, nil
+}
+
+// WrapKey encrypts a data key with the key encryption key using AES-256-GCM.
+// The result is base64 of nonce || ciphertext.
+func WrapKey(kek, dataKey []byte) (string, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, dataKey, nil)), nil
+}
+
+// UnwrapKey reverses WrapKey.
+func UnwrapKey(kek []byte, wrapped string) ([]byte, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, ErrInvalidKey
+	}
+	dataKey, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return dataKey, nil
+}
+
+// BoxMessage is the payload eth_decrypt accepts, once JSON-encoded and
+// hex-encoded.
+type BoxMessage struct {
+	Version        string `json:"version"`
+	Nonce          string `json:"nonce"`
+	EphemPublicKey string `json:"ephemPublicKey"`
+	Ciphertext     string `json:"ciphertext"`
+}
+
+// ParsePublicKey decodes a base64 x25519 public key as returned by
+// eth_getEncryptionPublicKey.
+func ParsePublicKey(s string) (*[32]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(data) != 32 {
+		return nil, fmt.Errorf("%w: expected a base64 x25519 public key", ErrInvalidKey)
+	}
+	var key [32]byte
+	copy(key[:], data)
+	return &key, nil
+}
+
+// WrapKeyFor encrypts a data key for a user's encryption public key. eth_decrypt
+// returns a string, so the sealed message is the base64 data key.
+func WrapKeyFor(publicKey string, dataKey []byte) (*BoxMessage, error) {
+	recipient, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemPublic, ephemPrivate, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	message := []byte(base64.StdEncoding.EncodeToString(dataKey))
+	sealed := box.Seal(nil, message, &nonce, recipient, ephemPrivate)
+
+	return &BoxMessage{
+		Version:        BoxVersion,
+		Nonce:          base64.StdEncoding.EncodeToString(nonce[:]),
+		EphemPublicKey: base64.StdEncoding.EncodeToString(ephemPublic[:]),
+		Ciphertext:     base64.StdEncoding.EncodeToString(sealed),
+	}, nil
+}
+
+// EthDecryptPayload returns the message in the hex form passed to eth_decrypt.
+func (m *BoxMessage) EthDecryptPayload() string {
+	data, _ := json.Marshal(m)
+	return "0x" + hex.EncodeToString(data)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
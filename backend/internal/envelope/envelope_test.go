@@ -0,0 +1,314 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func encrypt(t *testing.T, key, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Odd-sized writes, so chunks are filled across Write calls
+	for rest := plain; len(rest) > 0; {
+		n := 1000
+		if n > len(rest) {
+			n = len(rest)
+		}
+		if _, err := w.Write(rest[:n]); err != nil {
+			t.Fatal(err)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(key, sealed []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(sealed), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+var roundTripSizes = []int{0, 1, 1000, ChunkSize - 1, ChunkSize, ChunkSize + 1, 2 * ChunkSize, 3*ChunkSize + 17}
+
+func TestRoundTrip(t *testing.T) {
+	key := testKey(t)
+
+	for _, size := range roundTripSizes {
+		plain := randomBytes(t, size)
+		sealed := encrypt(t, key, plain)
+
+		// A full last chunk is sealed as the last one; only empty content
+		// gets an empty chunk
+		chunks := (size + ChunkSize - 1) / ChunkSize
+		if chunks == 0 {
+			chunks = 1
+		}
+		if want := headerSize + size + chunks*tagSize; len(sealed) != want {
+			t.Errorf("size %d: sealed %d bytes, want %d", size, len(sealed), want)
+		}
+
+		got, err := decrypt(key, sealed)
+		if err != nil {
+			t.Errorf("size %d: decrypt: %v", size, err)
+			continue
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("size %d: decrypted content differs", size)
+		}
+	}
+}
+
+func TestReadSeeker(t *testing.T) {
+	key := testKey(t)
+
+	for _, size := range roundTripSizes {
+		plain := randomBytes(t, size)
+		sealed := encrypt(t, key, plain)
+
+		r, plainSize, err := NewReadSeeker(bytes.NewReader(sealed), int64(len(sealed)), key)
+		if err != nil {
+			t.Errorf("size %d: %v", size, err)
+			continue
+		}
+		if plainSize != int64(size) {
+			t.Errorf("size %d: reported plaintext size %d", size, plainSize)
+		}
+
+		got, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("size %d: reading everything: %v", size, err)
+		}
+
+		// Ranges within a chunk, across chunk boundaries and at the end
+		for _, rng := range [][2]int{{0, 10}, {ChunkSize - 5, ChunkSize + 5}, {size - 3, size}, {size / 2, size}} {
+			start, end := rng[0], rng[1]
+			if start < 0 || end > size || start > end {
+				continue
+			}
+			if _, err := r.Seek(int64(start), io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			part := make([]byte, end-start)
+			if _, err := io.ReadFull(r, part); err != nil {
+				t.Errorf("size %d: reading [%d, %d): %v", size, start, end, err)
+				continue
+			}
+			if !bytes.Equal(part, plain[start:end]) {
+				t.Errorf("size %d: range [%d, %d) differs", size, start, end)
+			}
+		}
+
+		if pos, _ := r.Seek(0, io.SeekEnd); pos != int64(size) {
+			t.Errorf("size %d: SeekEnd returned %d", size, pos)
+		}
+		if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+			t.Errorf("size %d: read at the end returned %d, %v", size, n, err)
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	key := testKey(t)
+	plain := randomBytes(t, 3*ChunkSize+100) // Three full chunks and a short last one
+	sealed := encrypt(t, key, plain)
+
+	chunk := func(i int) []byte {
+		start := headerSize + i*sealedChunk
+		end := start + sealedChunk
+		if end > len(sealed) {
+			end = len(sealed)
+		}
+		return sealed[start:end]
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(append([][]byte{sealed[:headerSize]}, parts...), nil)
+	}
+
+	flipped := append([]byte(nil), sealed...)
+	flipped[headerSize+ChunkSize+100] ^= 1
+
+	header := append([]byte(nil), sealed...)
+	header[len(magic)+1] ^= 1 // Nonce prefix
+
+	tests := []struct {
+		name   string
+		sealed []byte
+	}{
+		{"last chunk dropped", join(chunk(0), chunk(1), chunk(2))},
+		{"cut at a chunk boundary", sealed[:headerSize+2*sealedChunk]},
+		{"cut inside a chunk", sealed[:len(sealed)-1]},
+		{"cut inside the header", sealed[:headerSize-1]},
+		{"chunks swapped", join(chunk(1), chunk(0), chunk(2), chunk(3))},
+		{"chunk repeated", join(chunk(0), chunk(0), chunk(1), chunk(2), chunk(3))},
+		{"last chunk moved", join(chunk(0), chunk(3), chunk(1), chunk(2))},
+		{"chunk dropped", join(chunk(0), chunk(2), chunk(3))},
+		{"bit flipped", flipped},
+		{"nonce prefix changed", header},
+		{"data appended", append(append([]byte(nil), sealed...), 0)},
+		{"bad magic", append([]byte("XENC"), sealed[len(magic):]...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decrypt(key, tt.sealed); !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Reader: got %v, want ErrCorrupt", err)
+			}
+
+			r, _, err := NewReadSeeker(bytes.NewReader(tt.sealed), int64(len(tt.sealed)), key)
+			if err == nil {
+				_, err = io.ReadAll(r)
+			}
+			if !errors.Is(err, ErrCorrupt) {
+				t.Errorf("ReadSeeker: got %v, want ErrCorrupt", err)
+			}
+		})
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	sealed := encrypt(t, testKey(t), []byte("evidence"))
+	if _, err := decrypt(testKey(t), sealed); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("got %v, want ErrCorrupt", err)
+	}
+	if _, err := NewWriter(io.Discard, []byte("short")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("NewWriter with a short key: got %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestWrapKey(t *testing.T) {
+	kek, dataKey := testKey(t), testKey(t)
+
+	wrapped, err := WrapKey(kek, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnwrapKey(kek, wrapped)
+	if err != nil || !bytes.Equal(got, dataKey) {
+		t.Fatalf("UnwrapKey: %x, %v", got, err)
+	}
+
+	tests := []struct {
+		name    string
+		kek     []byte
+		wrapped string
+	}{
+		{"other key", testKey(t), wrapped},
+		{"not base64", kek, "!"},
+		{"too short", kek, base64.StdEncoding.EncodeToString([]byte("short"))},
+		{"short key", []byte("short"), wrapped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnwrapKey(tt.kek, tt.wrapped); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("got %v, want ErrInvalidKey", err)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	valid := strings.Repeat("ab", KeySize)
+
+	tests := []struct {
+		input string
+		ok    bool
+	}{
+		{valid, true},
+		{"0x" + valid, true},
+		{valid[:len(valid)-2], false},
+		{strings.Repeat("zz", KeySize), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		_, err := ParseKey(tt.input)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("ParseKey(%q): got error %v", tt.input, err)
+		}
+	}
+}
+
+// TestWrapKeyFor opens the message the way eth_decrypt does.
+func TestWrapKeyFor(t *testing.T) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKey := testKey(t)
+
+	message, err := WrapKeyFor(base64.StdEncoding.EncodeToString(public[:]), dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := hex.DecodeString(strings.TrimPrefix(message.EthDecryptPayload(), "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BoxMessage
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Version != BoxVersion {
+		t.Errorf("version is %q, want %q", decoded.Version, BoxVersion)
+	}
+
+	decode := func(s string) []byte {
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	var nonce [24]byte
+	var ephemPublic [32]byte
+	copy(nonce[:], decode(decoded.Nonce))
+	copy(ephemPublic[:], decode(decoded.EphemPublicKey))
+
+	opened, ok := box.Open(nil, decode(decoded.Ciphertext), &nonce, &ephemPublic, private)
+	if !ok {
+		t.Fatal("box.Open failed")
+	}
+	if !bytes.Equal(decode(string(opened)), dataKey) {
+		t.Errorf("opened %q, want the base64 data key", opened)
+	}
+
+	if _, err := WrapKeyFor("not a key", dataKey); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("invalid public key: got %v, want ErrInvalidKey", err)
+	}
+}
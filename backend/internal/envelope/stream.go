@@ -0,0 +1,308 @@
+package envelope
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Encrypted content is a header followed by chunks sealed with AES-256-GCM
+// under the data key (the STREAM construction):
+//
+//	header: "FENC" || version (0x01) || 7-byte random nonce prefix
+//	chunk:  AES-GCM(plaintext, nonce = prefix || uint32 BE counter || last)
+//
+// Every chunk holds ChunkSize bytes of plaintext except the last, which may
+// be shorter or empty and is sealed with last = 1. Chunks cannot be
+// reordered, dropped or truncated without failing authentication.
+const (
+	ChunkSize = 64 << 10
+
+	magic        = "FENC"
+	version      = 1
+	prefixSize   = 7
+	headerSize   = len(magic) + 1 + prefixSize
+	tagSize      = 16
+	sealedChunk  = ChunkSize + tagSize
+	counterLimit = 1<<32 - 1
+)
+
+var ErrCorrupt = errors.New("encrypted content is corrupt")
+
+type writer struct {
+	dst     io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	closed  bool
+}
+
+// NewWriter returns a writer that encrypts to dst with dataKey. Close must be
+// called to seal the final chunk; it does not close dst.
+func NewWriter(dst io.Writer, dataKey []byte) (io.WriteCloser, error) {
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte(magic), version)
+	if _, err := dst.Write(append(header, prefix...)); err != nil {
+		return nil, err
+	}
+
+	return &writer{
+		dst:    dst,
+		aead:   aead,
+		prefix: prefix,
+		buf:    make([]byte, 0, ChunkSize),
+	}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("envelope: write after close")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// A full buffer is only sealed once more data arrives, so that the
+		// last chunk is always sealed by Close
+		if len(w.buf) == ChunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.seal(true)
+}
+
+func (w *writer) seal(last bool) error {
+	if w.counter == counterLimit {
+		return errors.New("envelope: content too large")
+	}
+
+	sealed := w.aead.Seal(nil, chunkNonce(w.prefix, w.counter, last), w.buf, nil)
+	if _, err := w.dst.Write(sealed); err != nil {
+		return err
+	}
+	w.counter++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+type reader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	chunk   []byte
+	plain   []byte
+	done    bool
+}
+
+// NewReader returns a reader that decrypts content written by NewWriter.
+// Reads fail with ErrCorrupt if the content was tampered with or truncated.
+func NewReader(src io.Reader, dataKey []byte) (io.Reader, error) {
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, ErrCorrupt
+	}
+	if string(header[:len(magic)]) != magic || header[len(magic)] != version {
+		return nil, ErrCorrupt
+	}
+
+	return &reader{
+		src:    bufio.NewReaderSize(src, sealedChunk+1),
+		aead:   aead,
+		prefix: header[len(magic)+1:],
+		chunk:  make([]byte, sealedChunk),
+	}, nil
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *reader) open() error {
+	n, err := io.ReadFull(r.src, r.chunk)
+	switch err {
+	case nil:
+		// A full chunk is the last one only if nothing follows it
+		_, peekErr := r.src.Peek(1)
+		r.done = peekErr == io.EOF
+	case io.ErrUnexpectedEOF, io.EOF:
+		r.done = true
+	default:
+		return err
+	}
+	if n < tagSize {
+		return ErrCorrupt
+	}
+
+	plain, err := r.aead.Open(r.chunk[:0], chunkNonce(r.prefix, r.counter, r.done), r.chunk[:n], nil)
+	if err != nil {
+		return ErrCorrupt
+	}
+	r.counter++
+	r.plain = plain
+	return nil
+}
+
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[prefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type readSeeker struct {
+	src       io.ReadSeeker
+	aead      cipher.AEAD
+	prefix    []byte
+	chunks    int64
+	lastChunk int64 // Sealed size of the last chunk
+	size      int64 // Plaintext size
+	pos       int64
+
+	loaded int64 // Index of the chunk in plain, or -1
+	chunk  []byte
+	plain  []byte
+}
+
+// NewReadSeeker decrypts content written by NewWriter with random access,
+// for serving byte ranges. size is the size of the encrypted content. It
+// returns the plaintext size along with the reader.
+func NewReadSeeker(src io.ReadSeeker, size int64, dataKey []byte) (io.ReadSeeker, int64, error) {
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, 0, ErrCorrupt
+	}
+	if string(header[:len(magic)]) != magic || header[len(magic)] != version {
+		return nil, 0, ErrCorrupt
+	}
+
+	body := size - int64(headerSize)
+	chunks := (body + sealedChunk - 1) / sealedChunk
+	lastChunk := body - (chunks-1)*sealedChunk
+	if chunks == 0 || lastChunk < tagSize {
+		return nil, 0, ErrCorrupt
+	}
+
+	return &readSeeker{
+		src:       src,
+		aead:      aead,
+		prefix:    header[len(magic)+1:],
+		chunks:    chunks,
+		lastChunk: lastChunk,
+		size:      body - chunks*tagSize,
+		loaded:    -1,
+		chunk:     make([]byte, sealedChunk),
+	}, body - chunks*tagSize, nil
+}
+
+func (r *readSeeker) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+
+	index := r.pos / ChunkSize
+	if index != r.loaded {
+		if err := r.load(index); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.plain[r.pos-index*ChunkSize:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *readSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("envelope: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("envelope: negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+func (r *readSeeker) load(index int64) error {
+	sealedSize := int64(sealedChunk)
+	last := index == r.chunks-1
+	if last {
+		sealedSize = r.lastChunk
+	}
+
+	if _, err := r.src.Seek(int64(headerSize)+index*sealedChunk, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r.src, r.chunk[:sealedSize]); err != nil {
+		return ErrCorrupt
+	}
+
+	plain, err := r.aead.Open(r.chunk[:0], chunkNonce(r.prefix, uint32(index), last), r.chunk[:sealedSize], nil)
+	if err != nil {
+		r.loaded = -1
+		return ErrCorrupt
+	}
+	r.loaded = index
+	r.plain = plain
+	return nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type AccountingHandler struct {
+	accountingService *services.AccountingService
+	logger            *logrus.Logger
+}
+
+func NewAccountingHandler(accountingService *services.AccountingService, logger *logrus.Logger) *AccountingHandler {
+	return &AccountingHandler{
+		accountingService: accountingService,
+		logger:            logger,
+	}
+}
+
+// @Summary Export my statement
+// @Description Escrow payments received as freelancer, and payments and refunds as client, oldest first, with fees and invoice numbers. CSV gives amounts in whole tokens and in base units.
+// @Tags users
+// @Security BearerAuth
+// @Produce json,text/csv
+// @Param format query string false "csv or json" default(json)
+// @Param from query string false "First day (YYYY-MM-DD, UTC)"
+// @Param to query string false "Last day (YYYY-MM-DD, UTC), inclusive"
+// @Success 200 {object} services.Statement
+// @Failure 400 {object} map[string]string
+// @Router /users/me/statement [get]
+func (h *AccountingHandler) GetStatement(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected csv or json"})
+		return
+	}
+	from, to, err := dateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statement, err := h.accountingService.GetStatement(userID, from, to)
+	if err != nil {
+		respondServiceError(c, err, "Failed to export statement")
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, statement)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statementFileName(c)))
+	c.Status(http.StatusOK)
+	if err := services.WriteStatementCSV(c.Writer, statement); err != nil {
+		h.logger.Errorf("Failed to write statement CSV: %v", err)
+	}
+}
+
+func statementFileName(c *gin.Context) string {
+	name := "fariima-statement"
+	if from := c.Query("from"); from != "" {
+		name += "-" + from
+	}
+	if to := c.Query("to"); to != "" {
+		name += "-to-" + to
+	}
+	return name + ".csv"
+}
+
+// @Summary List my invoices
+// @Description Invoices for completion and milestone payments, issued as freelancer or received as client, newest first.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "First day (YYYY-MM-DD, UTC)"
+// @Param to query string false "Last day (YYYY-MM-DD, UTC), inclusive"
+// @Param limit query int false "Page size" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /users/me/invoices [get]
+func (h *AccountingHandler) ListInvoices(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	from, to, err := dateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, total, err := h.accountingService.ListInvoices(userID, from, to, limit, offset)
+	if err != nil {
+		respondServiceError(c, err, "Failed to list invoices")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":  invoices,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// @Summary Download an invoice
+// @Description PDF invoice from the freelancer to the client, with both parties, the transaction hashes and the platform fee itemized. Only the two parties can download it.
+// @Tags users
+// @Security BearerAuth
+// @Produce application/pdf
+// @Param number path string true "Invoice number"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/me/invoices/{number}/pdf [get]
+func (h *AccountingHandler) GetInvoicePDF(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	number := c.Param("number")
+
+	doc, err := h.accountingService.GetInvoicePDF(userID, number)
+	if err != nil {
+		respondServiceError(c, err, "Failed to render invoice")
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", number+".pdf"))
+	c.Status(http.StatusOK)
+	if _, err := doc.WriteTo(c.Writer); err != nil {
+		h.logger.Errorf("Failed to write invoice %s: %v", number, err)
+	}
+}
@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/fariima/backend/internal/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,6 +33,53 @@ func (h *AnalyticsHandler) GetPlatformStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// @Summary Get platform metrics over time
+// @Description Daily rollups of platform metrics, summed per day, ISO week or month. escrow.tvl is the value locked at the end of each period. Token amounts are split by token address, in base units and whole tokens.
+// @Tags analytics
+// @Produce json
+// @Param metrics query string false "Comma-separated metrics (users.new, projects.posted, projects.started, projects.completed, escrow.gmv, escrow.platform_fees, escrow.tvl, disputes.opened, disputes.resolved); all by default"
+// @Param from query string false "First day (YYYY-MM-DD), default 29 days before to"
+// @Param to query string false "Last day (YYYY-MM-DD), default today"
+// @Param granularity query string false "day, week or month" default(day)
+// @Success 200 {object} services.MetricsResult
+// @Failure 400 {object} map[string]string
+// @Router /analytics/metrics [get]
+func (h *AnalyticsHandler) GetMetrics(c *gin.Context) {
+	query := services.MetricsQuery{
+		To:          time.Now(),
+		Granularity: c.DefaultQuery("granularity", services.GranularityDay),
+	}
+	if metrics := c.Query("metrics"); metrics != "" {
+		query.Metrics = strings.Split(metrics, ",")
+	}
+
+	if to := c.Query("to"); to != "" {
+		day, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		query.To = day
+	}
+	query.From = query.To.AddDate(0, 0, -29)
+	if from := c.Query("from"); from != "" {
+		day, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		query.From = day
+	}
+
+	result, err := h.analyticsService.GetMetrics(query)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get metrics")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *AnalyticsHandler) GetUserStats(c *gin.Context) {
 	address := c.Param("address")
 
@@ -42,6 +92,30 @@ func (h *AnalyticsHandler) GetUserStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// @Summary Get project analytics
+// @Description Views, applications per day, proposed rates against the budget, time to first application and to hire, the escrow timeline and the dispute status of a project. Only its client and admins can see them.
+// @Tags analytics
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} services.ProjectStats
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /analytics/project/{id} [get]
 func (h *AnalyticsHandler) GetProjectStats(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Project stats"})
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	stats, err := h.analyticsService.GetProjectStats(projectID, userID, c.GetString("address"))
+	if err != nil {
+		respondServiceError(c, err, "Failed to get project stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
 }
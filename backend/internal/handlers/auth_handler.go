@@ -5,6 +5,7 @@ import (
 
 	"github.com/fariima/backend/internal/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -115,3 +116,21 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Generate new token (implementation depends on your requirements)
 	c.JSON(http.StatusOK, gin.H{"user_id": claims.UserID})
 }
+
+// @Summary Sign out everywhere
+// @Description Revokes every token issued to the user, this one included.
+// @Tags auth
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /users/me/sessions/revoke [post]
+func (h *AuthHandler) RevokeSessions(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	if err := h.authService.RevokeSessions(userID); err != nil {
+		respondServiceError(c, err, "Failed to revoke sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Signed out of every session"})
+}
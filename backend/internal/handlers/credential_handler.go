@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// maxCredentialSize bounds the body of a verification request.
+const maxCredentialSize = 1 << 20
+
+type CredentialHandler struct {
+	credentialService *services.CredentialService
+	logger            *logrus.Logger
+}
+
+func NewCredentialHandler(credentialService *services.CredentialService, logger *logrus.Logger) *CredentialHandler {
+	return &CredentialHandler{
+		credentialService: credentialService,
+		logger:            logger,
+	}
+}
+
+// @Summary Export a Proof-of-Work NFT as a Verifiable Credential
+// @Description W3C Verifiable Credential (JSON-LD) with a detached ES256K JWS proof signed by the platform key. Only the NFT's owner can export it.
+// @Tags credentials
+// @Security BearerAuth
+// @Produce json
+// @Param tokenId path int true "Token ID"
+// @Success 200 {object} services.Credential
+// @Router /credentials/nfts/{tokenId} [get]
+func (h *CredentialHandler) GetNFTCredential(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	tokenID, err := strconv.ParseInt(c.Param("tokenId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	credential, err := h.credentialService.IssueNFTCredential(userID, tokenID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to issue credential")
+		return
+	}
+
+	c.JSON(http.StatusOK, credential)
+}
+
+// @Summary Export my work history as a Verifiable Credential
+// @Description Summary credential covering all of my Proof-of-Work NFTs.
+// @Tags credentials
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.Credential
+// @Router /credentials/work-history [get]
+func (h *CredentialHandler) GetWorkHistoryCredential(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	credential, err := h.credentialService.IssueWorkHistoryCredential(userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to issue credential")
+		return
+	}
+
+	c.JSON(http.StatusOK, credential)
+}
+
+// @Summary Verify a credential
+// @Description Checks the proof against the platform key, then re-checks ownership and the project link of each attested NFT against the index.
+// @Tags credentials
+// @Accept json
+// @Produce json
+// @Param credential body services.Credential true "Credential"
+// @Success 200 {object} services.CredentialVerification
+// @Router /public/credentials/verify [post]
+func (h *CredentialHandler) VerifyCredential(c *gin.Context) {
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCredentialSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read credential"})
+		return
+	}
+
+	result, err := h.credentialService.Verify(raw)
+	if err != nil {
+		respondServiceError(c, err, "Failed to verify credential")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Issuer DID document
+// @Description did:web document publishing the key that signs credentials.
+// @Tags credentials
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/did.json [get]
+func (h *CredentialHandler) GetDIDDocument(c *gin.Context) {
+	c.JSON(http.StatusOK, h.credentialService.DIDDocument())
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type DisputeFeedHandler struct {
+	feedService *services.DisputeFeedService
+	logger      *logrus.Logger
+}
+
+func NewDisputeFeedHandler(feedService *services.DisputeFeedService, logger *logrus.Logger) *DisputeFeedHandler {
+	return &DisputeFeedHandler{
+		feedService: feedService,
+		logger:      logger,
+	}
+}
+
+// @Summary Public dispute feed
+// @Description Redacted disputes: category, amount band, vote tallies, outcome and timing. No evidence or personal data.
+// @Tags public
+// @Produce json
+// @Param status query string false "Dispute status"
+// @Param category query string false "Dispute category"
+// @Success 200 {object} map[string]interface{}
+// @Router /public/disputes [get]
+func (h *DisputeFeedHandler) ListDisputes(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	disputes, total, err := h.feedService.List(c.Query("status"), c.Query("category"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get disputes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"disputes": disputes,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// @Summary Public dispute
+// @Tags public
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} services.PublicDispute
+// @Router /public/disputes/{id} [get]
+func (h *DisputeFeedHandler) GetDispute(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	dispute, err := h.feedService.Get(id)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get dispute")
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// @Summary Dispute outcome statistics
+// @Description Outcomes overall and by category.
+// @Tags public
+// @Produce json
+// @Success 200 {object} services.DisputeStats
+// @Router /public/disputes/stats [get]
+func (h *DisputeFeedHandler) GetStats(c *gin.Context) {
+	stats, err := h.feedService.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dispute statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary Dispute RSS feed
+// @Tags public
+// @Produce xml
+// @Router /public/disputes/feed.rss [get]
+func (h *DisputeFeedHandler) GetRSS(c *gin.Context) {
+	h.serveFeed(c, "application/rss+xml; charset=utf-8", h.feedService.RSS)
+}
+
+// @Summary Dispute Atom feed
+// @Tags public
+// @Produce xml
+// @Router /public/disputes/feed.atom [get]
+func (h *DisputeFeedHandler) GetAtom(c *gin.Context) {
+	h.serveFeed(c, "application/atom+xml; charset=utf-8", h.feedService.Atom)
+}
+
+func (h *DisputeFeedHandler) serveFeed(c *gin.Context, contentType string, render func() ([]byte, error)) {
+	body, err := render()
+	if err != nil {
+		h.logger.Errorf("Failed to render dispute feed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render feed"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Data(http.StatusOK, contentType, body)
+}
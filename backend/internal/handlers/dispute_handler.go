@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
 	"github.com/fariima/backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,15 +25,46 @@ func NewDisputeHandler(disputeService *services.DisputeService, logger *logrus.L
 	}
 }
 
+// CreateDisputeRequest is the body for opening a dispute. Status, votes and
+// splits are managed by the platform and cannot be set by the caller.
+type CreateDisputeRequest struct {
+	ProjectID   uuid.UUID  `json:"project_id" binding:"required"`
+	EscrowID    *uuid.UUID `json:"escrow_id"`
+	Title       string     `json:"title" binding:"required,max=200"`
+	Description string     `json:"description" binding:"required"`
+	Category    string     `json:"category" binding:"omitempty,max=50"`
+}
+
+// @Summary Open a dispute
+// @Description Only the project's client or freelancer can open a dispute, and only while the escrow is funded. The category is one of quality, payment, deadline, scope, communication or other (the default).
+// @Tags disputes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateDisputeRequest true "Dispute"
+// @Success 201 {object} models.Dispute
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /disputes [post]
 func (h *DisputeHandler) CreateDispute(c *gin.Context) {
-	var dispute models.Dispute
-	if err := c.ShouldBindJSON(&dispute); err != nil {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var req CreateDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.disputeService.CreateDispute(&dispute); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create dispute"})
+	dispute, err := h.disputeService.CreateDispute(userID, services.CreateDisputeInput{
+		ProjectID:   req.ProjectID,
+		EscrowID:    req.EscrowID,
+		Title:       req.Title,
+		Description: req.Description,
+		Category:    req.Category,
+	})
+	if err != nil {
+		respondServiceError(c, err, "Failed to create dispute")
 		return
 	}
 
@@ -54,12 +87,39 @@ func (h *DisputeHandler) GetDispute(c *gin.Context) {
 	c.JSON(http.StatusOK, dispute)
 }
 
+// @Summary List disputes
+// @Tags disputes
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "open, voting, awaiting_finalization, resolved or closed"
+// @Param needs_my_vote query bool false "Only disputes in voting the caller can still vote on"
+// @Param ending_soon query bool false "Only disputes whose voting closes within ending_within hours"
+// @Param ending_within query int false "Hours for ending_soon (default 24)"
+// @Success 200 {object} map[string]interface{}
+// @Router /disputes [get]
 func (h *DisputeHandler) GetDisputes(c *gin.Context) {
-	status := c.Query("status")
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	disputes, total, err := h.disputeService.ListDisputes(status, limit, offset)
+	filter := repositories.DisputeFilter{Status: c.Query("status")}
+
+	if c.Query("needs_my_vote") == "true" {
+		userIDStr, _ := c.Get("user_id")
+		userID, _ := uuid.Parse(userIDStr.(string))
+		filter.NeedsVoteBy = &userID
+	}
+
+	if c.Query("ending_soon") == "true" {
+		hours, err := strconv.Atoi(c.DefaultQuery("ending_within", "24"))
+		if err != nil || hours <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ending_within"})
+			return
+		}
+		endingBefore := time.Now().Add(time.Duration(hours) * time.Hour)
+		filter.EndingBefore = &endingBefore
+	}
+
+	disputes, total, err := h.disputeService.ListDisputes(filter, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list disputes"})
 		return
@@ -73,6 +133,48 @@ func (h *DisputeHandler) GetDisputes(c *gin.Context) {
 	})
 }
 
+// @Summary Finalization transaction for a dispute
+// @Description Returns the unsigned DAO finalizeDispute call once voting has ended.
+// @Tags disputes
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} services.TransactionPayload
+// @Failure 409 {object} map[string]string
+// @Router /disputes/{id}/finalize-tx [get]
+func (h *DisputeHandler) GetFinalizeTransaction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	payload, err := h.disputeService.FinalizePayload(id)
+	if err != nil {
+		respondServiceError(c, err, "Failed to build finalize transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, payload)
+}
+
+// CastVoteRequest points at the user's DAO voteOnDispute transaction.
+type CastVoteRequest struct {
+	TxHash string `json:"tx_hash" binding:"required"`
+}
+
+// @Summary Record a juror vote
+// @Description Verifies the DisputeVoted event in the transaction receipt and records the vote with the juror's FARI voting power.
+// @Tags disputes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Param request body CastVoteRequest true "Vote transaction"
+// @Success 201 {object} models.Vote
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /disputes/{id}/vote [post]
 func (h *DisputeHandler) Vote(c *gin.Context) {
 	disputeID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -83,23 +185,59 @@ func (h *DisputeHandler) Vote(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	voterID, _ := uuid.Parse(userIDStr.(string))
 
-	var vote models.Vote
-	if err := c.ShouldBindJSON(&vote); err != nil {
+	var req CastVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	vote.DisputeID = disputeID
-	vote.VoterID = voterID
-
-	if err := h.disputeService.CreateVote(&vote); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to vote"})
+	vote, err := h.disputeService.CastVote(disputeID, voterID, req.TxHash)
+	if err != nil {
+		respondServiceError(c, err, "Failed to vote")
 		return
 	}
 
 	c.JSON(http.StatusCreated, vote)
 }
 
+// @Summary Juror eligibility
+// @Description Whether the caller can vote on the dispute, with their current FARI stake and voting power.
+// @Tags disputes
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} services.JurorEligibility
+// @Router /disputes/{id}/eligibility [get]
+func (h *DisputeHandler) GetEligibility(c *gin.Context) {
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	eligibility, err := h.disputeService.CheckEligibility(disputeID, userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to check eligibility")
+		return
+	}
+
+	c.JSON(http.StatusOK, eligibility)
+}
+
+// @Summary Submit dispute evidence
+// @Description Multipart form with "title", optional "description" and a "file". The file is stored as an evidence upload: its type is checked from its magic bytes, it counts toward the submitter's quota, and it is encrypted and pinned to IPFS with its SHA-256 recorded. Files flagged by the malware scanner are recorded as quarantined evidence (202) until a moderator reviews them. Evidence is locked once voting starts.
+// @Tags disputes
+// @Security BearerAuth
+// @Accept mpfd
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 201 {object} models.Evidence
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /disputes/{id}/evidence [post]
 func (h *DisputeHandler) SubmitEvidence(c *gin.Context) {
 	disputeID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -110,23 +248,120 @@ func (h *DisputeHandler) SubmitEvidence(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	submitterID, _ := uuid.Parse(userIDStr.(string))
 
-	var evidence models.Evidence
-	if err := c.ShouldBindJSON(&evidence); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	title := c.PostForm("title")
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Evidence file is required"})
+		return
+	}
+	if header.Size > services.MaxEvidenceSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Evidence file too large"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read evidence file"})
 		return
 	}
+	defer file.Close()
 
-	evidence.DisputeID = disputeID
-	evidence.SubmitterID = submitterID
+	evidence, err := h.disputeService.SubmitEvidence(disputeID, submitterID, services.EvidenceUpload{
+		Title:       title,
+		Description: c.PostForm("description"),
+		FileName:    header.Filename,
+		Content:     file,
+	})
+	if err != nil {
+		respondServiceError(c, err, "Failed to submit evidence")
+		return
+	}
 
-	if err := h.disputeService.SubmitEvidence(&evidence); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit evidence"})
+	if evidence.Quarantined {
+		c.JSON(http.StatusAccepted, gin.H{
+			"evidence": evidence,
+			"message":  "The file was flagged by the malware scanner and is quarantined until a moderator reviews it",
+		})
 		return
 	}
 
 	c.JSON(http.StatusCreated, evidence)
 }
 
+// @Summary List dispute evidence
+// @Description Only the project's client and freelancer and the jurors who accepted the dispute can list its evidence.
+// @Tags disputes
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {array} models.Evidence
+// @Router /disputes/{id}/evidence [get]
+func (h *DisputeHandler) GetEvidence(c *gin.Context) {
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	evidence, err := h.disputeService.GetEvidence(disputeID, userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get evidence")
+		return
+	}
+
+	c.JSON(http.StatusOK, evidence)
+}
+
+// @Summary Download verified evidence
+// @Description Fetches the file from IPFS, decrypting it for the parties and accepted jurors, and serves it only if it matches the SHA-256 recorded at submission.
+// @Tags disputes
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param id path string true "Dispute ID"
+// @Param evidenceId path string true "Evidence ID"
+// @Success 200 {file} binary
+// @Router /disputes/{id}/evidence/{evidenceId}/content [get]
+func (h *DisputeHandler) GetEvidenceContent(c *gin.Context) {
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	evidenceID, err := uuid.Parse(c.Param("evidenceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid evidence ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	evidence, content, err := h.disputeService.GetEvidenceContent(disputeID, evidenceID, userID)
+	if err != nil {
+		h.logger.Errorf("Failed to load evidence %s: %v", evidenceID, err)
+		respondServiceError(c, err, "Failed to load verified evidence")
+		return
+	}
+
+	contentType := evidence.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("X-Content-SHA256", evidence.ContentHash)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", evidence.FileName))
+	c.Data(http.StatusOK, contentType, content)
+}
+
 func (h *DisputeHandler) GetVotes(c *gin.Context) {
 	disputeID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type EmailHandler struct {
+	emailService *services.EmailService
+	logger       *logrus.Logger
+}
+
+func NewEmailHandler(emailService *services.EmailService, logger *logrus.Logger) *EmailHandler {
+	return &EmailHandler{
+		emailService: emailService,
+		logger:       logger,
+	}
+}
+
+type TokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type BounceRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Type  string `json:"type" binding:"required"` // hard, soft, complaint
+}
+
+// @Summary Set or change email address
+// @Description Stores the address unverified and sends a verification link to it.
+// @Tags email
+// @Security BearerAuth
+// @Accept json
+// @Param request body ChangeEmailRequest true "New address"
+// @Success 202 {object} map[string]interface{}
+// @Failure 409 {object} map[string]string
+// @Router /users/me/email [put]
+func (h *EmailHandler) ChangeEmail(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.ChangeEmail(userID, req.Email); err != nil {
+		respondServiceError(c, err, "Failed to change email")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+// @Summary Send email verification link
+// @Tags email
+// @Security BearerAuth
+// @Success 202 {object} map[string]interface{}
+// @Router /users/me/email/verification [post]
+func (h *EmailHandler) SendVerification(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	if err := h.emailService.SendVerification(userID); err != nil {
+		respondServiceError(c, err, "Failed to send verification email")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+// @Summary Verify email address
+// @Tags email
+// @Accept json
+// @Produce json
+// @Param request body TokenRequest true "Verification token"
+// @Success 200 {object} map[string]interface{}
+// @Router /auth/email/verify [post]
+func (h *EmailHandler) VerifyEmail(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.emailService.VerifyEmail(req.Token)
+	if err != nil {
+		respondServiceError(c, err, "Failed to verify email")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Email verified",
+		"email_verified_at": user.EmailVerifiedAt,
+	})
+}
+
+// @Summary Unsubscribe from notification emails
+// @Description Target of the signed link in notification emails; also accepts RFC 8058 one-click POSTs.
+// @Tags email
+// @Param email query string true "Email address"
+// @Param token query string true "Signed unsubscribe token"
+// @Success 200 {object} map[string]interface{}
+// @Router /email/unsubscribe [get]
+func (h *EmailHandler) Unsubscribe(c *gin.Context) {
+	if err := h.emailService.Unsubscribe(c.Query("email"), c.Query("token")); err != nil {
+		respondServiceError(c, err, "Failed to unsubscribe")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You will no longer receive notification emails"})
+}
+
+// @Summary Report a bounce or complaint
+// @Description Webhook for the mail provider, authenticated with the X-Mail-Webhook-Secret header.
+// @Tags email
+// @Accept json
+// @Param request body BounceRequest true "Bounce report"
+// @Success 200 {object} map[string]interface{}
+// @Router /email/bounces [post]
+func (h *EmailHandler) Bounce(c *gin.Context) {
+	if !h.emailService.ValidWebhookSecret(c.GetHeader("X-Mail-Webhook-Secret")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook secret"})
+		return
+	}
+
+	var req BounceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.HandleBounce(req.Email, req.Type); err != nil {
+		respondServiceError(c, err, "Failed to record bounce")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recorded"})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// respondServiceError maps service sentinel errors to HTTP responses and
+// falls back to a 500 with the given message.
+func respondServiceError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIntegrity):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
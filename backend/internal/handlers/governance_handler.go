@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type GovernanceHandler struct {
+	governanceService *services.GovernanceService
+	logger            *logrus.Logger
+}
+
+func NewGovernanceHandler(governanceService *services.GovernanceService, logger *logrus.Logger) *GovernanceHandler {
+	return &GovernanceHandler{
+		governanceService: governanceService,
+		logger:            logger,
+	}
+}
+
+// @Summary List DAO proposals
+// @Description Proposals with live tallies against quorum and approval thresholds, and decoded callData.
+// @Tags governance
+// @Produce json
+// @Param state query string false "active, succeeded, defeated, executed or cancelled"
+// @Param type query string false "FeeChange, TreasurySpend, ContractUpgrade or ParameterChange"
+// @Success 200 {object} map[string]interface{}
+// @Router /governance/proposals [get]
+func (h *GovernanceHandler) ListProposals(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	proposals, total, err := h.governanceService.ListProposals(c.Query("state"), c.Query("type"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get proposals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proposals": proposals,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// @Summary Get a DAO proposal
+// @Tags governance
+// @Produce json
+// @Param id path string true "Proposal ID"
+// @Success 200 {object} services.ProposalView
+// @Router /governance/proposals/{id} [get]
+func (h *GovernanceHandler) GetProposal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	proposal, err := h.governanceService.GetProposal(id)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get proposal")
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
+
+// @Summary List votes on a DAO proposal
+// @Tags governance
+// @Produce json
+// @Param id path string true "Proposal ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /governance/proposals/{id}/votes [get]
+func (h *GovernanceHandler) GetProposalVotes(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	votes, total, err := h.governanceService.GetProposalVotes(id, limit, offset)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get votes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"votes":  votes,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// @Summary A voter's proposal history
+// @Tags governance
+// @Produce json
+// @Param address path string true "Wallet address"
+// @Success 200 {object} map[string]interface{}
+// @Router /governance/voters/{address}/votes [get]
+func (h *GovernanceHandler) GetVoterHistory(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	votes, total, err := h.governanceService.GetVoterHistory(c.Param("address"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get voter history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"votes":  votes,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fariima/backend/internal/metrics"
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type HealthHandler struct {
+	healthService *services.HealthService
+	logger        *logrus.Logger
+}
+
+func NewHealthHandler(healthService *services.HealthService, logger *logrus.Logger) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		logger:        logger,
+	}
+}
+
+// @Summary Liveness probe
+// @Description Succeeds while the process can serve requests, without checking dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Readiness probe
+// @Description Pings Postgres and Redis and checks that the RPC node's latest block is recent. Responds 503 if any check fails. Results are reused for 5 seconds.
+// @Tags health
+// @Produce json
+// @Success 200 {object} services.Readiness
+// @Failure 503 {object} services.Readiness
+// @Router /readyz [get]
+// @Router /health [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	readiness := h.healthService.Readiness(c.Request.Context())
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, readiness)
+}
+
+// @Summary Prometheus metrics
+// @Description HTTP latency per route, database pool, indexer progress, WebSocket connections and IPFS upload latency in the Prometheus text format. Requires METRICS_TOKEN as a bearer token, which must be set in release mode.
+// @Tags health
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func (h *HealthHandler) Metrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if _, err := metrics.Default.WriteTo(c.Writer); err != nil {
+		h.logger.Errorf("Failed to write metrics: %v", err)
+	}
+}
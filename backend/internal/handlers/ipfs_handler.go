@@ -1,58 +1,410 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// downloadTimeout bounds how long a single file download may take.
+	downloadTimeout = 30 * time.Minute
+	// uploadTimeout bounds how long a single file upload may take.
+	uploadTimeout = 30 * time.Minute
+)
+
 type IPFSHandler struct {
-	ipfsService *services.IPFSService
-	logger      *logrus.Logger
+	ipfsService        *services.IPFSService
+	uploadService      *services.UploadService
+	privateFileService *services.PrivateFileService
+	logger             *logrus.Logger
 }
 
-func NewIPFSHandler(ipfsService *services.IPFSService, logger *logrus.Logger) *IPFSHandler {
+func NewIPFSHandler(
+	ipfsService *services.IPFSService,
+	uploadService *services.UploadService,
+	privateFileService *services.PrivateFileService,
+	logger *logrus.Logger,
+) *IPFSHandler {
 	return &IPFSHandler{
-		ipfsService: ipfsService,
-		logger:      logger,
+		ipfsService:        ipfsService,
+		uploadService:      uploadService,
+		privateFileService: privateFileService,
+		logger:             logger,
 	}
 }
 
+// @Summary Upload a file
+// @Description Streams the multipart "file" part to storage. The purpose decides the accepted file types (checked from the content's magic bytes) and size, and the file counts toward the user's storage quota. Evidence (for a dispute) and deliverables (for a project) are encrypted and only readable by the project's parties and the dispute's accepted jurors. Image metadata such as EXIF is stripped, and files flagged by the malware scanner are quarantined (202) until a moderator reviews them.
+// @Tags ipfs
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param purpose query string true "avatar, resume, evidence, deliverable or attachment"
+// @Param project_id query string false "Project of a deliverable"
+// @Param dispute_id query string false "Dispute of evidence"
+// @Param file formData file true "File"
+// @Success 201 {object} map[string]interface{}
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /ipfs/upload [post]
 func (h *IPFSHandler) Upload(c *gin.Context) {
-	file, header, err := c.Request.FormFile("file")
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	input := services.UploadInput{Purpose: models.UploadPurpose(c.Query("purpose"))}
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+		input.ProjectID = &id
+	}
+	if disputeID := c.Query("dispute_id"); disputeID != "" {
+		id, err := uuid.Parse(disputeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+			return
+		}
+		input.DisputeID = &id
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart form data is required"})
+		return
+	}
+
+	// Large files outlast the server's read timeout, and the response is
+	// written only once the whole body has been read
+	deadline := time.Now().Add(uploadTimeout)
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		h.logger.Warnf("Failed to extend read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		h.logger.Warnf("Failed to extend write deadline: %v", err)
+	}
+
+	// Parts are read in order without buffering the form, so anything before
+	// the file is skipped
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+			return
+		}
+		if err != nil {
+			h.respondReadError(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		input.FileName = part.FileName()
+		upload, err := h.uploadService.Upload(c.Request.Context(), userID, input, part)
+		part.Close()
+		if err != nil {
+			h.respondReadError(c, err)
+			return
+		}
+
+		if upload.ScanStatus == models.ScanStatusQuarantined {
+			c.JSON(http.StatusAccepted, gin.H{
+				"upload":  upload,
+				"message": "The file was flagged by the malware scanner and is quarantined until a moderator reviews it",
+			})
+			return
+		}
+
+		url := h.ipfsService.GetFileURL(upload.CID)
+		if upload.Private {
+			url = h.privateFileService.FileURL(upload.CID)
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"upload": upload,
+			"hash":   upload.CID,
+			"url":    url,
+		})
+		return
+	}
+}
+
+func (h *IPFSHandler) respondReadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+		return
+	}
+	respondServiceError(c, err, "Failed to upload file")
+}
+
+// @Summary Download a file
+// @Description Serves a file by CID from the configured gateway or node through a local disk cache, with range requests and ETag caching. Encrypted files are decrypted for users with access and refused to everyone else.
+// @Tags ipfs
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param hash path string true "CID"
+// @Param Range header string false "Byte range"
+// @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Success 304
+// @Failure 403 {object} map[string]string "No access, or quarantined"
+// @Failure 404 {object} map[string]string
+// @Router /ipfs/{hash} [get]
+func (h *IPFSHandler) Get(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	file, err := h.privateFileService.Open(c.Request.Context(), userID, c.Param("hash"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		respondServiceError(c, err, "Failed to load file")
 		return
 	}
 	defer file.Close()
 
-	content, err := io.ReadAll(file)
+	// Content never changes under a CID, but this route requires a bearer
+	// token, so shared caches must not keep it. Decrypted files are not
+	// stored at all, not even by the user's browser.
+	c.Header("ETag", `"`+c.Param("hash")+`"`)
+	if file.Private {
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	}
+	c.Header("Content-Type", file.MimeType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "sandbox")
+	if file.FileName != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", file.FileName))
+	}
+
+	// Large files outlast the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(downloadTimeout)); err != nil {
+		h.logger.Warnf("Failed to extend write deadline: %v", err)
+	}
+
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, file)
+}
+
+// @Summary Get my wrapped key for an encrypted file
+// @Description Returns the file's data key encrypted for the caller's registered encryption public key, to decrypt the file client-side with eth_decrypt.
+// @Tags ipfs
+// @Security BearerAuth
+// @Produce json
+// @Param hash path string true "CID"
+// @Success 200 {object} services.FileKey
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /ipfs/{hash}/key [get]
+func (h *IPFSHandler) GetKey(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	key, err := h.privateFileService.GetKey(userID, c.Param("hash"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		respondServiceError(c, err, "Failed to load file key")
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+type SetEncryptionKeyRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
+// @Summary Register my encryption public key
+// @Description Registers the base64 x25519 key returned by eth_getEncryptionPublicKey. Keys of encrypted files shared with the user are wrapped for it.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body SetEncryptionKeyRequest true "Public key"
+// @Success 204
+// @Router /users/me/encryption-key [put]
+func (h *IPFSHandler) SetEncryptionKey(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var req SetEncryptionKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.privateFileService.SetEncryptionKey(userID, req.PublicKey); err != nil {
+		respondServiceError(c, err, "Failed to register encryption key")
 		return
 	}
 
-	hash, err := h.ipfsService.UploadFile(header.Filename, content)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List my uploads
+// @Tags ipfs
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /uploads [get]
+func (h *IPFSHandler) ListUploads(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	uploads, total, err := h.uploadService.ListUploads(userID, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to IPFS"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list uploads"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"hash": hash,
-		"url":  h.ipfsService.GetFileURL(hash),
+		"uploads": uploads,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
 	})
 }
 
-func (h *IPFSHandler) Get(c *gin.Context) {
-	hash := c.Param("hash")
-	url := h.ipfsService.GetFileURL(hash)
+// @Summary Get my storage usage
+// @Tags ipfs
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.UploadUsage
+// @Router /uploads/usage [get]
+func (h *IPFSHandler) GetUsage(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	usage, err := h.uploadService.GetUsage(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load storage usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// @Summary List quarantined uploads
+// @Description Uploads flagged by the malware scanner that await review, oldest first.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/uploads/quarantine [get]
+func (h *IPFSHandler) ListQuarantined(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	uploads, total, err := h.uploadService.ListQuarantined(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list quarantined uploads"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"hash": hash,
-		"url":  url,
+		"uploads": uploads,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
 	})
 }
+
+// @Summary Download a quarantined upload
+// @Description Serves the decrypted content of a quarantined upload as an opaque attachment for review.
+// @Tags admin
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param id path string true "Upload ID"
+// @Success 200 {file} binary
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/uploads/{id}/content [get]
+func (h *IPFSHandler) GetQuarantinedContent(c *gin.Context) {
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload ID"})
+		return
+	}
+
+	file, err := h.uploadService.OpenQuarantined(c.Request.Context(), uploadID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to load upload")
+		return
+	}
+	defer file.Close()
+
+	// Never rendered by the browser
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(downloadTimeout)); err != nil {
+		h.logger.Warnf("Failed to extend write deadline: %v", err)
+	}
+
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, file)
+}
+
+type ReviewUploadRequest struct {
+	Decision services.ReviewDecision `json:"decision" binding:"required"`
+	Note     string                  `json:"note"`
+}
+
+// @Summary Review a quarantined upload
+// @Description Releasing shares the file as if it had passed the scan; rejecting unpins it and frees the owner's quota.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Param request body ReviewUploadRequest true "Decision"
+// @Success 200 {object} models.Upload
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/uploads/{id}/review [post]
+func (h *IPFSHandler) ReviewUpload(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	moderatorID, _ := uuid.Parse(userIDStr.(string))
+
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload ID"})
+		return
+	}
+
+	var req ReviewUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upload, err := h.uploadService.ReviewUpload(c.Request.Context(), uploadID, moderatorID, req.Decision, req.Note)
+	if err != nil {
+		respondServiceError(c, err, "Failed to review upload")
+		return
+	}
+
+	c.JSON(http.StatusOK, upload)
+}
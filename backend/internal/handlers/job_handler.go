@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type JobHandler struct {
+	jobQueue *services.JobQueue
+	logger   *logrus.Logger
+}
+
+func NewJobHandler(jobQueue *services.JobQueue, logger *logrus.Logger) *JobHandler {
+	return &JobHandler{
+		jobQueue: jobQueue,
+		logger:   logger,
+	}
+}
+
+// @Summary List background jobs
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "pending, running, completed or dead"
+// @Param type query string false "Job type"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	jobs, total, err := h.jobQueue.ListJobs(c.Query("status"), c.Query("type"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":   jobs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// @Summary Job counts by type and status
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/jobs/stats [get]
+func (h *JobHandler) GetStats(c *gin.Context) {
+	counts, err := h.jobQueue.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"counts": counts})
+}
+
+// @Summary Get a background job
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.Job
+// @Router /admin/jobs/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.jobQueue.GetJob(id)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get job")
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// @Summary Retry a background job
+// @Description Requeues a dead job with a fresh attempt budget, or runs a pending retry now.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.Job
+// @Router /admin/jobs/{id}/retry [post]
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.jobQueue.RetryJob(id)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retry job")
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// @Summary List job schedules
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/schedules [get]
+func (h *JobHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.jobQueue.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
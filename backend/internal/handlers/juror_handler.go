@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type JurorHandler struct {
+	jurorService *services.JurorService
+	logger       *logrus.Logger
+}
+
+func NewJurorHandler(jurorService *services.JurorService, logger *logrus.Logger) *JurorHandler {
+	return &JurorHandler{
+		jurorService: jurorService,
+		logger:       logger,
+	}
+}
+
+// @Summary Get a dispute's jury
+// @Description Assigned jurors plus every draw with its seed and weighted candidate list, so the selection can be recomputed.
+// @Tags jurors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} services.Jury
+// @Router /disputes/{id}/jury [get]
+func (h *JurorHandler) GetJury(c *gin.Context) {
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	jury, err := h.jurorService.GetJury(disputeID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get jury")
+		return
+	}
+
+	c.JSON(http.StatusOK, jury)
+}
+
+// @Summary Accept a juror assignment
+// @Tags jurors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} models.JurorAssignment
+// @Router /disputes/{id}/jury/accept [post]
+func (h *JurorHandler) Accept(c *gin.Context) {
+	h.respond(c, true)
+}
+
+// @Summary Decline a juror assignment
+// @Description The seat is refilled with a new verifiable draw.
+// @Tags jurors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} models.JurorAssignment
+// @Router /disputes/{id}/jury/decline [post]
+func (h *JurorHandler) Decline(c *gin.Context) {
+	h.respond(c, false)
+}
+
+func (h *JurorHandler) respond(c *gin.Context, accept bool) {
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	assignment, err := h.jurorService.Respond(disputeID, userID, accept)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update assignment")
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// @Summary List my juror assignments
+// @Tags jurors
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "pending, accepted or declined"
+// @Success 200 {object} map[string]interface{}
+// @Router /jury/assignments [get]
+func (h *JurorHandler) ListAssignments(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	assignments, total, err := h.jurorService.ListAssignments(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list assignments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assignments": assignments,
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+	})
+}
+
+// @Summary Get a juror's reputation
+// @Description Participation, accuracy against final outcomes, rewards earned and pool membership.
+// @Tags jurors
+// @Produce json
+// @Param address path string true "Wallet address"
+// @Success 200 {object} services.JurorProfile
+// @Router /jurors/{address} [get]
+func (h *JurorHandler) GetProfile(c *gin.Context) {
+	profile, err := h.jurorService.GetProfile(c.Param("address"))
+	if err != nil {
+		respondServiceError(c, err, "Failed to get juror profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// @Summary Juror leaderboard
+// @Tags jurors
+// @Produce json
+// @Param sort query string false "accuracy (default), participation or rewards"
+// @Success 200 {object} map[string]interface{}
+// @Router /jurors/leaderboard [get]
+func (h *JurorHandler) GetLeaderboard(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	jurors, total, err := h.jurorService.Leaderboard(c.DefaultQuery("sort", "accuracy"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jurors": jurors,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// @Summary Build a juror reward claim
+// @Description Unsigned DAO claimJurorRewards call covering every finalized dispute I voted on and have not claimed.
+// @Tags jurors
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.RewardClaim
+// @Router /jury/rewards/claim-tx [get]
+func (h *JurorHandler) GetClaimTransaction(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	claim, err := h.jurorService.ClaimRewardsPayload(userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to build claim transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+	logger        *logrus.Logger
+}
+
+func NewLedgerHandler(ledgerService *services.LedgerService, logger *logrus.Logger) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+		logger:        logger,
+	}
+}
+
+// @Summary My earnings as a freelancer
+// @Description Completion payments, milestone payments and dispute payouts, newest first, with per-token gross, fee and net totals. Amounts are exact integers in the token's base units; *_units give whole tokens for known stablecoins.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "First day (YYYY-MM-DD, UTC)"
+// @Param to query string false "Last day (YYYY-MM-DD, UTC), inclusive"
+// @Param limit query int false "Page size" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} services.LedgerStatement
+// @Failure 400 {object} map[string]string
+// @Router /users/me/earnings [get]
+func (h *LedgerHandler) GetEarnings(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	query, err := ledgerQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statement, err := h.ledgerService.GetEarnings(userID, query)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get earnings")
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// @Summary My spend as a client
+// @Description Payments out of my escrows, including platform fees, and dispute refunds, newest first, with per-token totals. Amounts are exact integers in the token's base units; *_units give whole tokens for known stablecoins.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "First day (YYYY-MM-DD, UTC)"
+// @Param to query string false "Last day (YYYY-MM-DD, UTC), inclusive"
+// @Param limit query int false "Page size" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} services.LedgerStatement
+// @Failure 400 {object} map[string]string
+// @Router /users/me/spend [get]
+func (h *LedgerHandler) GetSpend(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	query, err := ledgerQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statement, err := h.ledgerService.GetSpend(userID, query)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get spend")
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+func ledgerQuery(c *gin.Context) (services.LedgerQuery, error) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	query := services.LedgerQuery{Limit: limit, Offset: offset}
+
+	from, to, err := dateRange(c)
+	query.From, query.To = from, to
+	return query, err
+}
+
+// dateRange parses the optional from and to days of a request as the UTC
+// range [from, to+1).
+func dateRange(c *gin.Context) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if value := c.Query("from"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, nil, errors.New("Invalid from date, expected YYYY-MM-DD")
+		}
+		from = &day
+	}
+	if value := c.Query("to"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, nil, errors.New("Invalid to date, expected YYYY-MM-DD")
+		}
+		end := day.AddDate(0, 0, 1)
+		to = &end
+	}
+	return from, to, nil
+}
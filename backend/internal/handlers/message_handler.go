@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type MessageHandler struct {
+	messageService *services.MessageService
+	logger         *logrus.Logger
+}
+
+func NewMessageHandler(messageService *services.MessageService, logger *logrus.Logger) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+		logger:         logger,
+	}
+}
+
+type OpenConversationRequest struct {
+	ProjectID     uuid.UUID  `json:"project_id" binding:"required"`
+	ApplicationID *uuid.UUID `json:"application_id"`
+}
+
+type SendMessageRequest struct {
+	Body string `json:"body"`
+}
+
+type MarkReadRequest struct {
+	MessageID uuid.UUID `json:"message_id" binding:"required"`
+}
+
+// @Summary Open a project or application conversation
+// @Tags messages
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body OpenConversationRequest true "Conversation scope"
+// @Success 200 {object} models.Conversation
+// @Router /conversations [post]
+func (h *MessageHandler) OpenConversation(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var req OpenConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.messageService.OpenConversation(userID, req.ProjectID, req.ApplicationID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to open conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// @Summary List my conversations
+// @Tags messages
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /conversations [get]
+func (h *MessageHandler) GetConversations(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	conversations, total, err := h.messageService.ListConversations(userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversations": conversations,
+		"total":         total,
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
+
+// @Summary Get conversation
+// @Tags messages
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} models.Conversation
+// @Router /conversations/{id} [get]
+func (h *MessageHandler) GetConversation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	conversation, err := h.messageService.GetConversation(userID, id)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// @Summary Get conversation messages (newest first)
+// @Tags messages
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Router /conversations/{id}/messages [get]
+func (h *MessageHandler) GetMessages(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	messages, total, err := h.messageService.ListMessages(userID, id, limit, offset)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get messages")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// @Summary Send a message
+// @Description Accepts JSON, or multipart form data with a "body" field and "attachments" files. Attachments are stored as uploads of the sender and count toward their quota. Attachments flagged by the malware scanner are sent quarantined, without a file URL, until a moderator reviews them.
+// @Tags messages
+// @Security BearerAuth
+// @Accept json,mpfd
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 201 {object} models.Message
+// @Router /conversations/{id}/messages [post]
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var body string
+	var uploads []services.AttachmentUpload
+
+	if c.ContentType() == "multipart/form-data" {
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data"})
+			return
+		}
+
+		body = c.PostForm("body")
+
+		for _, header := range form.File["attachments"] {
+			if header.Size > services.MaxAttachmentSize {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Attachment too large"})
+				return
+			}
+
+			file, err := header.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read attachment"})
+				return
+			}
+			defer file.Close()
+
+			uploads = append(uploads, services.AttachmentUpload{
+				FileName: header.Filename,
+				Content:  file,
+			})
+		}
+	} else {
+		var req SendMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		body = req.Body
+	}
+
+	message, err := h.messageService.SendMessage(userID, id, body, uploads)
+	if err != nil {
+		respondServiceError(c, err, "Failed to send message")
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// @Summary Mark conversation read up to a message
+// @Tags messages
+// @Security BearerAuth
+// @Accept json
+// @Param id path string true "Conversation ID"
+// @Param request body MarkReadRequest true "Last read message"
+// @Success 200 {object} map[string]interface{}
+// @Router /conversations/{id}/read [post]
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var req MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.messageService.MarkRead(userID, id, req.MessageID); err != nil {
+		respondServiceError(c, err, "Failed to mark conversation read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Marked as read"})
+}
+
+// @Summary Get read receipts
+// @Tags messages
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {array} models.ConversationRead
+// @Router /conversations/{id}/reads [get]
+func (h *MessageHandler) GetReadReceipts(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	reads, err := h.messageService.GetReadReceipts(userID, id)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get read receipts")
+		return
+	}
+
+	c.JSON(http.StatusOK, reads)
+}
+
+// @Summary Send typing indicator
+// @Tags messages
+// @Security BearerAuth
+// @Param id path string true "Conversation ID"
+// @Success 204
+// @Router /conversations/{id}/typing [post]
+func (h *MessageHandler) Typing(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	if err := h.messageService.SendTyping(userID, id); err != nil {
+		respondServiceError(c, err, "Failed to send typing indicator")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
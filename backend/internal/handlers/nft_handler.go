@@ -30,19 +30,26 @@ func (h *NFTHandler) GetNFT(c *gin.Context) {
 
 	nft, err := h.nftService.GetNFTByTokenID(tokenID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "NFT not found"})
+		respondServiceError(c, err, "Failed to get NFT")
 		return
 	}
 
 	c.JSON(http.StatusOK, nft)
 }
 
+// @Summary Get user NFTs
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param address path string true "User address"
+// @Success 200 {array} models.NFT
+// @Router /users/{address}/nfts [get]
 func (h *NFTHandler) GetUserNFTs(c *gin.Context) {
 	address := c.Param("address")
 
 	nfts, err := h.nftService.GetUserNFTs(address)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get NFTs"})
+		respondServiceError(c, err, "Failed to get NFTs")
 		return
 	}
 
@@ -56,16 +63,59 @@ func (h *NFTHandler) GetMetadata(c *gin.Context) {
 		return
 	}
 
-	nft, err := h.nftService.GetNFTByTokenID(tokenID)
+	metadata, err := h.nftService.Metadata(tokenID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get NFT metadata")
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+// @Summary Get NFT tokenURI metadata
+// @Description OpenSea-style ERC-721 metadata for a Proof of Work NFT. The route is the tokenURI base: base + tokenId.
+// @Tags nfts
+// @Produce json
+// @Param tokenId path int true "Token ID"
+// @Success 200 {object} services.NFTMetadata
+// @Router /public/nfts/{tokenId} [get]
+func (h *NFTHandler) GetTokenMetadata(c *gin.Context) {
+	tokenID, err := strconv.ParseInt(c.Param("tokenId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	metadata, err := h.nftService.Metadata(tokenID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get NFT metadata")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, metadata)
+}
+
+// @Summary Get NFT image
+// @Description The certificate as SVG, as rendered by ProofOfWorkNFT.
+// @Tags nfts
+// @Produce image/svg+xml
+// @Param tokenId path int true "Token ID"
+// @Success 200 {string} string
+// @Router /public/nfts/{tokenId}/image.svg [get]
+func (h *NFTHandler) GetImage(c *gin.Context) {
+	tokenID, err := strconv.ParseInt(c.Param("tokenId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	svg, err := h.nftService.Image(tokenID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "NFT not found"})
+		respondServiceError(c, err, "Failed to render NFT image")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"name":        nft.Name,
-		"description": nft.Description,
-		"image":       nft.Image,
-		"attributes":  nft.Attributes,
-	})
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "image/svg+xml", []byte(svg))
 }
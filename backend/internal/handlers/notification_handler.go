@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	logger              *logrus.Logger
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService, logger *logrus.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+type UpdatePreferencesRequest struct {
+	Preferences []models.NotificationPreference `json:"preferences" binding:"required"`
+}
+
+type WebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// @Summary List notifications
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	unreadOnly := c.Query("unread") == "true"
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	notifications, total, err := h.notificationService.ListNotifications(userID, unreadOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"total":         total,
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
+
+// @Summary Count unread notifications
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	count, err := h.notificationService.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// @Summary Mark notification read
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/{id}/read [put]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	h.setRead(c, true)
+}
+
+// @Summary Mark notification unread
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/{id}/unread [put]
+func (h *NotificationHandler) MarkUnread(c *gin.Context) {
+	h.setRead(c, false)
+}
+
+func (h *NotificationHandler) setRead(c *gin.Context, read bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	if err := h.notificationService.SetRead(userID, id, read); err != nil {
+		respondServiceError(c, err, "Failed to update notification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "read": read})
+}
+
+// @Summary Mark all notifications read
+// @Tags notifications
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	if err := h.notificationService.MarkAllRead(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
+}
+
+// @Summary Get notification preferences
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	preferences, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get preferences"})
+		return
+	}
+
+	response := gin.H{"preferences": preferences, "webhook_url": nil}
+	if webhook, err := h.notificationService.GetWebhook(userID); err == nil {
+		response["webhook_url"] = webhook.URL
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Update notification preferences
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body UpdatePreferencesRequest true "Preferences by event type and channel"
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.notificationService.UpdatePreferences(userID, req.Preferences); err != nil {
+		respondServiceError(c, err, "Failed to update preferences")
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+// @Summary Set notification webhook
+// @Description Registers the webhook URL and returns a new signing secret. The secret is only shown once.
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body WebhookRequest true "Webhook URL"
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/webhook [put]
+func (h *NotificationHandler) SetWebhook(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, secret, err := h.notificationService.SetWebhook(userID, req.URL)
+	if err != nil {
+		respondServiceError(c, err, "Failed to set webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":    webhook.URL,
+		"secret": secret,
+	})
+}
+
+// @Summary Remove notification webhook
+// @Tags notifications
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/webhook [delete]
+func (h *NotificationHandler) DeleteWebhook(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	if err := h.notificationService.DeleteWebhook(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook removed"})
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/services"
@@ -12,14 +11,16 @@ import (
 )
 
 type ProjectHandler struct {
-	projectService *services.ProjectService
-	logger         *logrus.Logger
+	projectService   *services.ProjectService
+	analyticsService *services.AnalyticsService
+	logger           *logrus.Logger
 }
 
-func NewProjectHandler(projectService *services.ProjectService, logger *logrus.Logger) *ProjectHandler {
+func NewProjectHandler(projectService *services.ProjectService, analyticsService *services.AnalyticsService, logger *logrus.Logger) *ProjectHandler {
 	return &ProjectHandler{
-		projectService: projectService,
-		logger:         logger,
+		projectService:   projectService,
+		analyticsService: analyticsService,
+		logger:           logger,
 	}
 }
 
@@ -70,6 +71,10 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
 		return
 	}
 
+	userIDStr, _ := c.Get("user_id")
+	viewerID, _ := uuid.Parse(userIDStr.(string))
+	h.analyticsService.RecordProjectView(project, viewerID)
+
 	c.JSON(http.StatusOK, project)
 }
 
@@ -154,7 +159,7 @@ func (h *ProjectHandler) ApplyToProject(c *gin.Context) {
 	app.FreelancerID = freelancerID
 
 	if err := h.projectService.ApplyToProject(&app); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply"})
+		respondServiceError(c, err, "Failed to apply")
 		return
 	}
 
@@ -166,7 +171,8 @@ func (h *ProjectHandler) ApplyToProject(c *gin.Context) {
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "Project ID"
-// @Success 200 {array} models.Application
+// @Description Freelancers with a FARI stake boost are listed first and flagged as boosted.
+// @Success 200 {array} services.RankedApplication
 // @Router /projects/{id}/applications [get]
 func (h *ProjectHandler) GetApplications(c *gin.Context) {
 	projectID, err := uuid.Parse(c.Param("id"))
@@ -184,6 +190,10 @@ func (h *ProjectHandler) GetApplications(c *gin.Context) {
 	c.JSON(http.StatusOK, applications)
 }
 
+type UpdateApplicationRequest struct {
+	Status string `json:"status" binding:"required"` // accepted, rejected
+}
+
 // @Summary Update application status
 // @Tags projects
 // @Security BearerAuth
@@ -195,7 +205,34 @@ func (h *ProjectHandler) GetApplications(c *gin.Context) {
 // @Success 200 {object} models.Application
 // @Router /projects/{id}/applications/{appId} [put]
 func (h *ProjectHandler) UpdateApplication(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Application updated"})
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	appID, err := uuid.Parse(c.Param("appId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	clientID, _ := uuid.Parse(userIDStr.(string))
+
+	var req UpdateApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	app, err := h.projectService.UpdateApplicationStatus(clientID, projectID, appID, req.Status)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update application")
+		return
+	}
+
+	c.JSON(http.StatusOK, app)
 }
 
 // @Summary Complete project
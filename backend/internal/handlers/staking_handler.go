@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fariima/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type StakingHandler struct {
+	stakingService *services.StakingService
+	logger         *logrus.Logger
+}
+
+func NewStakingHandler(stakingService *services.StakingService, logger *logrus.Logger) *StakingHandler {
+	return &StakingHandler{
+		stakingService: stakingService,
+		logger:         logger,
+	}
+}
+
+// @Summary Get an address's FARI staking position
+// @Description Staked amount, pending unstake and when it can be withdrawn, voting power, vesting schedule with releasable amount, and recent staking history.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param address path string true "Wallet address"
+// @Success 200 {object} services.StakingOverview
+// @Router /users/{address}/staking [get]
+func (h *StakingHandler) GetStaking(c *gin.Context) {
+	overview, err := h.stakingService.GetOverview(c.Param("address"))
+	if err != nil {
+		respondServiceError(c, err, "Failed to get staking position")
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
@@ -111,16 +111,6 @@ func (h *UserHandler) GetUserProjects(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"user_id": user.ID})
 }
 
-// @Summary Get user NFTs
-// @Tags users
-// @Produce json
-// @Param address path string true "User address"
-// @Success 200 {array} models.NFT
-// @Router /users/{address}/nfts [get]
-func (h *UserHandler) GetUserNFTs(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"nfts": []interface{}{}})
-}
-
 // @Summary Follow user
 // @Tags users
 // @Security BearerAuth
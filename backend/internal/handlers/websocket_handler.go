@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/fariima/backend/internal/services"
 	"github.com/gin-gonic/gin"
@@ -9,16 +10,22 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// bearerProtocol is the subprotocol browsers offer along with the token,
+// since they cannot set an Authorization header on WebSocket requests.
+const bearerProtocol = "bearer"
+
 type WebSocketHandler struct {
-	wsService *services.WebSocketService
-	logger    *logrus.Logger
-	upgrader  websocket.Upgrader
+	wsService   *services.WebSocketService
+	authService *services.AuthService
+	logger      *logrus.Logger
+	upgrader    websocket.Upgrader
 }
 
-func NewWebSocketHandler(wsService *services.WebSocketService, logger *logrus.Logger) *WebSocketHandler {
+func NewWebSocketHandler(wsService *services.WebSocketService, authService *services.AuthService, logger *logrus.Logger) *WebSocketHandler {
 	return &WebSocketHandler{
-		wsService: wsService,
-		logger:    logger,
+		wsService:   wsService,
+		authService: authService,
+		logger:      logger,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -27,14 +34,30 @@ func NewWebSocketHandler(wsService *services.WebSocketService, logger *logrus.Lo
 	}
 }
 
+// @Summary Open the user's live connection
+// @Description Authenticated with the JWT, either in the Authorization header or, from browsers, as the `Sec-WebSocket-Protocol: bearer, <token>` subprotocols. The server answers with the `bearer` subprotocol.
+// @Tags websocket
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Router /ws [get]
 func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
-	userID := c.Query("user_id")
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id required"})
+	token, protocol := wsToken(c.Request)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+		return
+	}
+	claims, err := h.authService.ValidateToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 		return
 	}
+	userID := claims.UserID
 
-	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	var header http.Header
+	if protocol != "" {
+		header = http.Header{"Sec-WebSocket-Protocol": {protocol}}
+	}
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, header)
 	if err != nil {
 		h.logger.Errorf("Failed to upgrade connection: %v", err)
 		return
@@ -43,7 +66,7 @@ func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 	h.wsService.AddClient(userID, conn)
 
 	defer func() {
-		h.wsService.RemoveClient(userID)
+		h.wsService.RemoveClient(userID, conn)
 		conn.Close()
 	}()
 
@@ -54,3 +77,53 @@ func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 		}
 	}
 }
+
+// wsToken returns the JWT of an upgrade request and the subprotocol to
+// accept, which is empty when the token came in the Authorization header.
+func wsToken(r *http.Request) (token, protocol string) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if scheme, token, ok := strings.Cut(header, " "); ok && scheme == "Bearer" {
+			return token, ""
+		}
+		return "", ""
+	}
+
+	protocols := websocket.Subprotocols(r)
+	if len(protocols) == 2 && protocols[0] == bearerProtocol {
+		return protocols[1], bearerProtocol
+	}
+	return "", ""
+}
+
+// @Summary Subscribe to a public topic
+// @Description Anonymous, read-only stream. The `disputes` topic carries redacted dispute updates.
+// @Tags websocket
+// @Param topic query string true "Topic (disputes)"
+// @Router /ws/public [get]
+func (h *WebSocketHandler) HandlePublicConnection(c *gin.Context) {
+	topic := c.Query("topic")
+	if !services.PublicTopics[topic] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown topic"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Errorf("Failed to upgrade connection: %v", err)
+		return
+	}
+
+	h.wsService.Subscribe(topic, conn)
+
+	defer func() {
+		h.wsService.Unsubscribe(topic, conn)
+		conn.Close()
+	}()
+
+	// Subscribers only listen; reading detects the close.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package imagemeta removes metadata from images: EXIF (which can include
+// GPS coordinates and camera serial numbers), XMP, IPTC and text comments.
+// Pixel data and color profiles are copied unchanged, without re-encoding.
+package imagemeta
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrMalformed is returned when an image's structure cannot be parsed.
+var ErrMalformed = errors.New("malformed image")
+
+var (
+	jpegMagic = []byte{0xFF, 0xD8, 0xFF}
+	pngMagic  = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// Strip copies src to dst without metadata if src is a JPEG, PNG or WebP
+// image, and unchanged otherwise. dst must be seekable so the size in a
+// WebP header can be rewritten.
+//
+// Dropping EXIF also drops the orientation tag, so photos that relied on it
+// display as the camera stored them.
+func Strip(dst io.WriteSeeker, src io.Reader) error {
+	r := bufio.NewReader(src)
+	head, _ := r.Peek(12)
+
+	switch {
+	case bytes.HasPrefix(head, jpegMagic):
+		return stripJPEG(dst, r)
+	case bytes.HasPrefix(head, pngMagic):
+		return stripPNG(dst, r)
+	case len(head) == 12 && string(head[:4]) == "RIFF" && string(head[8:]) == "WEBP":
+		return stripWebP(dst, r)
+	default:
+		_, err := io.Copy(dst, r)
+		return err
+	}
+}
+
+// stripJPEG drops APP1 (EXIF, XMP), APP13 (IPTC) and COM segments before the
+// start of scan. Everything from the first scan on is copied as is.
+func stripJPEG(dst io.Writer, r *bufio.Reader) error {
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(r, soi); err != nil {
+		return ErrMalformed
+	}
+	if _, err := dst.Write(soi); err != nil {
+		return err
+	}
+
+	for {
+		prefix, err := r.ReadByte()
+		if err != nil || prefix != 0xFF {
+			return ErrMalformed
+		}
+		marker, err := r.ReadByte()
+		for err == nil && marker == 0xFF { // Fill bytes
+			marker, err = r.ReadByte()
+		}
+		if err != nil {
+			return ErrMalformed
+		}
+
+		switch {
+		case marker == 0xDA || marker == 0xD9: // Start of scan, end of image
+			if _, err := dst.Write([]byte{0xFF, marker}); err != nil {
+				return err
+			}
+			_, err := io.Copy(dst, r)
+			return err
+		case marker >= 0xD0 && marker <= 0xD7 || marker == 0x01: // No payload
+			if _, err := dst.Write([]byte{0xFF, marker}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+			return ErrMalformed
+		}
+
+		if marker == 0xE1 || marker == 0xED || marker == 0xFE {
+			if _, err := r.Discard(int(length) - 2); err != nil {
+				return ErrMalformed
+			}
+			continue
+		}
+
+		header := []byte{0xFF, marker, byte(length >> 8), byte(length)}
+		if _, err := dst.Write(header); err != nil {
+			return err
+		}
+		if err := copyN(dst, r, int64(length)-2); err != nil {
+			return err
+		}
+	}
+}
+
+// pngMetadata are the chunks stripPNG drops.
+var pngMetadata = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPNG(dst io.Writer, r *bufio.Reader) error {
+	if err := copyN(dst, r, int64(len(pngMagic))); err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return ErrMalformed
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		chunkType := string(header[4:])
+
+		if pngMetadata[chunkType] {
+			if _, err := r.Discard(int(length) + 4); err != nil {
+				return ErrMalformed
+			}
+			continue
+		}
+
+		if _, err := dst.Write(header); err != nil {
+			return err
+		}
+		if err := copyN(dst, r, length+4); err != nil { // Data and CRC
+			return err
+		}
+		if chunkType == "IEND" {
+			return nil
+		}
+	}
+}
+
+// VP8X flags announcing EXIF and XMP chunks.
+const (
+	vp8xEXIF = 0x08
+	vp8xXMP  = 0x04
+)
+
+// stripWebP drops EXIF and XMP chunks, clears their VP8X flags and rewrites
+// the RIFF size.
+func stripWebP(dst io.WriteSeeker, r *bufio.Reader) error {
+	start, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return ErrMalformed
+	}
+	remaining := int64(binary.LittleEndian.Uint32(header[4:])) - 4
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	size := int64(4) // "WEBP"
+	chunk := make([]byte, 8)
+	for remaining >= 8 {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return ErrMalformed
+		}
+		fourCC := string(chunk[:4])
+		length := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		padded := length + length&1
+		remaining -= 8 + padded
+
+		if fourCC == "EXIF" || fourCC == "XMP " {
+			if _, err := r.Discard(int(padded)); err != nil {
+				return ErrMalformed
+			}
+			continue
+		}
+
+		if _, err := dst.Write(chunk); err != nil {
+			return err
+		}
+		if fourCC == "VP8X" && padded > 0 {
+			flags, err := r.ReadByte()
+			if err != nil {
+				return ErrMalformed
+			}
+			if _, err := dst.Write([]byte{flags &^ (vp8xEXIF | vp8xXMP)}); err != nil {
+				return err
+			}
+			padded--
+		}
+		if err := copyN(dst, r, padded); err != nil {
+			return err
+		}
+		size += 8 + length + length&1
+	}
+	if remaining != 0 {
+		return ErrMalformed
+	}
+
+	end, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Seek(start+4, io.SeekStart); err != nil {
+		return err
+	}
+	if err := binary.Write(dst, binary.LittleEndian, uint32(size)); err != nil {
+		return err
+	}
+	_, err = dst.Seek(end, io.SeekStart)
+	return err
+}
+
+func copyN(dst io.Writer, r io.Reader, n int64) error {
+	written, err := io.CopyN(dst, r, n)
+	if written < n {
+		if err == nil || err == io.EOF {
+			return ErrMalformed
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The fixtures are small real images with metadata added: EXIF, XMP, IPTC
+// and a comment in exif.jpg; eXIf, tEXt, zTXt, iTXt and tIME in text.png;
+// EXIF and XMP chunks in exif.webp. Every piece of metadata contains
+// "fariima-test".
+var metadataMarker = []byte("fariima-test")
+
+func strip(t *testing.T, src []byte) []byte {
+	t.Helper()
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "stripped"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := Strip(f, bytes.NewReader(src)); err != nil {
+		t.Fatalf("Strip: %v", err)
+	}
+	out, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		fixture string
+		check   func(t *testing.T, original, stripped []byte)
+	}{
+		{"exif.jpg", samePixels(jpeg.Decode)},
+		{"text.png", samePixels(png.Decode)},
+		{"exif.webp", sameWebPChunks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			original := readFixture(t, tt.fixture)
+			if !bytes.Contains(original, metadataMarker) {
+				t.Fatalf("fixture has no metadata to strip")
+			}
+
+			stripped := strip(t, original)
+			if bytes.Contains(stripped, metadataMarker) {
+				t.Errorf("metadata left in the output")
+			}
+			if len(stripped) >= len(original) {
+				t.Errorf("output is %d bytes, want less than %d", len(stripped), len(original))
+			}
+			tt.check(t, original, stripped)
+
+			if again := strip(t, stripped); !bytes.Equal(again, stripped) {
+				t.Errorf("stripping a clean image changed it")
+			}
+		})
+	}
+}
+
+func samePixels(decode func(r io.Reader) (image.Image, error)) func(t *testing.T, original, stripped []byte) {
+	return func(t *testing.T, original, stripped []byte) {
+		want, err := decode(bytes.NewReader(original))
+		if err != nil {
+			t.Fatalf("decoding the fixture: %v", err)
+		}
+		got, err := decode(bytes.NewReader(stripped))
+		if err != nil {
+			t.Fatalf("decoding the output: %v", err)
+		}
+
+		if got.Bounds() != want.Bounds() {
+			t.Fatalf("bounds are %v, want %v", got.Bounds(), want.Bounds())
+		}
+		bounds := want.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if got.At(x, y) != want.At(x, y) {
+					t.Fatalf("pixel (%d, %d) is %v, want %v", x, y, got.At(x, y), want.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+type riffChunk struct {
+	fourCC string
+	data   []byte
+}
+
+func parseWebP(t *testing.T, data []byte) []riffChunk {
+	t.Helper()
+
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		t.Fatalf("not a WebP file")
+	}
+	if size := binary.LittleEndian.Uint32(data[4:]); int(size) != len(data)-8 {
+		t.Fatalf("RIFF size is %d, want %d", size, len(data)-8)
+	}
+
+	var chunks []riffChunk
+	for rest := data[12:]; len(rest) > 0; {
+		if len(rest) < 8 {
+			t.Fatalf("truncated chunk header")
+		}
+		length := int(binary.LittleEndian.Uint32(rest[4:]))
+		padded := length + length&1
+		if len(rest) < 8+padded {
+			t.Fatalf("truncated %q chunk", rest[:4])
+		}
+		chunks = append(chunks, riffChunk{string(rest[:4]), rest[8 : 8+length]})
+		rest = rest[8+padded:]
+	}
+	return chunks
+}
+
+func sameWebPChunks(t *testing.T, original, stripped []byte) {
+	var want []riffChunk
+	for _, c := range parseWebP(t, original) {
+		if c.fourCC != "EXIF" && c.fourCC != "XMP " {
+			want = append(want, c)
+		}
+	}
+	got := parseWebP(t, stripped)
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].fourCC != want[i].fourCC {
+			t.Fatalf("chunk %d is %q, want %q", i, got[i].fourCC, want[i].fourCC)
+		}
+		if got[i].fourCC == "VP8X" {
+			if flags := got[i].data[0]; flags&(vp8xEXIF|vp8xXMP) != 0 {
+				t.Errorf("VP8X flags %#x still announce metadata", flags)
+			}
+			if !bytes.Equal(got[i].data[1:], want[i].data[1:]) {
+				t.Errorf("VP8X chunk changed beyond its flags")
+			}
+			continue
+		}
+		if !bytes.Equal(got[i].data, want[i].data) {
+			t.Errorf("%q chunk changed", got[i].fourCC)
+		}
+	}
+}
+
+func TestStripCopiesOtherContent(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"pdf", []byte("%PDF-1.4\n% fariima-test\n")},
+		{"short", []byte("RIFF")},
+		{"empty", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strip(t, tt.data); !bytes.Equal(got, tt.data) {
+				t.Errorf("got %q, want the input unchanged", got)
+			}
+		})
+	}
+}
+
+func TestStripMalformed(t *testing.T) {
+	jpg := readFixture(t, "exif.jpg")
+	pngData := readFixture(t, "text.png")
+	webp := readFixture(t, "exif.webp")
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"jpeg without segments", jpg[:2+3]},
+		{"jpeg cut inside a segment", jpg[:30]},
+		{"png without IEND", pngData[:len(pngData)-12]},
+		{"png cut inside a chunk", pngData[:40]},
+		{"webp cut inside a chunk", webp[:40]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Create(filepath.Join(t.TempDir(), "stripped"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if err := Strip(f, bytes.NewReader(tt.data)); !errors.Is(err, ErrMalformed) {
+				t.Errorf("got %v, want ErrMalformed", err)
+			}
+		})
+	}
+}
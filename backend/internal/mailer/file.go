@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// FileMailer writes each message as an .eml file, which is handy for local
+// development and for asserting on outgoing mail without an SMTP server.
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o644)
+}
+
+// WriterMailer dumps messages to a writer such as stdout.
+type WriterMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterMailer(w io.Writer) *WriterMailer {
+	return &WriterMailer{w: w}
+}
+
+func (m *WriterMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "----- email to %s -----\nSubject: %s\n\n%s\n----- end email -----\n",
+		msg.To, msg.Subject, msg.Text)
+	return err
+}
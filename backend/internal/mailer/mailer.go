@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fariima/backend/internal/config"
+)
+
+// Message is a fully rendered email ready to hand to a Mailer.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string
+}
+
+// Mailer delivers a single message. Implementations must be safe for
+// concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New returns the mailer selected by MAIL_DRIVER.
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword), nil
+	case "file":
+		return NewFileMailer(cfg.MailFileDir)
+	case "stdout", "":
+		return NewWriterMailer(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MailDriver)
+	}
+}
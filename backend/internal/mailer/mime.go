@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"sort"
+	"time"
+)
+
+// Bytes encodes the message as a multipart/alternative MIME document with a
+// plain text part followed by an HTML part.
+func (m *Message) Bytes() ([]byte, error) {
+	boundaryBytes := make([]byte, 16)
+	if _, err := rand.Read(boundaryBytes); err != nil {
+		return nil, err
+	}
+	boundary := "fariima-" + hex.EncodeToString(boundaryBytes)
+
+	var buf bytes.Buffer
+
+	headers := map[string]string{
+		"From":         m.From,
+		"To":           m.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+		"Content-Type": fmt.Sprintf("multipart/alternative; boundary=%q", boundary),
+	}
+	for key, value := range m.Headers {
+		headers[key] = value
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headers[key])
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		writer := quotedprintable.NewWriter(&buf)
+		if _, err := writer.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// envelopeAddress extracts the bare address from a "Name <addr>" header value.
+func envelopeAddress(value string) (string, error) {
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+	return addr.Address, nil
+}
@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends mail through an SMTP relay, upgrading to TLS with
+// STARTTLS whenever the server offers it.
+type SMTPMailer struct {
+	host     string
+	addr     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(host string, port int, username, password string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		addr:     net.JoinHostPort(host, fmt.Sprint(port)),
+		username: username,
+		password: password,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	from, err := envelopeAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	to, err := envelopeAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,109 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// DefaultLocale is used when a template is not available in the requested
+// locale.
+const DefaultLocale = "en"
+
+// Locales lists the languages templates are provided in.
+var Locales = []string{"en", "fa"}
+
+// Template names.
+const (
+	TemplateVerifyEmail  = "verify_email"
+	TemplateNotification = "notification"
+	TemplateDigest       = "digest"
+)
+
+var templateNames = []string{TemplateVerifyEmail, TemplateNotification, TemplateDigest}
+
+// Renderer renders the embedded templates. Each template has a .txt file that
+// defines a "subject" block and the plain text body, and a .html file that
+// defines a "content" block wrapped by the locale's layout.html.
+type Renderer struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+
+	for _, locale := range Locales {
+		for _, name := range templateNames {
+			key := locale + "/" + name
+
+			text, err := texttemplate.ParseFS(templateFS, "templates/"+key+".txt")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s.txt: %w", key, err)
+			}
+			r.text[key] = text
+
+			html, err := htmltemplate.ParseFS(templateFS,
+				"templates/"+locale+"/layout.html", "templates/"+key+".html")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s.html: %w", key, err)
+			}
+			r.html[key] = html
+		}
+	}
+
+	return r, nil
+}
+
+// Render returns the subject, HTML body and text body for a template.
+func (r *Renderer) Render(name, locale string, data interface{}) (subject, html, text string, err error) {
+	key := locale + "/" + name
+	if _, ok := r.text[key]; !ok {
+		key = DefaultLocale + "/" + name
+	}
+
+	textTmpl, ok := r.text[key]
+	if !ok {
+		return "", "", "", fmt.Errorf("unknown email template %q", name)
+	}
+
+	var buf bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := textTmpl.Execute(&buf, data); err != nil {
+		return "", "", "", err
+	}
+	text = strings.TrimSpace(buf.String()) + "\n"
+
+	buf.Reset()
+	if err := r.html[key].ExecuteTemplate(&buf, "layout.html", data); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+
+	return subject, html, text, nil
+}
+
+// NormalizeLocale maps a user's locale to one templates exist for.
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(locale)
+	for _, supported := range Locales {
+		if locale == supported || strings.HasPrefix(locale, supported+"-") {
+			return supported
+		}
+	}
+	return DefaultLocale
+}
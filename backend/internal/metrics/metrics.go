@@ -0,0 +1,398 @@
+// Package metrics keeps counters, gauges and histograms in memory and
+// exposes them in the Prometheus text format.
+//
+// Metrics are declared as package-level variables next to the code that
+// updates them and register themselves with the default registry.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types of the exposition format.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefBuckets are latency buckets in seconds for requests to this API.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Labels are constant label values of a function metric.
+type Labels map[string]string
+
+// sample is one line of a metric family.
+type sample struct {
+	suffix string // _bucket, _sum or _count for histograms
+	labels string // Rendered, e.g. `method="GET",route="/health"`
+	value  float64
+}
+
+// collector produces the samples of a metric family at scrape time.
+type collector interface {
+	collect() []sample
+}
+
+type family struct {
+	name       string
+	help       string
+	typ        string
+	shared     bool // Made of function metrics
+	collectors []collector
+}
+
+// Registry is a set of metric families, written in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+	byName   map[string]*family
+}
+
+// Default is the registry the metric constructors register with.
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]*family)}
+}
+
+// register adds a collector to the family of the given name. Function
+// metrics with different constant labels share a family; anything else
+// registered twice is a programming error.
+func (r *Registry) register(name, help, typ string, c collector, shared bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.byName[name]
+	if ok {
+		if !shared || !f.shared || f.typ != typ {
+			panic(fmt.Sprintf("metrics: %s registered twice", name))
+		}
+		f.collectors = append(f.collectors, c)
+		return
+	}
+
+	f = &family{name: name, help: help, typ: typ, shared: shared, collectors: []collector{c}}
+	r.families = append(r.families, f)
+	r.byName[name] = f
+}
+
+// WriteTo writes every metric in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		var samples []sample
+		for _, c := range f.collectors {
+			samples = append(samples, c.collect()...)
+		}
+
+		fmt.Fprintf(cw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(cw, "# TYPE %s %s\n", f.name, f.typ)
+		for _, s := range samples {
+			cw.writeString(f.name + s.suffix)
+			if s.labels != "" {
+				cw.writeString("{" + s.labels + "}")
+			}
+			cw.writeString(" " + formatValue(s.value) + "\n")
+		}
+	}
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// vec holds the series of a labeled metric by their label values.
+type vec struct {
+	labelNames []string
+	mu         sync.RWMutex
+	series     map[string]interface{}
+	order      []string
+}
+
+func newVec(labelNames []string) *vec {
+	return &vec{labelNames: labelNames, series: make(map[string]interface{})}
+}
+
+// get returns the series for the label values, creating it with create.
+func (v *vec) get(values []string, create func() interface{}) interface{} {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: got %d label values for %d labels", len(values), len(v.labelNames)))
+	}
+	key := renderLabels(v.labelNames, values)
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s
+	}
+	s = create()
+	v.series[key] = s
+	v.order = append(v.order, key)
+	sort.Strings(v.order)
+	return s
+}
+
+// each calls fn for every series in label order.
+func (v *vec) each(fn func(labels string, s interface{})) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, key := range v.order {
+		fn(key, v.series[key])
+	}
+}
+
+// value is a float64 safe for concurrent use.
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (v *value) add(delta float64) {
+	v.mu.Lock()
+	v.v += delta
+	v.mu.Unlock()
+}
+
+func (v *value) set(x float64) {
+	v.mu.Lock()
+	v.v = x
+	v.mu.Unlock()
+}
+
+func (v *value) get() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.v
+}
+
+// Counter only goes up.
+type Counter struct{ value }
+
+func (c *Counter) Inc() { c.add(1) }
+
+// Add adds a non-negative delta.
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.add(delta)
+}
+
+type CounterVec struct{ *vec }
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{newVec(labelNames)}
+	Default.register(name, help, typeCounter, c, false)
+	return c
+}
+
+func (c *CounterVec) WithLabelValues(values ...string) *Counter {
+	return c.get(values, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+func (c *CounterVec) collect() []sample {
+	var samples []sample
+	c.each(func(labels string, s interface{}) {
+		samples = append(samples, sample{labels: labels, value: s.(*Counter).get()})
+	})
+	return samples
+}
+
+// Gauge goes up and down.
+type Gauge struct{ value }
+
+func (g *Gauge) Set(x float64) { g.set(x) }
+func (g *Gauge) Inc()          { g.add(1) }
+func (g *Gauge) Dec()          { g.add(-1) }
+
+// NewGauge registers a gauge without labels.
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{}
+	Default.register(name, help, typeGauge, gaugeCollector{g}, false)
+	return g
+}
+
+type gaugeCollector struct{ g *Gauge }
+
+func (c gaugeCollector) collect() []sample {
+	return []sample{{value: c.g.get()}}
+}
+
+// Histogram counts observations in cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	upper   []float64
+	buckets []uint64 // Per bucket, not cumulative
+	count   uint64
+	sum     float64
+}
+
+func (h *Histogram) Observe(x float64) {
+	i := sort.SearchFloat64s(h.upper, x)
+	h.mu.Lock()
+	if i < len(h.buckets) {
+		h.buckets[i]++
+	}
+	h.count++
+	h.sum += x
+	h.mu.Unlock()
+}
+
+type HistogramVec struct {
+	*vec
+	buckets []float64
+}
+
+// NewHistogramVec registers a histogram with the given upper bucket bounds,
+// in increasing order; the +Inf bucket is implicit.
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: buckets of %s are not sorted", name))
+	}
+	h := &HistogramVec{vec: newVec(labelNames), buckets: buckets}
+	Default.register(name, help, typeHistogram, h, false)
+	return h
+}
+
+func (h *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return h.get(values, func() interface{} {
+		return &Histogram{upper: h.buckets, buckets: make([]uint64, len(h.buckets))}
+	}).(*Histogram)
+}
+
+func (h *HistogramVec) collect() []sample {
+	var samples []sample
+	h.each(func(labels string, s interface{}) {
+		hist := s.(*Histogram)
+		hist.mu.Lock()
+		defer hist.mu.Unlock()
+
+		prefix := labels
+		if prefix != "" {
+			prefix += ","
+		}
+		var cumulative uint64
+		for i, upper := range hist.upper {
+			cumulative += hist.buckets[i]
+			samples = append(samples, sample{
+				suffix: "_bucket",
+				labels: prefix + `le="` + formatValue(upper) + `"`,
+				value:  float64(cumulative),
+			})
+		}
+		samples = append(samples,
+			sample{suffix: "_bucket", labels: prefix + `le="+Inf"`, value: float64(hist.count)},
+			sample{suffix: "_sum", labels: labels, value: hist.sum},
+			sample{suffix: "_count", labels: labels, value: float64(hist.count)},
+		)
+	})
+	return samples
+}
+
+// funcCollector reads a value kept elsewhere at scrape time.
+type funcCollector struct {
+	labels string
+	fn     func() float64
+}
+
+func (c funcCollector) collect() []sample {
+	return []sample{{labels: c.labels, value: c.fn()}}
+}
+
+// NewGaugeFunc registers a gauge read from fn at scrape time. Gauges
+// registered under one name with different labels form one metric.
+func NewGaugeFunc(name, help string, labels Labels, fn func() float64) {
+	Default.register(name, help, typeGauge, newFuncCollector(labels, fn), true)
+}
+
+// NewCounterFunc registers a counter read from fn at scrape time, for
+// totals kept by other packages.
+func NewCounterFunc(name, help string, labels Labels, fn func() float64) {
+	Default.register(name, help, typeCounter, newFuncCollector(labels, fn), true)
+}
+
+func newFuncCollector(labels Labels, fn func() float64) funcCollector {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = labels[name]
+	}
+	return funcCollector{labels: renderLabels(names, values), fn: fn}
+}
+
+func renderLabels(names, values []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
+
+func (w *countingWriter) writeString(s string) {
+	w.Write([]byte(s))
+}
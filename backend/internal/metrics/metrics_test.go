@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// useRegistry points the metric constructors at an empty registry for the
+// rest of the test.
+func useRegistry(t *testing.T) *Registry {
+	t.Helper()
+	previous := Default
+	Default = NewRegistry()
+	t.Cleanup(func() { Default = previous })
+	return Default
+}
+
+// checkGolden compares what the registry writes with testdata/name.golden.
+func checkGolden(t *testing.T, r *Registry, name string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("output differs from %s:\n%s", path, buf.Bytes())
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	r := useRegistry(t)
+
+	requests := NewCounterVec("test_requests_total", "Requests by path.\nPaths are \"raw\", e.g. C:\\temp.", "path")
+	requests.WithLabelValues(`C:\temp`).Inc()
+	requests.WithLabelValues("say \"hi\"").Add(2)
+	requests.WithLabelValues("two\nlines").Add(0.5)
+
+	checkGolden(t, r, "escaping")
+}
+
+func TestHistogram(t *testing.T) {
+	r := useRegistry(t)
+
+	latency := NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.25, 0.5, 1}, "route")
+	// Bounds are inclusive; 4 only lands in +Inf
+	for _, x := range []float64{0.25, 0.5, 0.75, 4} {
+		latency.WithLabelValues("/b").Observe(x)
+	}
+	latency.WithLabelValues("/a").Observe(0.125)
+
+	unlabeled := NewHistogramVec("test_unlabeled_seconds", "No labels.", []float64{1})
+	unlabeled.WithLabelValues().Observe(2)
+
+	checkGolden(t, r, "histogram")
+}
+
+func TestSharedFamilies(t *testing.T) {
+	r := useRegistry(t)
+
+	// Written in registration order, with the series of a vec sorted
+	queue := NewGauge("test_queue_depth", "Queued jobs.")
+	queue.Set(3)
+	queue.Inc()
+	queue.Dec()
+	queue.Dec()
+
+	NewGaugeFunc("test_pool_connections", "Connections by state.", Labels{"state": "idle", "pool": "db"}, func() float64 { return 2 })
+	NewGaugeFunc("test_pool_connections", "Ignored, the first help wins.", Labels{"state": "in_use", "pool": "db"}, func() float64 { return 5 })
+	NewCounterFunc("test_cache_hits_total", "Cache hits.", nil, func() float64 { return 1e21 })
+	NewGaugeFunc("test_special_values", "Special values.", Labels{"v": "inf"}, func() float64 { return math.Inf(1) })
+	NewGaugeFunc("test_special_values", "", Labels{"v": "nan"}, func() float64 { return math.NaN() })
+
+	checkGolden(t, r, "shared")
+}
+
+func TestRegisterPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func()
+	}{
+		{"vec twice", func() {
+			NewCounterVec("test_total", "")
+			NewCounterVec("test_total", "")
+		}},
+		{"func metrics of different types", func() {
+			NewGaugeFunc("test_value", "", Labels{"a": "1"}, func() float64 { return 0 })
+			NewCounterFunc("test_value", "", Labels{"a": "2"}, func() float64 { return 0 })
+		}},
+		{"func metric sharing a vec's name", func() {
+			NewGauge("test_gauge", "")
+			NewGaugeFunc("test_gauge", "", nil, func() float64 { return 0 })
+		}},
+		{"unsorted buckets", func() {
+			NewHistogramVec("test_seconds", "", []float64{1, 0.5})
+		}},
+		{"wrong number of label values", func() {
+			NewCounterVec("test_labeled_total", "", "a", "b").WithLabelValues("x")
+		}},
+		{"decreasing counter", func() {
+			NewCounterVec("test_decreasing_total", "").WithLabelValues().Add(-1)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRegistry(t)
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			tt.register()
+		})
+	}
+}
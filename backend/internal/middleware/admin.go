@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Admin only lets wallets listed in ADMIN_ADDRESSES through. It must run
+// after Auth.
+func Admin(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.IsAdmin(c.GetString("address")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so scanners probing
+// random paths and methods cannot blow up the number of series.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequestDuration = metrics.NewHistogramVec(
+		"fariima_http_request_duration_seconds",
+		"Latency of HTTP requests by route.",
+		metrics.DefBuckets,
+		"method", "route",
+	)
+	httpRequests = metrics.NewCounterVec(
+		"fariima_http_requests_total",
+		"HTTP requests by route and status code.",
+		"method", "route", "status",
+	)
+)
+
+// Metrics records the latency and status of requests, labeled by route
+// pattern rather than path.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route, method := c.FullPath(), c.Request.Method
+		if route == "" {
+			route, method = unmatchedRoute, unmatchedRoute
+		}
+		httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	}
+}
+
+// MetricsAuth requires METRICS_TOKEN as a bearer token, if it is set. It
+// always is in release mode, which config validation enforces.
+func MetricsAuth(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.MetricsToken == "" {
+			c.Next()
+			return
+		}
+
+		expected := "Bearer " + cfg.MetricsToken
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(expected)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -10,12 +10,33 @@ import (
 type DisputeStatus string
 
 const (
-	DisputeStatusOpen     DisputeStatus = "open"
-	DisputeStatusVoting   DisputeStatus = "voting"
-	DisputeStatusResolved DisputeStatus = "resolved"
-	DisputeStatusClosed   DisputeStatus = "closed"
+	DisputeStatusOpen                 DisputeStatus = "open"
+	DisputeStatusVoting               DisputeStatus = "voting"
+	DisputeStatusAwaitingFinalization DisputeStatus = "awaiting_finalization"
+	DisputeStatusResolved             DisputeStatus = "resolved"
+	DisputeStatusClosed               DisputeStatus = "closed"
 )
 
+// Dispute categories. Categories appear on the public dispute feed, so
+// disputes can only be filed under one of these.
+const (
+	DisputeCategoryQuality       = "quality"
+	DisputeCategoryPayment       = "payment"
+	DisputeCategoryDeadline      = "deadline"
+	DisputeCategoryScope         = "scope"
+	DisputeCategoryCommunication = "communication"
+	DisputeCategoryOther         = "other"
+)
+
+var DisputeCategories = []string{
+	DisputeCategoryQuality,
+	DisputeCategoryPayment,
+	DisputeCategoryDeadline,
+	DisputeCategoryScope,
+	DisputeCategoryCommunication,
+	DisputeCategoryOther,
+}
+
 type Dispute struct {
 	ID          uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	ProjectID   uuid.UUID     `json:"project_id" gorm:"type:uuid;not null;index"`
@@ -24,13 +45,13 @@ type Dispute struct {
 	Escrow      Escrow        `json:"escrow" gorm:"foreignKey:EscrowID"`
 	
 	// Blockchain data
-	OnChainID   int64         `json:"on_chain_id" gorm:"uniqueIndex;not null"`
+	OnChainID   *int64        `json:"on_chain_id" gorm:"uniqueIndex"` // Set once initiated on-chain
 	InitiatorAddr string      `json:"initiator_address" gorm:"not null"`
 	
 	// Dispute details
 	Title       string        `json:"title" gorm:"not null"`
 	Description string        `json:"description" gorm:"type:text;not null"`
-	Category    string        `json:"category"` // quality, payment, deadline, etc.
+	Category    string        `json:"category"` // One of DisputeCategories
 	
 	// Evidence
 	Evidence    []Evidence    `json:"evidence" gorm:"foreignKey:DisputeID"`
@@ -40,9 +61,12 @@ type Dispute struct {
 	TotalVotes   int          `json:"total_votes" gorm:"default:0"`
 	ClientVotes  int          `json:"client_votes" gorm:"default:0"`
 	FreelancerVotes int       `json:"freelancer_votes" gorm:"default:0"`
+	ClientWeight string       `json:"client_weight" gorm:"type:numeric(78,0);not null;default:0"` // Voting power behind the client, wei
+	FreelancerWeight string   `json:"freelancer_weight" gorm:"type:numeric(78,0);not null;default:0"` // Voting power behind the freelancer, wei
+	DeadlineWarningsSent int  `json:"-" gorm:"default:0"` // Deadline reminders already sent
 	
 	// Resolution
-	Status      DisputeStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Status      DisputeStatus `json:"status" gorm:"type:varchar(30);not null;index"`
 	Resolution  string        `json:"resolution" gorm:"type:text"`
 	ClientSplit int           `json:"client_split"` // Percentage (0-100)
 	FreelancerSplit int       `json:"freelancer_split"` // Percentage (0-100)
@@ -66,27 +90,44 @@ type Evidence struct {
 	Title      string    `json:"title" gorm:"not null"`
 	Description string   `json:"description" gorm:"type:text"`
 	FileType   string    `json:"file_type"` // document, image, video
+	FileName   string    `json:"file_name"`
+	MimeType   string    `json:"mime_type"`
+	FileSize   int64     `json:"file_size"`
 	IPFSHash   string    `json:"ipfs_hash" gorm:"not null"`
 	FileURL    string    `json:"file_url"`
+	ContentHash string   `json:"content_hash" gorm:"type:char(64)"` // SHA-256 of the file, hex
+	UploadID   *uuid.UUID `json:"upload_id,omitempty" gorm:"type:uuid;index"`
+	Quarantined bool     `json:"quarantined" gorm:"default:false"` // Flagged by the malware scanner, unreadable until released
 	
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+const (
+	VoteForClient     = "client"
+	VoteForFreelancer = "freelancer"
+)
+
+// Vote is a juror's on-chain DisputeVoted vote. One per wallet per dispute.
 type Vote struct {
 	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	DisputeID  uuid.UUID `json:"dispute_id" gorm:"type:uuid;not null;index"`
-	VoterID    uuid.UUID `json:"voter_id" gorm:"type:uuid;not null;index"`
-	Voter      User      `json:"voter" gorm:"foreignKey:VoterID"`
+	DisputeID  uuid.UUID `json:"dispute_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_votes_dispute_voter,priority:1;uniqueIndex:idx_votes_dispute_addr,priority:1"`
+	VoterID    *uuid.UUID `json:"voter_id" gorm:"type:uuid;index;uniqueIndex:idx_votes_dispute_voter,priority:2"` // Nil if the wallet has no account
+	Voter      *User     `json:"voter,omitempty" gorm:"foreignKey:VoterID"`
 	
 	// Vote details
-	VoterAddr  string    `json:"voter_address" gorm:"not null"`
+	VoterAddr  string    `json:"voter_address" gorm:"not null;uniqueIndex:idx_votes_dispute_addr,priority:2"` // Lowercase
 	VoteFor    string    `json:"vote_for" gorm:"not null"` // client, freelancer
-	Weight     int64     `json:"weight" gorm:"not null"` // Staked FARI amount
+	Weight     string    `json:"weight" gorm:"not null"` // FARI voting power at the voting block (wei)
+	Unassigned bool      `json:"unassigned" gorm:"not null;default:false"` // Voter held no active juror assignment
 	
 	// Blockchain
-	TxHash     string    `json:"tx_hash" gorm:"not null"`
+	TxHash     string    `json:"tx_hash" gorm:"not null;uniqueIndex"`
 	BlockNumber uint64   `json:"block_number"`
 	
+	// Juror reward, claimed with claimJurorRewards once the dispute is finalized
+	RewardTxHash    string     `json:"reward_tx_hash,omitempty"`
+	RewardClaimedAt *time.Time `json:"reward_claimed_at"`
+	
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EmailStatus string
+
+const (
+	EmailStatusPending    EmailStatus = "pending"
+	EmailStatusSent       EmailStatus = "sent"
+	EmailStatusFailed     EmailStatus = "failed"
+	EmailStatusSuppressed EmailStatus = "suppressed"
+)
+
+// EmailCategory decides which suppressions apply. Unsubscribing only stops
+// notification emails; account emails are still sent unless the address
+// bounced.
+type EmailCategory string
+
+const (
+	EmailCategoryAccount      EmailCategory = "account"
+	EmailCategoryNotification EmailCategory = "notification"
+)
+
+// EmailMessage is a rendered email waiting in (or finished with) the outbox.
+type EmailMessage struct {
+	ID            uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        *uuid.UUID    `json:"user_id" gorm:"type:uuid;index"`
+	To            string        `json:"to" gorm:"not null;index"`
+	Template      string        `json:"template" gorm:"not null"`
+	Locale        string        `json:"locale" gorm:"type:varchar(10);not null"`
+	Category      EmailCategory `json:"category" gorm:"type:varchar(20);not null"`
+	Subject       string        `json:"subject" gorm:"not null"`
+	HTML          string        `json:"-" gorm:"type:text"`
+	Text          string        `json:"-" gorm:"type:text"`
+	Status        EmailStatus   `json:"status" gorm:"type:varchar(20);not null;index:idx_email_outbox,priority:1"`
+	Attempts      int           `json:"attempts" gorm:"default:0"`
+	NextAttemptAt time.Time     `json:"next_attempt_at" gorm:"not null;index:idx_email_outbox,priority:2"`
+	LastError     string        `json:"last_error" gorm:"type:text"`
+	SentAt        *time.Time    `json:"sent_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// EmailSuppression blocks future mail to an address after a hard bounce,
+// spam complaint or unsubscribe.
+type EmailSuppression struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
+	Reason    string    `json:"reason" gorm:"type:varchar(20);not null"` // bounce, complaint, unsubscribe
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const EmailTokenVerifyEmail = "verify_email"
+
+// EmailToken is a single-use link token. Only its SHA-256 hash is stored.
+type EmailToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(20);not null"`
+	Email     string     `json:"-" gorm:"type:text"` // Address the link was sent to
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (e *EmailMessage) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *EmailSuppression) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *EmailToken) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
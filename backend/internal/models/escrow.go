@@ -14,7 +14,8 @@ const (
 	EscrowStatusFunded    EscrowStatus = "funded"
 	EscrowStatusReleased  EscrowStatus = "released"
 	EscrowStatusDisputed  EscrowStatus = "disputed"
-	EscrowStatusRefunded  EscrowStatus = "refunded"
+	EscrowStatusRefunded  EscrowStatus = "refunded"  // Cancelled after funding, the deposit returned to the client
+	EscrowStatusCancelled EscrowStatus = "cancelled" // Cancelled before funding
 )
 
 type Escrow struct {
@@ -54,12 +55,15 @@ type EscrowEvent struct {
 	
 	// Event details
 	EventType  string    `json:"event_type" gorm:"not null"` // deposit, release, dispute_initiated
-	TxHash     string    `json:"tx_hash" gorm:"not null;index"`
+	TxHash     string    `json:"tx_hash" gorm:"not null;index;uniqueIndex:idx_escrow_events_log,priority:1"`
+	LogIndex   *uint     `json:"log_index" gorm:"uniqueIndex:idx_escrow_events_log,priority:2"` // Unset on events indexed before it was recorded
 	BlockNumber uint64   `json:"block_number" gorm:"not null"`
 	
 	// Data
-	Data       map[string]interface{} `json:"data" gorm:"type:jsonb"`
+	Data       map[string]interface{} `json:"data" gorm:"type:jsonb;serializer:json"`
 	
+	BlockTime  *time.Time `json:"block_time" gorm:"index"` // When the event was mined
+	LedgerAttempts int    `json:"-" gorm:"not null;default:0"` // Failed attempts to record the event in the ledger
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// IndexerCursor is the last block the chain indexer processed, so indexing
+// resumes there after a restart and on any instance.
+type IndexerCursor struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Block     uint64    `json:"block" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
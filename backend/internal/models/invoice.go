@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Invoice is the freelancer's invoice to the client for a completion or
+// milestone payment. Numbers run per year of payment, e.g. FAR-2026-000042,
+// and are never reused.
+type Invoice struct {
+	ID            uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Number        string      `json:"number" gorm:"not null;uniqueIndex"`
+	Year          int         `json:"year" gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	Sequence      int         `json:"sequence" gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	LedgerEntryID uuid.UUID   `json:"ledger_entry_id" gorm:"type:uuid;not null;uniqueIndex"`
+	LedgerEntry   LedgerEntry `json:"ledger_entry" gorm:"foreignKey:LedgerEntryID"`
+	IssuedAt      time.Time   `json:"issued_at" gorm:"not null"` // When the payment was made
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusDead      JobStatus = "dead" // Exhausted its retries
+)
+
+// Job is a unit of background work. Jobs with a UniqueKey are deduplicated:
+// schedules set one so a schedule never has two runs pending or running.
+type Job struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Type        string     `json:"type" gorm:"not null;index"`
+	UniqueKey   *string    `json:"unique_key" gorm:"uniqueIndex:idx_jobs_unique_key,where:unique_key IS NOT NULL AND status <> 'completed' AND status <> 'dead'"`
+	Status      JobStatus  `json:"status" gorm:"type:varchar(20);not null;index:idx_jobs_due,priority:1"`
+	Attempts    int        `json:"attempts" gorm:"default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null"`
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index:idx_jobs_due,priority:2"`
+	LockedUntil *time.Time `json:"locked_until"`
+	LockedBy    string     `json:"locked_by" gorm:"type:varchar(255)"` // Instance running the job
+	LastError   string     `json:"last_error" gorm:"type:text"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JobSchedule enqueues a job of JobType whenever Cron fires.
+type JobSchedule struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string     `json:"name" gorm:"uniqueIndex;not null"`
+	Cron      string     `json:"cron" gorm:"not null"`
+	JobType   string     `json:"job_type" gorm:"not null"`
+	Enabled   bool       `json:"enabled" gorm:"default:true"`
+	NextRunAt time.Time  `json:"next_run_at" gorm:"not null;index"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *JobSchedule) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
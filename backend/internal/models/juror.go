@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// JurorPoolMember is a user whose FARI stake meets MIN_JUROR_STAKE. The pool
+// is refreshed from the token contract periodically.
+type JurorPoolMember struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	User          User      `json:"-" gorm:"foreignKey:UserID"`
+	Address       string    `json:"address" gorm:"not null"`
+	StakedAmount  string    `json:"staked_amount" gorm:"not null"`  // Wei string
+	Participation int       `json:"participation" gorm:"default:0"` // Disputes voted on
+	RefreshedAt   time.Time `json:"refreshed_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type JurorAssignmentStatus string
+
+const (
+	JurorAssignmentPending  JurorAssignmentStatus = "pending"
+	JurorAssignmentAccepted JurorAssignmentStatus = "accepted"
+	JurorAssignmentDeclined JurorAssignmentStatus = "declined"
+)
+
+// JurorAssignment is a juror drawn for a dispute.
+type JurorAssignment struct {
+	ID          uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DisputeID   uuid.UUID             `json:"dispute_id" gorm:"type:uuid;not null;uniqueIndex:idx_juror_assignments_dispute_juror,priority:1"`
+	Dispute     *Dispute              `json:"dispute,omitempty" gorm:"foreignKey:DisputeID"`
+	JurorID     uuid.UUID             `json:"juror_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_juror_assignments_dispute_juror,priority:2"`
+	Juror       User                  `json:"juror" gorm:"foreignKey:JurorID"`
+	DrawID      uuid.UUID             `json:"draw_id" gorm:"type:uuid;not null"`
+	Address     string                `json:"address" gorm:"not null"`
+	Weight      string                `json:"weight" gorm:"not null"`
+	Status      JurorAssignmentStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	ReplacedBy  *uuid.UUID            `json:"replaced_by" gorm:"type:uuid"` // Assignment drawn after a decline
+	RespondedAt *time.Time            `json:"responded_at"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// JurorDraw records one random selection so anyone can recompute it: the
+// seed, the screened candidates with their weights in draw order, and the
+// addresses picked.
+type JurorDraw struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DisputeID  uuid.UUID      `json:"dispute_id" gorm:"type:uuid;not null;index"`
+	Seed       string         `json:"seed" gorm:"not null"`
+	SeedSource string         `json:"seed_source" gorm:"type:text"` // How the seed was derived
+	Candidates datatypes.JSON `json:"candidates" gorm:"type:jsonb"`
+	Selected   datatypes.JSON `json:"selected" gorm:"type:jsonb"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// JurorReward is an indexed JurorRewarded event. The disputes it pays for
+// are decoded from the claimJurorRewards call that emitted it.
+type JurorReward struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	JurorID     *uuid.UUID     `json:"juror_id" gorm:"type:uuid;index"` // Nil if the wallet has no account
+	Address     string         `json:"address" gorm:"not null;index"`
+	Amount      string         `json:"amount" gorm:"not null"`        // Wei string
+	DisputeIDs  datatypes.JSON `json:"dispute_ids" gorm:"type:jsonb"` // On-chain dispute IDs claimed
+	TxHash      string         `json:"tx_hash" gorm:"not null;uniqueIndex:idx_juror_rewards_tx_log,priority:1"`
+	LogIndex    uint           `json:"log_index" gorm:"uniqueIndex:idx_juror_rewards_tx_log,priority:2"`
+	BlockNumber uint64         `json:"block_number"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+func (m *JurorPoolMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *JurorAssignment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (d *JurorDraw) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *JurorReward) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LedgerKind is what a ledger entry paid out of an escrow.
+type LedgerKind string
+
+const (
+	LedgerKindPayment       LedgerKind = "payment"           // ProjectCompleted
+	LedgerKindMilestone     LedgerKind = "milestone_payment" // MilestoneReleased
+	LedgerKindDisputePayout LedgerKind = "dispute_payout"    // DisputeResolved, freelancer's share
+	LedgerKindRefund        LedgerKind = "refund"            // DisputeResolved, client's share, or ProjectCancelled
+)
+
+// LedgerEntry is one payout of an escrow, derived from its indexed event.
+// Amounts are decimal integers in the token's base units: Gross left the
+// escrow, Fee went to the treasury and Net reached the freelancer, or the
+// client for refunds.
+type LedgerEntry struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EventID        uuid.UUID  `json:"event_id" gorm:"type:uuid;not null;uniqueIndex:idx_ledger_event"`
+	Kind           LedgerKind `json:"kind" gorm:"type:varchar(20);not null;uniqueIndex:idx_ledger_event"`
+	EscrowID       uuid.UUID  `json:"escrow_id" gorm:"type:uuid;not null;index"`
+	ProjectID      uuid.UUID  `json:"project_id" gorm:"type:uuid;not null;index"`
+	ClientID       uuid.UUID  `json:"client_id" gorm:"type:uuid;not null;index"`
+	FreelancerID   *uuid.UUID `json:"freelancer_id" gorm:"type:uuid;index"`
+	ClientAddr     string     `json:"client_address" gorm:"not null"`
+	FreelancerAddr string     `json:"freelancer_address"`
+	MilestoneIndex *int       `json:"milestone_index,omitempty"`
+
+	Token string `json:"token" gorm:"not null"`
+	Gross string `json:"gross" gorm:"type:numeric(78,0);not null"`
+	Fee   string `json:"fee" gorm:"type:numeric(78,0);not null"`
+	Net   string `json:"net" gorm:"type:numeric(78,0);not null"`
+
+	TxHash      string    `json:"tx_hash" gorm:"not null"`
+	BlockNumber uint64    `json:"block_number" gorm:"not null"`
+	OccurredAt  time.Time `json:"occurred_at" gorm:"not null;index"` // Block time
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (e *LedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ConversationScope string
+
+const (
+	ConversationScopeProject     ConversationScope = "project"
+	ConversationScopeApplication ConversationScope = "application"
+)
+
+// Conversation is the chat of a project or of an application. There is at
+// most one per project and one per application; the participants follow from
+// the scope.
+type Conversation struct {
+	ID            uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID     uuid.UUID         `json:"project_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_conversation_project_scope,where:scope = 'project'"`
+	Project       Project           `json:"project" gorm:"foreignKey:ProjectID"`
+	ApplicationID *uuid.UUID        `json:"application_id" gorm:"type:uuid;index;uniqueIndex:idx_conversation_application_scope,where:scope = 'application'"`
+	Application   *Application      `json:"application,omitempty" gorm:"foreignKey:ApplicationID"`
+	Scope         ConversationScope `json:"scope" gorm:"type:varchar(20);not null;index"`
+
+	LastMessageAt *time.Time `json:"last_message_at" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ConversationID uuid.UUID `json:"conversation_id" gorm:"type:uuid;not null;index"`
+	SenderID       uuid.UUID `json:"sender_id" gorm:"type:uuid;not null;index"`
+	Sender         User      `json:"sender" gorm:"foreignKey:SenderID"`
+
+	Body        string              `json:"body" gorm:"type:text"`
+	Attachments []MessageAttachment `json:"attachments" gorm:"foreignKey:MessageID"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MessageAttachment struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MessageID uuid.UUID `json:"message_id" gorm:"type:uuid;not null;index"`
+
+	FileName string `json:"file_name" gorm:"not null"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	IPFSHash string `json:"ipfs_hash" gorm:"not null"`
+	FileURL  string `json:"file_url"` // Empty while quarantined
+
+	UploadID    *uuid.UUID `json:"upload_id,omitempty" gorm:"type:uuid;index"`
+	Quarantined bool       `json:"quarantined" gorm:"default:false"` // Flagged by the malware scanner, unreadable until released
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConversationRead stores how far a participant has read, used for read
+// receipts and unread counts.
+type ConversationRead struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ConversationID    uuid.UUID `json:"conversation_id" gorm:"type:uuid;not null;uniqueIndex:idx_conversation_read_user"`
+	UserID            uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_conversation_read_user"`
+	LastReadMessageID uuid.UUID `json:"last_read_message_id" gorm:"type:uuid"`
+	LastReadAt        time.Time `json:"last_read_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (c *Conversation) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (m *Message) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *MessageAttachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *ConversationRead) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
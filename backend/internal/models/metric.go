@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DailyMetric is one value of a platform metric for a UTC day, written by
+// the analytics rollup. Dimension splits a metric, e.g. by user role or by
+// payment token address, and is empty otherwise. Value is a decimal integer
+// so token amounts in base units stay exact.
+type DailyMetric struct {
+	ID        uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Day       time.Time `json:"day" gorm:"type:date;not null;uniqueIndex:idx_daily_metric"`
+	Metric    string    `json:"metric" gorm:"type:varchar(50);not null;uniqueIndex:idx_daily_metric"`
+	Dimension string    `json:"dimension" gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_daily_metric"`
+	Value     string    `json:"value" gorm:"type:numeric(78,0);not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Metrics written by the analytics rollup. Token amounts are split by token
+// address; new users by role.
+const (
+	MetricNewUsers          = "users.new"
+	MetricProjectsPosted    = "projects.posted"
+	MetricProjectsStarted   = "projects.started"   // Freelancer hired
+	MetricProjectsCompleted = "projects.completed" // Escrow released on completion
+	MetricGMV               = "escrow.gmv"         // Amount of escrows funded
+	MetricPlatformFees      = "escrow.platform_fees"
+	MetricTVL               = "escrow.tvl" // Value locked in funded escrows at the end of the day
+	MetricDisputesOpened    = "disputes.opened"
+	MetricDisputesResolved  = "disputes.resolved"
+)
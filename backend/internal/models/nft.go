@@ -19,6 +19,14 @@ type NFT struct {
 	OwnerAddr   string    `json:"owner_address" gorm:"not null;index"`
 	TokenURI    string    `json:"token_uri"`
 	
+	// ProofOfWorkNFT.getMetadata
+	OnChainProjectID int64      `json:"on_chain_project_id"`
+	ClientID         *uuid.UUID `json:"client_id" gorm:"type:uuid;index"` // Nil if the wallet has no account
+	ClientAddr       string     `json:"client_address" gorm:"index"`
+	Amount           string     `json:"amount"` // Payment token base units
+	Category         string     `json:"category"`
+	CompletedAt      *time.Time `json:"completed_at"` // Nil until enriched from the contract
+	
 	// Metadata
 	Name        string    `json:"name" gorm:"not null"`
 	Description string    `json:"description" gorm:"type:text"`
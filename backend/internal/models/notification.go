@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationType string
+
+const (
+	NotificationApplicationReceived NotificationType = "application_received"
+	NotificationApplicationAccepted NotificationType = "application_accepted"
+	NotificationEscrowFunded        NotificationType = "escrow_funded"
+	NotificationEscrowReleased      NotificationType = "escrow_released"
+	NotificationDisputeOpened       NotificationType = "dispute_opened"
+	NotificationDisputeVoteNeeded   NotificationType = "dispute_vote_needed"
+	NotificationDisputeDeadline     NotificationType = "dispute_deadline"
+	NotificationDisputeVotingEnded  NotificationType = "dispute_voting_ended"
+	NotificationNFTMinted           NotificationType = "nft_minted"
+	// NotificationDigest is the daily email digest. Only its email channel
+	// is used.
+	NotificationDigest NotificationType = "digest"
+)
+
+// NotificationTypes lists every event users can set preferences for.
+var NotificationTypes = []NotificationType{
+	NotificationApplicationReceived,
+	NotificationApplicationAccepted,
+	NotificationEscrowFunded,
+	NotificationEscrowReleased,
+	NotificationDisputeOpened,
+	NotificationDisputeVoteNeeded,
+	NotificationDisputeDeadline,
+	NotificationDisputeVotingEnded,
+	NotificationNFTMinted,
+	NotificationDigest,
+}
+
+type NotificationChannelName string
+
+const (
+	NotificationChannelInApp   NotificationChannelName = "in_app"
+	NotificationChannelEmail   NotificationChannelName = "email"
+	NotificationChannelWebhook NotificationChannelName = "webhook"
+)
+
+var NotificationChannels = []NotificationChannelName{
+	NotificationChannelInApp,
+	NotificationChannelEmail,
+	NotificationChannelWebhook,
+}
+
+type Notification struct {
+	ID     uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	Type   NotificationType `json:"type" gorm:"type:varchar(40);not null;index"`
+
+	Title string                 `json:"title" gorm:"not null"`
+	Body  string                 `json:"body" gorm:"type:text"`
+	Data  map[string]interface{} `json:"data" gorm:"type:jsonb;serializer:json"`
+
+	ReadAt    *time.Time `json:"read_at" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
+
+// NotificationPreference overrides the default for one event type and
+// channel. Missing rows fall back to the channel default.
+type NotificationPreference struct {
+	ID        uuid.UUID               `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID               `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_notification_pref"`
+	EventType NotificationType        `json:"event_type" gorm:"type:varchar(40);not null;uniqueIndex:idx_notification_pref"`
+	Channel   NotificationChannelName `json:"channel" gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_pref"`
+	Enabled   bool                    `json:"enabled"`
+	UpdatedAt time.Time               `json:"updated_at"`
+}
+
+// NotificationWebhook is the endpoint webhook notifications are posted to.
+type NotificationWebhook struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	URL       string    `json:"url" gorm:"not null"`
+	Secret    string    `json:"-"` // HMAC key for the X-Fariima-Signature header
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (w *NotificationWebhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProposalType mirrors FARIIMADao.ProposalType; the index is the on-chain
+// enum value.
+type ProposalType string
+
+const (
+	ProposalTypeFeeChange       ProposalType = "FeeChange"
+	ProposalTypeTreasurySpend   ProposalType = "TreasurySpend"
+	ProposalTypeContractUpgrade ProposalType = "ContractUpgrade"
+	ProposalTypeParameterChange ProposalType = "ParameterChange"
+)
+
+// ProposalTypes lists the proposal types in on-chain enum order.
+var ProposalTypes = []ProposalType{
+	ProposalTypeFeeChange,
+	ProposalTypeTreasurySpend,
+	ProposalTypeContractUpgrade,
+	ProposalTypeParameterChange,
+}
+
+// ProposalState mirrors FARIIMADao.ProposalState, in on-chain enum order.
+type ProposalState string
+
+const (
+	ProposalStateActive    ProposalState = "active"
+	ProposalStateSucceeded ProposalState = "succeeded"
+	ProposalStateDefeated  ProposalState = "defeated"
+	ProposalStateExecuted  ProposalState = "executed"
+	ProposalStateCancelled ProposalState = "cancelled"
+)
+
+// ProposalStates lists the proposal states in on-chain enum order.
+var ProposalStates = []ProposalState{
+	ProposalStateActive,
+	ProposalStateSucceeded,
+	ProposalStateDefeated,
+	ProposalStateExecuted,
+	ProposalStateCancelled,
+}
+
+// Proposal is a DAO governance proposal indexed from ProposalCreated.
+type Proposal struct {
+	ID           uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OnChainID    int64        `json:"on_chain_id" gorm:"not null;uniqueIndex"`
+	ProposerID   *uuid.UUID   `json:"proposer_id" gorm:"type:uuid;index"` // Nil if the wallet has no account
+	Proposer     *User        `json:"proposer,omitempty" gorm:"foreignKey:ProposerID"`
+	ProposerAddr string       `json:"proposer_address" gorm:"not null;index"`
+	Type         ProposalType `json:"type" gorm:"type:varchar(30);not null;index"`
+	Title        string       `json:"title" gorm:"not null"`
+	Description  string       `json:"description" gorm:"type:text"`
+	CallData     string       `json:"call_data"` // Hex, executed by the DAO against itself
+
+	// Voting
+	VotingEndsAt time.Time     `json:"voting_ends_at" gorm:"index"`
+	VotesFor     string        `json:"votes_for" gorm:"type:numeric(78,0);not null;default:0"`     // Voting power, wei
+	VotesAgainst string        `json:"votes_against" gorm:"type:numeric(78,0);not null;default:0"` // Voting power, wei
+	VoterCount   int           `json:"voter_count" gorm:"default:0"`
+	State        ProposalState `json:"state" gorm:"type:varchar(20);not null;index"`
+
+	// Blockchain
+	TxHash         string     `json:"tx_hash"`
+	BlockNumber    uint64     `json:"block_number"`
+	ExecutedTxHash string     `json:"executed_tx_hash,omitempty"`
+	ExecutedAt     *time.Time `json:"executed_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProposalVote is an indexed ProposalVoted event. One per voter per proposal.
+type ProposalVote struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProposalID  uuid.UUID  `json:"proposal_id" gorm:"type:uuid;not null;uniqueIndex:idx_proposal_votes_proposal_voter,priority:1"`
+	Proposal    *Proposal  `json:"proposal,omitempty" gorm:"foreignKey:ProposalID"`
+	VoterID     *uuid.UUID `json:"voter_id" gorm:"type:uuid;index"` // Nil if the wallet has no account
+	VoterAddr   string     `json:"voter_address" gorm:"not null;index;uniqueIndex:idx_proposal_votes_proposal_voter,priority:2"`
+	Support     bool       `json:"support"`
+	VotingPower string     `json:"voting_power" gorm:"not null"` // Wei string
+	TxHash      string     `json:"tx_hash" gorm:"not null"`
+	BlockNumber uint64     `json:"block_number"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (p *Proposal) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (v *ProposalVote) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
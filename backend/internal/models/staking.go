@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StakeAccount is an address's current FARI stake, maintained from
+// FARIToken events.
+type StakeAccount struct {
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Address            string     `json:"address" gorm:"not null;uniqueIndex"`
+	StakedAmount       string     `json:"staked_amount" gorm:"type:numeric(78,0);not null;default:0"`   // Wei
+	PendingUnstake     string     `json:"pending_unstake" gorm:"type:numeric(78,0);not null;default:0"` // Wei
+	UnstakeAvailableAt *time.Time `json:"unstake_available_at"`
+	StakedSince        *time.Time `json:"staked_since"`                   // First stake; drives the voting power multiplier
+	RankingBoost       float64    `json:"ranking_boost" gorm:"default:0"` // Percent, see RankingService
+	UpdatedBlock       uint64     `json:"updated_block"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+type StakeEventType string
+
+const (
+	StakeEventStaked           StakeEventType = "staked"
+	StakeEventUnstakeRequested StakeEventType = "unstake_requested"
+	StakeEventUnstaked         StakeEventType = "unstaked"
+	StakeEventVestingCreated   StakeEventType = "vesting_created"
+	StakeEventTokensReleased   StakeEventType = "tokens_released"
+)
+
+// StakeEvent is one entry of an address's staking and vesting ledger.
+type StakeEvent struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Address     string         `json:"address" gorm:"not null;index"`
+	Type        StakeEventType `json:"type" gorm:"type:varchar(30);not null"`
+	Amount      string         `json:"amount" gorm:"not null"` // Wei string
+	Balance     string         `json:"balance,omitempty"`      // Stake after the event, wei
+	AvailableAt *time.Time     `json:"available_at,omitempty"` // Unstake requests
+	TxHash      string         `json:"tx_hash" gorm:"not null;uniqueIndex:idx_stake_events_tx_log,priority:1"`
+	LogIndex    uint           `json:"log_index" gorm:"uniqueIndex:idx_stake_events_tx_log,priority:2"`
+	BlockNumber uint64         `json:"block_number"`
+	BlockTime   time.Time      `json:"block_time"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// VestingSchedule mirrors FARIToken.vestingSchedules; the contract allows
+// one schedule per beneficiary.
+type VestingSchedule struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Beneficiary string    `json:"beneficiary" gorm:"not null;uniqueIndex"`
+	TotalAmount string    `json:"total_amount" gorm:"type:numeric(78,0);not null"`       // Wei
+	Released    string    `json:"released" gorm:"type:numeric(78,0);not null;default:0"` // Wei
+	StartTime   time.Time `json:"start_time"`
+	Duration    int64     `json:"duration"` // Seconds
+	Cliff       int64     `json:"cliff"`    // Seconds
+	TxHash      string    `json:"tx_hash"`
+	BlockNumber uint64    `json:"block_number"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (a *StakeAccount) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *StakeEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (v *VestingSchedule) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
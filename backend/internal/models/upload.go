@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UploadPurpose decides which file types and sizes an upload accepts.
+type UploadPurpose string
+
+const (
+	UploadPurposeAvatar      UploadPurpose = "avatar"
+	UploadPurposeResume      UploadPurpose = "resume"
+	UploadPurposeEvidence    UploadPurpose = "evidence"
+	UploadPurposeDeliverable UploadPurpose = "deliverable"
+	UploadPurposeAttachment  UploadPurpose = "attachment" // Sent with a message
+)
+
+type PinStatus string
+
+const (
+	PinStatusPending  PinStatus = "pending"
+	PinStatusPinned   PinStatus = "pinned"
+	PinStatusFailed   PinStatus = "failed"
+	PinStatusUnpinned PinStatus = "unpinned" // Orphaned past the retention period
+)
+
+// ScanStatus is the outcome of the malware scan of an upload.
+type ScanStatus string
+
+const (
+	ScanStatusClean       ScanStatus = "clean"
+	ScanStatusQuarantined ScanStatus = "quarantined" // Flagged, awaiting moderator review
+	ScanStatusReleased    ScanStatus = "released"    // Flagged, cleared by a moderator
+	ScanStatusRejected    ScanStatus = "rejected"    // Flagged, confirmed by a moderator
+)
+
+// Upload is a file a user stored through the API. Pending and pinned uploads
+// count toward the owner's storage quota. Quarantined uploads are stored
+// encrypted with no grants and are not served until a moderator releases
+// them.
+type Upload struct {
+	ID          uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OwnerID     uuid.UUID     `json:"owner_id" gorm:"type:uuid;not null;index"`
+	CID         string        `json:"cid" gorm:"column:cid;index"`
+	FileName    string        `json:"file_name" gorm:"not null"`
+	MimeType    string        `json:"mime_type" gorm:"not null"` // sniffed from the content
+	Size        int64         `json:"size" gorm:"not null"`
+	ContentHash string        `json:"content_hash" gorm:"type:varchar(64);not null"` // hex SHA-256
+	Purpose     UploadPurpose `json:"purpose" gorm:"type:varchar(20);not null;index"`
+	PinStatus   PinStatus     `json:"pin_status" gorm:"type:varchar(20);not null;index"`
+	Private     bool          `json:"private" gorm:"default:false"` // Stored encrypted, see PrivateFile
+
+	ScanStatus    ScanStatus `json:"scan_status" gorm:"type:varchar(20);not null;default:'clean';index"`
+	ScanSignature string     `json:"scan_signature,omitempty"` // What the scanner matched
+	ReviewedByID  *uuid.UUID `json:"reviewed_by_id,omitempty" gorm:"type:uuid"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote    string     `json:"review_note,omitempty" gorm:"type:text"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type FileGrantRole string
+
+const (
+	FileGrantOwner      FileGrantRole = "owner"
+	FileGrantClient     FileGrantRole = "client"
+	FileGrantFreelancer FileGrantRole = "freelancer"
+	FileGrantJuror      FileGrantRole = "juror"
+)
+
+// PrivateFile is a file stored encrypted, under the CID of its ciphertext.
+// Its data key is kept wrapped with the server's key.
+type PrivateFile struct {
+	ID         uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CID        string      `json:"cid" gorm:"column:cid;uniqueIndex;not null"`
+	OwnerID    uuid.UUID   `json:"owner_id" gorm:"type:uuid;not null;index"`
+	ProjectID  *uuid.UUID  `json:"project_id" gorm:"type:uuid;index"`
+	DisputeID  *uuid.UUID  `json:"dispute_id" gorm:"type:uuid;index"`
+	FileName   string      `json:"file_name" gorm:"not null"`
+	MimeType   string      `json:"mime_type" gorm:"not null"`
+	Size       int64       `json:"size" gorm:"not null"` // Plaintext size
+	WrappedKey string      `json:"-" gorm:"not null"`
+	Grants     []FileGrant `json:"grants,omitempty" gorm:"foreignKey:FileID"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// FileGrant gives a user access to a private file. WrappedKey is the file's
+// data key encrypted for the user's encryption public key, in eth_decrypt
+// format; it is empty until the user registers a key.
+type FileGrant struct {
+	ID         uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FileID     uuid.UUID     `json:"file_id" gorm:"type:uuid;not null;uniqueIndex:idx_file_grant_user"`
+	UserID     uuid.UUID     `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_file_grant_user;index"`
+	Role       FileGrantRole `json:"role" gorm:"type:varchar(20);not null"`
+	WrappedKey string        `json:"wrapped_key" gorm:"type:text"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
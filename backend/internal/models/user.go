@@ -4,15 +4,18 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 type User struct {
 	ID                uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	Email             string         `gorm:"uniqueIndex;not null" json:"email"`
+	Email             *string        `gorm:"uniqueIndex" json:"email"` // Unset until the user adds one
+	EmailVerifiedAt   *time.Time     `json:"email_verified_at"`
 	Password          string         `json:"-"` // Hashed password
 	Role              string         `gorm:"not null;check:role IN ('client', 'freelancer')" json:"role"` // client or freelancer
 	Address           string         `gorm:"uniqueIndex" json:"address"` // Wallet address (optional)
+	EncryptionPublicKey string       `json:"encryption_public_key"` // x25519 key from eth_getEncryptionPublicKey, for private files
 	Username          string         `gorm:"uniqueIndex" json:"username"`
 	FullName          string         `json:"full_name"`
 	Bio               string         `gorm:"type:text" json:"bio"`
@@ -23,6 +26,7 @@ type User struct {
 	Skills            datatypes.JSON `gorm:"type:jsonb;default:'[]'" json:"skills"` // For freelancers
 	HourlyRate        float64        `json:"hourly_rate"` // For freelancers
 	Location          string         `json:"location"`
+	Locale            string         `gorm:"type:varchar(10);default:'en'" json:"locale"` // Email language (en, fa)
 	ResumeURL         string         `json:"resume_url"` // For freelancers
 	Portfolio         datatypes.JSON `gorm:"type:jsonb;default:'[]'" json:"portfolio"` // For freelancers
 	TotalProjects     int            `gorm:"default:0" json:"total_projects"`
@@ -32,6 +36,7 @@ type User struct {
 	Verified          bool           `gorm:"default:false" json:"verified"` // For clients
 	Nonce             string         `json:"-"`
 	NonceExpiry       time.Time      `json:"-"`
+	SessionVersion    int            `gorm:"default:0" json:"-"` // Bumped to revoke every issued token
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
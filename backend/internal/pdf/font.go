@@ -0,0 +1,341 @@
+package pdf
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Open Sans covers Latin, Greek, Cyrillic and Vietnamese. It is licensed
+// under the Apache License 2.0, see fonts/LICENSE.txt.
+var (
+	//go:embed fonts/OpenSans-Regular.ttf
+	openSansRegular []byte
+	//go:embed fonts/OpenSans-Bold.ttf
+	openSansBold []byte
+)
+
+var errMalformedFont = errors.New("pdf: malformed TrueType font")
+
+// trueType is a parsed TrueType font, with what is needed to measure text
+// and embed a subset of it.
+type trueType struct {
+	name       string // PostScript name
+	stemV      int
+	tables     map[string][]byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	advances   []int // Per glyph, in font units
+	loca       []int // Offsets of the glyphs in glyf, one more than glyphs
+	cmap       map[rune]uint16
+}
+
+func mustParseTrueType(name string, stemV int, data []byte) *trueType {
+	font, err := parseTrueType(data)
+	if err != nil {
+		panic(fmt.Sprintf("pdf: %s: %v", name, err))
+	}
+	font.name = name
+	font.stemV = stemV
+	return font
+}
+
+// parseTrueType reads the tables of the font. Only Unicode BMP character
+// maps (format 4) are supported.
+func parseTrueType(data []byte) (*trueType, error) {
+	if len(data) < 12 {
+		return nil, errMalformedFont
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errMalformedFont
+	}
+
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, errMalformedFont
+		}
+		tables[string(record[:4])] = data[offset : offset+length]
+	}
+
+	minLength := map[string]int{"head": 54, "hhea": 36, "maxp": 6, "OS/2": 90, "cmap": 4, "hmtx": 0, "loca": 0, "glyf": 0}
+	for tag, length := range minLength {
+		if table, ok := tables[tag]; !ok || len(table) < length {
+			return nil, fmt.Errorf("%w: no valid %s table", errMalformedFont, tag)
+		}
+	}
+
+	head, hhea, os2 := tables["head"], tables["hhea"], tables["OS/2"]
+	font := &trueType{
+		tables:     tables,
+		unitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		ascent:     int(int16(binary.BigEndian.Uint16(hhea[4:]))),
+		descent:    int(int16(binary.BigEndian.Uint16(hhea[6:]))),
+		capHeight:  int(int16(binary.BigEndian.Uint16(os2[88:]))),
+	}
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	if font.unitsPerEm == 0 {
+		return nil, errMalformedFont
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || numMetrics > numGlyphs || len(hmtx) < 4*numMetrics {
+		return nil, errMalformedFont
+	}
+	font.advances = make([]int, numGlyphs)
+	for i := range font.advances {
+		// Glyphs past the last metric share its advance
+		font.advances[i] = int(binary.BigEndian.Uint16(hmtx[4*min(i, numMetrics-1):]))
+	}
+
+	loca, longOffsets := tables["loca"], binary.BigEndian.Uint16(head[50:]) == 1
+	font.loca = make([]int, numGlyphs+1)
+	for i := range font.loca {
+		switch {
+		case longOffsets && len(loca) >= 4*(i+1):
+			font.loca[i] = int(binary.BigEndian.Uint32(loca[4*i:]))
+		case !longOffsets && len(loca) >= 2*(i+1):
+			font.loca[i] = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		default:
+			return nil, errMalformedFont
+		}
+		if font.loca[i] > len(tables["glyf"]) || (i > 0 && font.loca[i] < font.loca[i-1]) {
+			return nil, errMalformedFont
+		}
+	}
+
+	cmap, err := parseCmap(tables["cmap"], numGlyphs)
+	if err != nil {
+		return nil, err
+	}
+	font.cmap = cmap
+	return font, nil
+}
+
+// parseCmap reads the Unicode BMP subtable of a cmap table.
+func parseCmap(table []byte, numGlyphs int) (map[rune]uint16, error) {
+	var sub []byte
+	for i, n := 0, int(binary.BigEndian.Uint16(table[2:])); i < n && len(table) >= 4+8*(i+1); i++ {
+		record := table[4+8*i:]
+		platform, encoding := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[2:])
+		offset := binary.BigEndian.Uint32(record[4:])
+		if (platform == 3 && encoding == 1) || platform == 0 {
+			if uint64(offset)+14 <= uint64(len(table)) && binary.BigEndian.Uint16(table[offset:]) == 4 {
+				sub = table[offset:]
+				break
+			}
+		}
+	}
+	if sub == nil {
+		return nil, fmt.Errorf("%w: no Unicode character map", errMalformedFont)
+	}
+
+	segments := int(binary.BigEndian.Uint16(sub[6:])) / 2
+	if len(sub) < 16+8*segments {
+		return nil, errMalformedFont
+	}
+	ends, starts := sub[14:], sub[16+2*segments:]
+	deltas, rangeOffsets := sub[16+4*segments:], sub[16+6*segments:]
+
+	cmap := make(map[rune]uint16)
+	for i := 0; i < segments; i++ {
+		start, end := int(binary.BigEndian.Uint16(starts[2*i:])), int(binary.BigEndian.Uint16(ends[2*i:]))
+		delta := binary.BigEndian.Uint16(deltas[2*i:])
+		rangeOffset := int(binary.BigEndian.Uint16(rangeOffsets[2*i:]))
+
+		for c := start; c <= end && c < 0xffff; c++ {
+			var glyph uint16
+			if rangeOffset == 0 {
+				glyph = uint16(c) + delta
+			} else {
+				// Relative to the rangeOffset entry itself
+				at := 16 + 6*segments + 2*i + rangeOffset + 2*(c-start)
+				if at+2 > len(sub) {
+					return nil, errMalformedFont
+				}
+				if glyph = binary.BigEndian.Uint16(sub[at:]); glyph != 0 {
+					glyph += delta
+				}
+			}
+			if glyph != 0 && int(glyph) < numGlyphs {
+				cmap[rune(c)] = glyph
+			}
+		}
+	}
+	return cmap, nil
+}
+
+// glyph returns the glyph of r, if the font has one.
+func (f *trueType) glyph(r rune) (uint16, bool) {
+	glyph, ok := f.cmap[r]
+	return glyph, ok
+}
+
+// width returns the advance of a glyph in thousandths of the font size.
+func (f *trueType) width(glyph uint16) int {
+	return (f.advances[glyph]*1000 + f.unitsPerEm/2) / f.unitsPerEm
+}
+
+// scale converts font units to thousandths of the font size.
+func (f *trueType) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+func (f *trueType) glyphData(glyph uint16) []byte {
+	return f.tables["glyf"][f.loca[glyph]:f.loca[glyph+1]]
+}
+
+// Composite glyph flags.
+const (
+	argsAreWords    = 0x0001
+	haveScale       = 0x0008
+	moreComponents  = 0x0020
+	haveXYScale     = 0x0040
+	haveTwoByTwo    = 0x0080
+	compositeHeader = 10
+)
+
+// components returns the glyphs a composite glyph is built from.
+func (f *trueType) components(glyph uint16) []uint16 {
+	data := f.glyphData(glyph)
+	if len(data) < compositeHeader || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+
+	var components []uint16
+	for at := compositeHeader; at+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[at:])
+		components = append(components, binary.BigEndian.Uint16(data[at+2:]))
+		at += 4
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			at += 2
+		case flags&haveXYScale != 0:
+			at += 4
+		case flags&haveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// subsetTables are copied into subsets; the hinting tables are optional.
+var subsetTables = []string{"head", "hhea", "maxp", "hmtx", "OS/2", "cmap", "cvt ", "fpgm", "prep"}
+
+// subset returns a font with the outlines of the given glyphs only, and of
+// the glyphs they are composed of. Glyph IDs are kept, so the subset works
+// with the glyph IDs of the full font.
+func (f *trueType) subset(glyphs []uint16) []byte {
+	keep := map[uint16]bool{0: true} // .notdef is required
+	var visit func(glyph uint16)
+	visit = func(glyph uint16) {
+		if keep[glyph] || int(glyph) >= len(f.advances) {
+			return
+		}
+		keep[glyph] = true
+		for _, component := range f.components(glyph) {
+			visit(component)
+		}
+	}
+	for _, glyph := range glyphs {
+		visit(glyph)
+	}
+
+	var glyf []byte
+	loca := make([]byte, 4*len(f.loca))
+	for glyph := range f.advances {
+		binary.BigEndian.PutUint32(loca[4*glyph:], uint32(len(glyf)))
+		if keep[uint16(glyph)] {
+			glyf = append(glyf, f.glyphData(uint16(glyph))...)
+			for len(glyf)%4 != 0 {
+				glyf = append(glyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*len(f.advances):], uint32(len(glyf)))
+
+	tables := map[string][]byte{"glyf": glyf, "loca": loca}
+	for _, tag := range subsetTables {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	head := append([]byte(nil), tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment, set below
+	binary.BigEndian.PutUint16(head[50:], 1) // Long loca offsets
+	tables["head"] = head
+
+	return writeTrueType(tables)
+}
+
+// writeTrueType assembles a font file from its tables.
+func writeTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= len(tags) {
+		searchRange *= 2
+		entrySelector++
+	}
+
+	out := make([]byte, 12+16*len(tags))
+	binary.BigEndian.PutUint32(out, 0x00010000)
+	binary.BigEndian.PutUint16(out[4:], uint16(len(tags)))
+	binary.BigEndian.PutUint16(out[6:], uint16(16*searchRange))
+	binary.BigEndian.PutUint16(out[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(out[10:], uint16(16*(len(tags)-searchRange)))
+
+	headOffset := 0
+	for i, tag := range tags {
+		table := tables[tag]
+		record := out[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+		if tag == "head" {
+			headOffset = len(out)
+		}
+		out = append(out, table...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+
+	binary.BigEndian.PutUint32(out[headOffset+8:], 0xb1b0afba-checksum(out))
+	return out
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,283 @@
+// Package pdf writes simple PDF documents of text and lines, such as
+// invoices. Text is set in Open Sans, embedded as a subset of the glyphs
+// each document uses, so it covers Latin, Greek, Cyrillic and Vietnamese.
+// Characters the font lacks are written as "?".
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+var fonts = []*trueType{
+	mustParseTrueType("OpenSans-Regular", 80, openSansRegular),
+	mustParseTrueType("OpenSans-Bold", 140, openSansBold),
+}
+
+// objectsPerFont are the objects written for each font: the Type0 font, its
+// CIDFont, font descriptor, font file and ToUnicode map.
+const objectsPerFont = 5
+
+// Document is a PDF under construction.
+type Document struct {
+	title string
+	pages []*Page
+	used  []map[uint16]rune // Glyphs drawn in each font, with their character
+}
+
+// Page holds the drawing operations of one page. Coordinates are in points
+// from the top-left corner.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+func New(title string) *Document {
+	used := make([]map[uint16]rune, len(fonts))
+	for i := range used {
+		used[i] = make(map[uint16]rune)
+	}
+	return &Document{title: title, used: used}
+}
+
+// AddPage appends an A4 page.
+func (d *Document) AddPage() *Page {
+	page := &Page{doc: d}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Text draws s with its baseline starting at (x, y).
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	var hex strings.Builder
+	for _, glyph := range glyphs(font, s) {
+		p.doc.used[font][glyph.id] = glyph.char
+		fmt.Fprintf(&hex, "%04X", glyph.id)
+	}
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td <%s> Tj ET\n",
+		font+1, num(size), num(x), num(PageHeight-y), hex.String())
+}
+
+// TextRight draws s so that it ends at x.
+func (p *Page) TextRight(x, y float64, font Font, size float64, s string) {
+	p.Text(x-Width(font, size, s), y, font, size, s)
+}
+
+// Line draws a line of the given width and gray level (0 black, 1 white).
+func (p *Page) Line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(&p.content, "%s G %s w %s %s m %s %s l S\n",
+		num(gray), num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// FillRect fills the rectangle with its top-left corner at (x, y).
+func (p *Page) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "%s g %s %s %s %s re f 0 g\n",
+		num(gray), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// Width returns the width of s in points.
+func Width(font Font, size float64, s string) float64 {
+	units := 0
+	for _, glyph := range glyphs(font, s) {
+		units += fonts[font].width(glyph.id)
+	}
+	return float64(units) * size / 1000
+}
+
+// WriteTo writes the document. Objects are numbered: 1 catalog, 2 page
+// tree, 3 info, then objectsPerFont per font, then a page and its content
+// stream for each page.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(data)
+		zw.Close()
+		object(fmt.Sprintf("<< %s /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", dict, compressed.Len(), compressed.Bytes()))
+	}
+
+	fontObject := 4
+	pageObject := fontObject + objectsPerFont*len(fonts)
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObject+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	object(fmt.Sprintf("<< /Title %s /Producer (FARIIMA) >>", textString(d.title)))
+
+	var resources []string
+	for i, font := range fonts {
+		first := fontObject + objectsPerFont*i
+		used := sortedGlyphs(d.used[i])
+		name := subsetTag(used) + "+" + font.name
+
+		var widths strings.Builder
+		for _, glyph := range used {
+			fmt.Fprintf(&widths, "%d [%d] ", glyph, font.width(glyph))
+		}
+
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+			name, first+1, first+4))
+		object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+			name, first+2, strings.TrimSpace(widths.String())))
+		object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV %d /FontFile2 %d 0 R >>",
+			name, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+			font.scale(font.ascent), font.scale(font.descent), font.scale(font.capHeight), font.stemV, first+3))
+		file := font.subset(used)
+		stream(fmt.Sprintf("/Length1 %d", len(file)), file)
+		stream("", toUnicode(d.used[i]))
+
+		resources = append(resources, fmt.Sprintf("/F%d %d 0 R", i+1, first))
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), strings.Join(resources, " "), pageObject+2*i+1))
+		stream("", page.content.Bytes())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// Encodable reports whether s can be written without replacing characters.
+func Encodable(s string) bool {
+	for _, r := range s {
+		if r < 0x20 {
+			continue
+		}
+		for _, font := range fonts {
+			if _, ok := font.glyph(r); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type glyph struct {
+	id   uint16
+	char rune
+}
+
+// glyphs maps s to the glyphs of the font. Control characters become
+// spaces and characters the font lacks become "?".
+func glyphs(font Font, s string) []glyph {
+	out := make([]glyph, 0, len(s))
+	for _, r := range s {
+		if r < 0x20 {
+			r = ' '
+		}
+		id, ok := fonts[font].glyph(r)
+		if !ok {
+			r = '?'
+			id, _ = fonts[font].glyph(r)
+		}
+		out = append(out, glyph{id, r})
+	}
+	return out
+}
+
+func sortedGlyphs(used map[uint16]rune) []uint16 {
+	glyphs := make([]uint16, 0, len(used))
+	for glyph := range used {
+		glyphs = append(glyphs, glyph)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// subsetTag derives the six capital letters that prefix the name of an
+// embedded subset from the glyphs it contains.
+func subsetTag(glyphs []uint16) string {
+	h := sha256.New()
+	for _, glyph := range glyphs {
+		h.Write([]byte{byte(glyph >> 8), byte(glyph)})
+	}
+	sum := h.Sum(nil)
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + sum[i]%26
+	}
+	return string(tag)
+}
+
+// toUnicode writes the CMap that maps glyphs back to their characters, so
+// text can be searched and copied.
+func toUnicode(used map[uint16]rune) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	glyphs := sortedGlyphs(used)
+	for len(glyphs) > 0 {
+		n := min(len(glyphs), 100) // Entries allowed per block
+		fmt.Fprintf(&b, "%d beginbfchar\n", n)
+		for _, glyph := range glyphs[:n] {
+			fmt.Fprintf(&b, "<%04X> <%s>\n", glyph, utf16Hex(string(used[glyph])))
+		}
+		b.WriteString("endbfchar\n")
+		glyphs = glyphs[n:]
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// textString encodes s as a PDF text string in UTF-16.
+func textString(s string) string {
+	return "<FEFF" + utf16Hex(s) + ">"
+}
+
+func utf16Hex(s string) string {
+	var b strings.Builder
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	return b.String()
+}
+
+func num(f float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", f), "0")
+	return strings.TrimSuffix(s, ".")
+}
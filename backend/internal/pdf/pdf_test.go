@@ -0,0 +1,194 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEncodable(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"Invoice FAR-2026-000042", true},
+		{"Zoë Ångström", true},
+		{"Σωκράτης Παπαδόπουλος", true},
+		{"Жанна Иванова", true},
+		{"Nguyễn Thị Minh", true},
+		{"tab\there", true},
+		{"王小明", false},
+		{"محمد", false},
+	}
+	for _, tt := range tests {
+		if got := Encodable(tt.s); got != tt.want {
+			t.Errorf("Encodable(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestGlyphs(t *testing.T) {
+	question, _ := fonts[Regular].glyph('?')
+	space, _ := fonts[Regular].glyph(' ')
+
+	got := glyphs(Regular, "Ж\n王")
+	if len(got) != 3 {
+		t.Fatalf("got %d glyphs, want 3", len(got))
+	}
+	if got[0].id == question || got[0].char != 'Ж' {
+		t.Errorf("Ж mapped to %+v", got[0])
+	}
+	if got[1].id != space || got[1].char != ' ' {
+		t.Errorf("newline mapped to %+v, want a space", got[1])
+	}
+	if got[2].id != question || got[2].char != '?' {
+		t.Errorf("王 mapped to %+v, want ?", got[2])
+	}
+}
+
+func TestWidth(t *testing.T) {
+	if w := Width(Regular, 10, ""); w != 0 {
+		t.Errorf("empty string is %v wide", w)
+	}
+	if narrow, wide := Width(Regular, 10, "iiii"), Width(Regular, 10, "WWWW"); narrow >= wide {
+		t.Errorf("iiii is %v wide, WWWW %v", narrow, wide)
+	}
+	if regular, bold := Width(Regular, 10, "Invoice"), Width(Bold, 10, "Invoice"); regular >= bold {
+		t.Errorf("regular is %v wide, bold %v", regular, bold)
+	}
+	if w10, w20 := Width(Bold, 10, "Жанна"), Width(Bold, 20, "Жанна"); w20 != 2*w10 {
+		t.Errorf("width does not scale with size: %v at 10, %v at 20", w10, w20)
+	}
+}
+
+func TestSubset(t *testing.T) {
+	font := fonts[Regular]
+	used := []uint16{}
+	for _, r := range "Aé" {
+		glyph, _ := font.glyph(r)
+		used = append(used, glyph)
+	}
+
+	subset, err := parseTrueType(font.subset(used))
+	if err != nil {
+		t.Fatalf("parsing the subset: %v", err)
+	}
+	if len(subset.advances) != len(font.advances) {
+		t.Fatalf("subset has %d glyphs, want %d", len(subset.advances), len(font.advances))
+	}
+
+	// é may be a composite of e and an accent, which must come along
+	keep := map[uint16]bool{0: true}
+	for _, glyph := range used {
+		keep[glyph] = true
+		for _, component := range font.components(glyph) {
+			keep[component] = true
+		}
+	}
+	for glyph := range font.advances {
+		data := subset.glyphData(uint16(glyph))
+		if keep[uint16(glyph)] {
+			if !bytes.Equal(data, font.glyphData(uint16(glyph))) {
+				t.Errorf("glyph %d differs from the font", glyph)
+			}
+		} else if len(data) != 0 {
+			t.Errorf("glyph %d was not used but has an outline", glyph)
+		}
+	}
+
+	if sum := checksum(font.subset(used)); sum != 0xb1b0afba {
+		t.Errorf("font checksum is %#x, want 0xb1b0afba", sum)
+	}
+}
+
+var (
+	objectPattern = regexp.MustCompile(`(?s)(\d+) 0 obj\n(.*?)\nendobj\n`)
+	streamPattern = regexp.MustCompile(`(?s)^(<<.*?>>)\nstream\n(.*)\nendstream$`)
+	xrefPattern   = regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`)
+)
+
+// readPDF returns the objects of a document by number, with streams
+// decompressed, checking the cross-reference table along the way.
+func readPDF(t *testing.T, data []byte) map[int]string {
+	t.Helper()
+
+	objects := make(map[int]string)
+	for _, m := range objectPattern.FindAllSubmatch(data, -1) {
+		n, _ := strconv.Atoi(string(m[1]))
+		body := string(m[2])
+		if s := streamPattern.FindStringSubmatch(body); s != nil {
+			zr, err := zlib.NewReader(strings.NewReader(s[2]))
+			if err != nil {
+				t.Fatalf("object %d: %v", n, err)
+			}
+			content, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("object %d: %v", n, err)
+			}
+			body = s[1] + "\n" + string(content)
+		}
+		objects[n] = body
+	}
+
+	offsets := xrefPattern.FindAllSubmatch(data, -1)
+	if len(offsets) != len(objects) {
+		t.Fatalf("xref has %d entries for %d objects", len(offsets), len(objects))
+	}
+	for i, m := range offsets {
+		offset, _ := strconv.Atoi(string(m[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d does not point at its object", i+1)
+		}
+	}
+	return objects
+}
+
+func TestWriteTo(t *testing.T) {
+	doc := New("Invoice Жанна")
+	page := doc.AddPage()
+	page.Text(50, 80, Bold, 24, "INVOICE")
+	page.Text(50, 100, Regular, 10, "Σωκράτης (Жанна) 王")
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	objects := readPDF(t, buf.Bytes())
+
+	if !strings.Contains(objects[3], textString("Invoice Жанна")) {
+		t.Errorf("info %q does not carry the title in UTF-16", objects[3])
+	}
+
+	// 4-8 are the regular font, 9-13 the bold one, then the page
+	for i, first := range []int{4, 9} {
+		if !strings.Contains(objects[first], "/Subtype /Type0") || !strings.Contains(objects[first], "/Identity-H") {
+			t.Errorf("font %d: %q is not a Type0 font", i, objects[first])
+		}
+		file := objects[first+3]
+		if _, err := parseTrueType([]byte(file[strings.Index(file, "\n")+1:])); err != nil {
+			t.Errorf("font %d: embedded font: %v", i, err)
+		}
+	}
+
+	content := objects[15]
+	var want strings.Builder
+	for _, glyph := range glyphs(Regular, "Σωκράτης (Жанна) 王") {
+		fmt.Fprintf(&want, "%04X", glyph.id)
+	}
+	if !strings.Contains(content, "/F1 10 Tf 50 741.89 Td <"+want.String()+"> Tj") {
+		t.Errorf("content %q does not draw the text with its glyphs", content)
+	}
+
+	cmap := objects[8]
+	for _, r := range "Σωκράτης(Жанна)?" {
+		glyph, _ := fonts[Regular].glyph(r)
+		if entry := fmt.Sprintf("<%04X> <%04X>", glyph, r); !strings.Contains(cmap, entry) {
+			t.Errorf("ToUnicode map lacks %s for %q", entry, r)
+		}
+	}
+}
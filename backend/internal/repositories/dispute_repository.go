@@ -1,11 +1,17 @@
 package repositories
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/fariima/backend/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var activeJurorStatuses = []models.JurorAssignmentStatus{models.JurorAssignmentPending, models.JurorAssignmentAccepted}
+
 type DisputeRepository struct {
 	db *gorm.DB
 }
@@ -27,7 +33,34 @@ func (r *DisputeRepository) GetByID(id uuid.UUID) (*models.Dispute, error) {
 
 func (r *DisputeRepository) GetByOnChainID(onChainID int64) (*models.Dispute, error) {
 	var dispute models.Dispute
-	err := r.db.Where("on_chain_id = ?", onChainID).First(&dispute).Error
+	err := r.db.Preload("Project").Where("on_chain_id = ?", onChainID).First(&dispute).Error
+	return &dispute, err
+}
+
+// GetUnlinked returns the newest dispute opened through the API for the
+// escrow that has not been matched to an on-chain dispute yet.
+func (r *DisputeRepository) GetUnlinked(escrowID uuid.UUID) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := r.db.Where("escrow_id = ? AND on_chain_id IS NULL AND status = ?", escrowID, models.DisputeStatusOpen).
+		Order("created_at DESC").
+		First(&dispute).Error
+	return &dispute, err
+}
+
+// HasActive reports whether the escrow has a dispute that is not yet
+// resolved or closed.
+func (r *DisputeRepository) HasActive(escrowID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Dispute{}).
+		Where("escrow_id = ? AND status NOT IN ?", escrowID,
+			[]models.DisputeStatus{models.DisputeStatusResolved, models.DisputeStatusClosed}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *DisputeRepository) GetByEscrowID(escrowID uuid.UUID) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := r.db.Where("escrow_id = ?", escrowID).Order("created_at DESC").First(&dispute).Error
 	return &dispute, err
 }
 
@@ -35,27 +68,108 @@ func (r *DisputeRepository) Update(dispute *models.Dispute) error {
 	return r.db.Save(dispute).Error
 }
 
-func (r *DisputeRepository) List(status string, limit, offset int) ([]models.Dispute, int64, error) {
+// DisputeFilter narrows List. Zero values are ignored.
+type DisputeFilter struct {
+	Status   string
+	Category string // Case-insensitive; unknown categories count as other
+	// NeedsVoteBy keeps disputes in voting where the user is an assigned juror
+	// who has not voted yet.
+	NeedsVoteBy *uuid.UUID
+	// EndingBefore keeps disputes whose voting closes before this time.
+	EndingBefore *time.Time
+}
+
+func (r *DisputeRepository) List(filter DisputeFilter, limit, offset int) ([]models.Dispute, int64, error) {
 	var disputes []models.Dispute
 	var total int64
 
 	db := r.db.Model(&models.Dispute{}).Preload("Project")
 
-	if status != "" {
-		db = db.Where("status = ?", status)
+	if filter.Status != "" {
+		db = db.Where("disputes.status = ?", filter.Status)
+	}
+
+	if filter.Category != "" {
+		db = db.Where(disputeCategoryExpr+" = LOWER(TRIM(?))", filter.Category)
+	}
+
+	if filter.NeedsVoteBy != nil {
+		db = db.Where("disputes.status = ?", models.DisputeStatusVoting).
+			Where("EXISTS (SELECT 1 FROM juror_assignments ja WHERE ja.dispute_id = disputes.id AND ja.juror_id = ? AND ja.status IN ?)",
+				*filter.NeedsVoteBy, activeJurorStatuses).
+			Where("NOT EXISTS (SELECT 1 FROM votes WHERE votes.dispute_id = disputes.id AND votes.voter_id = ?)",
+				*filter.NeedsVoteBy)
+	}
+
+	if filter.EndingBefore != nil {
+		db = db.Where("disputes.status = ? AND disputes.voting_ends_at > ? AND disputes.voting_ends_at <= ?",
+			models.DisputeStatusVoting, time.Now(), *filter.EndingBefore)
 	}
 
 	db.Count(&total)
-	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&disputes).Error
+	err := db.Order("disputes.created_at DESC").Limit(limit).Offset(offset).Find(&disputes).Error
 
 	return disputes, total, err
 }
 
+// StartVoting moves open disputes whose voting window is known into voting.
+func (r *DisputeRepository) StartVoting(now time.Time) (int64, error) {
+	result := r.db.Model(&models.Dispute{}).
+		Where("status = ? AND voting_ends_at IS NOT NULL AND voting_ends_at > ?", models.DisputeStatusOpen, now).
+		Update("status", models.DisputeStatusVoting)
+	return result.RowsAffected, result.Error
+}
+
+// GetVotingEnded returns open or voting disputes whose deadline has passed.
+func (r *DisputeRepository) GetVotingEnded(now time.Time) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.Preload("Project").
+		Where("status IN ? AND voting_ends_at <= ?",
+			[]models.DisputeStatus{models.DisputeStatusOpen, models.DisputeStatusVoting}, now).
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// GetDeadlineApproaching returns disputes in voting that close before the
+// given time and have had fewer than warnings reminders.
+func (r *DisputeRepository) GetDeadlineApproaching(now, before time.Time, warnings int) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.Preload("Project").
+		Where("status = ? AND voting_ends_at > ? AND voting_ends_at <= ? AND deadline_warnings_sent < ?",
+			models.DisputeStatusVoting, now, before, warnings).
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// TransitionStatus moves the dispute to status to if it is currently in one
+// of from, reporting whether it did.
+func (r *DisputeRepository) TransitionStatus(id uuid.UUID, from []models.DisputeStatus, to models.DisputeStatus) (bool, error) {
+	result := r.db.Model(&models.Dispute{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkDeadlineWarning records that reminder number level was sent, reporting
+// false if it already had been.
+func (r *DisputeRepository) MarkDeadlineWarning(id uuid.UUID, level int) (bool, error) {
+	result := r.db.Model(&models.Dispute{}).
+		Where("id = ? AND deadline_warnings_sent < ?", id, level).
+		Update("deadline_warnings_sent", level)
+	return result.RowsAffected > 0, result.Error
+}
+
 // Evidence
 func (r *DisputeRepository) CreateEvidence(evidence *models.Evidence) error {
 	return r.db.Create(evidence).Error
 }
 
+func (r *DisputeRepository) GetEvidenceByID(disputeID, evidenceID uuid.UUID) (*models.Evidence, error) {
+	var evidence models.Evidence
+	err := r.db.Where("id = ? AND dispute_id = ?", evidenceID, disputeID).First(&evidence).Error
+	return &evidence, err
+}
+
 func (r *DisputeRepository) GetEvidenceByDisputeID(disputeID uuid.UUID) ([]models.Evidence, error) {
 	var evidence []models.Evidence
 	err := r.db.Where("dispute_id = ?", disputeID).
@@ -66,8 +180,28 @@ func (r *DisputeRepository) GetEvidenceByDisputeID(disputeID uuid.UUID) ([]model
 }
 
 // Votes
-func (r *DisputeRepository) CreateVote(vote *models.Vote) error {
-	return r.db.Create(vote).Error
+
+// RecordVote stores the vote and bumps the dispute's tallies in one
+// transaction, so the counters always match the votes table.
+func (r *DisputeRepository) RecordVote(vote *models.Vote) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(vote).Error; err != nil {
+			return err
+		}
+
+		counter, weight := "client_votes", "client_weight"
+		if vote.VoteFor == models.VoteForFreelancer {
+			counter, weight = "freelancer_votes", "freelancer_weight"
+		}
+
+		return tx.Model(&models.Dispute{}).
+			Where("id = ?", vote.DisputeID).
+			Updates(map[string]interface{}{
+				"total_votes": gorm.Expr("total_votes + 1"),
+				counter:       gorm.Expr(counter + " + 1"),
+				weight:        gorm.Expr(weight+" + ?::numeric", vote.Weight),
+			}).Error
+	})
 }
 
 func (r *DisputeRepository) GetVotesByDisputeID(disputeID uuid.UUID) ([]models.Vote, error) {
@@ -86,3 +220,123 @@ func (r *DisputeRepository) HasUserVoted(disputeID uuid.UUID, voterID uuid.UUID)
 		Count(&count).Error
 	return count > 0, err
 }
+
+// HasAddressVoted reports whether the wallet has voted on the dispute,
+// whether or not it belongs to an account.
+func (r *DisputeRepository) HasAddressVoted(disputeID uuid.UUID, address string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Vote{}).
+		Where("dispute_id = ? AND voter_addr = ?", disputeID, strings.ToLower(address)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// IsProjectJuror reports whether the user holds a pending or accepted juror
+// assignment on an unresolved dispute raised for the project.
+func (r *DisputeRepository) IsProjectJuror(projectID uuid.UUID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.JurorAssignment{}).
+		Joins("JOIN disputes ON disputes.id = juror_assignments.dispute_id").
+		Where("disputes.project_id = ? AND juror_assignments.juror_id = ?", projectID, userID).
+		Where("juror_assignments.status IN ? AND disputes.status IN ?", activeJurorStatuses, []models.DisputeStatus{
+			models.DisputeStatusOpen, models.DisputeStatusVoting, models.DisputeStatusAwaitingFinalization,
+		}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetPendingJurorIDs returns the dispute's assigned jurors who have not
+// declined and have not voted yet.
+func (r *DisputeRepository) GetPendingJurorIDs(dispute *models.Dispute) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.JurorAssignment{}).
+		Where("dispute_id = ? AND status IN ?", dispute.ID, activeJurorStatuses).
+		Where("juror_id NOT IN (SELECT voter_id FROM votes WHERE dispute_id = ? AND voter_id IS NOT NULL)", dispute.ID).
+		Pluck("juror_id", &ids).Error
+	return ids, err
+}
+
+// CountEvidence returns the number of evidence items per dispute.
+func (r *DisputeRepository) CountEvidence(disputeIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	return r.countByDispute(r.db.Model(&models.Evidence{}), disputeIDs)
+}
+
+// CountActiveJurors returns the number of jurors per dispute who have not
+// declined.
+func (r *DisputeRepository) CountActiveJurors(disputeIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	return r.countByDispute(r.db.Model(&models.JurorAssignment{}).Where("status IN ?", activeJurorStatuses), disputeIDs)
+}
+
+func (r *DisputeRepository) countByDispute(db *gorm.DB, disputeIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(disputeIDs))
+	if len(disputeIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		DisputeID uuid.UUID
+		Count     int64
+	}
+	if err := db.Select("dispute_id, COUNT(*) AS count").
+		Where("dispute_id IN ?", disputeIDs).
+		Group("dispute_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.DisputeID] = row.Count
+	}
+	return counts, nil
+}
+
+// DisputeOutcomeStats summarizes dispute outcomes. Resolved disputes are
+// won by the freelancer or client when they got more than half of the
+// escrow; an even split is counted separately.
+type DisputeOutcomeStats struct {
+	Category           string  `json:"category,omitempty"`
+	Total              int64   `json:"total"`
+	Resolved           int64   `json:"resolved"`
+	FreelancerWins     int64   `json:"freelancer_wins"`
+	ClientWins         int64   `json:"client_wins"`
+	EvenSplits         int64   `json:"even_splits"`
+	AvgFreelancerSplit float64 `json:"avg_freelancer_split"`
+	AvgVotes           float64 `json:"avg_votes"`
+	AvgResolutionHours float64 `json:"avg_resolution_hours"`
+}
+
+const disputeOutcomeColumns = `
+	COUNT(*) AS total,
+	COUNT(*) FILTER (WHERE status = 'resolved') AS resolved,
+	COUNT(*) FILTER (WHERE status = 'resolved' AND freelancer_split > 50) AS freelancer_wins,
+	COUNT(*) FILTER (WHERE status = 'resolved' AND freelancer_split < 50) AS client_wins,
+	COUNT(*) FILTER (WHERE status = 'resolved' AND freelancer_split = 50) AS even_splits,
+	COALESCE(AVG(freelancer_split) FILTER (WHERE status = 'resolved'), 0) AS avg_freelancer_split,
+	COALESCE(AVG(total_votes) FILTER (WHERE status = 'resolved'), 0) AS avg_votes,
+	COALESCE(AVG(EXTRACT(EPOCH FROM resolved_at - created_at) / 3600) FILTER (WHERE status = 'resolved'), 0) AS avg_resolution_hours`
+
+// disputeCategoryExpr normalizes the category, mapping anything but a known
+// category to other. Disputes filed before categories were restricted may
+// have any text, which must not reach the public stats.
+var disputeCategoryExpr = fmt.Sprintf(
+	"CASE WHEN LOWER(TRIM(disputes.category)) IN ('%s') THEN LOWER(TRIM(disputes.category)) ELSE '%s' END",
+	strings.Join(models.DisputeCategories, "', '"), models.DisputeCategoryOther,
+)
+
+// OutcomeStats returns outcome statistics overall and per category, most
+// common category first.
+func (r *DisputeRepository) OutcomeStats() (*DisputeOutcomeStats, []DisputeOutcomeStats, error) {
+	var overall DisputeOutcomeStats
+	if err := r.db.Model(&models.Dispute{}).Select(disputeOutcomeColumns).Scan(&overall).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var byCategory []DisputeOutcomeStats
+	err := r.db.Model(&models.Dispute{}).
+		Select(disputeCategoryExpr + " AS category," + disputeOutcomeColumns).
+		Group(disputeCategoryExpr).
+		Order("total DESC, category").
+		Scan(&byCategory).Error
+
+	return &overall, byCategory, err
+}
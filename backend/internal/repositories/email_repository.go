@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailRepository(db *gorm.DB) *EmailRepository {
+	return &EmailRepository{db: db}
+}
+
+// Outbox
+func (r *EmailRepository) CreateMessage(message *models.EmailMessage) error {
+	return r.db.Create(message).Error
+}
+
+func (r *EmailRepository) UpdateMessage(message *models.EmailMessage) error {
+	return r.db.Save(message).Error
+}
+
+// ClaimDue leases up to limit pending messages whose next attempt is due. The
+// lease pushes next_attempt_at forward so a crashed worker's messages are
+// retried once it expires, and SKIP LOCKED lets several workers run at once.
+func (r *EmailRepository) ClaimDue(limit int, lease time.Duration) ([]models.EmailMessage, error) {
+	var messages []models.EmailMessage
+	now := time.Now()
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.EmailStatusPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+
+		for i := range messages {
+			messages[i].Attempts++
+			messages[i].NextAttemptAt = now.Add(lease)
+			if err := tx.Model(&messages[i]).Updates(map[string]interface{}{
+				"attempts":        messages[i].Attempts,
+				"next_attempt_at": messages[i].NextAttemptAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return messages, err
+}
+
+// SuppressPending stops queued mail to an address that just became suppressed.
+func (r *EmailRepository) SuppressPending(email string, category models.EmailCategory) error {
+	db := r.db.Model(&models.EmailMessage{}).
+		Where("LOWER(\"to\") = ? AND status = ?", strings.ToLower(email), models.EmailStatusPending)
+	if category != "" {
+		db = db.Where("category = ?", category)
+	}
+	return db.Update("status", models.EmailStatusSuppressed).Error
+}
+
+// Suppressions
+func (r *EmailRepository) UpsertSuppression(suppression *models.EmailSuppression) error {
+	suppression.Email = strings.ToLower(suppression.Email)
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "updated_at"}),
+	}).Create(suppression).Error
+}
+
+func (r *EmailRepository) GetSuppression(email string) (*models.EmailSuppression, error) {
+	var suppression models.EmailSuppression
+	err := r.db.Where("email = ?", strings.ToLower(email)).First(&suppression).Error
+	return &suppression, err
+}
+
+// Tokens
+func (r *EmailRepository) CreateToken(token *models.EmailToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *EmailRepository) GetTokenByHash(hash string) (*models.EmailToken, error) {
+	var token models.EmailToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// UseToken marks the token used, returning false if it was already used.
+func (r *EmailRepository) UseToken(token *models.EmailToken, at time.Time) (bool, error) {
+	result := r.db.Model(&models.EmailToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
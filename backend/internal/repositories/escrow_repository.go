@@ -44,6 +44,15 @@ func (r *EscrowRepository) CreateEvent(event *models.EscrowEvent) error {
 	return r.db.Create(event).Error
 }
 
+// HasEvent reports whether the event of a log was already recorded.
+func (r *EscrowRepository) HasEvent(txHash string, logIndex uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.EscrowEvent{}).
+		Where("tx_hash = ? AND log_index = ?", txHash, logIndex).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *EscrowRepository) GetEventsByEscrowID(escrowID uuid.UUID) ([]models.EscrowEvent, error) {
 	var events []models.EscrowEvent
 	err := r.db.Where("escrow_id = ?", escrowID).
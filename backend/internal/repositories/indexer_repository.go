@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"github.com/fariima/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IndexerRepository struct {
+	db *gorm.DB
+}
+
+func NewIndexerRepository(db *gorm.DB) *IndexerRepository {
+	return &IndexerRepository{db: db}
+}
+
+func (r *IndexerRepository) GetCursor(name string) (*models.IndexerCursor, error) {
+	var cursor models.IndexerCursor
+	err := r.db.Where("name = ?", name).First(&cursor).Error
+	return &cursor, err
+}
+
+func (r *IndexerRepository) SaveCursor(cursor *models.IndexerCursor) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"block", "updated_at"}),
+	}).Create(cursor).Error
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// invoiceLockKey serializes invoice numbering across API instances.
+const invoiceLockKey = "invoices"
+
+// issueInvoicesSQL numbers payments that have no invoice yet, in order of
+// payment within each year, after the last number used that year.
+const issueInvoicesSQL = `
+	WITH pending AS (
+		SELECT le.id, le.occurred_at, EXTRACT(YEAR FROM le.occurred_at AT TIME ZONE 'UTC')::int AS year
+		FROM ledger_entries le
+		WHERE le.kind IN @kinds
+			AND NOT EXISTS (SELECT 1 FROM invoices i WHERE i.ledger_entry_id = le.id)
+	), numbered AS (
+		SELECT p.*, COALESCE((SELECT MAX(i.sequence) FROM invoices i WHERE i.year = p.year), 0)
+			+ ROW_NUMBER() OVER (PARTITION BY p.year ORDER BY p.occurred_at, p.id) AS sequence
+		FROM pending p
+	)
+	INSERT INTO invoices (id, number, year, sequence, ledger_entry_id, issued_at, created_at)
+	SELECT gen_random_uuid(), @prefix || '-' || year || '-' || LPAD(sequence::text, 6, '0'),
+		year, sequence, id, occurred_at, NOW()
+	FROM numbered`
+
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+// IssuePending gives every ledger entry of the given kinds without an
+// invoice the next invoice number, and returns how many were issued.
+func (r *InvoiceRepository) IssuePending(prefix string, kinds []models.LedgerKind) (int64, error) {
+	var issued int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", invoiceLockKey).Error; err != nil {
+			return err
+		}
+
+		result := tx.Exec(issueInvoicesSQL, map[string]interface{}{"prefix": prefix, "kinds": kinds})
+		issued = result.RowsAffected
+		return result.Error
+	})
+	return issued, err
+}
+
+func (r *InvoiceRepository) GetByNumber(number string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.Preload("LedgerEntry").First(&invoice, "number = ?", number).Error
+	return &invoice, err
+}
+
+// ListByParty returns invoices the user issued as freelancer or received as
+// client, newest first, optionally paid within [from, to).
+func (r *InvoiceRepository) ListByParty(userID uuid.UUID, from, to *time.Time, limit, offset int) ([]models.Invoice, int64, error) {
+	var invoices []models.Invoice
+	var total int64
+
+	db := r.db.Model(&models.Invoice{}).
+		Joins("JOIN ledger_entries le ON le.id = invoices.ledger_entry_id").
+		Where("le.client_id = ? OR le.freelancer_id = ?", userID, userID)
+	if from != nil {
+		db = db.Where("invoices.issued_at >= ?", *from)
+	}
+	if to != nil {
+		db = db.Where("invoices.issued_at < ?", *to)
+	}
+
+	db.Count(&total)
+	err := db.Preload("LedgerEntry").
+		Order("invoices.issued_at DESC, invoices.sequence DESC").
+		Limit(limit).Offset(offset).
+		Find(&invoices).Error
+
+	return invoices, total, err
+}
+
+// NumbersForEntries returns the invoice numbers of the given ledger entries
+// by entry ID. Entries without an invoice are left out.
+func (r *InvoiceRepository) NumbersForEntries(entryIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	var invoices []models.Invoice
+	numbers := make(map[uuid.UUID]string, len(entryIDs))
+	if len(entryIDs) == 0 {
+		return numbers, nil
+	}
+
+	err := r.db.Select("ledger_entry_id, number").Where("ledger_entry_id IN ?", entryIDs).Find(&invoices).Error
+	for _, invoice := range invoices {
+		numbers[invoice.LedgerEntryID] = invoice.Number
+	}
+	return numbers, err
+}
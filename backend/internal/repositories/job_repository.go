@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// JobCount is the number of jobs of a type in a status.
+type JobCount struct {
+	Type   string           `json:"type"`
+	Status models.JobStatus `json:"status"`
+	Count  int64            `json:"count"`
+}
+
+// Create inserts the job, returning false when another active job already
+// holds its unique key.
+func (r *JobRepository) Create(job *models.Job) (bool, error) {
+	return createJob(r.db, job)
+}
+
+func createJob(db *gorm.DB, job *models.Job) (bool, error) {
+	if job.UniqueKey == nil {
+		return true, db.Create(job).Error
+	}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "unique_key"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "unique_key IS NOT NULL AND status <> 'completed' AND status <> 'dead'"},
+		}},
+		DoNothing: true,
+	}).Create(job)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *JobRepository) GetByID(id uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	err := r.db.First(&job, "id = ?", id).Error
+	return &job, err
+}
+
+func (r *JobRepository) Update(job *models.Job) error {
+	return r.db.Save(job).Error
+}
+
+// ClaimDue leases up to limit pending jobs whose run_at has passed, each for
+// the lease of its type, to owner. Only types with a lease are claimed.
+func (r *JobRepository) ClaimDue(leases map[string]time.Duration, owner string, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	now := time.Now()
+
+	types := make([]string, 0, len(leases))
+	for jobType := range leases {
+		types = append(types, jobType)
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type IN ? AND status = ? AND run_at <= ?", types, models.JobStatusPending, now).
+			Order("run_at").
+			Limit(limit).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+
+		for i := range jobs {
+			lockedUntil := now.Add(leases[jobs[i].Type])
+			jobs[i].Status = models.JobStatusRunning
+			jobs[i].Attempts++
+			jobs[i].LockedUntil = &lockedUntil
+			jobs[i].LockedBy = owner
+			if err := tx.Model(&jobs[i]).Updates(map[string]interface{}{
+				"status":       jobs[i].Status,
+				"attempts":     jobs[i].Attempts,
+				"locked_until": lockedUntil,
+				"locked_by":    owner,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return jobs, err
+}
+
+// ReclaimRunning locks the running jobs whose lease expired before now, or
+// that owner holds, and saves those reclaim changed. It returns how many were
+// saved.
+func (r *JobRepository) ReclaimRunning(now time.Time, owner string, reclaim func(*models.Job) bool) (int, error) {
+	reclaimed := 0
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var jobs []models.Job
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.JobStatusRunning).
+			Where("locked_until < ? OR locked_until IS NULL OR locked_by = ?", now, owner).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+
+		for i := range jobs {
+			if !reclaim(&jobs[i]) {
+				continue
+			}
+			if err := tx.Save(&jobs[i]).Error; err != nil {
+				return err
+			}
+			reclaimed++
+		}
+
+		return nil
+	})
+
+	return reclaimed, err
+}
+
+func (r *JobRepository) List(status, jobType string, limit, offset int) ([]models.Job, int64, error) {
+	var jobs []models.Job
+	var total int64
+
+	db := r.db.Model(&models.Job{})
+
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if jobType != "" {
+		db = db.Where("type = ?", jobType)
+	}
+
+	db.Count(&total)
+	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&jobs).Error
+
+	return jobs, total, err
+}
+
+func (r *JobRepository) CountByStatus() ([]JobCount, error) {
+	var counts []JobCount
+	err := r.db.Model(&models.Job{}).
+		Select("type, status, COUNT(*) AS count").
+		Group("type, status").
+		Order("type, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// DeleteFinishedBefore prunes completed and dead jobs last touched before t.
+func (r *JobRepository) DeleteFinishedBefore(t time.Time) (int64, error) {
+	result := r.db.Where("status IN ? AND updated_at < ?",
+		[]models.JobStatus{models.JobStatusCompleted, models.JobStatusDead}, t).
+		Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
+
+// Schedules
+func (r *JobRepository) GetScheduleByName(name string) (*models.JobSchedule, error) {
+	var schedule models.JobSchedule
+	err := r.db.Where("name = ?", name).First(&schedule).Error
+	return &schedule, err
+}
+
+func (r *JobRepository) CreateSchedule(schedule *models.JobSchedule) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(schedule).Error
+}
+
+func (r *JobRepository) UpdateSchedule(schedule *models.JobSchedule) error {
+	return r.db.Save(schedule).Error
+}
+
+func (r *JobRepository) ListSchedules() ([]models.JobSchedule, error) {
+	var schedules []models.JobSchedule
+	err := r.db.Order("name").Find(&schedules).Error
+	return schedules, err
+}
+
+// FireDueSchedules locks every enabled schedule that is due, inserts the job
+// built by makeJob and advances next_run_at, all in one transaction so a
+// schedule fires exactly once per slot even with several API instances.
+func (r *JobRepository) FireDueSchedules(now time.Time, next func(*models.JobSchedule) (time.Time, error), makeJob func(*models.JobSchedule) *models.Job) ([]models.JobSchedule, error) {
+	var schedules []models.JobSchedule
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("enabled = ? AND next_run_at <= ?", true, now).
+			Find(&schedules).Error; err != nil {
+			return err
+		}
+
+		for i := range schedules {
+			if _, err := createJob(tx, makeJob(&schedules[i])); err != nil {
+				return err
+			}
+
+			nextRun, err := next(&schedules[i])
+			if err != nil {
+				return err
+			}
+
+			schedules[i].LastRunAt = &now
+			schedules[i].NextRunAt = nextRun
+			if err := tx.Model(&schedules[i]).Updates(map[string]interface{}{
+				"last_run_at": now,
+				"next_run_at": nextRun,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return schedules, err
+}
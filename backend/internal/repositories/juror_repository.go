@@ -0,0 +1,300 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JurorRepository struct {
+	db *gorm.DB
+}
+
+func NewJurorRepository(db *gorm.DB) *JurorRepository {
+	return &JurorRepository{db: db}
+}
+
+// Pool
+func (r *JurorRepository) UpsertPoolMember(member *models.JurorPoolMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"address", "staked_amount", "participation", "refreshed_at", "updated_at"}),
+	}).Create(member).Error
+}
+
+// DeletePoolMembersRefreshedBefore drops members whose stake was not
+// confirmed by the latest refresh.
+func (r *JurorRepository) DeletePoolMembersRefreshedBefore(t time.Time) (int64, error) {
+	result := r.db.Where("refreshed_at < ?", t).Delete(&models.JurorPoolMember{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *JurorRepository) ListPool() ([]models.JurorPoolMember, error) {
+	var members []models.JurorPoolMember
+	err := r.db.Order("address").Find(&members).Error
+	return members, err
+}
+
+// ParticipationCounts returns how many disputes each user has voted on.
+func (r *JurorRepository) ParticipationCounts() (map[uuid.UUID]int, error) {
+	var rows []struct {
+		VoterID uuid.UUID
+		Count   int
+	}
+	if err := r.db.Model(&models.Vote{}).
+		Select("voter_id, COUNT(DISTINCT dispute_id) AS count").
+		Where("voter_id IS NOT NULL").
+		Group("voter_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.VoterID] = row.Count
+	}
+	return counts, nil
+}
+
+// ConflictedUserIDs returns users with a conflict of interest towards any of
+// the parties: they worked on a project together, one follows the other, or
+// they list the same company.
+func (r *JurorRepository) ConflictedUserIDs(partyIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Raw(`
+		SELECT freelancer_id FROM projects WHERE client_id IN @parties AND freelancer_id IS NOT NULL
+		UNION
+		SELECT client_id FROM projects WHERE freelancer_id IN @parties
+		UNION
+		SELECT following_id FROM follows WHERE follower_id IN @parties
+		UNION
+		SELECT follower_id FROM follows WHERE following_id IN @parties
+		UNION
+		SELECT u.id FROM users u
+		WHERE u.deleted_at IS NULL AND TRIM(u.company_name) <> '' AND LOWER(TRIM(u.company_name)) IN (
+			SELECT LOWER(TRIM(company_name)) FROM users
+			WHERE id IN @parties AND TRIM(company_name) <> ''
+		)`,
+		map[string]interface{}{"parties": partyIDs},
+	).Scan(&ids).Error
+	return ids, err
+}
+
+// Draws and assignments
+
+// CreateDraw stores a draw with the assignments it produced and, for a
+// replacement, links the declined assignment to its successor.
+func (r *JurorRepository) CreateDraw(draw *models.JurorDraw, assignments []models.JurorAssignment, replaced *models.JurorAssignment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(draw).Error; err != nil {
+			return err
+		}
+
+		for i := range assignments {
+			assignments[i].DrawID = draw.ID
+			if err := tx.Create(&assignments[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		if replaced != nil && len(assignments) > 0 {
+			replaced.ReplacedBy = &assignments[0].ID
+			return tx.Model(replaced).Update("replaced_by", replaced.ReplacedBy).Error
+		}
+		return nil
+	})
+}
+
+func (r *JurorRepository) CountDraws(disputeID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.JurorDraw{}).Where("dispute_id = ?", disputeID).Count(&count).Error
+	return count, err
+}
+
+func (r *JurorRepository) GetDraws(disputeID uuid.UUID) ([]models.JurorDraw, error) {
+	var draws []models.JurorDraw
+	err := r.db.Where("dispute_id = ?", disputeID).Order("created_at").Find(&draws).Error
+	return draws, err
+}
+
+func (r *JurorRepository) GetFirstDraw(disputeID uuid.UUID) (*models.JurorDraw, error) {
+	var draw models.JurorDraw
+	err := r.db.Where("dispute_id = ?", disputeID).Order("created_at").First(&draw).Error
+	return &draw, err
+}
+
+func (r *JurorRepository) GetAssignments(disputeID uuid.UUID) ([]models.JurorAssignment, error) {
+	var assignments []models.JurorAssignment
+	err := r.db.Preload("Juror").
+		Where("dispute_id = ?", disputeID).
+		Order("created_at").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *JurorRepository) GetAssignment(disputeID, jurorID uuid.UUID) (*models.JurorAssignment, error) {
+	var assignment models.JurorAssignment
+	err := r.db.Where("dispute_id = ? AND juror_id = ?", disputeID, jurorID).First(&assignment).Error
+	return &assignment, err
+}
+
+func (r *JurorRepository) ListAssignmentsByJuror(jurorID uuid.UUID, status string, limit, offset int) ([]models.JurorAssignment, int64, error) {
+	var assignments []models.JurorAssignment
+	var total int64
+
+	db := r.db.Model(&models.JurorAssignment{}).Preload("Dispute").Where("juror_id = ?", jurorID)
+
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	db.Count(&total)
+	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&assignments).Error
+
+	return assignments, total, err
+}
+
+// RespondToAssignment moves a pending assignment to status, reporting false
+// if it was no longer pending.
+func (r *JurorRepository) RespondToAssignment(id uuid.UUID, status models.JurorAssignmentStatus) (bool, error) {
+	result := r.db.Model(&models.JurorAssignment{}).
+		Where("id = ? AND status = ?", id, models.JurorAssignmentPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetDisputesWithoutDraw returns on-chain disputes in voting that have no
+// jurors yet, e.g. because the pool was empty when they were indexed.
+func (r *JurorRepository) GetDisputesWithoutDraw() ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.Where("status = ? AND on_chain_id IS NOT NULL", models.DisputeStatusVoting).
+		Where("NOT EXISTS (SELECT 1 FROM juror_draws WHERE juror_draws.dispute_id = disputes.id)").
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// Rewards and reputation
+
+// JurorStats aggregates a juror's voting record. A vote is correct when it
+// sided with the majority outcome of a finalized dispute; 50/50 outcomes
+// count towards neither.
+type JurorStats struct {
+	UserID            uuid.UUID `json:"user_id"`
+	Address           string    `json:"address"`
+	Username          string    `json:"username"`
+	Avatar            string    `json:"avatar"`
+	Participation     int64     `json:"participation"`      // Disputes voted on
+	DecidedVotes      int64     `json:"decided_votes"`      // Votes on finalized disputes with a majority outcome
+	CorrectVotes      int64     `json:"correct_votes"`      // Decided votes that matched the outcome
+	Accuracy          float64   `json:"accuracy"`           // CorrectVotes / DecidedVotes
+	RewardsEarned     string    `json:"rewards_earned"`     // Wei string
+	UnclaimedDisputes int64     `json:"unclaimed_disputes"` // Finalized disputes with an unclaimed reward
+}
+
+const jurorStatsQuery = `
+	SELECT *, CASE WHEN decided_votes > 0 THEN correct_votes::float8 / decided_votes ELSE 0 END AS accuracy
+	FROM (
+		SELECT u.id AS user_id, u.address, u.username, u.avatar,
+			COUNT(v.id) AS participation,
+			COUNT(v.id) FILTER (WHERE d.status = 'resolved' AND d.freelancer_split <> 50) AS decided_votes,
+			COUNT(v.id) FILTER (WHERE d.status = 'resolved' AND (
+				(v.vote_for = 'freelancer' AND d.freelancer_split > 50) OR
+				(v.vote_for = 'client' AND d.freelancer_split < 50))) AS correct_votes,
+			COUNT(v.id) FILTER (WHERE d.status = 'resolved' AND d.on_chain_id IS NOT NULL AND v.reward_claimed_at IS NULL) AS unclaimed_disputes,
+			COALESCE((SELECT SUM(r.amount::numeric) FROM juror_rewards r WHERE r.juror_id = u.id), 0)::text AS rewards_earned
+		FROM votes v
+		JOIN users u ON u.id = v.voter_id AND u.deleted_at IS NULL
+		JOIN disputes d ON d.id = v.dispute_id
+		GROUP BY u.id
+	) stats`
+
+// GetJurorStats returns the user's juror record, zero-valued if they never
+// voted.
+func (r *JurorRepository) GetJurorStats(userID uuid.UUID) (*JurorStats, error) {
+	var stats JurorStats
+	result := r.db.Raw(jurorStatsQuery+" WHERE user_id = ?", userID).Scan(&stats)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		stats.UserID = userID
+		stats.RewardsEarned = "0"
+	}
+	return &stats, nil
+}
+
+// Leaderboard ranks jurors by orderBy, keeping those with at least
+// minDecided decided votes.
+func (r *JurorRepository) Leaderboard(orderBy string, minDecided, limit, offset int) ([]JurorStats, int64, error) {
+	var total int64
+	if err := r.db.Raw("SELECT COUNT(*) FROM ("+jurorStatsQuery+" WHERE decided_votes >= ?) ranked", minDecided).
+		Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var stats []JurorStats
+	err := r.db.Raw(jurorStatsQuery+" WHERE decided_votes >= ? ORDER BY "+orderBy+", address LIMIT ? OFFSET ?",
+		minDecided, limit, offset).Scan(&stats).Error
+	return stats, total, err
+}
+
+// UnclaimedRewardDisputes returns the on-chain IDs of finalized disputes the
+// juror voted on but has not claimed a reward for.
+func (r *JurorRepository) UnclaimedRewardDisputes(jurorID uuid.UUID) ([]int64, error) {
+	var ids []int64
+	err := r.db.Model(&models.Vote{}).
+		Joins("JOIN disputes ON disputes.id = votes.dispute_id").
+		Where("votes.voter_id = ? AND votes.reward_claimed_at IS NULL", jurorID).
+		Where("disputes.status = ? AND disputes.on_chain_id IS NOT NULL", models.DisputeStatusResolved).
+		Order("disputes.on_chain_id").
+		Pluck("disputes.on_chain_id", &ids).Error
+	return ids, err
+}
+
+// RecordReward stores a JurorRewarded event and marks the juror's votes on
+// the claimed disputes as paid. It returns gorm.ErrDuplicatedKey if the
+// event was already recorded.
+func (r *JurorRepository) RecordReward(reward *models.JurorReward, disputeIDs []int64, claimedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reward).Error; err != nil {
+			return err
+		}
+		if len(disputeIDs) == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.Vote{}).
+			Where("voter_addr = ? AND reward_claimed_at IS NULL", reward.Address).
+			Where("dispute_id IN (?)", tx.Model(&models.Dispute{}).
+				Select("id").
+				Where("on_chain_id IN ? AND status = ?", disputeIDs, models.DisputeStatusResolved)).
+			Updates(map[string]interface{}{
+				"reward_tx_hash":    reward.TxHash,
+				"reward_claimed_at": claimedAt,
+			}).Error
+	})
+}
+
+func (r *JurorRepository) ListRewards(address string, limit, offset int) ([]models.JurorReward, int64, error) {
+	var rewards []models.JurorReward
+	var total int64
+
+	db := r.db.Model(&models.JurorReward{}).Where("address = ?", address)
+
+	db.Count(&total)
+	err := db.Order("block_number DESC, log_index DESC").Limit(limit).Offset(offset).Find(&rewards).Error
+
+	return rewards, total, err
+}
+
+func (r *JurorRepository) GetPoolMember(userID uuid.UUID) (*models.JurorPoolMember, error) {
+	var member models.JurorPoolMember
+	err := r.db.Where("user_id = ?", userID).First(&member).Error
+	return &member, err
+}
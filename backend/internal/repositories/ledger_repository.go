@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ledgerEventTypes are the escrow events that pay out of an escrow.
+// Cancellations only do when they refund a deposit.
+var ledgerEventTypes = []string{"release", "milestone_release", "dispute_resolved"}
+
+// maxLedgerAttempts is how often an event that fails to be recorded is
+// retried before it is left for an admin to look at.
+const maxLedgerAttempts = 10
+
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// Create stores the entries of an event, skipping those already recorded.
+func (r *LedgerRepository) Create(entries []models.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}, {Name: "kind"}},
+		DoNothing: true,
+	}).Create(&entries).Error
+}
+
+// UnrecordedEvents returns payout events that have no ledger entries yet,
+// oldest first. Events that failed before come after the others, so a batch
+// of events that cannot be recorded does not hold back new ones, and are
+// given up on after maxLedgerAttempts.
+func (r *LedgerRepository) UnrecordedEvents(limit int) ([]models.EscrowEvent, error) {
+	var events []models.EscrowEvent
+	err := r.db.
+		Where("event_type IN ? OR (event_type = 'cancelled' AND COALESCE(data->>'refund_amount', '0') <> '0')", ledgerEventTypes).
+		Where("NOT EXISTS (SELECT 1 FROM ledger_entries le WHERE le.event_id = escrow_events.id)").
+		Where("ledger_attempts < ?", maxLedgerAttempts).
+		Order("ledger_attempts ASC, block_number ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// RecordFailure counts a failed attempt to record the event in the ledger.
+// It returns whether the event will be retried.
+func (r *LedgerRepository) RecordFailure(eventID uuid.UUID) (bool, error) {
+	var attempts int
+	err := r.db.Raw(`
+		UPDATE escrow_events SET ledger_attempts = ledger_attempts + 1
+		WHERE id = ? RETURNING ledger_attempts`, eventID).Scan(&attempts).Error
+	return attempts < maxLedgerAttempts, err
+}
+
+// LedgerFilter narrows ledger queries. Zero values are ignored.
+type LedgerFilter struct {
+	FreelancerID *uuid.UUID
+	ClientID     *uuid.UUID
+	Kinds        []models.LedgerKind
+	From         *time.Time // Inclusive
+	To           *time.Time // Exclusive
+}
+
+func (r *LedgerRepository) filtered(filter LedgerFilter) *gorm.DB {
+	db := r.db.Model(&models.LedgerEntry{})
+
+	if filter.FreelancerID != nil {
+		db = db.Where("freelancer_id = ?", *filter.FreelancerID)
+	}
+	if filter.ClientID != nil {
+		db = db.Where("client_id = ?", *filter.ClientID)
+	}
+	if len(filter.Kinds) > 0 {
+		db = db.Where("kind IN ?", filter.Kinds)
+	}
+	if filter.From != nil {
+		db = db.Where("occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("occurred_at < ?", *filter.To)
+	}
+	return db
+}
+
+// List returns matching entries, newest first. A negative limit returns all
+// of them.
+func (r *LedgerRepository) List(filter LedgerFilter, limit, offset int) ([]models.LedgerEntry, int64, error) {
+	var entries []models.LedgerEntry
+	var total int64
+
+	db := r.filtered(filter)
+	db.Count(&total)
+	err := db.Order("occurred_at DESC, block_number DESC").Limit(limit).Offset(offset).Find(&entries).Error
+
+	return entries, total, err
+}
+
+// LedgerTotal sums the matching entries of one kind in one token. Amounts
+// are decimal integers in base units.
+type LedgerTotal struct {
+	Token string
+	Kind  models.LedgerKind
+	Gross string
+	Fee   string
+	Net   string
+}
+
+func (r *LedgerRepository) Totals(filter LedgerFilter) ([]LedgerTotal, error) {
+	var totals []LedgerTotal
+	err := r.filtered(filter).
+		Select("token, kind, SUM(gross)::text AS gross, SUM(fee)::text AS fee, SUM(net)::text AS net").
+		Group("token, kind").
+		Scan(&totals).Error
+	return totals, err
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// Conversations
+
+// CreateConversation inserts the conversation unless one already exists for
+// its scope, e.g. because a concurrent request created it first.
+func (r *MessageRepository) CreateConversation(conversation *models.Conversation) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(conversation).Error
+}
+
+func (r *MessageRepository) GetConversationByID(id uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.Preload("Project").Preload("Application").
+		First(&conversation, "id = ?", id).Error
+	return &conversation, err
+}
+
+func (r *MessageRepository) GetProjectConversation(projectID uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.Preload("Project").
+		Where("project_id = ? AND scope = ?", projectID, models.ConversationScopeProject).
+		First(&conversation).Error
+	return &conversation, err
+}
+
+func (r *MessageRepository) GetApplicationConversation(applicationID uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.Preload("Project").Preload("Application").
+		Where("application_id = ? AND scope = ?", applicationID, models.ConversationScopeApplication).
+		First(&conversation).Error
+	return &conversation, err
+}
+
+// ListUserConversations returns conversations the user takes part in as the
+// project client, the hired freelancer or the applicant.
+func (r *MessageRepository) ListUserConversations(userID uuid.UUID, limit, offset int) ([]models.Conversation, int64, error) {
+	var conversations []models.Conversation
+	var total int64
+
+	db := r.db.Model(&models.Conversation{}).
+		Joins("JOIN projects ON projects.id = conversations.project_id").
+		Joins("LEFT JOIN applications ON applications.id = conversations.application_id").
+		Where(`(conversations.scope = ? AND (projects.client_id = ? OR projects.freelancer_id = ?)) OR
+			(conversations.scope = ? AND (projects.client_id = ? OR applications.freelancer_id = ?))`,
+			models.ConversationScopeProject, userID, userID,
+			models.ConversationScopeApplication, userID, userID)
+
+	db.Count(&total)
+	err := db.Preload("Project").Preload("Application").
+		Order("conversations.last_message_at DESC NULLS LAST").
+		Limit(limit).Offset(offset).
+		Find(&conversations).Error
+
+	return conversations, total, err
+}
+
+func (r *MessageRepository) TouchConversation(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.Conversation{}).
+		Where("id = ?", id).
+		Update("last_message_at", at).Error
+}
+
+// Messages
+func (r *MessageRepository) CreateMessage(message *models.Message) error {
+	return r.db.Create(message).Error
+}
+
+func (r *MessageRepository) GetMessageByID(id uuid.UUID) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Preload("Attachments").First(&message, "id = ?", id).Error
+	return &message, err
+}
+
+func (r *MessageRepository) ListMessages(conversationID uuid.UUID, limit, offset int) ([]models.Message, int64, error) {
+	var messages []models.Message
+	var total int64
+
+	db := r.db.Model(&models.Message{}).Where("conversation_id = ?", conversationID)
+
+	db.Count(&total)
+	err := db.Preload("Sender").Preload("Attachments").
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&messages).Error
+
+	return messages, total, err
+}
+
+// Read receipts
+
+// UpsertRead moves the user's read pointer forward to the given message. It
+// never moves it back, and reports whether it moved.
+func (r *MessageRepository) UpsertRead(read *models.ConversationRead) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "conversation_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_read_message_id", "last_read_at", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "conversation_reads.last_read_at < excluded.last_read_at"},
+		}},
+	}).Create(read)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *MessageRepository) GetReads(conversationID uuid.UUID) ([]models.ConversationRead, error) {
+	var reads []models.ConversationRead
+	err := r.db.Where("conversation_id = ?", conversationID).Find(&reads).Error
+	return reads, err
+}
+
+func (r *MessageRepository) CountUnread(conversationID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Message{}).
+		Where("conversation_id = ? AND sender_id <> ?", conversationID, userID).
+		Where(`created_at > COALESCE((SELECT last_read_at FROM conversation_reads
+			WHERE conversation_id = ? AND user_id = ?), 'epoch')`, conversationID, userID).
+		Count(&count).Error
+	return count, err
+}
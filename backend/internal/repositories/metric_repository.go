@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// MetricRepository computes platform metrics from the other tables and
+// stores their daily rollups.
+type MetricRepository struct {
+	db *gorm.DB
+}
+
+func NewMetricRepository(db *gorm.DB) *MetricRepository {
+	return &MetricRepository{db: db}
+}
+
+// eventTime dates escrow events by their block, or by when they were indexed
+// for events recorded before block times were kept.
+const eventTime = "COALESCE(ev.block_time, ev.created_at)"
+
+// valueLockedSQL sums, per token, what funded escrows that were not yet
+// settled at @end still held: their amount less milestone releases.
+const valueLockedSQL = `
+	SELECT e.token AS dimension,
+		SUM(e.amount::numeric - COALESCE((
+			SELECT SUM((ev.data->>'amount')::numeric) FROM escrow_events ev
+			WHERE ev.escrow_id = e.id AND ev.event_type = 'milestone_release' AND ` + eventTime + ` < @end
+		), 0))::text AS value
+	FROM escrows e
+	WHERE EXISTS (
+			SELECT 1 FROM escrow_events ev
+			WHERE ev.escrow_id = e.id AND ev.event_type = 'deposit' AND ` + eventTime + ` < @end
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM escrow_events ev
+			WHERE ev.escrow_id = e.id AND ev.event_type IN ('release', 'dispute_resolved', 'cancelled') AND ` + eventTime + ` < @end
+		)
+	GROUP BY e.token`
+
+// dailyMetricQueries compute each metric over the day [@start, @end) as
+// rows of (dimension, value).
+var dailyMetricQueries = []struct {
+	metric string
+	sql    string
+}{
+	{models.MetricNewUsers, `
+		SELECT role AS dimension, COUNT(*)::text AS value FROM users
+		WHERE created_at >= @start AND created_at < @end
+		GROUP BY role`},
+	{models.MetricProjectsPosted, `
+		SELECT '' AS dimension, COUNT(*)::text AS value FROM projects
+		WHERE created_at >= @start AND created_at < @end`},
+	{models.MetricProjectsStarted, `
+		SELECT '' AS dimension, COUNT(*)::text AS value FROM projects
+		WHERE start_date >= @start AND start_date < @end`},
+	{models.MetricProjectsCompleted, `
+		SELECT '' AS dimension, COUNT(DISTINCT ev.escrow_id)::text AS value FROM escrow_events ev
+		WHERE ev.event_type = 'release' AND ` + eventTime + ` >= @start AND ` + eventTime + ` < @end`},
+	{models.MetricGMV, `
+		SELECT e.token AS dimension, SUM(e.amount::numeric)::text AS value
+		FROM escrow_events ev JOIN escrows e ON e.id = ev.escrow_id
+		WHERE ev.event_type = 'deposit' AND ` + eventTime + ` >= @start AND ` + eventTime + ` < @end
+		GROUP BY e.token`},
+	{models.MetricPlatformFees, `
+		SELECT e.token AS dimension, SUM((ev.data->>'platform_fee')::numeric)::text AS value
+		FROM escrow_events ev JOIN escrows e ON e.id = ev.escrow_id
+		WHERE ev.event_type = 'release' AND ` + eventTime + ` >= @start AND ` + eventTime + ` < @end
+		GROUP BY e.token`},
+	{models.MetricTVL, valueLockedSQL},
+	{models.MetricDisputesOpened, `
+		SELECT '' AS dimension, COUNT(*)::text AS value FROM disputes
+		WHERE created_at >= @start AND created_at < @end`},
+	{models.MetricDisputesResolved, `
+		SELECT '' AS dimension, COUNT(*)::text AS value FROM disputes
+		WHERE resolved_at >= @start AND resolved_at < @end`},
+}
+
+type metricRow struct {
+	Dimension string
+	Value     string
+}
+
+// ComputeDay computes every metric for the UTC day starting at day.
+func (r *MetricRepository) ComputeDay(day time.Time) ([]models.DailyMetric, error) {
+	args := map[string]interface{}{"start": day, "end": day.AddDate(0, 0, 1)}
+
+	var metrics []models.DailyMetric
+	for _, query := range dailyMetricQueries {
+		var rows []metricRow
+		if err := r.db.Raw(query.sql, args).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to compute %s: %w", query.metric, err)
+		}
+		for _, row := range rows {
+			metrics = append(metrics, models.DailyMetric{
+				Day:       day,
+				Metric:    query.metric,
+				Dimension: row.Dimension,
+				Value:     row.Value,
+			})
+		}
+	}
+	return metrics, nil
+}
+
+// SaveDay replaces the rollup of a day.
+func (r *MetricRepository) SaveDay(day time.Time, metrics []models.DailyMetric) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day = ?", day).Delete(&models.DailyMetric{}).Error; err != nil {
+			return err
+		}
+		if len(metrics) == 0 {
+			return nil
+		}
+		return tx.Create(&metrics).Error
+	})
+}
+
+// LastDay returns the latest day rolled up, or nil if there is none.
+func (r *MetricRepository) LastDay() (*time.Time, error) {
+	var day *time.Time
+	err := r.db.Model(&models.DailyMetric{}).Select("MAX(day)").Scan(&day).Error
+	return day, err
+}
+
+// FirstActivity returns when the first user signed up, or nil if none has.
+func (r *MetricRepository) FirstActivity() (*time.Time, error) {
+	var first *time.Time
+	err := r.db.Raw("SELECT MIN(created_at) FROM users").Scan(&first).Error
+	return first, err
+}
+
+// Range returns the rollups of the metrics between two days, inclusive.
+func (r *MetricRepository) Range(metrics []string, from, to time.Time) ([]models.DailyMetric, error) {
+	var rows []models.DailyMetric
+	err := r.db.
+		Where("metric IN ? AND day >= ? AND day <= ?", metrics, from, to).
+		Order("day ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// PlatformTotals are the all-time counts behind the platform stats.
+type PlatformTotals struct {
+	TotalProjects     int64
+	ActiveProjects    int64
+	CompletedProjects int64
+	TotalUsers        int64
+	TotalFreelancers  int64
+	TotalClients      int64
+	TotalDisputes     int64
+}
+
+func (r *MetricRepository) PlatformTotals() (*PlatformTotals, error) {
+	var totals PlatformTotals
+	err := r.db.Raw(`
+		SELECT
+			(SELECT COUNT(*) FROM projects WHERE deleted_at IS NULL) AS total_projects,
+			(SELECT COUNT(*) FROM projects WHERE deleted_at IS NULL AND status = ?) AS active_projects,
+			(SELECT COUNT(DISTINCT escrow_id) FROM escrow_events WHERE event_type = 'release') AS completed_projects,
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL) AS total_users,
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND role = 'freelancer') AS total_freelancers,
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND role = 'client') AS total_clients,
+			(SELECT COUNT(*) FROM disputes) AS total_disputes`,
+		models.ProjectStatusInProgress,
+	).Scan(&totals).Error
+	return &totals, err
+}
+
+// ValueLocked returns, per token address, the base units held by funded
+// escrows that are not settled yet.
+func (r *MetricRepository) ValueLocked() (map[string]string, error) {
+	var rows []metricRow
+	if err := r.db.Raw(valueLockedSQL, map[string]interface{}{"end": time.Now()}).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	locked := make(map[string]string, len(rows))
+	for _, row := range rows {
+		locked[row.Dimension] = row.Value
+	}
+	return locked, nil
+}
@@ -27,7 +27,7 @@ func (r *NFTRepository) GetByTokenID(tokenID int64) (*models.NFT, error) {
 
 func (r *NFTRepository) GetByOwnerAddress(ownerAddr string) ([]models.NFT, error) {
 	var nfts []models.NFT
-	err := r.db.Where("owner_address = ?", ownerAddr).
+	err := r.db.Where("LOWER(owner_addr) = LOWER(?)", ownerAddr).
 		Preload("Project").
 		Order("minted_at DESC").
 		Find(&nfts).Error
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *NotificationRepository) GetByID(id uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.First(&notification, "id = ?", id).Error
+	return &notification, err
+}
+
+func (r *NotificationRepository) List(userID uuid.UUID, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var total int64
+
+	db := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+
+	db.Count(&total)
+	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error
+
+	return notifications, total, err
+}
+
+// ListUnreadSince returns the unread notifications created since the given
+// time, grouped by user and oldest first.
+func (r *NotificationRepository) ListUnreadSince(since time.Time) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("read_at IS NULL AND created_at >= ?", since).
+		Order("user_id, created_at").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *NotificationRepository) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// SetReadAt marks a notification read (at non-nil) or unread (at nil).
+func (r *NotificationRepository) SetReadAt(id, userID uuid.UUID, at *time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
+
+func (r *NotificationRepository) MarkAllRead(userID uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at).Error
+}
+
+// Preferences
+func (r *NotificationRepository) GetPreferences(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *NotificationRepository) UpsertPreference(preference *models.NotificationPreference) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(preference).Error
+}
+
+// Webhooks
+func (r *NotificationRepository) GetWebhook(userID uuid.UUID) (*models.NotificationWebhook, error) {
+	var webhook models.NotificationWebhook
+	err := r.db.Where("user_id = ?", userID).First(&webhook).Error
+	return &webhook, err
+}
+
+func (r *NotificationRepository) UpsertWebhook(webhook *models.NotificationWebhook) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "secret", "updated_at"}),
+	}).Create(webhook).Error
+}
+
+func (r *NotificationRepository) DeleteWebhook(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.NotificationWebhook{}).Error
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// PinRepository finds the CIDs the platform depends on across tables.
+type PinRepository struct {
+	db *gorm.DB
+}
+
+func NewPinRepository(db *gorm.DB) *PinRepository {
+	return &PinRepository{db: db}
+}
+
+// ReferencedCIDs returns the CIDs stored on evidence, message attachments,
+// projects and private files, plus every pinned upload.
+func (r *PinRepository) ReferencedCIDs() ([]string, error) {
+	var cids []string
+	err := r.db.Raw(`
+		SELECT ipfs_hash FROM evidences WHERE ipfs_hash <> ''
+		UNION SELECT ipfs_hash FROM message_attachments WHERE ipfs_hash <> ''
+		UNION SELECT ipfs_hash FROM projects WHERE ipfs_hash <> '' AND deleted_at IS NULL
+		UNION SELECT cid FROM private_files
+		UNION SELECT cid FROM uploads WHERE pin_status = ?`,
+		models.PinStatusPinned,
+	).Scan(&cids).Error
+	return cids, err
+}
+
+// IsKnownCID reports whether the CID was uploaded here or is stored on
+// evidence, a message attachment, a private file or a minted NFT. Free text
+// is not searched, so nobody can make a CID known by mentioning it.
+func (r *PinRepository) IsKnownCID(cid string) (bool, error) {
+	var known bool
+	err := r.db.Raw(`
+		SELECT EXISTS (SELECT 1 FROM uploads WHERE cid = @cid)
+		OR EXISTS (SELECT 1 FROM evidences WHERE ipfs_hash = @cid)
+		OR EXISTS (SELECT 1 FROM message_attachments WHERE ipfs_hash = @cid)
+		OR EXISTS (SELECT 1 FROM private_files WHERE cid = @cid)
+		OR EXISTS (SELECT 1 FROM nfts WHERE image = 'ipfs://' || @cid OR token_uri = 'ipfs://' || @cid)`,
+		map[string]interface{}{"cid": cid},
+	).Scan(&known).Error
+	return known, err
+}
+
+// TokenURIs returns the token URIs of minted NFTs.
+func (r *PinRepository) TokenURIs() ([]string, error) {
+	var uris []string
+	err := r.db.Model(&models.NFT{}).Where("token_uri <> ''").Pluck("token_uri", &uris).Error
+	return uris, err
+}
+
+// cidReferences are the rows that can refer to an upload's CID, as
+// subqueries on u.cid. Avatars, resumes, JSON lists and free text refer to
+// uploads by URL or in links, so they are matched by substring.
+var cidReferences = []string{
+	`SELECT 1 FROM evidences e WHERE e.ipfs_hash = u.cid OR e.description LIKE '%' || u.cid || '%'`,
+	`SELECT 1 FROM message_attachments a WHERE a.ipfs_hash = u.cid`,
+	`SELECT 1 FROM messages m WHERE m.body LIKE '%' || u.cid || '%'`,
+	`SELECT 1 FROM private_files f WHERE f.cid = u.cid`,
+	`SELECT 1 FROM projects p WHERE p.deleted_at IS NULL AND (
+		p.ipfs_hash = u.cid
+		OR p.attachments::text LIKE '%' || u.cid || '%'
+		OR p.description LIKE '%' || u.cid || '%')`,
+	`SELECT 1 FROM applications ap WHERE ap.cover_letter LIKE '%' || u.cid || '%'`,
+	`SELECT 1 FROM reviews r WHERE r.comment LIKE '%' || u.cid || '%'`,
+	`SELECT 1 FROM users s WHERE s.deleted_at IS NULL AND (
+		s.avatar LIKE '%' || u.cid || '%'
+		OR s.resume_url LIKE '%' || u.cid || '%'
+		OR s.portfolio::text LIKE '%' || u.cid || '%'
+		OR s.bio LIKE '%' || u.cid || '%'
+		OR s.website LIKE '%' || u.cid || '%')`,
+	`SELECT 1 FROM disputes d WHERE d.description LIKE '%' || u.cid || '%'`,
+	`SELECT 1 FROM proposals pr WHERE pr.description LIKE '%' || u.cid || '%'`,
+	`SELECT 1 FROM nfts n WHERE
+		n.token_uri LIKE '%' || u.cid || '%'
+		OR n.image LIKE '%' || u.cid || '%'
+		OR n.attributes::text LIKE '%' || u.cid || '%'`,
+}
+
+// OrphanedCIDs returns pinned uploads that nothing refers to and that were
+// last uploaded before the given time.
+func (r *PinRepository) OrphanedCIDs(before time.Time) ([]string, error) {
+	query := `SELECT u.cid FROM uploads u WHERE u.pin_status = ?`
+	for _, reference := range cidReferences {
+		query += "\n\t\tAND NOT EXISTS (" + reference + ")"
+	}
+	query += "\n\t\tGROUP BY u.cid HAVING MAX(u.created_at) < ?"
+
+	var cids []string
+	err := r.db.Raw(query, models.PinStatusPinned, before).Scan(&cids).Error
+	return cids, err
+}
+
+// MarkUnpinned records that a CID's uploads are no longer pinned, which
+// frees them from their owners' quotas.
+func (r *PinRepository) MarkUnpinned(cid string) error {
+	return r.db.Model(&models.Upload{}).
+		Where("cid = ? AND pin_status = ?", cid, models.PinStatusPinned).
+		Update("pin_status", models.PinStatusUnpinned).Error
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrivateFileRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivateFileRepository(db *gorm.DB) *PrivateFileRepository {
+	return &PrivateFileRepository{db: db}
+}
+
+// Create saves the file together with its grants.
+func (r *PrivateFileRepository) Create(file *models.PrivateFile) error {
+	return r.db.Create(file).Error
+}
+
+// Delete removes the file together with its grants.
+func (r *PrivateFileRepository) Delete(file *models.PrivateFile) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("file_id = ?", file.ID).Delete(&models.FileGrant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(file).Error
+	})
+}
+
+func (r *PrivateFileRepository) GetByCID(cid string) (*models.PrivateFile, error) {
+	var file models.PrivateFile
+	err := r.db.First(&file, "cid = ?", cid).Error
+	return &file, err
+}
+
+func (r *PrivateFileRepository) ListByDispute(disputeID uuid.UUID) ([]models.PrivateFile, error) {
+	var files []models.PrivateFile
+	err := r.db.Where("dispute_id = ?", disputeID).Find(&files).Error
+	return files, err
+}
+
+// ListByGrantee returns the files the user has been granted, with only that
+// user's grant preloaded.
+func (r *PrivateFileRepository) ListByGrantee(userID uuid.UUID) ([]models.PrivateFile, error) {
+	var files []models.PrivateFile
+	err := r.db.
+		Preload("Grants", "user_id = ?", userID).
+		Where("id IN (?)", r.db.Model(&models.FileGrant{}).Select("file_id").Where("user_id = ?", userID)).
+		Find(&files).Error
+	return files, err
+}
+
+func (r *PrivateFileRepository) GetGrant(fileID, userID uuid.UUID) (*models.FileGrant, error) {
+	var grant models.FileGrant
+	err := r.db.First(&grant, "file_id = ? AND user_id = ?", fileID, userID).Error
+	return &grant, err
+}
+
+// AddGrant grants access unless the user already has it.
+func (r *PrivateFileRepository) AddGrant(grant *models.FileGrant) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_id"}, {Name: "user_id"}},
+		DoNothing: true,
+	}).Create(grant).Error
+}
+
+func (r *PrivateFileRepository) SetGrantKey(grantID uuid.UUID, wrappedKey string) error {
+	return r.db.Model(&models.FileGrant{}).Where("id = ?", grantID).Update("wrapped_key", wrappedKey).Error
+}
@@ -24,6 +24,33 @@ func (r *ProjectRepository) GetByID(id uuid.UUID) (*models.Project, error) {
 	return &project, err
 }
 
+func (r *ProjectRepository) GetByOnChainID(onChainID int64) (*models.Project, error) {
+	var project models.Project
+	err := r.db.Preload("Client").Preload("Freelancer").
+		Where("on_chain_id = ?", onChainID).First(&project).Error
+	return &project, err
+}
+
+// FindUnlinked finds the most recent in-progress project between a client and
+// freelancer address pair that has not been matched to an on-chain project yet.
+func (r *ProjectRepository) FindUnlinked(txHash, clientAddr, freelancerAddr string) (*models.Project, error) {
+	var project models.Project
+
+	err := r.db.Where("on_chain_id IS NULL AND tx_hash = ?", txHash).First(&project).Error
+	if err == nil {
+		return &project, nil
+	}
+
+	err = r.db.Model(&models.Project{}).
+		Joins("JOIN users clients ON clients.id = projects.client_id").
+		Joins("JOIN users freelancers ON freelancers.id = projects.freelancer_id").
+		Where("projects.on_chain_id IS NULL AND projects.status = ?", models.ProjectStatusInProgress).
+		Where("LOWER(clients.address) = LOWER(?) AND LOWER(freelancers.address) = LOWER(?)", clientAddr, freelancerAddr).
+		Order("projects.start_date DESC").
+		First(&project).Error
+	return &project, err
+}
+
 func (r *ProjectRepository) Update(project *models.Project) error {
 	return r.db.Save(project).Error
 }
@@ -87,11 +114,36 @@ func (r *ProjectRepository) GetByFreelancerID(freelancerID uuid.UUID) ([]models.
 	return projects, err
 }
 
+// GetTitles returns the titles of the given projects, including deleted
+// ones, by ID.
+func (r *ProjectRepository) GetTitles(ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	var rows []struct {
+		ID    uuid.UUID
+		Title string
+	}
+	titles := make(map[uuid.UUID]string, len(ids))
+	if len(ids) == 0 {
+		return titles, nil
+	}
+
+	err := r.db.Unscoped().Model(&models.Project{}).Select("id, title").Where("id IN ?", ids).Scan(&rows).Error
+	for _, row := range rows {
+		titles[row.ID] = row.Title
+	}
+	return titles, err
+}
+
 // Applications
 func (r *ProjectRepository) CreateApplication(app *models.Application) error {
 	return r.db.Create(app).Error
 }
 
+func (r *ProjectRepository) GetApplicationByID(id uuid.UUID) (*models.Application, error) {
+	var app models.Application
+	err := r.db.Preload("Freelancer").First(&app, "id = ?", id).Error
+	return &app, err
+}
+
 func (r *ProjectRepository) GetApplicationsByProjectID(projectID uuid.UUID) ([]models.Application, error) {
 	var applications []models.Application
 	err := r.db.Where("project_id = ?", projectID).
@@ -104,3 +156,12 @@ func (r *ProjectRepository) GetApplicationsByProjectID(projectID uuid.UUID) ([]m
 func (r *ProjectRepository) UpdateApplication(app *models.Application) error {
 	return r.db.Save(app).Error
 }
+
+// GetReview returns the review left for the reviewee on a project.
+func (r *ProjectRepository) GetReview(projectID, revieweeID uuid.UUID) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Where("project_id = ? AND reviewee_id = ?", projectID, revieweeID).
+		Order("created_at DESC").
+		First(&review).Error
+	return &review, err
+}
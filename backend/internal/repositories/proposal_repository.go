@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProposalRepository struct {
+	db *gorm.DB
+}
+
+func NewProposalRepository(db *gorm.DB) *ProposalRepository {
+	return &ProposalRepository{db: db}
+}
+
+func (r *ProposalRepository) Create(proposal *models.Proposal) error {
+	return r.db.Create(proposal).Error
+}
+
+func (r *ProposalRepository) GetByID(id uuid.UUID) (*models.Proposal, error) {
+	var proposal models.Proposal
+	err := r.db.Preload("Proposer").First(&proposal, "id = ?", id).Error
+	return &proposal, err
+}
+
+func (r *ProposalRepository) GetByOnChainID(onChainID int64) (*models.Proposal, error) {
+	var proposal models.Proposal
+	err := r.db.Where("on_chain_id = ?", onChainID).First(&proposal).Error
+	return &proposal, err
+}
+
+// SetState updates only the state, leaving tallies to RecordVote.
+func (r *ProposalRepository) SetState(id uuid.UUID, state models.ProposalState) error {
+	return r.db.Model(&models.Proposal{}).Where("id = ?", id).Update("state", state).Error
+}
+
+func (r *ProposalRepository) MarkExecuted(id uuid.UUID, txHash string, executedAt time.Time) error {
+	return r.db.Model(&models.Proposal{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"state":            models.ProposalStateExecuted,
+		"executed_tx_hash": txHash,
+		"executed_at":      executedAt,
+	}).Error
+}
+
+func (r *ProposalRepository) List(state, proposalType string, limit, offset int) ([]models.Proposal, int64, error) {
+	var proposals []models.Proposal
+	var total int64
+
+	db := r.db.Model(&models.Proposal{}).Preload("Proposer")
+
+	if state != "" {
+		db = db.Where("state = ?", state)
+	}
+
+	if proposalType != "" {
+		db = db.Where("type = ?", proposalType)
+	}
+
+	db.Count(&total)
+	err := db.Order("on_chain_id DESC").Limit(limit).Offset(offset).Find(&proposals).Error
+
+	return proposals, total, err
+}
+
+// GetEndedActive returns proposals still marked active whose voting period
+// has ended, i.e. awaiting executeProposal.
+func (r *ProposalRepository) GetEndedActive(now time.Time) ([]models.Proposal, error) {
+	var proposals []models.Proposal
+	err := r.db.Where("state = ? AND voting_ends_at <= ?", models.ProposalStateActive, now).
+		Find(&proposals).Error
+	return proposals, err
+}
+
+// RecordVote stores a vote and adds its voting power to the proposal's
+// tally in one transaction. It returns gorm.ErrDuplicatedKey if the voter
+// already voted.
+func (r *ProposalRepository) RecordVote(vote *models.ProposalVote) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(vote).Error; err != nil {
+			return err
+		}
+
+		column := "votes_against"
+		if vote.Support {
+			column = "votes_for"
+		}
+
+		return tx.Model(&models.Proposal{}).
+			Where("id = ?", vote.ProposalID).
+			Updates(map[string]interface{}{
+				column:        gorm.Expr(column+" + ?::numeric", vote.VotingPower),
+				"voter_count": gorm.Expr("voter_count + 1"),
+			}).Error
+	})
+}
+
+func (r *ProposalRepository) GetVotes(proposalID uuid.UUID, limit, offset int) ([]models.ProposalVote, int64, error) {
+	var votes []models.ProposalVote
+	var total int64
+
+	db := r.db.Model(&models.ProposalVote{}).Where("proposal_id = ?", proposalID)
+
+	db.Count(&total)
+	err := db.Order("block_number DESC").Limit(limit).Offset(offset).Find(&votes).Error
+
+	return votes, total, err
+}
+
+// GetVotesByVoter returns an address's proposal votes with their proposals,
+// newest first.
+func (r *ProposalRepository) GetVotesByVoter(address string, limit, offset int) ([]models.ProposalVote, int64, error) {
+	var votes []models.ProposalVote
+	var total int64
+
+	db := r.db.Model(&models.ProposalVote{}).Preload("Proposal").Where("voter_addr = ?", address)
+
+	db.Count(&total)
+	err := db.Order("block_number DESC").Limit(limit).Offset(offset).Find(&votes).Error
+
+	return votes, total, err
+}
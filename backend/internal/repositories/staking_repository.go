@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StakingRepository struct {
+	db *gorm.DB
+}
+
+func NewStakingRepository(db *gorm.DB) *StakingRepository {
+	return &StakingRepository{db: db}
+}
+
+func (r *StakingRepository) GetAccount(address string) (*models.StakeAccount, error) {
+	var account models.StakeAccount
+	err := r.db.Where("address = ?", address).First(&account).Error
+	return &account, err
+}
+
+func (r *StakingRepository) GetVestingSchedule(beneficiary string) (*models.VestingSchedule, error) {
+	var schedule models.VestingSchedule
+	err := r.db.Where("beneficiary = ?", beneficiary).First(&schedule).Error
+	return &schedule, err
+}
+
+// GetAccounts returns the stake accounts of the given addresses; addresses
+// that never staked are absent.
+func (r *StakingRepository) GetAccounts(addresses []string) ([]models.StakeAccount, error) {
+	var accounts []models.StakeAccount
+	if len(addresses) == 0 {
+		return accounts, nil
+	}
+	err := r.db.Where("address IN ?", addresses).Find(&accounts).Error
+	return accounts, err
+}
+
+// GetRankedAccounts returns accounts whose ranking boost may need
+// recomputing: those with a stake or a boost left over from one.
+func (r *StakingRepository) GetRankedAccounts() ([]models.StakeAccount, error) {
+	var accounts []models.StakeAccount
+	err := r.db.Where("staked_amount > 0 OR ranking_boost > 0").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *StakingRepository) SetRankingBoost(address string, boost float64) error {
+	return r.db.Model(&models.StakeAccount{}).
+		Where("address = ?", address).
+		Update("ranking_boost", boost).Error
+}
+
+func (r *StakingRepository) ListEvents(address string, limit, offset int) ([]models.StakeEvent, int64, error) {
+	var events []models.StakeEvent
+	var total int64
+
+	db := r.db.Model(&models.StakeEvent{}).Where("address = ?", address)
+
+	db.Count(&total)
+	err := db.Order("block_number DESC, log_index DESC").Limit(limit).Offset(offset).Find(&events).Error
+
+	return events, total, err
+}
+
+// RecordStaked applies a Staked event. The first stake sets StakedSince,
+// which the contract never resets.
+func (r *StakingRepository) RecordStaked(event *models.StakeEvent) error {
+	return r.recordEvent(event, func(tx *gorm.DB) error {
+		return r.upsertAccount(tx, event, map[string]interface{}{
+			"staked_amount": event.Balance,
+			"staked_since":  gorm.Expr("COALESCE(staked_since, ?)", event.BlockTime),
+		})
+	})
+}
+
+// RecordUnstakeRequested applies an UnstakeRequested event. A new request
+// replaces any pending one.
+func (r *StakingRepository) RecordUnstakeRequested(event *models.StakeEvent) error {
+	return r.recordEvent(event, func(tx *gorm.DB) error {
+		return r.upsertAccount(tx, event, map[string]interface{}{
+			"pending_unstake":      event.Amount,
+			"unstake_available_at": event.AvailableAt,
+		})
+	})
+}
+
+func (r *StakingRepository) RecordUnstaked(event *models.StakeEvent) error {
+	return r.recordEvent(event, func(tx *gorm.DB) error {
+		return r.upsertAccount(tx, event, map[string]interface{}{
+			"staked_amount":        event.Balance,
+			"pending_unstake":      "0",
+			"unstake_available_at": nil,
+		})
+	})
+}
+
+// ClearPendingUnstake drops a pending request that was cancelled on-chain.
+// cancelUnstake emits no event, so this is found by reconciliation.
+func (r *StakingRepository) ClearPendingUnstake(address string) error {
+	return r.db.Model(&models.StakeAccount{}).
+		Where("address = ?", address).
+		Updates(map[string]interface{}{
+			"pending_unstake":      "0",
+			"unstake_available_at": nil,
+		}).Error
+}
+
+func (r *StakingRepository) RecordVestingCreated(event *models.StakeEvent, schedule *models.VestingSchedule) error {
+	return r.recordEvent(event, func(tx *gorm.DB) error {
+		return tx.Create(schedule).Error
+	})
+}
+
+func (r *StakingRepository) RecordTokensReleased(event *models.StakeEvent) error {
+	return r.recordEvent(event, func(tx *gorm.DB) error {
+		return tx.Model(&models.VestingSchedule{}).
+			Where("beneficiary = ?", event.Address).
+			Update("released", gorm.Expr("released + ?::numeric", event.Amount)).Error
+	})
+}
+
+// recordEvent stores a ledger entry and applies its effect in one
+// transaction. It returns gorm.ErrDuplicatedKey if the event was already
+// recorded, in which case nothing is applied.
+func (r *StakingRepository) recordEvent(event *models.StakeEvent, apply func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return apply(tx)
+	})
+}
+
+func (r *StakingRepository) upsertAccount(tx *gorm.DB, event *models.StakeEvent, updates map[string]interface{}) error {
+	account := &models.StakeAccount{
+		Address:        event.Address,
+		StakedAmount:   "0",
+		PendingUnstake: "0",
+		UpdatedBlock:   event.BlockNumber,
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(account).Error; err != nil {
+		return err
+	}
+
+	updates["updated_block"] = event.BlockNumber
+	updates["updated_at"] = time.Now()
+	return tx.Model(&models.StakeAccount{}).Where("address = ?", event.Address).Updates(updates).Error
+}
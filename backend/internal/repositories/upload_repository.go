@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// Reserve records a pending upload if it fits in the owner's quota. The
+// owner's row is locked so concurrent uploads cannot both use the last of
+// the quota.
+func (r *UploadRepository) Reserve(upload *models.Upload, quota int64) (bool, error) {
+	reserved := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			First(&user, "id = ?", upload.OwnerID).Error; err != nil {
+			return err
+		}
+
+		used, err := usage(tx, upload.OwnerID)
+		if err != nil {
+			return err
+		}
+		if used+upload.Size > quota {
+			return nil
+		}
+
+		upload.PinStatus = models.PinStatusPending
+		if err := tx.Create(upload).Error; err != nil {
+			return err
+		}
+		reserved = true
+		return nil
+	})
+
+	return reserved, err
+}
+
+func (r *UploadRepository) Update(upload *models.Upload) error {
+	return r.db.Save(upload).Error
+}
+
+// ReleaseReferences lifts the quarantine of the evidence and message
+// attachments stored by a released upload. Attachments are public, so they
+// are pointed at the upload's new CID and fileURL.
+func (r *UploadRepository) ReleaseReferences(upload *models.Upload, fileURL string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.MessageAttachment{}).
+			Where("upload_id = ?", upload.ID).
+			Updates(map[string]interface{}{"quarantined": false, "ipfs_hash": upload.CID, "file_url": fileURL}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Evidence{}).
+			Where("upload_id = ?", upload.ID).
+			Update("quarantined", false).Error
+	})
+}
+
+func (r *UploadRepository) GetByID(id uuid.UUID) (*models.Upload, error) {
+	var upload models.Upload
+	err := r.db.First(&upload, "id = ?", id).Error
+	return &upload, err
+}
+
+// GetByCID returns the most recent pinned upload of the content.
+func (r *UploadRepository) GetByCID(cid string) (*models.Upload, error) {
+	var upload models.Upload
+	err := r.db.Where("cid = ? AND pin_status = ?", cid, models.PinStatusPinned).
+		Order("created_at DESC").
+		First(&upload).Error
+	return &upload, err
+}
+
+func (r *UploadRepository) ListByOwner(ownerID uuid.UUID, limit, offset int) ([]models.Upload, int64, error) {
+	var uploads []models.Upload
+	var total int64
+
+	db := r.db.Model(&models.Upload{}).Where("owner_id = ?", ownerID)
+
+	db.Count(&total)
+	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&uploads).Error
+
+	return uploads, total, err
+}
+
+// ListQuarantined returns stored uploads awaiting review, oldest first.
+func (r *UploadRepository) ListQuarantined(limit, offset int) ([]models.Upload, int64, error) {
+	var uploads []models.Upload
+	var total int64
+
+	db := r.db.Model(&models.Upload{}).
+		Where("scan_status = ? AND pin_status = ?", models.ScanStatusQuarantined, models.PinStatusPinned)
+
+	db.Count(&total)
+	err := db.Order("created_at ASC").Limit(limit).Offset(offset).Find(&uploads).Error
+
+	return uploads, total, err
+}
+
+// Usage returns the bytes and number of uploads counting toward the owner's
+// quota.
+func (r *UploadRepository) Usage(ownerID uuid.UUID) (int64, int64, error) {
+	var result struct {
+		Bytes int64
+		Count int64
+	}
+	err := quotaUploads(r.db, ownerID).
+		Select("COALESCE(SUM(size), 0) AS bytes, COUNT(*) AS count").
+		Scan(&result).Error
+	return result.Bytes, result.Count, err
+}
+
+func usage(db *gorm.DB, ownerID uuid.UUID) (int64, error) {
+	var used int64
+	err := quotaUploads(db, ownerID).Select("COALESCE(SUM(size), 0)").Scan(&used).Error
+	return used, err
+}
+
+func quotaUploads(db *gorm.DB, ownerID uuid.UUID) *gorm.DB {
+	return db.Model(&models.Upload{}).
+		Where("owner_id = ? AND pin_status IN ?", ownerID, []models.PinStatus{models.PinStatusPending, models.PinStatusPinned})
+}
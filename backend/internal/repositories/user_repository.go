@@ -30,6 +30,19 @@ func (r *UserRepository) GetByAddress(address string) (*models.User, error) {
 	return &user, err
 }
 
+// ListWithAddress returns every user with a linked wallet.
+func (r *UserRepository) ListWithAddress() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("address <> ''").Order("address").Find(&users).Error
+	return users, err
+}
+
+func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	return &user, err
+}
+
 func (r *UserRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
@@ -50,7 +63,14 @@ func (r *UserRepository) Search(query string, limit, offset int) ([]models.User,
 	}
 
 	db.Count(&total)
-	err := db.Limit(limit).Offset(offset).Find(&users).Error
+
+	// Rating, raised by the freelancer's stake boost (stake_accounts.ranking_boost, in percent)
+	err := db.Select("users.*").
+		Joins("LEFT JOIN stake_accounts ON stake_accounts.address = users.address AND users.role = 'freelancer'").
+		Order("(1 + users.rating) * (1 + COALESCE(stake_accounts.ranking_boost, 0) / 100) DESC").
+		Order("users.completed_projects DESC").
+		Order("users.created_at DESC").
+		Limit(limit).Offset(offset).Find(&users).Error
 
 	return users, total, err
 }
@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the size of the chunks streamed to clamd. It must stay
+// below clamd's StreamMaxLength.
+const clamdChunkSize = 64 << 10
+
+// ClamAV scans content with a clamd daemon using the INSTREAM command.
+// clamd's StreamMaxLength must be at least the largest upload accepted, or
+// larger files fail to scan.
+type ClamAV struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV returns a scanner for the clamd daemon at address, given as
+// tcp://host:port, unix:///path/to/clamd.sock or host:port. timeout bounds a
+// whole scan.
+func NewClamAV(address string, timeout time.Duration) (*ClamAV, error) {
+	network, addr := "tcp", address
+	switch {
+	case strings.HasPrefix(address, "tcp://"):
+		addr = strings.TrimPrefix(address, "tcp://")
+	case strings.HasPrefix(address, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(address, "unix://")
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("invalid clamd address %q", address)
+	}
+
+	return &ClamAV{network: network, address: addr, timeout: timeout}, nil
+}
+
+func (s *ClamAV) Name() string {
+	return "clamav"
+}
+
+func (s *ClamAV) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// Unblocks reads and writes if the caller gives up first
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	writeErr := s.stream(conn, r)
+	var readErr *sourceError
+	if errors.As(writeErr, &readErr) {
+		return nil, readErr.err
+	}
+
+	// clamd stops reading and replies with an error when the stream exceeds
+	// its limit, so the reply is read even if writing failed
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		if writeErr != nil {
+			return nil, fmt.Errorf("clamd: %w", writeErr)
+		}
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00"))
+}
+
+// stream sends r as INSTREAM chunks, each prefixed with its length, ending
+// with an empty chunk.
+func (s *ClamAV) stream(conn net.Conn, r io.Reader) error {
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, clamdChunkSize)
+	var size [4]byte
+	for {
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(chunk[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return &sourceError{err}
+		}
+	}
+
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// sourceError is a failure to read the content being scanned, as opposed to
+// a failure to talk to clamd.
+type sourceError struct {
+	err error
+}
+
+func (e *sourceError) Error() string {
+	return e.err.Error()
+}
+
+// parseClamdReply parses "stream: OK", "stream: <signature> FOUND" or
+// "<message> ERROR".
+func parseClamdReply(reply string) (*Result, error) {
+	switch {
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(reply, " FOUND")
+		if i := strings.Index(signature, ": "); i >= 0 {
+			signature = signature[i+2:]
+		}
+		return &Result{Infected: true, Signature: signature}, nil
+	case strings.HasSuffix(reply, " OK"):
+		return &Result{}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,46 @@
+// Package scanner checks uploaded content for malware before it is stored.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Scanner inspects content for malware. Implementations must be safe for
+// concurrent use.
+type Scanner interface {
+	// Name identifies the scanner in logs and scan results.
+	Name() string
+
+	// Scan reads r to the end and reports what it found. An error means the
+	// content could not be scanned, not that it is infected.
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
+
+// Result is the verdict of a scan.
+type Result struct {
+	Infected  bool
+	Signature string // Name of the matched signature if infected
+}
+
+// New returns the scanner selected by MALWARE_SCANNER: "clamav" for a clamd
+// daemon at CLAMAV_ADDRESS, or "local", a stand-in that only detects the
+// EICAR test file, for development and tests.
+func New(cfg *config.Config, logger *logrus.Logger) (Scanner, error) {
+	switch cfg.MalwareScanner {
+	case "clamav":
+		return NewClamAV(cfg.ClamAVAddress, time.Duration(cfg.ScanTimeoutSeconds)*time.Second)
+	case "local":
+		if cfg.GinMode == "release" {
+			logger.Warn("MALWARE_SCANNER is local; uploads are only checked for the EICAR test file")
+		}
+		return NewLocal(), nil
+	default:
+		return nil, fmt.Errorf("unknown malware scanner %q", cfg.MalwareScanner)
+	}
+}
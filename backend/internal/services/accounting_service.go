@@ -0,0 +1,298 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/pdf"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Ledger roles of a statement line.
+const (
+	RoleFreelancer = "freelancer"
+	RoleClient     = "client"
+)
+
+// statementColumns is the CSV header of statements.
+var statementColumns = []string{
+	"date", "type", "role", "project_id", "project", "counterparty", "invoice",
+	"token", "symbol", "gross", "fee", "net", "gross_base_units", "fee_base_units", "net_base_units",
+	"tx_hash", "block_number",
+}
+
+// AccountingService exports the ledger as statements and invoices for users'
+// bookkeeping and tax reporting.
+type AccountingService struct {
+	cfg           *config.Config
+	ledgerService *LedgerService
+	invoiceRepo   *repositories.InvoiceRepository
+	escrowRepo    *repositories.EscrowRepository
+	projectRepo   *repositories.ProjectRepository
+	userRepo      *repositories.UserRepository
+	tokens        *TokenRegistry
+	logger        *logrus.Logger
+}
+
+// StatementLine is a payout from the user's side: received as freelancer,
+// or paid or refunded as client.
+type StatementLine struct {
+	LedgerLine
+	Role         string `json:"role"`
+	ProjectTitle string `json:"project_title"`
+	Invoice      string `json:"invoice,omitempty"`
+}
+
+// Statement lists a user's payouts in [From, To), oldest first.
+type Statement struct {
+	UserID      uuid.UUID       `json:"user_id"`
+	From        *time.Time      `json:"from,omitempty"`
+	To          *time.Time      `json:"to,omitempty"` // Exclusive
+	GeneratedAt time.Time       `json:"generated_at"`
+	Items       []StatementLine `json:"items"`
+	Earnings    LedgerTotals    `json:"earnings"`
+	Spend       LedgerTotals    `json:"spend"`
+}
+
+// InvoiceItem is an invoice with the payment it is for.
+type InvoiceItem struct {
+	ID       uuid.UUID  `json:"id"`
+	Number   string     `json:"number"`
+	IssuedAt time.Time  `json:"issued_at"`
+	Role     string     `json:"role"`
+	Payment  LedgerLine `json:"payment"`
+}
+
+func NewAccountingService(
+	cfg *config.Config,
+	ledgerService *LedgerService,
+	invoiceRepo *repositories.InvoiceRepository,
+	escrowRepo *repositories.EscrowRepository,
+	projectRepo *repositories.ProjectRepository,
+	userRepo *repositories.UserRepository,
+	tokens *TokenRegistry,
+	logger *logrus.Logger,
+) *AccountingService {
+	return &AccountingService{
+		cfg:           cfg,
+		ledgerService: ledgerService,
+		invoiceRepo:   invoiceRepo,
+		escrowRepo:    escrowRepo,
+		projectRepo:   projectRepo,
+		userRepo:      userRepo,
+		tokens:        tokens,
+		logger:        logger,
+	}
+}
+
+// GetStatement returns the user's earnings as a freelancer and spend as a
+// client in [from, to), with nil bounds open.
+func (s *AccountingService) GetStatement(userID uuid.UUID, from, to *time.Time) (*Statement, error) {
+	query := LedgerQuery{From: from, To: to, Limit: -1}
+
+	earnings, err := s.ledgerService.GetEarnings(userID, query)
+	if err != nil {
+		return nil, err
+	}
+	spend, err := s.ledgerService.GetSpend(userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		UserID:      userID,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+		Items:       make([]StatementLine, 0, len(earnings.Items)+len(spend.Items)),
+		Earnings:    earnings.Totals,
+		Spend:       spend.Totals,
+	}
+	for _, line := range earnings.Items {
+		statement.Items = append(statement.Items, StatementLine{LedgerLine: line, Role: RoleFreelancer})
+	}
+	for _, line := range spend.Items {
+		statement.Items = append(statement.Items, StatementLine{LedgerLine: line, Role: RoleClient})
+	}
+	sort.SliceStable(statement.Items, func(i, j int) bool {
+		a, b := statement.Items[i], statement.Items[j]
+		if !a.OccurredAt.Equal(b.OccurredAt) {
+			return a.OccurredAt.Before(b.OccurredAt)
+		}
+		return a.BlockNumber < b.BlockNumber
+	})
+
+	projectIDs := make([]uuid.UUID, 0, len(statement.Items))
+	entryIDs := make([]uuid.UUID, 0, len(statement.Items))
+	for _, line := range statement.Items {
+		projectIDs = append(projectIDs, line.ProjectID)
+		entryIDs = append(entryIDs, line.ID)
+	}
+	titles, err := s.projectRepo.GetTitles(projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := s.invoiceRepo.NumbersForEntries(entryIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range statement.Items {
+		statement.Items[i].ProjectTitle = titles[statement.Items[i].ProjectID]
+		statement.Items[i].Invoice = invoices[statement.Items[i].ID]
+	}
+
+	return statement, nil
+}
+
+// WriteStatementCSV writes the statement's lines as CSV. Amounts are given
+// in whole tokens for known stablecoins and always in base units.
+func WriteStatementCSV(w io.Writer, statement *Statement) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(statementColumns); err != nil {
+		return err
+	}
+
+	for _, line := range statement.Items {
+		counterparty := line.ClientAddr
+		if line.Role == RoleClient {
+			counterparty = line.FreelancerAddr
+		}
+
+		err := cw.Write([]string{
+			line.OccurredAt.UTC().Format(time.RFC3339),
+			string(line.Kind),
+			line.Role,
+			line.ProjectID.String(),
+			csvSafe(line.ProjectTitle),
+			counterparty,
+			line.Invoice,
+			line.Token,
+			line.Symbol,
+			line.GrossUnits,
+			line.FeeUnits,
+			line.NetUnits,
+			line.Gross,
+			line.Fee,
+			line.Net,
+			line.TxHash,
+			strconv.FormatUint(line.BlockNumber, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe keeps user-provided text from being run as a formula by
+// spreadsheets.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ListInvoices returns invoices the user issued as freelancer or received
+// as client, newest first.
+func (s *AccountingService) ListInvoices(userID uuid.UUID, from, to *time.Time, limit, offset int) ([]InvoiceItem, int64, error) {
+	invoices, total, err := s.invoiceRepo.ListByParty(userID, from, to, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]InvoiceItem, len(invoices))
+	for i, invoice := range invoices {
+		items[i] = InvoiceItem{
+			ID:       invoice.ID,
+			Number:   invoice.Number,
+			IssuedAt: invoice.IssuedAt,
+			Role:     invoiceRole(&invoice.LedgerEntry, userID),
+			Payment:  s.ledgerService.line(invoice.LedgerEntry),
+		}
+	}
+	return items, total, nil
+}
+
+// GetInvoicePDF renders an invoice for one of its parties.
+func (s *AccountingService) GetInvoicePDF(userID uuid.UUID, number string) (*pdf.Document, error) {
+	invoice, err := s.invoiceRepo.GetByNumber(number)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if invoiceRole(&invoice.LedgerEntry, userID) == "" {
+		return nil, fmt.Errorf("%w: not a party to this invoice", ErrForbidden)
+	}
+
+	escrow, err := s.escrowRepo.GetByID(invoice.LedgerEntry.EscrowID)
+	if err != nil {
+		return nil, err
+	}
+	titles, err := s.projectRepo.GetTitles([]uuid.UUID{invoice.LedgerEntry.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	data := invoiceData{
+		Invoice:      invoice,
+		Escrow:       escrow,
+		ProjectTitle: titles[invoice.LedgerEntry.ProjectID],
+		Payment:      s.ledgerService.line(invoice.LedgerEntry),
+		Client:       s.party(&invoice.LedgerEntry.ClientID, invoice.LedgerEntry.ClientAddr),
+		Freelancer:   s.party(invoice.LedgerEntry.FreelancerID, invoice.LedgerEntry.FreelancerAddr),
+		ChainID:      s.cfg.GetChainID(),
+	}
+	return renderInvoice(data), nil
+}
+
+// party looks up an invoice party, falling back to the wallet address if
+// the user is gone.
+func (s *AccountingService) party(userID *uuid.UUID, address string) invoiceParty {
+	party := invoiceParty{Address: address}
+	if userID == nil {
+		return party
+	}
+
+	user, err := s.userRepo.GetByID(*userID)
+	if err != nil {
+		s.logger.Warnf("Failed to load invoice party %s: %v", *userID, err)
+		return party
+	}
+
+	party.Name = user.FullName
+	if party.Name == "" || !pdf.Encodable(party.Name) {
+		party.Name = user.Username
+	}
+	party.Company = user.CompanyName
+	party.Location = user.Location
+	if user.Email != nil {
+		party.Email = *user.Email
+	}
+	return party
+}
+
+func invoiceRole(entry *models.LedgerEntry, userID uuid.UUID) string {
+	switch {
+	case entry.FreelancerID != nil && *entry.FreelancerID == userID:
+		return RoleFreelancer
+	case entry.ClientID == userID:
+		return RoleClient
+	}
+	return ""
+}
@@ -1,26 +1,87 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+const (
+	// rollupLookbackDays is how many past days each rollup recomputes, to
+	// pick up events the indexer recorded late.
+	rollupLookbackDays = 7
+
+	// maxMetricsRangeDays caps the span of a metrics query.
+	maxMetricsRangeDays = 3 * 366
+
+	platformStatsKey = "analytics:platform"
+	platformStatsTTL = 5 * time.Minute
+
+	// Cached metric queries are keyed by a generation the rollup bumps, so
+	// they never outlive the data they were computed from.
+	metricsGenerationKey = "analytics:metrics:generation"
+	metricsCacheTTL      = time.Hour
+)
+
+// Metric granularities.
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// metricInfo describes how a metric is aggregated. Flow metrics are summed
+// over a period; gauges take their value on the period's last day. Token
+// metrics are amounts split by token address.
+type metricInfo struct {
+	gauge bool
+	token bool
+}
+
+var platformMetrics = map[string]metricInfo{
+	models.MetricNewUsers:          {},
+	models.MetricProjectsPosted:    {},
+	models.MetricProjectsStarted:   {},
+	models.MetricProjectsCompleted: {},
+	models.MetricGMV:               {token: true},
+	models.MetricPlatformFees:      {token: true},
+	models.MetricTVL:               {gauge: true, token: true},
+	models.MetricDisputesOpened:    {},
+	models.MetricDisputesResolved:  {},
+}
+
 type AnalyticsService struct {
-	db          *gorm.DB
-	redisClient *redis.Client
-	logger      *logrus.Logger
+	cfg           *config.Config
+	db            *gorm.DB
+	metricRepo    *repositories.MetricRepository
+	ledgerService *LedgerService
+	tokens        *TokenRegistry
+	redisClient   *redis.Client
+	logger        *logrus.Logger
 }
 
 type PlatformStats struct {
-	TotalProjects     int64   `json:"total_projects"`
-	ActiveProjects    int64   `json:"active_projects"`
-	CompletedProjects int64   `json:"completed_projects"`
-	TotalUsers        int64   `json:"total_users"`
-	TotalFreelancers  int64   `json:"total_freelancers"`
-	TotalClients      int64   `json:"total_clients"`
-	TotalValueLocked  float64 `json:"total_value_locked"`
-	DisputeRate       float64 `json:"dispute_rate"`
+	TotalProjects     int64         `json:"total_projects"`
+	ActiveProjects    int64         `json:"active_projects"`
+	CompletedProjects int64         `json:"completed_projects"`
+	TotalUsers        int64         `json:"total_users"`
+	TotalFreelancers  int64         `json:"total_freelancers"`
+	TotalClients      int64         `json:"total_clients"`
+	TotalValueLocked  float64       `json:"total_value_locked"` // Known stablecoins at par
+	ValueLocked       []TokenAmount `json:"value_locked"`
+	DisputeRate       float64       `json:"dispute_rate"`
 }
 
 type UserStats struct {
@@ -29,41 +90,313 @@ type UserStats struct {
 	ActiveProjects    int     `json:"active_projects"`
 	Rating            float64 `json:"rating"`
 	ReviewCount       int     `json:"review_count"`
-	TotalEarned       float64 `json:"total_earned"`
+	TotalEarned       float64 `json:"total_earned"` // Known stablecoins at par
 	SuccessRate       float64 `json:"success_rate"`
 }
 
-func NewAnalyticsService(db *gorm.DB, redisClient *redis.Client, logger *logrus.Logger) *AnalyticsService {
+// MetricsQuery selects metrics between two UTC days, inclusive.
+type MetricsQuery struct {
+	Metrics     []string
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+// MetricPoint is a metric's value over one period.
+type MetricPoint struct {
+	Period    string `json:"period"`              // First day of the period
+	Dimension string `json:"dimension,omitempty"` // User role or token address
+	Value     string `json:"value"`               // Count, or amount in base units
+	Symbol    string `json:"symbol,omitempty"`    // Token metrics
+	Units     string `json:"units,omitempty"`     // Token metrics: amount in whole tokens
+}
+
+type MetricSeries struct {
+	Metric string        `json:"metric"`
+	Points []MetricPoint `json:"points"`
+}
+
+type MetricsResult struct {
+	From        string         `json:"from"`
+	To          string         `json:"to"`
+	Granularity string         `json:"granularity"`
+	Series      []MetricSeries `json:"series"`
+}
+
+func NewAnalyticsService(cfg *config.Config, db *gorm.DB, metricRepo *repositories.MetricRepository, ledgerService *LedgerService, tokens *TokenRegistry, redisClient *redis.Client, logger *logrus.Logger) *AnalyticsService {
 	return &AnalyticsService{
-		db:          db,
-		redisClient: redisClient,
-		logger:      logger,
+		cfg:           cfg,
+		db:            db,
+		metricRepo:    metricRepo,
+		ledgerService: ledgerService,
+		tokens:        tokens,
+		redisClient:   redisClient,
+		logger:        logger,
 	}
 }
 
+// GetPlatformStats returns all-time platform totals, cached for a few
+// minutes.
 func (s *AnalyticsService) GetPlatformStats() (*PlatformStats, error) {
+	ctx := context.Background()
+
 	var stats PlatformStats
+	if s.getCached(ctx, platformStatsKey, &stats) {
+		return &stats, nil
+	}
 
-	// Total projects
-	s.db.Table("projects").Count(&stats.TotalProjects)
+	totals, err := s.metricRepo.PlatformTotals()
+	if err != nil {
+		return nil, err
+	}
+	locked, err := s.metricRepo.ValueLocked()
+	if err != nil {
+		return nil, err
+	}
 
-	// Active projects
-	s.db.Table("projects").Where("status = ?", "in_progress").Count(&stats.ActiveProjects)
+	amounts := make(map[string]*big.Int, len(locked))
+	for token, value := range locked {
+		amount, ok := parseAmount(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid value locked %q for token %s", value, token)
+		}
+		amounts[token] = amount
+	}
 
-	// Completed projects
-	s.db.Table("projects").Where("status = ?", "completed").Count(&stats.CompletedProjects)
+	stats = PlatformStats{
+		TotalProjects:     totals.TotalProjects,
+		ActiveProjects:    totals.ActiveProjects,
+		CompletedProjects: totals.CompletedProjects,
+		TotalUsers:        totals.TotalUsers,
+		TotalFreelancers:  totals.TotalFreelancers,
+		TotalClients:      totals.TotalClients,
+		TotalValueLocked:  s.tokens.USDValue(amounts),
+		ValueLocked:       s.tokens.Amounts(amounts),
+	}
+	if totals.CompletedProjects > 0 {
+		stats.DisputeRate = float64(totals.TotalDisputes) / float64(totals.CompletedProjects) * 100
+	}
 
-	// Total users
-	s.db.Table("users").Count(&stats.TotalUsers)
+	s.setCached(ctx, platformStatsKey, &stats, platformStatsTTL)
+	return &stats, nil
+}
+
+// RollupMetrics is the JobRollupMetrics handler. It recomputes the daily
+// metrics of the last few days and today, or of every day since the first
+// signup if nothing was rolled up yet or the last rollup is older.
+func (s *AnalyticsService) RollupMetrics(ctx context.Context, job *models.Job) error {
+	today := truncateDay(time.Now())
+	start := today.AddDate(0, 0, -rollupLookbackDays)
 
-	// Calculate dispute rate
-	var totalDisputes int64
-	s.db.Table("disputes").Count(&totalDisputes)
-	if stats.CompletedProjects > 0 {
-		stats.DisputeRate = float64(totalDisputes) / float64(stats.CompletedProjects) * 100
+	last, err := s.metricRepo.LastDay()
+	if err != nil {
+		return err
+	}
+	if last == nil {
+		first, err := s.metricRepo.FirstActivity()
+		if err != nil {
+			return err
+		}
+		if first != nil {
+			start = truncateDay(*first)
+		}
+	} else if day := truncateDay(*last); day.Before(start) {
+		start = day
 	}
 
-	return &stats, nil
+	days := 0
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		metrics, err := s.metricRepo.ComputeDay(day)
+		if err != nil {
+			return err
+		}
+		if err := s.metricRepo.SaveDay(day, metrics); err != nil {
+			return err
+		}
+		days++
+	}
+
+	if err := s.redisClient.Incr(ctx, metricsGenerationKey).Err(); err != nil {
+		s.logger.Warnf("Failed to invalidate cached metrics: %v", err)
+	}
+	s.logger.Infof("Rolled up metrics for %d days", days)
+	return nil
+}
+
+// GetMetrics returns the daily rollups of the metrics, aggregated by the
+// query's granularity. Results are cached until the next rollup.
+func (s *AnalyticsService) GetMetrics(query MetricsQuery) (*MetricsResult, error) {
+	if len(query.Metrics) == 0 {
+		for metric := range platformMetrics {
+			query.Metrics = append(query.Metrics, metric)
+		}
+	}
+	sort.Strings(query.Metrics)
+	for _, metric := range query.Metrics {
+		if _, ok := platformMetrics[metric]; !ok {
+			return nil, fmt.Errorf("%w: unknown metric %q", ErrInvalidInput, metric)
+		}
+	}
+
+	switch query.Granularity {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+	default:
+		return nil, fmt.Errorf("%w: granularity must be day, week or month", ErrInvalidInput)
+	}
+	query.From, query.To = truncateDay(query.From), truncateDay(query.To)
+	if query.To.Before(query.From) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidInput)
+	}
+	if query.To.Sub(query.From) > maxMetricsRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: ranges are limited to %d days", ErrInvalidInput, maxMetricsRangeDays)
+	}
+
+	ctx := context.Background()
+	generation, err := s.redisClient.Get(ctx, metricsGenerationKey).Result()
+	if err != nil && err != redis.Nil {
+		s.logger.Warnf("Failed to read the metrics cache generation: %v", err)
+	}
+	cacheKey := fmt.Sprintf("analytics:metrics:%s:%s:%s:%s:%s", generation,
+		strings.Join(query.Metrics, ","), query.Granularity, query.From.Format(dayLayout), query.To.Format(dayLayout))
+
+	var result MetricsResult
+	if s.getCached(ctx, cacheKey, &result) {
+		return &result, nil
+	}
+
+	rows, err := s.metricRepo.Range(query.Metrics, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	last, err := s.metricRepo.LastDay()
+	if err != nil {
+		return nil, err
+	}
+
+	result = MetricsResult{
+		From:        query.From.Format(dayLayout),
+		To:          query.To.Format(dayLayout),
+		Granularity: query.Granularity,
+	}
+	for _, metric := range query.Metrics {
+		points, err := s.aggregate(metric, rows, query, last)
+		if err != nil {
+			return nil, err
+		}
+		result.Series = append(result.Series, MetricSeries{Metric: metric, Points: points})
+	}
+
+	s.setCached(ctx, cacheKey, &result, metricsCacheTTL)
+	return &result, nil
+}
+
+// aggregate buckets a metric's daily rows into periods. Gauges are read on
+// one day of each period, where a missing dimension means zero.
+func (s *AnalyticsService) aggregate(metric string, rows []models.DailyMetric, query MetricsQuery, lastRolledUp *time.Time) ([]MetricPoint, error) {
+	info := platformMetrics[metric]
+
+	type key struct {
+		period    time.Time
+		dimension string
+	}
+	sums := map[key]*big.Int{}
+
+	for _, row := range rows {
+		if row.Metric != metric {
+			continue
+		}
+		day := truncateDay(row.Day)
+		period := periodStart(day, query.Granularity)
+		if info.gauge && !day.Equal(gaugeDay(period, query, lastRolledUp)) {
+			continue
+		}
+
+		value, ok := parseAmount(row.Value)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value %q on %s", metric, row.Value, day.Format(dayLayout))
+		}
+		k := key{period, row.Dimension}
+		if sums[k] == nil {
+			sums[k] = new(big.Int)
+		}
+		sums[k].Add(sums[k], value)
+	}
+
+	points := make([]MetricPoint, 0, len(sums))
+	for k, value := range sums {
+		point := MetricPoint{Period: k.period.Format(dayLayout), Dimension: k.dimension, Value: value.String()}
+		if info.token {
+			amount := s.tokens.Amount(k.dimension, value)
+			point.Dimension, point.Symbol, point.Units = amount.Token, amount.Symbol, amount.Value
+		}
+		points = append(points, point)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Period != points[j].Period {
+			return points[i].Period < points[j].Period
+		}
+		return points[i].Dimension < points[j].Dimension
+	})
+	return points, nil
+}
+
+func (s *AnalyticsService) getCached(ctx context.Context, key string, v interface{}) bool {
+	data, err := s.redisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			s.logger.Warnf("Failed to read %s from cache: %v", key, err)
+		}
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+func (s *AnalyticsService) setCached(ctx context.Context, key string, v interface{}, ttl time.Duration) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := s.redisClient.Set(ctx, key, data, ttl).Err(); err != nil {
+		s.logger.Warnf("Failed to cache %s: %v", key, err)
+	}
+}
+
+const dayLayout = "2006-01-02"
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// gaugeDay is the day a gauge is read for a period: its last day, bounded by
+// the end of the query and the last day rolled up.
+func gaugeDay(period time.Time, query MetricsQuery, lastRolledUp *time.Time) time.Time {
+	day := periodEnd(period, query.Granularity).AddDate(0, 0, -1)
+	if day.After(query.To) {
+		day = query.To
+	}
+	if lastRolledUp != nil && day.After(truncateDay(*lastRolledUp)) {
+		day = truncateDay(*lastRolledUp)
+	}
+	return day
+}
+
+// periodStart returns the first day of the period containing day: the day
+// itself, its ISO week's Monday or the first of its month.
+func periodStart(day time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case GranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
 }
 
 func (s *AnalyticsService) GetUserStats(userAddress string) (*UserStats, error) {
@@ -71,6 +404,7 @@ func (s *AnalyticsService) GetUserStats(userAddress string) (*UserStats, error)
 
 	// Get user stats from database
 	var user struct {
+		ID                uuid.UUID
 		TotalProjects     int     `json:"total_projects"`
 		CompletedProjects int     `json:"completed_projects"`
 		Rating            float64 `json:"rating"`
@@ -78,7 +412,7 @@ func (s *AnalyticsService) GetUserStats(userAddress string) (*UserStats, error)
 	}
 
 	s.db.Table("users").
-		Select("total_projects, completed_projects, rating, review_count").
+		Select("id, total_projects, completed_projects, rating, review_count").
 		Where("address = ?", userAddress).
 		Scan(&user)
 
@@ -87,6 +421,14 @@ func (s *AnalyticsService) GetUserStats(userAddress string) (*UserStats, error)
 	stats.Rating = user.Rating
 	stats.ReviewCount = user.ReviewCount
 
+	if user.ID != uuid.Nil {
+		earned, err := s.ledgerService.EarnedValue(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		stats.TotalEarned = earned
+	}
+
 	// Calculate active projects
 	var activeCount int64
 	s.db.Table("projects").
@@ -102,3 +444,15 @@ func (s *AnalyticsService) GetUserStats(userAddress string) (*UserStats, error)
 
 	return &stats, nil
 }
+
+// periodEnd returns the first day after the period starting at start.
+func periodEnd(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
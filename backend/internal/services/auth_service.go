@@ -14,6 +14,7 @@ import (
 	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/repositories"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,8 +25,9 @@ type AuthService struct {
 }
 
 type Claims struct {
-	UserID  string `json:"user_id"`
-	Address string `json:"address"`
+	UserID         string `json:"user_id"`
+	Address        string `json:"address"`
+	SessionVersion int    `json:"sv"` // Must match the user's, which a password reset bumps
 	jwt.RegisteredClaims
 }
 
@@ -144,8 +146,9 @@ func (s *AuthService) GenerateToken(user *models.User) (string, error) {
 	expirationTime := time.Now().Add(time.Duration(s.cfg.JWTExpirationHours) * time.Hour)
 
 	claims := &Claims{
-		UserID:  user.ID.String(),
-		Address: user.Address,
+		UserID:         user.ID.String(),
+		Address:        user.Address,
+		SessionVersion: user.SessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -175,9 +178,30 @@ func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
+	// Tokens issued before the user's sessions were revoked are rejected.
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || user.SessionVersion != claims.SessionVersion {
+		return nil, fmt.Errorf("token revoked")
+	}
+
 	return claims, nil
 }
 
+// RevokeSessions invalidates every token issued to the user so far.
+func (s *AuthService) RevokeSessions(userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	user.SessionVersion++
+	return s.userRepo.Update(user)
+}
+
 func (s *AuthService) Login(address, signature, nonce string) (string, *models.User, error) {
 	// Verify signature
 	if err := s.VerifySignature(address, signature, nonce); err != nil {
@@ -2,145 +2,272 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
+	"errors"
+
 	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/metrics"
 	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// chainCursor names the indexer's row in indexer_cursors.
+const chainCursor = "chain"
+
+var (
+	indexerHeadBlock = metrics.NewGauge("fariima_indexer_head_block", "Latest block number reported by the RPC node.")
+	indexerLastBlock = metrics.NewGauge("fariima_indexer_last_indexed_block", "Last block number the indexer processed.")
+	indexerLagBlocks = metrics.NewGauge("fariima_indexer_lag_blocks", "Blocks between the RPC node's head and the last indexed block.")
+	indexerLogs      = metrics.NewCounterVec(
+		"fariima_indexer_logs_processed_total",
+		"Contract logs processed by the indexer, by event and outcome.",
+		"event", "status",
+	)
 )
 
+// indexedContracts names the ABIs of the contracts the indexer watches.
+var indexedContracts = []struct {
+	name string
+	abi  *abi.ABI
+}{
+	{"escrow", &escrowABI},
+	{"dao", &daoABI},
+	{"nft", &nftABI},
+	{"fari_token", &fariTokenABI},
+}
+
 type BlockchainIndexer struct {
-	cfg              *config.Config
-	blockchainService *BlockchainService
-	escrowRepo       *repositories.EscrowRepository
-	disputeRepo      *repositories.DisputeRepository
-	nftRepo          *repositories.NFTRepository
-	logger           *logrus.Logger
-	lastIndexedBlock uint64
+	cfg                 *config.Config
+	blockchainService   *BlockchainService
+	indexerRepo         *repositories.IndexerRepository
+	escrowRepo          *repositories.EscrowRepository
+	disputeRepo         *repositories.DisputeRepository
+	projectRepo         *repositories.ProjectRepository
+	disputeService      *DisputeService
+	jurorService        *JurorService
+	feedService         *DisputeFeedService
+	governanceService   *GovernanceService
+	nftService          *NFTService
+	stakingService      *StakingService
+	ledgerService       *LedgerService
+	notificationService *NotificationService
+	logger              *logrus.Logger
 }
 
 func NewBlockchainIndexer(
 	cfg *config.Config,
 	blockchainService *BlockchainService,
+	indexerRepo *repositories.IndexerRepository,
 	escrowRepo *repositories.EscrowRepository,
 	disputeRepo *repositories.DisputeRepository,
-	nftRepo *repositories.NFTRepository,
+	projectRepo *repositories.ProjectRepository,
+	disputeService *DisputeService,
+	jurorService *JurorService,
+	feedService *DisputeFeedService,
+	governanceService *GovernanceService,
+	nftService *NFTService,
+	stakingService *StakingService,
+	ledgerService *LedgerService,
+	notificationService *NotificationService,
 	logger *logrus.Logger,
 ) *BlockchainIndexer {
 	return &BlockchainIndexer{
-		cfg:              cfg,
-		blockchainService: blockchainService,
-		escrowRepo:       escrowRepo,
-		disputeRepo:      disputeRepo,
-		nftRepo:          nftRepo,
-		logger:           logger,
-		lastIndexedBlock: uint64(cfg.IndexerStartBlock),
+		cfg:                 cfg,
+		blockchainService:   blockchainService,
+		indexerRepo:         indexerRepo,
+		escrowRepo:          escrowRepo,
+		disputeRepo:         disputeRepo,
+		projectRepo:         projectRepo,
+		disputeService:      disputeService,
+		jurorService:        jurorService,
+		feedService:         feedService,
+		governanceService:   governanceService,
+		nftService:          nftService,
+		stakingService:      stakingService,
+		ledgerService:       ledgerService,
+		notificationService: notificationService,
+		logger:              logger,
 	}
 }
 
-func (i *BlockchainIndexer) Start(ctx context.Context) {
-	i.logger.Info("Starting blockchain indexer...")
-
-	ticker := time.NewTicker(time.Duration(i.cfg.IndexerIntervalSeconds) * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			i.logger.Info("Stopping blockchain indexer...")
-			return
-		case <-ticker.C:
-			if err := i.indexBlocks(); err != nil {
-				i.logger.Errorf("Error indexing blocks: %v", err)
-			}
-		}
+// Index indexes the next batch of blocks. It runs as the JobIndexChain job
+// every INDEXER_INTERVAL_SECONDS; its schedule keeps runs from overlapping.
+//
+// The last indexed block is stored in indexer_cursors after the batch, or,
+// when ctx is cancelled part way, after the last block whose logs were all
+// processed.
+func (i *BlockchainIndexer) Index(ctx context.Context, job *models.Job) error {
+	lastIndexed, err := i.lastIndexedBlock()
+	if err != nil {
+		return err
 	}
-}
 
-func (i *BlockchainIndexer) indexBlocks() error {
-	latestBlock, err := i.blockchainService.GetLatestBlockNumber()
+	latestBlock, err := i.blockchainService.GetLatestBlockNumber(ctx)
 	if err != nil {
 		return err
 	}
 
-	if i.lastIndexedBlock >= latestBlock {
+	i.recordProgress(lastIndexed, latestBlock)
+
+	if lastIndexed >= latestBlock {
 		return nil
 	}
 
 	// Index in batches
-	toBlock := i.lastIndexedBlock + uint64(i.cfg.IndexerBatchSize)
+	toBlock := lastIndexed + uint64(i.cfg.IndexerBatchSize)
 	if toBlock > latestBlock {
 		toBlock = latestBlock
 	}
 
-	i.logger.Infof("Indexing blocks from %d to %d", i.lastIndexedBlock+1, toBlock)
+	i.logger.Infof("Indexing blocks from %d to %d", lastIndexed+1, toBlock)
 
 	// Get contract addresses
 	escrowAddr, _ := i.blockchainService.GetContractAddress("escrow")
 	daoAddr, _ := i.blockchainService.GetContractAddress("dao")
 	nftAddr, _ := i.blockchainService.GetContractAddress("nft")
+	fariAddr, _ := i.blockchainService.GetContractAddress("fari_token")
 
-	addresses := []common.Address{escrowAddr, daoAddr, nftAddr}
+	addresses := []common.Address{escrowAddr, daoAddr, nftAddr, fariAddr}
 
 	// Get logs
-	logs, err := i.blockchainService.GetLogs(i.lastIndexedBlock+1, toBlock, addresses, nil)
+	logs, err := i.blockchainService.GetLogs(ctx, lastIndexed+1, toBlock, addresses, nil)
 	if err != nil {
 		return err
 	}
 
 	// Process logs
+	var block uint64
 	for _, log := range logs {
+		// Stop between blocks, so a block is never left half indexed.
+		if log.BlockNumber != block && ctx.Err() != nil {
+			if err := i.saveLastIndexedBlock(log.BlockNumber - 1); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+		block = log.BlockNumber
+
 		if err := i.processLog(log); err != nil {
 			i.logger.Errorf("Error processing log: %v", err)
+			indexerLogs.WithLabelValues(logEventName(log), "error").Inc()
 			continue
 		}
+		indexerLogs.WithLabelValues(logEventName(log), "ok").Inc()
 	}
 
-	i.lastIndexedBlock = toBlock
+	if err := i.saveLastIndexedBlock(toBlock); err != nil {
+		return err
+	}
+	i.recordProgress(toBlock, latestBlock)
 	return nil
 }
 
-func (i *BlockchainIndexer) processLog(log common.Log) error {
-	// Event signatures
-	projectCreatedSig := crypto.Keccak256Hash([]byte("ProjectCreated(uint256,address,address,uint256)"))
-	depositedSig := crypto.Keccak256Hash([]byte("Deposited(uint256,address,uint256)"))
-	releasedSig := crypto.Keccak256Hash([]byte("Released(uint256,address,address,uint256,uint256)"))
-	disputeInitiatedSig := crypto.Keccak256Hash([]byte("DisputeInitiated(uint256,uint256,address)"))
-	disputeResolvedSig := crypto.Keccak256Hash([]byte("DisputeResolved(uint256,uint256,uint256)"))
-	nftMintedSig := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+// lastIndexedBlock returns the stored cursor, or INDEXER_START_BLOCK before
+// the first run.
+func (i *BlockchainIndexer) lastIndexedBlock() (uint64, error) {
+	cursor, err := i.indexerRepo.GetCursor(chainCursor)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return uint64(i.cfg.IndexerStartBlock), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return cursor.Block, nil
+}
+
+func (i *BlockchainIndexer) saveLastIndexedBlock(block uint64) error {
+	return i.indexerRepo.SaveCursor(&models.IndexerCursor{Name: chainCursor, Block: block})
+}
+
+// recordProgress exports the indexer's position behind the chain head.
+func (i *BlockchainIndexer) recordProgress(lastIndexed, head uint64) {
+	indexerHeadBlock.Set(float64(head))
+	indexerLastBlock.Set(float64(lastIndexed))
+	if head > lastIndexed {
+		indexerLagBlocks.Set(float64(head - lastIndexed))
+	} else {
+		indexerLagBlocks.Set(0)
+	}
+}
+
+// logEventName names a log's event as contract.Event, e.g. escrow.ProjectFunded.
+func logEventName(log types.Log) string {
+	if len(log.Topics) == 0 {
+		return "unknown"
+	}
+	for _, contract := range indexedContracts {
+		if event, err := contract.abi.EventByID(log.Topics[0]); err == nil {
+			return contract.name + "." + event.Name
+		}
+	}
+	return "unknown"
+}
 
-	eventSig := log.Topics[0]
+func (i *BlockchainIndexer) processLog(log types.Log) error {
+	if len(log.Topics) == 0 {
+		return nil
+	}
 
-	switch {
-	case eventSig == projectCreatedSig:
+	switch log.Topics[0] {
+	case escrowABI.Events["ProjectCreated"].ID:
 		return i.handleProjectCreated(log)
-	case eventSig == depositedSig:
-		return i.handleDeposited(log)
-	case eventSig == releasedSig:
-		return i.handleReleased(log)
-	case eventSig == disputeInitiatedSig:
+	case escrowABI.Events["ProjectFunded"].ID:
+		return i.handleProjectFunded(log)
+	case escrowABI.Events["ProjectCompleted"].ID:
+		return i.handleProjectCompleted(log)
+	case escrowABI.Events["ProjectCancelled"].ID:
+		return i.handleProjectCancelled(log)
+	case escrowABI.Events["MilestoneReleased"].ID:
+		return i.handleMilestoneReleased(log)
+	case escrowABI.Events["DisputeInitiated"].ID:
 		return i.handleDisputeInitiated(log)
-	case eventSig == disputeResolvedSig:
+	case escrowABI.Events["DisputeResolved"].ID:
 		return i.handleDisputeResolved(log)
-	case eventSig == nftMintedSig:
-		return i.handleNFTMinted(log)
+	case daoABI.Events["DisputeCreated"].ID:
+		return i.handleDaoDisputeCreated(log)
+	case daoABI.Events["DisputeVoted"].ID:
+		return i.disputeService.RecordChainVote(log)
+	case daoABI.Events["DisputeFinalized"].ID:
+		return i.handleDaoDisputeFinalized(log)
+	case daoABI.Events["JurorRewarded"].ID:
+		return i.jurorService.RecordReward(log)
+	case daoABI.Events["ProposalCreated"].ID:
+		return i.governanceService.RecordProposalCreated(log)
+	case daoABI.Events["ProposalVoted"].ID:
+		return i.governanceService.RecordProposalVote(log)
+	case daoABI.Events["ProposalExecuted"].ID:
+		return i.governanceService.RecordProposalExecuted(log)
+	case nftABI.Events["NFTMinted"].ID:
+		return i.nftService.RecordMint(log)
+	case fariTokenABI.Events["Staked"].ID,
+		fariTokenABI.Events["UnstakeRequested"].ID,
+		fariTokenABI.Events["Unstaked"].ID,
+		fariTokenABI.Events["VestingScheduleCreated"].ID,
+		fariTokenABI.Events["TokensReleased"].ID:
+		return i.stakingService.RecordEvent(log)
 	}
 
 	return nil
 }
 
-func (i *BlockchainIndexer) handleProjectCreated(log common.Log) error {
-	// ProjectCreated(uint256 indexed projectId, address indexed client, address freelancer, uint256 amount)
-	if len(log.Topics) < 3 {
+func (i *BlockchainIndexer) handleProjectCreated(log types.Log) error {
+	// ProjectCreated(uint256 indexed projectId, address indexed client, address indexed freelancer, uint256 amount, address token)
+	if len(log.Topics) < 4 {
 		return nil
 	}
 
 	projectID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
 	clientAddr := common.BytesToAddress(log.Topics[2].Bytes())
+	freelancerAddr := common.BytesToAddress(log.Topics[3].Bytes())
 
 	i.logger.Infof("Project created: ID=%d, Client=%s", projectID, clientAddr.Hex())
 
@@ -150,43 +277,98 @@ func (i *BlockchainIndexer) handleProjectCreated(log common.Log) error {
 		return nil // Already indexed
 	}
 
-	// Create escrow record
+	var event struct {
+		Amount *big.Int
+		Token  common.Address
+	}
+	if err := escrowABI.UnpackIntoInterface(&event, "ProjectCreated", log.Data); err != nil {
+		return err
+	}
+
+	project, err := i.projectRepo.FindUnlinked(log.TxHash.Hex(), clientAddr.Hex(), freelancerAddr.Hex())
+	if err != nil {
+		i.logger.Warnf("Project created: no off-chain project matches on-chain project %d", projectID)
+		return nil
+	}
+
+	project.OnChainID = &projectID
+	if project.TxHash == "" {
+		project.TxHash = log.TxHash.Hex()
+	}
+	if err := i.projectRepo.Update(project); err != nil {
+		return err
+	}
+
+	fee := platformFee(event.Amount)
+
 	escrow := &models.Escrow{
+		ProjectID:      project.ID,
 		OnChainID:      projectID,
 		ClientAddr:     clientAddr.Hex(),
+		FreelancerAddr: freelancerAddr.Hex(),
+		Amount:         event.Amount.String(),
+		Token:          event.Token.Hex(),
+		PlatformFee:    fee.String(),
 		Status:         models.EscrowStatusCreated,
 		CreatedAtBlock: log.BlockNumber,
 	}
 
-	return i.escrowRepo.Create(escrow)
+	if err := i.escrowRepo.Create(escrow); err != nil {
+		return err
+	}
+
+	return i.recordEscrowEvent(escrow, "created", log, map[string]interface{}{
+		"amount": event.Amount.String(),
+		"token":  event.Token.Hex(),
+	})
 }
 
-func (i *BlockchainIndexer) handleDeposited(log common.Log) error {
-	// Deposited(uint256 indexed projectId, address indexed token, uint256 amount)
-	if len(log.Topics) < 3 {
+func (i *BlockchainIndexer) handleProjectFunded(log types.Log) error {
+	// ProjectFunded(uint256 indexed projectId, uint256 amount)
+	if len(log.Topics) < 2 {
 		return nil
 	}
 
 	projectID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
-	tokenAddr := common.BytesToAddress(log.Topics[2].Bytes())
 
-	i.logger.Infof("Deposit made: ProjectID=%d, Token=%s", projectID, tokenAddr.Hex())
+	i.logger.Infof("Project funded: ProjectID=%d", projectID)
 
 	escrow, err := i.escrowRepo.GetByOnChainID(projectID)
 	if err != nil {
 		return err
 	}
+	if escrow.Status != models.EscrowStatusCreated {
+		return nil // Already indexed
+	}
 
 	escrow.Status = models.EscrowStatusFunded
-	escrow.Token = tokenAddr.Hex()
 	escrow.FundedAtBlock = log.BlockNumber
 	escrow.DepositTxHash = log.TxHash.Hex()
 
-	return i.escrowRepo.Update(escrow)
+	if err := i.escrowRepo.Update(escrow); err != nil {
+		return err
+	}
+
+	if err := i.recordEscrowEvent(escrow, "deposit", log, nil); err != nil {
+		return err
+	}
+
+	i.notifyParties(escrow.ProjectID, NotificationEvent{
+		Type:  models.NotificationEscrowFunded,
+		Title: "Escrow funded",
+		Body:  "The project escrow has been funded and work can begin.",
+		Data: map[string]interface{}{
+			"project_id": escrow.ProjectID,
+			"escrow_id":  escrow.ID,
+			"amount":     escrow.Amount,
+		},
+	})
+
+	return nil
 }
 
-func (i *BlockchainIndexer) handleReleased(log common.Log) error {
-	// Released(uint256 indexed projectId, address indexed freelancer, address client, uint256 amount, uint256 fee)
+func (i *BlockchainIndexer) handleProjectCompleted(log types.Log) error {
+	// ProjectCompleted(uint256 indexed projectId, uint256 freelancerPayment, uint256 platformFee, uint256 nftTokenId)
 	if len(log.Topics) < 2 {
 		return nil
 	}
@@ -199,89 +381,446 @@ func (i *BlockchainIndexer) handleReleased(log common.Log) error {
 	if err != nil {
 		return err
 	}
+	if escrow.Status == models.EscrowStatusReleased {
+		return nil // Already indexed
+	}
+
+	var event struct {
+		FreelancerPayment *big.Int
+		PlatformFee       *big.Int
+		NftTokenId        *big.Int
+	}
+	if err := escrowABI.UnpackIntoInterface(&event, "ProjectCompleted", log.Data); err != nil {
+		return err
+	}
 
 	escrow.Status = models.EscrowStatusReleased
+	escrow.PlatformFee = event.PlatformFee.String()
+	escrow.ReleasedAtBlock = log.BlockNumber
+	escrow.ReleaseTxHash = log.TxHash.Hex()
+
+	if err := i.escrowRepo.Update(escrow); err != nil {
+		return err
+	}
+
+	if err := i.recordEscrowEvent(escrow, "release", log, map[string]interface{}{
+		"freelancer_payment": event.FreelancerPayment.String(),
+		"platform_fee":       event.PlatformFee.String(),
+		"nft_token_id":       event.NftTokenId.String(),
+	}); err != nil {
+		return err
+	}
+
+	i.notifyParties(escrow.ProjectID, NotificationEvent{
+		Type:  models.NotificationEscrowReleased,
+		Title: "Escrow released",
+		Body:  "The project was completed and the escrow payment has been released.",
+		Data: map[string]interface{}{
+			"project_id": escrow.ProjectID,
+			"escrow_id":  escrow.ID,
+			"amount":     event.FreelancerPayment.String(),
+		},
+	})
+
+	return nil
+}
+
+// handleProjectCancelled settles an escrow the client cancelled, either
+// before funding or, once funded, with the deposit refunded to them.
+func (i *BlockchainIndexer) handleProjectCancelled(log types.Log) error {
+	// ProjectCancelled(uint256 indexed projectId, uint256 refundAmount)
+	if len(log.Topics) < 2 {
+		return nil
+	}
+
+	projectID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
+
+	i.logger.Infof("Project cancelled: ProjectID=%d", projectID)
+
+	escrow, err := i.escrowRepo.GetByOnChainID(projectID)
+	if err != nil {
+		return err
+	}
+	if escrow.Status == models.EscrowStatusRefunded || escrow.Status == models.EscrowStatusCancelled {
+		return nil // Already indexed
+	}
+
+	var event struct {
+		RefundAmount *big.Int
+	}
+	if err := escrowABI.UnpackIntoInterface(&event, "ProjectCancelled", log.Data); err != nil {
+		return err
+	}
+
+	escrow.Status = models.EscrowStatusCancelled
+	if event.RefundAmount.Sign() > 0 {
+		escrow.Status = models.EscrowStatusRefunded
+	}
 	escrow.ReleasedAtBlock = log.BlockNumber
 	escrow.ReleaseTxHash = log.TxHash.Hex()
+	if err := i.escrowRepo.Update(escrow); err != nil {
+		return err
+	}
 
-	return i.escrowRepo.Update(escrow)
+	project, err := i.projectRepo.GetByID(escrow.ProjectID)
+	if err != nil {
+		return err
+	}
+	if project.Status != models.ProjectStatusCancelled {
+		project.Status = models.ProjectStatusCancelled
+		if err := i.projectRepo.Update(project); err != nil {
+			return err
+		}
+	}
+
+	return i.recordEscrowEvent(escrow, "cancelled", log, map[string]interface{}{
+		"refund_amount": event.RefundAmount.String(),
+	})
 }
 
-func (i *BlockchainIndexer) handleDisputeInitiated(log common.Log) error {
-	// DisputeInitiated(uint256 indexed disputeId, uint256 indexed projectId, address initiator)
+func (i *BlockchainIndexer) handleMilestoneReleased(log types.Log) error {
+	// MilestoneReleased(uint256 indexed projectId, uint256 milestoneIndex, uint256 amount)
+	if len(log.Topics) < 2 {
+		return nil
+	}
+
+	projectID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
+
+	// Re-indexed blocks must not record the payout or notify twice.
+	recorded, err := i.escrowRepo.HasEvent(log.TxHash.Hex(), log.Index)
+	if err != nil || recorded {
+		return err
+	}
+
+	escrow, err := i.escrowRepo.GetByOnChainID(projectID)
+	if err != nil {
+		return err
+	}
+
+	var event struct {
+		MilestoneIndex *big.Int
+		Amount         *big.Int
+	}
+	if err := escrowABI.UnpackIntoInterface(&event, "MilestoneReleased", log.Data); err != nil {
+		return err
+	}
+
+	i.logger.Infof("Milestone released: ProjectID=%d, Milestone=%s", projectID, event.MilestoneIndex)
+
+	if err := i.recordEscrowEvent(escrow, "milestone_release", log, map[string]interface{}{
+		"milestone_index": event.MilestoneIndex.String(),
+		"amount":          event.Amount.String(),
+	}); err != nil {
+		return err
+	}
+
+	i.notificationService.NotifyAddress(escrow.FreelancerAddr, NotificationEvent{
+		Type:  models.NotificationEscrowReleased,
+		Title: "Milestone payment released",
+		Body:  fmt.Sprintf("Milestone %s was approved and its payment released.", event.MilestoneIndex),
+		Data: map[string]interface{}{
+			"project_id":      escrow.ProjectID,
+			"escrow_id":       escrow.ID,
+			"milestone_index": event.MilestoneIndex.String(),
+			"amount":          event.Amount.String(),
+		},
+	})
+
+	return nil
+}
+
+func (i *BlockchainIndexer) handleDisputeInitiated(log types.Log) error {
+	// DisputeInitiated(uint256 indexed projectId, uint256 indexed disputeId, address initiator, string evidenceHash)
 	if len(log.Topics) < 3 {
 		return nil
 	}
 
+	projectID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
+	disputeID := new(big.Int).SetBytes(log.Topics[2].Bytes()).Int64()
+
+	i.logger.Infof("Dispute initiated: ID=%d, ProjectID=%d", disputeID, projectID)
+
+	var event struct {
+		Initiator    common.Address
+		EvidenceHash string
+	}
+	if err := escrowABI.UnpackIntoInterface(&event, "DisputeInitiated", log.Data); err != nil {
+		return err
+	}
+
+	escrow, err := i.escrowRepo.GetByOnChainID(projectID)
+	if err != nil {
+		return err
+	}
+
+	// The DAO's DisputeCreated precedes this log in the same transaction and
+	// usually creates the dispute already.
+	if _, err := i.ensureDispute(escrow, disputeID, event.Initiator, event.EvidenceHash, log); err != nil {
+		return err
+	}
+
+	if escrow.Status != models.EscrowStatusCreated && escrow.Status != models.EscrowStatusFunded {
+		return nil // Already indexed
+	}
+
+	escrow.Status = models.EscrowStatusDisputed
+	if err := i.escrowRepo.Update(escrow); err != nil {
+		return err
+	}
+
+	return i.recordEscrowEvent(escrow, "dispute_initiated", log, map[string]interface{}{
+		"dispute_id":    disputeID,
+		"initiator":     event.Initiator.Hex(),
+		"evidence_hash": event.EvidenceHash,
+	})
+}
+
+func (i *BlockchainIndexer) handleDaoDisputeCreated(log types.Log) error {
+	// DisputeCreated(uint256 indexed disputeId, uint256 indexed projectId, address indexed initiator, string evidenceHash)
+	if len(log.Topics) < 4 {
+		return nil
+	}
+
 	disputeID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
 	projectID := new(big.Int).SetBytes(log.Topics[2].Bytes()).Int64()
+	initiator := common.BytesToAddress(log.Topics[3].Bytes())
 
-	i.logger.Infof("Dispute initiated: ID=%d, ProjectID=%d", disputeID, projectID)
+	var event struct {
+		EvidenceHash string
+	}
+	if err := daoABI.UnpackIntoInterface(&event, "DisputeCreated", log.Data); err != nil {
+		return err
+	}
 
-	// Check if dispute already exists
-	_, err := i.disputeRepo.GetByOnChainID(disputeID)
-	if err == nil {
+	escrow, err := i.escrowRepo.GetByOnChainID(projectID)
+	if err != nil {
+		return err
+	}
+
+	dispute, err := i.ensureDispute(escrow, disputeID, initiator, event.EvidenceHash, log)
+	if err != nil {
+		return err
+	}
+	if dispute.VotingEndsAt != nil {
 		return nil // Already indexed
 	}
 
-	// Create dispute record
-	dispute := &models.Dispute{
-		OnChainID:   disputeID,
-		Status:      models.DisputeStatusOpen,
-		BlockNumber: log.BlockNumber,
-		TxHash:      log.TxHash.Hex(),
+	// The contract sets votingDeadline = block.timestamp + DISPUTE_VOTING_DURATION.
+	blockTime, err := i.blockchainService.GetBlockTime(log.BlockNumber)
+	if err != nil {
+		return err
+	}
+	votingEndsAt := blockTime.Add(DisputeVotingDuration)
+
+	dispute.VotingEndsAt = &votingEndsAt
+	if dispute.Status == models.DisputeStatusOpen && votingEndsAt.After(time.Now()) {
+		dispute.Status = models.DisputeStatusVoting
 	}
 
-	return i.disputeRepo.Create(dispute)
+	i.logger.Infof("Dispute voting opened: ID=%d, EndsAt=%s", disputeID, votingEndsAt.Format(time.RFC3339))
+
+	if err := i.disputeRepo.Update(dispute); err != nil {
+		return err
+	}
+	i.feedService.Publish(dispute.ID, DisputeFeedVotingStarted)
+	return nil
 }
 
-func (i *BlockchainIndexer) handleDisputeResolved(log common.Log) error {
-	// DisputeResolved(uint256 indexed disputeId, uint256 clientSplit, uint256 freelancerSplit)
+func (i *BlockchainIndexer) handleDaoDisputeFinalized(log types.Log) error {
+	// DisputeFinalized(uint256 indexed disputeId, uint8 freelancerPercentage, uint256 totalVoters)
 	if len(log.Topics) < 2 {
 		return nil
 	}
 
 	disputeID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
 
-	i.logger.Infof("Dispute resolved: ID=%d", disputeID)
-
 	dispute, err := i.disputeRepo.GetByOnChainID(disputeID)
 	if err != nil {
 		return err
 	}
+	if dispute.Status == models.DisputeStatusResolved {
+		return nil // Already indexed
+	}
 
+	var event struct {
+		FreelancerPercentage uint8
+		TotalVoters          *big.Int
+	}
+	if err := daoABI.UnpackIntoInterface(&event, "DisputeFinalized", log.Data); err != nil {
+		return err
+	}
+
+	i.logger.Infof("Dispute finalized: ID=%d, FreelancerPercentage=%d", disputeID, event.FreelancerPercentage)
+
+	// The escrow payout follows in Escrow's DisputeResolved; the outcome is
+	// final from here on.
 	dispute.Status = models.DisputeStatusResolved
+	dispute.FreelancerSplit = int(event.FreelancerPercentage)
+	dispute.ClientSplit = 100 - int(event.FreelancerPercentage)
 	now := time.Now()
 	dispute.ResolvedAt = &now
 
-	return i.disputeRepo.Update(dispute)
+	if err := i.disputeRepo.Update(dispute); err != nil {
+		return err
+	}
+	i.feedService.Publish(dispute.ID, DisputeFeedResolved)
+	return nil
 }
 
-func (i *BlockchainIndexer) handleNFTMinted(log common.Log) error {
-	// Transfer(address indexed from, address indexed to, uint256 indexed tokenId)
-	// Only process mints (from = 0x0)
-	if len(log.Topics) < 4 {
+// ensureDispute returns the dispute with the given on-chain ID. A dispute
+// opened through the API is linked to it; otherwise one is created. Jurors
+// are drawn for every newly indexed dispute.
+func (i *BlockchainIndexer) ensureDispute(escrow *models.Escrow, disputeID int64, initiator common.Address, evidenceHash string, log types.Log) (*models.Dispute, error) {
+	if dispute, err := i.disputeRepo.GetByOnChainID(disputeID); err == nil {
+		return dispute, nil
+	}
+
+	dispute, err := i.disputeRepo.GetUnlinked(escrow.ID)
+	linked := err == nil
+	if linked {
+		dispute.OnChainID = &disputeID
+		dispute.InitiatorAddr = initiator.Hex()
+		dispute.BlockNumber = log.BlockNumber
+		dispute.TxHash = log.TxHash.Hex()
+		if err := i.disputeRepo.Update(dispute); err != nil {
+			return nil, err
+		}
+	} else {
+		dispute = &models.Dispute{
+			ProjectID:     escrow.ProjectID,
+			EscrowID:      escrow.ID,
+			OnChainID:     &disputeID,
+			InitiatorAddr: initiator.Hex(),
+			Title:         fmt.Sprintf("Dispute #%d", disputeID),
+			Description:   "Evidence: " + evidenceHash,
+			Status:        models.DisputeStatusOpen,
+			BlockNumber:   log.BlockNumber,
+			TxHash:        log.TxHash.Hex(),
+		}
+		if err := i.disputeRepo.Create(dispute); err != nil {
+			return nil, err
+		}
+	}
+
+	// Disputes opened through the API notified the parties already.
+	if !linked {
+		i.notifyParties(escrow.ProjectID, NotificationEvent{
+			Type:  models.NotificationDisputeOpened,
+			Title: "Dispute opened",
+			Body:  "A dispute has been opened on your project.",
+			Data: map[string]interface{}{
+				"project_id": escrow.ProjectID,
+				"dispute_id": dispute.ID,
+			},
+		})
+		i.feedService.Publish(dispute.ID, DisputeFeedOpened)
+	}
+
+	if err := i.jurorService.AssignJurors(dispute.ID); err != nil {
+		i.logger.Errorf("Failed to assign jurors to dispute %d: %v", disputeID, err)
+	}
+
+	return dispute, nil
+}
+
+func (i *BlockchainIndexer) handleDisputeResolved(log types.Log) error {
+	// DisputeResolved(uint256 indexed projectId, uint8 freelancerPercentage, uint256 freelancerAmount, uint256 clientRefund)
+	if len(log.Topics) < 2 {
 		return nil
 	}
 
-	from := common.BytesToAddress(log.Topics[1].Bytes())
-	if from != (common.Address{}) {
-		return nil // Not a mint
+	projectID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
+
+	i.logger.Infof("Dispute resolved: ProjectID=%d", projectID)
+
+	escrow, err := i.escrowRepo.GetByOnChainID(projectID)
+	if err != nil {
+		return err
 	}
 
-	to := common.BytesToAddress(log.Topics[2].Bytes())
-	tokenID := new(big.Int).SetBytes(log.Topics[3].Bytes()).Int64()
+	if escrow.Status == models.EscrowStatusReleased {
+		return nil // Already indexed
+	}
 
-	i.logger.Infof("NFT minted: TokenID=%d, Owner=%s", tokenID, to.Hex())
+	dispute, err := i.disputeRepo.GetByEscrowID(escrow.ID)
+	if err != nil {
+		return err
+	}
 
-	// Create NFT record
-	nft := &models.NFT{
-		TokenID:     tokenID,
-		OwnerAddr:   to.Hex(),
-		MintTxHash:  log.TxHash.Hex(),
+	var event struct {
+		FreelancerPercentage uint8
+		FreelancerAmount     *big.Int
+		ClientRefund         *big.Int
+	}
+	if err := escrowABI.UnpackIntoInterface(&event, "DisputeResolved", log.Data); err != nil {
+		return err
+	}
+
+	dispute.Status = models.DisputeStatusResolved
+	dispute.FreelancerSplit = int(event.FreelancerPercentage)
+	dispute.ClientSplit = 100 - int(event.FreelancerPercentage)
+	if dispute.ResolvedAt == nil {
+		now := time.Now()
+		dispute.ResolvedAt = &now
+	}
+
+	if err := i.disputeRepo.Update(dispute); err != nil {
+		return err
+	}
+
+	escrow.Status = models.EscrowStatusReleased
+	escrow.ReleasedAtBlock = log.BlockNumber
+	escrow.ReleaseTxHash = log.TxHash.Hex()
+	if err := i.escrowRepo.Update(escrow); err != nil {
+		return err
+	}
+
+	return i.recordEscrowEvent(escrow, "dispute_resolved", log, map[string]interface{}{
+		"freelancer_percentage": event.FreelancerPercentage,
+		"freelancer_amount":     event.FreelancerAmount.String(),
+		"client_refund":         event.ClientRefund.String(),
+	})
+}
+
+// recordEscrowEvent stores the event with the time of its block, so
+// analytics date it by when it happened rather than when it was indexed, and
+// adds payouts to the ledger. Ledger failures are left to the reconcile job.
+func (i *BlockchainIndexer) recordEscrowEvent(escrow *models.Escrow, eventType string, log types.Log, data map[string]interface{}) error {
+	logIndex := log.Index
+	event := &models.EscrowEvent{
+		EscrowID:    escrow.ID,
+		EventType:   eventType,
+		TxHash:      log.TxHash.Hex(),
+		LogIndex:    &logIndex,
 		BlockNumber: log.BlockNumber,
-		MintedAt:    time.Now(),
+		Data:        data,
+	}
+	if blockTime, err := i.blockchainService.GetBlockTime(log.BlockNumber); err == nil {
+		event.BlockTime = &blockTime
+	} else {
+		i.logger.Warnf("Failed to get the time of block %d: %v", log.BlockNumber, err)
 	}
 
-	return i.nftRepo.Create(nft)
+	if err := i.escrowRepo.CreateEvent(event); err != nil {
+		return err
+	}
+
+	if err := i.ledgerService.Record(event); err != nil {
+		i.logger.Errorf("Failed to record escrow event %s in the ledger: %v", event.ID, err)
+	}
+	return nil
+}
+
+// notifyParties sends the event to the client and, once hired, the freelancer.
+func (i *BlockchainIndexer) notifyParties(projectID uuid.UUID, event NotificationEvent) {
+	project, err := i.projectRepo.GetByID(projectID)
+	if err != nil {
+		i.logger.Errorf("Failed to load project %s for notification: %v", projectID, err)
+		return
+	}
+
+	i.notificationService.Notify(project.ClientID, event)
+	if project.FreelancerID != nil {
+		i.notificationService.Notify(*project.FreelancerID, event)
+	}
 }
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fariima/backend/internal/config"
@@ -36,8 +39,8 @@ func (s *BlockchainService) GetClient() *ethclient.Client {
 	return s.client
 }
 
-func (s *BlockchainService) GetLatestBlockNumber() (uint64, error) {
-	header, err := s.client.HeaderByNumber(context.Background(), nil)
+func (s *BlockchainService) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	header, err := s.client.HeaderByNumber(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -48,7 +51,25 @@ func (s *BlockchainService) GetBlockByNumber(blockNumber uint64) (*types.Block,
 	return s.client.BlockByNumber(context.Background(), big.NewInt(int64(blockNumber)))
 }
 
-func (s *BlockchainService) GetLogs(fromBlock, toBlock uint64, addresses []common.Address, topics [][]common.Hash) ([]types.Log, error) {
+// GetBlockTime returns the timestamp of a block.
+func (s *BlockchainService) GetBlockTime(blockNumber uint64) (time.Time, error) {
+	header, err := s.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// GetBlockHash returns the hash of a block.
+func (s *BlockchainService) GetBlockHash(blockNumber uint64) (common.Hash, error) {
+	header, err := s.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+func (s *BlockchainService) GetLogs(ctx context.Context, fromBlock, toBlock uint64, addresses []common.Address, topics [][]common.Hash) ([]types.Log, error) {
 	query := ethereum.FilterQuery{
 		FromBlock: big.NewInt(int64(fromBlock)),
 		ToBlock:   big.NewInt(int64(toBlock)),
@@ -56,7 +77,7 @@ func (s *BlockchainService) GetLogs(fromBlock, toBlock uint64, addresses []commo
 		Topics:    topics,
 	}
 
-	return s.client.FilterLogs(context.Background(), query)
+	return s.client.FilterLogs(ctx, query)
 }
 
 func (s *BlockchainService) GetTransaction(txHash common.Hash) (*types.Transaction, bool, error) {
@@ -97,3 +118,73 @@ func (s *BlockchainService) GetContractAddress(name string) (common.Address, err
 
 	return common.HexToAddress(addr), nil
 }
+
+// CallContract runs a read-only contract call at the given block, or at the
+// latest block when blockNumber is nil.
+func (s *BlockchainService) CallContract(contract string, contractABI abi.ABI, method string, blockNumber *big.Int, args ...interface{}) ([]interface{}, error) {
+	to, err := s.GetContractAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.client.CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return contractABI.Unpack(method, output)
+}
+
+// GetFARIStake returns the address's staked FARI and time-weighted voting
+// power at the given block, or at the latest block when blockNumber is nil.
+func (s *BlockchainService) GetFARIStake(address common.Address, blockNumber *big.Int) (*big.Int, *big.Int, error) {
+	staked, err := s.CallContract("fari_token", fariTokenABI, "getStakedAmount", blockNumber, address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	power, err := s.CallContract("fari_token", fariTokenABI, "getVotingPower", blockNumber, address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return staked[0].(*big.Int), power[0].(*big.Int), nil
+}
+
+// TransactionPayload is an unsigned contract call for the user's wallet to
+// sign and submit.
+type TransactionPayload struct {
+	ChainID int64       `json:"chain_id"`
+	To      string      `json:"to"`
+	Data    string      `json:"data"`
+	Value   string      `json:"value"`
+	Method  string      `json:"method"`
+	Args    interface{} `json:"args"`
+}
+
+// BuildContractCall ABI-encodes a call to one of the platform contracts.
+func (s *BlockchainService) BuildContractCall(contract string, contractABI abi.ABI, method string, args ...interface{}) (*TransactionPayload, error) {
+	to, err := s.GetContractAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactionPayload{
+		ChainID: s.cfg.GetChainID(),
+		To:      to.Hex(),
+		Data:    hexutil.Encode(data),
+		Value:   "0",
+		Method:  contractABI.Methods[method].Sig,
+		Args:    args,
+	}, nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Minimal ABI fragments for the events and calls the backend uses. They
+// mirror the contracts in /contracts and must be kept in sync with them.
+const escrowABIJSON = `[
+	{"type":"event","name":"ProjectCreated","inputs":[
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"client","type":"address","indexed":true},
+		{"name":"freelancer","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"token","type":"address","indexed":false}]},
+	{"type":"event","name":"ProjectFunded","inputs":[
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"event","name":"ProjectCompleted","inputs":[
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"freelancerPayment","type":"uint256","indexed":false},
+		{"name":"platformFee","type":"uint256","indexed":false},
+		{"name":"nftTokenId","type":"uint256","indexed":false}]},
+	{"type":"event","name":"DisputeInitiated","inputs":[
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"disputeId","type":"uint256","indexed":true},
+		{"name":"initiator","type":"address","indexed":false},
+		{"name":"evidenceHash","type":"string","indexed":false}]},
+	{"type":"event","name":"DisputeResolved","inputs":[
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"freelancerPercentage","type":"uint8","indexed":false},
+		{"name":"freelancerAmount","type":"uint256","indexed":false},
+		{"name":"clientRefund","type":"uint256","indexed":false}]},
+	{"type":"event","name":"ProjectCancelled","inputs":[
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"refundAmount","type":"uint256","indexed":false}]},
+	{"type":"event","name":"MilestoneReleased","inputs":[
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"milestoneIndex","type":"uint256","indexed":false},
+		{"name":"amount","type":"uint256","indexed":false}]}
+]`
+
+const nftABIJSON = `[
+	{"type":"event","name":"NFTMinted","inputs":[
+		{"name":"tokenId","type":"uint256","indexed":true},
+		{"name":"freelancer","type":"address","indexed":true},
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"function","name":"getMetadata","stateMutability":"view","inputs":[
+		{"name":"tokenId","type":"uint256"}],"outputs":[
+		{"name":"","type":"tuple","components":[
+			{"name":"projectId","type":"uint256"},
+			{"name":"projectTitle","type":"string"},
+			{"name":"amount","type":"uint256"},
+			{"name":"client","type":"address"},
+			{"name":"freelancer","type":"address"},
+			{"name":"completionDate","type":"uint256"},
+			{"name":"category","type":"string"}]}]}
+]`
+
+const daoABIJSON = `[
+	{"type":"event","name":"DisputeCreated","inputs":[
+		{"name":"disputeId","type":"uint256","indexed":true},
+		{"name":"projectId","type":"uint256","indexed":true},
+		{"name":"initiator","type":"address","indexed":true},
+		{"name":"evidenceHash","type":"string","indexed":false}]},
+	{"type":"event","name":"DisputeVoted","inputs":[
+		{"name":"disputeId","type":"uint256","indexed":true},
+		{"name":"juror","type":"address","indexed":true},
+		{"name":"voteForFreelancer","type":"bool","indexed":false},
+		{"name":"votingPower","type":"uint256","indexed":false}]},
+	{"type":"event","name":"DisputeFinalized","inputs":[
+		{"name":"disputeId","type":"uint256","indexed":true},
+		{"name":"freelancerPercentage","type":"uint8","indexed":false},
+		{"name":"totalVoters","type":"uint256","indexed":false}]},
+	{"type":"event","name":"JurorRewarded","inputs":[
+		{"name":"juror","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"function","name":"finalizeDispute","stateMutability":"nonpayable",
+		"inputs":[{"name":"disputeId","type":"uint256"}],
+		"outputs":[{"name":"freelancerPercentage","type":"uint8"}]},
+	{"type":"function","name":"claimJurorRewards","stateMutability":"nonpayable",
+		"inputs":[{"name":"disputeIds","type":"uint256[]"}],
+		"outputs":[]},
+	{"type":"event","name":"ProposalCreated","inputs":[
+		{"name":"proposalId","type":"uint256","indexed":true},
+		{"name":"proposer","type":"address","indexed":true},
+		{"name":"proposalType","type":"uint8","indexed":false},
+		{"name":"title","type":"string","indexed":false}]},
+	{"type":"event","name":"ProposalVoted","inputs":[
+		{"name":"proposalId","type":"uint256","indexed":true},
+		{"name":"voter","type":"address","indexed":true},
+		{"name":"support","type":"bool","indexed":false},
+		{"name":"votingPower","type":"uint256","indexed":false}]},
+	{"type":"event","name":"ProposalExecuted","inputs":[
+		{"name":"proposalId","type":"uint256","indexed":true}]},
+	{"type":"function","name":"proposals","stateMutability":"view",
+		"inputs":[{"name":"","type":"uint256"}],
+		"outputs":[
+			{"name":"id","type":"uint256"},
+			{"name":"proposer","type":"address"},
+			{"name":"proposalType","type":"uint8"},
+			{"name":"title","type":"string"},
+			{"name":"description","type":"string"},
+			{"name":"callData","type":"bytes"},
+			{"name":"votingDeadline","type":"uint256"},
+			{"name":"votesFor","type":"uint256"},
+			{"name":"votesAgainst","type":"uint256"},
+			{"name":"state","type":"uint8"}]},
+	{"type":"function","name":"getProposalVotes","stateMutability":"view",
+		"inputs":[{"name":"proposalId","type":"uint256"}],
+		"outputs":[
+			{"name":"votesFor","type":"uint256"},
+			{"name":"votesAgainst","type":"uint256"},
+			{"name":"state","type":"uint8"}]},
+	{"type":"function","name":"quorumBps","stateMutability":"view",
+		"inputs":[{"name":"","type":"uint8"}],
+		"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"approvalThresholdBps","stateMutability":"view",
+		"inputs":[{"name":"","type":"uint8"}],
+		"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"updateQuorum","stateMutability":"nonpayable",
+		"inputs":[{"name":"proposalType","type":"uint8"},{"name":"newQuorumBps","type":"uint256"}],
+		"outputs":[]},
+	{"type":"function","name":"updateApprovalThreshold","stateMutability":"nonpayable",
+		"inputs":[{"name":"proposalType","type":"uint8"},{"name":"newThresholdBps","type":"uint256"}],
+		"outputs":[]},
+	{"type":"function","name":"grantRole","stateMutability":"nonpayable",
+		"inputs":[{"name":"role","type":"bytes32"},{"name":"account","type":"address"}],
+		"outputs":[]},
+	{"type":"function","name":"revokeRole","stateMutability":"nonpayable",
+		"inputs":[{"name":"role","type":"bytes32"},{"name":"account","type":"address"}],
+		"outputs":[]},
+	{"type":"function","name":"upgradeTo","stateMutability":"nonpayable",
+		"inputs":[{"name":"newImplementation","type":"address"}],
+		"outputs":[]},
+	{"type":"function","name":"upgradeToAndCall","stateMutability":"payable",
+		"inputs":[{"name":"newImplementation","type":"address"},{"name":"data","type":"bytes"}],
+		"outputs":[]}
+]`
+
+const fariTokenABIJSON = `[
+	{"type":"function","name":"getStakedAmount","stateMutability":"view",
+		"inputs":[{"name":"account","type":"address"}],
+		"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"getVotingPower","stateMutability":"view",
+		"inputs":[{"name":"account","type":"address"}],
+		"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"totalStaked","stateMutability":"view",
+		"inputs":[],
+		"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"stakes","stateMutability":"view",
+		"inputs":[{"name":"","type":"address"}],
+		"outputs":[
+			{"name":"amount","type":"uint256"},
+			{"name":"timestamp","type":"uint256"},
+			{"name":"pendingUnstake","type":"uint256"},
+			{"name":"unstakeRequestTime","type":"uint256"}]},
+	{"type":"event","name":"Staked","inputs":[
+		{"name":"user","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"totalStaked","type":"uint256","indexed":false}]},
+	{"type":"event","name":"UnstakeRequested","inputs":[
+		{"name":"user","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"availableAt","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Unstaked","inputs":[
+		{"name":"user","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"totalStaked","type":"uint256","indexed":false}]},
+	{"type":"event","name":"VestingScheduleCreated","inputs":[
+		{"name":"beneficiary","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"duration","type":"uint256","indexed":false},
+		{"name":"cliff","type":"uint256","indexed":false}]},
+	{"type":"event","name":"TokensReleased","inputs":[
+		{"name":"beneficiary","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false}]}
+]`
+
+var (
+	escrowABI    = mustParseABI(escrowABIJSON)
+	nftABI       = mustParseABI(nftABIJSON)
+	daoABI       = mustParseABI(daoABIJSON)
+	fariTokenABI = mustParseABI(fariTokenABIJSON)
+)
+
+// DisputeVotingDuration mirrors FARIIMADao.DISPUTE_VOTING_DURATION.
+const DisputeVotingDuration = 72 * time.Hour
+
+// MinJurorStake mirrors FARIIMADao.MIN_JUROR_STAKE (5,000 FARI).
+var MinJurorStake = new(big.Int).Mul(big.NewInt(5_000), big.NewInt(1e18))
+
+// UnstakeCooldown mirrors FARIToken.UNSTAKE_COOLDOWN.
+const UnstakeCooldown = 7 * 24 * time.Hour
+
+// StakeMultipliers mirrors FARIToken's time-weighted voting power
+// multipliers (percent), longest stake first.
+var StakeMultipliers = []struct {
+	After      time.Duration
+	Multiplier int64
+}{
+	{365 * 24 * time.Hour, 200},
+	{180 * 24 * time.Hour, 150},
+	{90 * 24 * time.Hour, 120},
+	{0, 100},
+}
+
+// BaseJurorReward mirrors FARIIMADao.BASE_JUROR_REWARD (50 FARI), paid per
+// claimed dispute.
+var BaseJurorReward = new(big.Int).Mul(big.NewInt(50), big.NewInt(1e18))
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
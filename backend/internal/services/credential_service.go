@@ -0,0 +1,443 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	CredentialTypeProofOfWork = "ProofOfWorkCredential"
+	CredentialTypeWorkHistory = "WorkHistoryCredential"
+
+	credentialsContext  = "https://www.w3.org/2018/credentials/v1"
+	credentialProofType = "EcdsaSecp256k1Signature2019"
+)
+
+// credentialJWSHeader is the protected header of the detached, unencoded
+// (RFC 7797) JWS in each proof.
+var credentialJWSHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256K","b64":false,"crit":["b64"]}`))
+
+// Credential is a W3C Verifiable Credential (data model 1.1).
+type Credential struct {
+	Context           []interface{}      `json:"@context"`
+	ID                string             `json:"id"`
+	Type              []string           `json:"type"`
+	Issuer            string             `json:"issuer"`
+	IssuanceDate      string             `json:"issuanceDate"`
+	CredentialSubject *CredentialSubject `json:"credentialSubject"`
+	Proof             *CredentialProof   `json:"proof,omitempty"`
+}
+
+// CredentialSubject is the freelancer a credential is about, with either a
+// single certificate or their whole work history.
+type CredentialSubject struct {
+	ID      string `json:"id"` // did:pkh of the freelancer's wallet
+	Address string `json:"address"`
+
+	// ProofOfWorkCredential
+	Certificate *CredentialCertificate `json:"certificate,omitempty"`
+
+	// WorkHistoryCredential
+	CertificateCount int                     `json:"certificateCount,omitempty"`
+	Categories       []string                `json:"categories,omitempty"`
+	FirstCompletedAt string                  `json:"firstCompletedAt,omitempty"`
+	LastCompletedAt  string                  `json:"lastCompletedAt,omitempty"`
+	Certificates     []CredentialCertificate `json:"certificates,omitempty"`
+}
+
+// CredentialCertificate is a Proof-of-Work NFT as attested in a credential.
+type CredentialCertificate struct {
+	TokenID          int64  `json:"tokenId"`
+	Contract         string `json:"contract"`
+	ChainID          int64  `json:"chainId"`
+	ProjectID        string `json:"projectId"`
+	OnChainProjectID int64  `json:"onChainProjectId"`
+	ProjectTitle     string `json:"projectTitle,omitempty"`
+	ProjectURL       string `json:"projectUrl,omitempty"`
+	Category         string `json:"category,omitempty"`
+	Amount           string `json:"amount,omitempty"` // Payment token base units
+	Currency         string `json:"currency,omitempty"`
+	CompletedAt      string `json:"completedAt,omitempty"`
+	MintTxHash       string `json:"mintTxHash"`
+}
+
+type CredentialProof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	ProofPurpose       string `json:"proofPurpose"`
+	VerificationMethod string `json:"verificationMethod"`
+	JWS                string `json:"jws"`
+}
+
+// CredentialVerification is the outcome of verifying a credential.
+type CredentialVerification struct {
+	Verified bool              `json:"verified"`
+	Checks   []CredentialCheck `json:"checks"`
+}
+
+type CredentialCheck struct {
+	Check   string `json:"check"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// CredentialService issues and verifies Verifiable Credentials for
+// Proof-of-Work NFTs. The platform is a did:web issuer whose DID document
+// publishes the secp256k1 signing key.
+//
+// Proofs are detached ES256K JWS over the credential without its proof,
+// serialized as JSON with object keys sorted and no insignificant
+// whitespace.
+type CredentialService struct {
+	cfg        *config.Config
+	nftRepo    *repositories.NFTRepository
+	userRepo   *repositories.UserRepository
+	signingKey *ecdsa.PrivateKey
+	issuer     string
+	logger     *logrus.Logger
+}
+
+func NewCredentialService(
+	cfg *config.Config,
+	nftRepo *repositories.NFTRepository,
+	userRepo *repositories.UserRepository,
+	logger *logrus.Logger,
+) (*CredentialService, error) {
+	var key *ecdsa.PrivateKey
+	var err error
+	if cfg.CredentialSigningKey != "" {
+		key, err = crypto.HexToECDSA(strings.TrimPrefix(cfg.CredentialSigningKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CREDENTIAL_SIGNING_KEY: %w", err)
+		}
+	} else {
+		logger.Warn("CREDENTIAL_SIGNING_KEY is not set; credentials are signed with a temporary key and stop verifying after a restart")
+		if key, err = crypto.GenerateKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	issuer := "did:web:localhost"
+	if u, err := url.Parse(cfg.PublicAPIURL); err == nil && u.Host != "" {
+		issuer = "did:web:" + strings.ReplaceAll(u.Host, ":", "%3A")
+	}
+
+	return &CredentialService{
+		cfg:        cfg,
+		nftRepo:    nftRepo,
+		userRepo:   userRepo,
+		signingKey: key,
+		issuer:     issuer,
+		logger:     logger,
+	}, nil
+}
+
+func (s *CredentialService) verificationMethod() string {
+	return s.issuer + "#credentials"
+}
+
+// DIDDocument is the issuer's did:web document, served at
+// /.well-known/did.json.
+func (s *CredentialService) DIDDocument() map[string]interface{} {
+	pub := s.signingKey.PublicKey
+	jwk := map[string]string{
+		"kty": "EC",
+		"crv": "secp256k1",
+		"x":   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+	}
+
+	return map[string]interface{}{
+		"@context": []string{"https://www.w3.org/ns/did/v1", "https://w3id.org/security/suites/secp256k1-2019/v1"},
+		"id":       s.issuer,
+		"verificationMethod": []map[string]interface{}{{
+			"id":           s.verificationMethod(),
+			"type":         "EcdsaSecp256k1VerificationKey2019",
+			"controller":   s.issuer,
+			"publicKeyJwk": jwk,
+		}},
+		"assertionMethod": []string{s.verificationMethod()},
+	}
+}
+
+// IssueNFTCredential issues a ProofOfWorkCredential for one of the user's
+// NFTs.
+func (s *CredentialService) IssueNFTCredential(userID uuid.UUID, tokenID int64) (*Credential, error) {
+	user, err := s.holder(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	nft, err := s.nftRepo.GetByTokenID(tokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !strings.EqualFold(nft.OwnerAddr, user.Address) {
+		return nil, ErrForbidden
+	}
+
+	certificate := s.certificate(nft)
+	return s.issue(CredentialTypeProofOfWork, &CredentialSubject{
+		ID:          s.subjectDID(user.Address),
+		Address:     strings.ToLower(user.Address),
+		Certificate: &certificate,
+	})
+}
+
+// IssueWorkHistoryCredential issues a WorkHistoryCredential covering all of
+// the user's NFTs.
+func (s *CredentialService) IssueWorkHistoryCredential(userID uuid.UUID) (*Credential, error) {
+	user, err := s.holder(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts, err := s.nftRepo.GetByOwnerAddress(user.Address)
+	if err != nil {
+		return nil, err
+	}
+	if len(nfts) == 0 {
+		return nil, fmt.Errorf("%w: no Proof-of-Work NFTs to attest", ErrInvalidInput)
+	}
+
+	subject := &CredentialSubject{
+		ID:               s.subjectDID(user.Address),
+		Address:          strings.ToLower(user.Address),
+		CertificateCount: len(nfts),
+	}
+
+	categories := map[string]bool{}
+	var first, last time.Time
+	for i := range nfts {
+		subject.Certificates = append(subject.Certificates, s.certificate(&nfts[i]))
+
+		if category := nfts[i].Category; category != "" && !categories[category] {
+			categories[category] = true
+			subject.Categories = append(subject.Categories, category)
+		}
+
+		completed := nfts[i].MintedAt
+		if nfts[i].CompletedAt != nil {
+			completed = *nfts[i].CompletedAt
+		}
+		if first.IsZero() || completed.Before(first) {
+			first = completed
+		}
+		if completed.After(last) {
+			last = completed
+		}
+	}
+	sort.Strings(subject.Categories)
+	subject.FirstCompletedAt = first.UTC().Format(time.RFC3339)
+	subject.LastCompletedAt = last.UTC().Format(time.RFC3339)
+
+	return s.issue(CredentialTypeWorkHistory, subject)
+}
+
+func (s *CredentialService) holder(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if user.Address == "" {
+		return nil, fmt.Errorf("%w: link a wallet to export credentials", ErrInvalidInput)
+	}
+	return user, nil
+}
+
+func (s *CredentialService) subjectDID(address string) string {
+	return fmt.Sprintf("did:pkh:eip155:%d:%s", s.cfg.GetChainID(), common.HexToAddress(address).Hex())
+}
+
+func (s *CredentialService) certificate(nft *models.NFT) CredentialCertificate {
+	certificate := CredentialCertificate{
+		TokenID:          nft.TokenID,
+		Contract:         strings.ToLower(s.cfg.NFTContract),
+		ChainID:          s.cfg.GetChainID(),
+		ProjectID:        nft.ProjectID.String(),
+		OnChainProjectID: nft.OnChainProjectID,
+		ProjectTitle:     nft.Project.Title,
+		ProjectURL:       s.cfg.AppBaseURL + "/projects/" + nft.ProjectID.String(),
+		Category:         nft.Category,
+		Amount:           nft.Amount,
+		Currency:         nft.Project.Currency,
+		MintTxHash:       nft.MintTxHash,
+	}
+	if nft.CompletedAt != nil {
+		certificate.CompletedAt = nft.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	return certificate
+}
+
+func (s *CredentialService) issue(credentialType string, subject *CredentialSubject) (*Credential, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	credential := &Credential{
+		Context: []interface{}{
+			credentialsContext,
+			map[string]string{"@vocab": s.cfg.AppBaseURL + "/credentials#"},
+		},
+		ID:                "urn:uuid:" + uuid.New().String(),
+		Type:              []string{"VerifiableCredential", credentialType},
+		Issuer:            s.issuer,
+		IssuanceDate:      now,
+		CredentialSubject: subject,
+	}
+
+	payload, err := canonicalJSON(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(jwsSigningInput(payload))
+	sig, err := crypto.Sign(hash[:], s.signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.Proof = &CredentialProof{
+		Type:               credentialProofType,
+		Created:            now,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: s.verificationMethod(),
+		JWS:                credentialJWSHeader + ".." + base64.RawURLEncoding.EncodeToString(sig[:64]),
+	}
+	return credential, nil
+}
+
+// Verification
+
+// Verify checks a credential's proof against the platform key, then
+// re-checks each attested certificate against the indexed NFTs: it must
+// still exist, belong to the subject and be linked to the same project.
+func (s *CredentialService) Verify(raw []byte) (*CredentialVerification, error) {
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("%w: credential is not valid JSON", ErrInvalidInput)
+	}
+
+	var credential Credential
+	if err := json.Unmarshal(raw, &credential); err != nil || credential.CredentialSubject == nil {
+		return nil, fmt.Errorf("%w: not a Fariima credential", ErrInvalidInput)
+	}
+
+	result := &CredentialVerification{}
+	check := func(name string, passed bool, message string) {
+		if passed {
+			message = ""
+		}
+		result.Checks = append(result.Checks, CredentialCheck{Check: name, Passed: passed, Message: message})
+	}
+
+	check("issuer", credential.Issuer == s.issuer, "Not issued by "+s.issuer)
+	check("signature", s.verifyProof(document, credential.Proof), "Proof is missing or the signature does not match")
+
+	subject := credential.CredentialSubject
+	var certificates []CredentialCertificate
+	switch {
+	case containsString(credential.Type, CredentialTypeProofOfWork) && subject.Certificate != nil:
+		certificates = []CredentialCertificate{*subject.Certificate}
+	case containsString(credential.Type, CredentialTypeWorkHistory):
+		certificates = subject.Certificates
+		check("history", len(certificates) == subject.CertificateCount, "Certificate count does not match the listed certificates")
+	default:
+		check("type", false, "Unsupported credential type")
+	}
+
+	for _, certificate := range certificates {
+		name := fmt.Sprintf("nft:%d", certificate.TokenID)
+
+		nft, err := s.nftRepo.GetByTokenID(certificate.TokenID)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			check(name, false, "NFT is not indexed")
+			continue
+		}
+
+		switch {
+		case !strings.EqualFold(nft.OwnerAddr, subject.Address):
+			check(name, false, "NFT is no longer owned by the subject")
+		case nft.ProjectID.String() != certificate.ProjectID || nft.OnChainProjectID != certificate.OnChainProjectID:
+			check(name, false, "NFT is linked to a different project")
+		case !strings.EqualFold(nft.MintTxHash, certificate.MintTxHash):
+			check(name, false, "Mint transaction does not match")
+		default:
+			check(name, true, "")
+		}
+	}
+
+	result.Verified = true
+	for _, c := range result.Checks {
+		result.Verified = result.Verified && c.Passed
+	}
+	return result, nil
+}
+
+func (s *CredentialService) verifyProof(document map[string]interface{}, proof *CredentialProof) bool {
+	if proof == nil || proof.Type != credentialProofType || proof.VerificationMethod != s.verificationMethod() {
+		return false
+	}
+
+	parts := strings.Split(proof.JWS, ".")
+	if len(parts) != 3 || parts[0] != credentialJWSHeader || parts[1] != "" {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return false
+	}
+
+	delete(document, "proof")
+	payload, err := canonicalJSON(document)
+	if err != nil {
+		return false
+	}
+
+	hash := sha256.Sum256(jwsSigningInput(payload))
+	return crypto.VerifySignature(crypto.FromECDSAPub(&s.signingKey.PublicKey), hash[:], sig)
+}
+
+func jwsSigningInput(payload []byte) []byte {
+	return append([]byte(credentialJWSHeader+"."), payload...)
+}
+
+// canonicalJSON serializes v with object keys sorted. Numbers are kept as
+// written so that re-serializing a received credential is byte-identical.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression. It accepts the standard five
+// fields (minute hour day-of-month month day-of-week) with *, lists, ranges
+// and steps, the @hourly/@daily/@weekly/@monthly shorthands, and
+// "@every <duration>" for fixed intervals.
+type CronSchedule struct {
+	every  time.Duration
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Day-of-month and day-of-week are OR-ed when both are restricted.
+	domStar bool
+	dowStar bool
+}
+
+var cronShorthands = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %q: %w", expr, err)
+		}
+		if every < time.Second {
+			return nil, fmt.Errorf("invalid cron %q: interval must be at least 1s", expr)
+		}
+		return &CronSchedule{every: every}, nil
+	}
+
+	if shorthand, ok := cronShorthands[expr]; ok {
+		expr = shorthand
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron %q: expected 5 fields", expr)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+
+	return &CronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("bad range %q", part)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first activation strictly after t.
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(c.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return limit
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Dispute feed events published on TopicDisputes.
+const (
+	DisputeFeedOpened        = "opened"
+	DisputeFeedVotingStarted = "voting_started"
+	DisputeFeedVoted         = "voted"
+	DisputeFeedVotingEnded   = "voting_ended"
+	DisputeFeedResolved      = "resolved"
+)
+
+// feedItemLimit is the number of disputes in the RSS and Atom feeds.
+const feedItemLimit = 50
+
+// feedQueueSize bounds the events waiting to be published. Events beyond it
+// are dropped, as the feed is best effort.
+const feedQueueSize = 256
+
+// disputeAmountBands buckets project budgets so the feed does not reveal
+// exact amounts. Each entry is the lower bound of a band.
+var disputeAmountBands = []struct {
+	Min   float64
+	Label string
+}{
+	{50000, "50,000+"},
+	{10000, "10,000-50,000"},
+	{2000, "2,000-10,000"},
+	{500, "500-2,000"},
+	{0, "under 500"},
+}
+
+// PublicDispute is the redacted view of a dispute. It carries no titles,
+// descriptions, evidence, parties or addresses.
+type PublicDispute struct {
+	ID               uuid.UUID            `json:"id"`
+	OnChainID        *int64               `json:"on_chain_id"`
+	Category         string               `json:"category"`
+	AmountBand       string               `json:"amount_band"`
+	Currency         string               `json:"currency"`
+	Status           models.DisputeStatus `json:"status"`
+	EvidenceCount    int64                `json:"evidence_count"`
+	JurorCount       int64                `json:"juror_count"`
+	TotalVotes       int                  `json:"total_votes"`
+	ClientVotes      int                  `json:"client_votes"`
+	FreelancerVotes  int                  `json:"freelancer_votes"`
+	ClientWeight     string               `json:"client_weight"`     // Voting power, wei
+	FreelancerWeight string               `json:"freelancer_weight"` // Voting power, wei
+	Outcome          string               `json:"outcome,omitempty"` // freelancer, client or split once resolved
+	FreelancerSplit  *int                 `json:"freelancer_split,omitempty"`
+	OpenedAt         time.Time            `json:"opened_at"`
+	VotingEndsAt     *time.Time           `json:"voting_ends_at"`
+	ResolvedAt       *time.Time           `json:"resolved_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+// DisputeFeedEvent is the payload of a TopicDisputes message.
+type DisputeFeedEvent struct {
+	Event   string         `json:"event"`
+	Dispute *PublicDispute `json:"dispute"`
+}
+
+// DisputeStats is the public summary of dispute outcomes.
+type DisputeStats struct {
+	Overall    *repositories.DisputeOutcomeStats  `json:"overall"`
+	ByCategory []repositories.DisputeOutcomeStats `json:"by_category"`
+}
+
+// DisputeFeedService serves the public, redacted dispute feed over HTTP,
+// RSS/Atom and the public WebSocket topic.
+type DisputeFeedService struct {
+	cfg         *config.Config
+	disputeRepo *repositories.DisputeRepository
+	wsService   *WebSocketService
+	logger      *logrus.Logger
+	events      chan feedEvent
+}
+
+type feedEvent struct {
+	disputeID uuid.UUID
+	event     string
+}
+
+func NewDisputeFeedService(
+	cfg *config.Config,
+	disputeRepo *repositories.DisputeRepository,
+	wsService *WebSocketService,
+	logger *logrus.Logger,
+) *DisputeFeedService {
+	s := &DisputeFeedService{
+		cfg:         cfg,
+		disputeRepo: disputeRepo,
+		wsService:   wsService,
+		logger:      logger,
+		events:      make(chan feedEvent, feedQueueSize),
+	}
+	go s.publishLoop()
+	return s
+}
+
+// List returns redacted disputes, newest first. Only status and category
+// filters apply.
+func (s *DisputeFeedService) List(status, category string, limit, offset int) ([]PublicDispute, int64, error) {
+	disputes, total, err := s.disputeRepo.List(repositories.DisputeFilter{
+		Status:   status,
+		Category: category,
+	}, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	public, err := s.redact(disputes)
+	return public, total, err
+}
+
+func (s *DisputeFeedService) Get(id uuid.UUID) (*PublicDispute, error) {
+	dispute, err := s.disputeRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	public, err := s.redact([]models.Dispute{*dispute})
+	if err != nil {
+		return nil, err
+	}
+	return &public[0], nil
+}
+
+func (s *DisputeFeedService) Stats() (*DisputeStats, error) {
+	overall, byCategory, err := s.disputeRepo.OutcomeStats()
+	if err != nil {
+		return nil, err
+	}
+	return &DisputeStats{Overall: overall, ByCategory: byCategory}, nil
+}
+
+// Publish queues the dispute's current redacted state for TopicDisputes and
+// returns without waiting, so callers in request handlers and the indexer
+// are not held up by the database or slow subscribers. Events are published
+// in order by one goroutine; failures are logged.
+func (s *DisputeFeedService) Publish(disputeID uuid.UUID, event string) {
+	select {
+	case s.events <- feedEvent{disputeID: disputeID, event: event}:
+	default:
+		s.logger.Warnf("Dispute feed queue is full, dropping %s event of dispute %s", event, disputeID)
+	}
+}
+
+func (s *DisputeFeedService) publishLoop() {
+	for e := range s.events {
+		s.publish(e.disputeID, e.event)
+	}
+}
+
+func (s *DisputeFeedService) publish(disputeID uuid.UUID, event string) {
+	dispute, err := s.Get(disputeID)
+	if err != nil {
+		s.logger.Errorf("Failed to load dispute %s for the public feed: %v", disputeID, err)
+		return
+	}
+
+	s.wsService.Publish(TopicDisputes, WSMessage{
+		Type:    "dispute_feed",
+		Payload: DisputeFeedEvent{Event: event, Dispute: dispute},
+	})
+}
+
+func (s *DisputeFeedService) redact(disputes []models.Dispute) ([]PublicDispute, error) {
+	ids := make([]uuid.UUID, len(disputes))
+	for i := range disputes {
+		ids[i] = disputes[i].ID
+	}
+
+	evidence, err := s.disputeRepo.CountEvidence(ids)
+	if err != nil {
+		return nil, err
+	}
+	jurors, err := s.disputeRepo.CountActiveJurors(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	public := make([]PublicDispute, len(disputes))
+	for i, d := range disputes {
+		// Disputes filed before categories were restricted may have any
+		// text here.
+		category, _ := disputeCategory(d.Category)
+
+		public[i] = PublicDispute{
+			ID:               d.ID,
+			OnChainID:        d.OnChainID,
+			Category:         category,
+			AmountBand:       amountBand(d.Project.Budget),
+			Currency:         d.Project.Currency,
+			Status:           d.Status,
+			EvidenceCount:    evidence[d.ID],
+			JurorCount:       jurors[d.ID],
+			TotalVotes:       d.TotalVotes,
+			ClientVotes:      d.ClientVotes,
+			FreelancerVotes:  d.FreelancerVotes,
+			ClientWeight:     d.ClientWeight,
+			FreelancerWeight: d.FreelancerWeight,
+			OpenedAt:         d.CreatedAt,
+			VotingEndsAt:     d.VotingEndsAt,
+			ResolvedAt:       d.ResolvedAt,
+			UpdatedAt:        d.UpdatedAt,
+		}
+
+		if d.Status == models.DisputeStatusResolved {
+			split := d.FreelancerSplit
+			public[i].FreelancerSplit = &split
+			public[i].Outcome = disputeOutcome(split)
+		}
+	}
+
+	return public, nil
+}
+
+// disputeCategory normalizes a category, mapping an empty one to other.
+// Unknown categories are also mapped to other and reported as not ok.
+func disputeCategory(category string) (string, bool) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return models.DisputeCategoryOther, true
+	}
+	for _, known := range models.DisputeCategories {
+		if category == known {
+			return category, true
+		}
+	}
+	return models.DisputeCategoryOther, false
+}
+
+func amountBand(budget float64) string {
+	for _, band := range disputeAmountBands {
+		if budget >= band.Min {
+			return band.Label
+		}
+	}
+	return disputeAmountBands[len(disputeAmountBands)-1].Label
+}
+
+func disputeOutcome(freelancerSplit int) string {
+	switch {
+	case freelancerSplit > 50:
+		return models.VoteForFreelancer
+	case freelancerSplit < 50:
+		return models.VoteForClient
+	default:
+		return "split"
+	}
+}
+
+// Syndication
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Category    string  `xml:"category"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title    string       `xml:"title"`
+	ID       string       `xml:"id"`
+	Updated  string       `xml:"updated"`
+	Link     atomLink     `xml:"link"`
+	Category atomCategory `xml:"category"`
+	Summary  string       `xml:"summary"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// RSS renders the latest disputes as an RSS 2.0 feed.
+func (s *DisputeFeedService) RSS() ([]byte, error) {
+	disputes, _, err := s.List("", "", feedItemLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         "Fariima disputes",
+			Link:          s.cfg.AppBaseURL + "/disputes",
+			Description:   "Public record of disputes and juror decisions on Fariima",
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for i := range disputes {
+		d := &disputes[i]
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       feedTitle(d),
+			Link:        s.disputeURL(d),
+			Description: feedSummary(d),
+			Category:    d.Category,
+			GUID:        rssGUID{Value: "urn:uuid:" + d.ID.String()},
+			PubDate:     d.UpdatedAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshalFeed(feed)
+}
+
+// Atom renders the latest disputes as an Atom feed.
+func (s *DisputeFeedService) Atom() ([]byte, error) {
+	disputes, _, err := s.List("", "", feedItemLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := time.Now().UTC()
+	if len(disputes) > 0 {
+		updated = disputes[0].UpdatedAt.UTC()
+	}
+
+	feed := atomFeed{
+		Title:   "Fariima disputes",
+		ID:      s.cfg.PublicAPIURL + "/api/v1/public/disputes/feed.atom",
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: s.cfg.PublicAPIURL + "/api/v1/public/disputes/feed.atom", Rel: "self"},
+			{Href: s.cfg.AppBaseURL + "/disputes"},
+		},
+	}
+	for i := range disputes {
+		d := &disputes[i]
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:    feedTitle(d),
+			ID:       "urn:uuid:" + d.ID.String(),
+			Updated:  d.UpdatedAt.UTC().Format(time.RFC3339),
+			Link:     atomLink{Href: s.disputeURL(d)},
+			Category: atomCategory{Term: d.Category},
+			Summary:  feedSummary(d),
+		})
+	}
+
+	return marshalFeed(feed)
+}
+
+func (s *DisputeFeedService) disputeURL(d *PublicDispute) string {
+	return s.cfg.AppBaseURL + "/disputes/" + d.ID.String()
+}
+
+func marshalFeed(feed interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func feedTitle(d *PublicDispute) string {
+	name := "Dispute"
+	if d.OnChainID != nil {
+		name = fmt.Sprintf("Dispute #%d", *d.OnChainID)
+	}
+
+	switch {
+	case d.Outcome == "split":
+		return fmt.Sprintf("%s (%s): resolved with an even split", name, d.Category)
+	case d.Outcome != "":
+		return fmt.Sprintf("%s (%s): resolved for the %s, %d%% to the freelancer", name, d.Category, d.Outcome, *d.FreelancerSplit)
+	default:
+		return fmt.Sprintf("%s (%s): %s", name, d.Category, strings.ReplaceAll(string(d.Status), "_", " "))
+	}
+}
+
+func feedSummary(d *PublicDispute) string {
+	summary := fmt.Sprintf("Amount: %s %s. Evidence items: %d. Jurors: %d. Votes: %d for the client, %d for the freelancer. Opened %s.",
+		d.AmountBand, d.Currency, d.EvidenceCount, d.JurorCount, d.ClientVotes, d.FreelancerVotes,
+		d.OpenedAt.UTC().Format(time.RFC1123))
+	if d.ResolvedAt != nil {
+		summary += fmt.Sprintf(" Resolved %s.", d.ResolvedAt.UTC().Format(time.RFC1123))
+	} else if d.VotingEndsAt != nil {
+		summary += fmt.Sprintf(" Voting ends %s.", d.VotingEndsAt.UTC().Format(time.RFC1123))
+	}
+	return summary
+}
@@ -1,62 +1,734 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/repositories"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+// disputeDeadlineWarnings are the reminders sent before voting closes, latest
+// first. The index+1 is the reminder level stored on the dispute.
+var disputeDeadlineWarnings = []time.Duration{24 * time.Hour, time.Hour}
+
+// MaxEvidenceSize caps a single evidence file.
+const MaxEvidenceSize = 20 << 20 // 20 MB
+
 type DisputeService struct {
-	disputeRepo       *repositories.DisputeRepository
-	blockchainService *BlockchainService
-	logger            *logrus.Logger
+	disputeRepo         *repositories.DisputeRepository
+	userRepo            *repositories.UserRepository
+	jurorRepo           *repositories.JurorRepository
+	projectRepo         *repositories.ProjectRepository
+	escrowRepo          *repositories.EscrowRepository
+	blockchainService   *BlockchainService
+	uploadService       *UploadService
+	privateFileService  *PrivateFileService
+	notificationService *NotificationService
+	feedService         *DisputeFeedService
+	logger              *logrus.Logger
 }
 
 func NewDisputeService(
 	disputeRepo *repositories.DisputeRepository,
+	userRepo *repositories.UserRepository,
+	jurorRepo *repositories.JurorRepository,
+	projectRepo *repositories.ProjectRepository,
+	escrowRepo *repositories.EscrowRepository,
 	blockchainService *BlockchainService,
+	uploadService *UploadService,
+	privateFileService *PrivateFileService,
+	notificationService *NotificationService,
+	feedService *DisputeFeedService,
 	logger *logrus.Logger,
 ) *DisputeService {
 	return &DisputeService{
-		disputeRepo:       disputeRepo,
-		blockchainService: blockchainService,
-		logger:            logger,
+		disputeRepo:         disputeRepo,
+		userRepo:            userRepo,
+		jurorRepo:           jurorRepo,
+		projectRepo:         projectRepo,
+		escrowRepo:          escrowRepo,
+		blockchainService:   blockchainService,
+		uploadService:       uploadService,
+		privateFileService:  privateFileService,
+		notificationService: notificationService,
+		feedService:         feedService,
+		logger:              logger,
 	}
 }
 
-func (s *DisputeService) CreateDispute(dispute *models.Dispute) error {
-	dispute.Status = models.DisputeStatusOpen
-	return s.disputeRepo.Create(dispute)
+// CreateDisputeInput is what a party may set when opening a dispute.
+type CreateDisputeInput struct {
+	ProjectID   uuid.UUID
+	EscrowID    *uuid.UUID // Optional; must match the project's escrow
+	Title       string
+	Description string
+	Category    string
+}
+
+// EvidenceUpload is an evidence file received before it is pinned to IPFS.
+type EvidenceUpload struct {
+	Title       string
+	Description string
+	FileName    string
+	Content     io.Reader
+}
+
+// CreateDispute opens a dispute on a funded escrow. Only the project's client
+// or freelancer may do so, and only one dispute per escrow can be active. The
+// dispute is linked to its on-chain counterpart when the indexer sees the
+// escrow's DisputeInitiated event.
+func (s *DisputeService) CreateDispute(userID uuid.UUID, input CreateDisputeInput) (*models.Dispute, error) {
+	project, err := s.projectRepo.GetByID(input.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: project not found", ErrNotFound)
+	}
+
+	if !isProjectParty(project, userID) {
+		return nil, fmt.Errorf("%w: only the project's client or freelancer can open a dispute", ErrForbidden)
+	}
+
+	escrow, err := s.escrowRepo.GetByProjectID(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: project has no escrow", ErrInvalidInput)
+	}
+	if input.EscrowID != nil && *input.EscrowID != escrow.ID {
+		return nil, fmt.Errorf("%w: escrow does not belong to the project", ErrInvalidInput)
+	}
+	if escrow.Status != models.EscrowStatusFunded {
+		return nil, fmt.Errorf("%w: disputes can only be opened while the escrow is funded", ErrConflict)
+	}
+
+	active, err := s.disputeRepo.HasActive(escrow.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, fmt.Errorf("%w: the escrow already has an open dispute", ErrConflict)
+	}
+
+	category, ok := disputeCategory(input.Category)
+	if !ok {
+		return nil, fmt.Errorf("%w: category must be one of %s", ErrInvalidInput, strings.Join(models.DisputeCategories, ", "))
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	dispute := &models.Dispute{
+		ProjectID:     project.ID,
+		EscrowID:      escrow.ID,
+		InitiatorAddr: user.Address,
+		Title:         input.Title,
+		Description:   input.Description,
+		Category:      category,
+		Status:        models.DisputeStatusOpen,
+	}
+
+	if err := s.disputeRepo.Create(dispute); err != nil {
+		return nil, err
+	}
+
+	created, err := s.disputeRepo.GetByID(dispute.ID)
+	if err != nil {
+		return dispute, nil
+	}
+
+	s.notifyParties(created, NotificationEvent{
+		Type:  models.NotificationDisputeOpened,
+		Title: "Dispute opened",
+		Body:  fmt.Sprintf("A dispute was opened on %q: %s", created.Project.Title, created.Title),
+		Data: map[string]interface{}{
+			"project_id": created.ProjectID,
+			"dispute_id": created.ID,
+		},
+	})
+	s.feedService.Publish(created.ID, DisputeFeedOpened)
+
+	return created, nil
 }
 
 func (s *DisputeService) GetDispute(id uuid.UUID) (*models.Dispute, error) {
 	return s.disputeRepo.GetByID(id)
 }
 
-func (s *DisputeService) ListDisputes(status string, limit, offset int) ([]models.Dispute, int64, error) {
-	return s.disputeRepo.List(status, limit, offset)
+func (s *DisputeService) ListDisputes(filter repositories.DisputeFilter, limit, offset int) ([]models.Dispute, int64, error) {
+	return s.disputeRepo.List(filter, limit, offset)
 }
 
-func (s *DisputeService) SubmitEvidence(evidence *models.Evidence) error {
-	return s.disputeRepo.CreateEvidence(evidence)
+// FinalizePayload returns the DAO finalizeDispute call for a dispute whose
+// voting period has ended. Anyone may submit it.
+func (s *DisputeService) FinalizePayload(id uuid.UUID) (*TransactionPayload, error) {
+	dispute, err := s.disputeRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if dispute.Status != models.DisputeStatusAwaitingFinalization {
+		return nil, fmt.Errorf("%w: dispute is %s", ErrConflict, dispute.Status)
+	}
+
+	return s.finalizePayload(dispute)
 }
 
-func (s *DisputeService) GetEvidence(disputeID uuid.UUID) ([]models.Evidence, error) {
-	return s.disputeRepo.GetEvidenceByDisputeID(disputeID)
+func (s *DisputeService) finalizePayload(dispute *models.Dispute) (*TransactionPayload, error) {
+	if dispute.OnChainID == nil {
+		return nil, fmt.Errorf("%w: dispute has not been initiated on-chain", ErrConflict)
+	}
+	return s.blockchainService.BuildContractCall("dao", daoABI, "finalizeDispute", big.NewInt(*dispute.OnChainID))
 }
 
-func (s *DisputeService) CreateVote(vote *models.Vote) error {
-	// Check if user already voted
-	hasVoted, err := s.disputeRepo.HasUserVoted(vote.DisputeID, vote.VoterID)
+// ProcessDeadlines is the JobDisputeDeadlines handler. It moves disputes
+// through open -> voting -> awaiting_finalization as their voting windows
+// open and close, and reminds parties and outstanding jurors as the deadline
+// approaches.
+func (s *DisputeService) ProcessDeadlines(ctx context.Context, job *models.Job) error {
+	now := time.Now()
+
+	if started, err := s.disputeRepo.StartVoting(now); err != nil {
+		return err
+	} else if started > 0 {
+		s.logger.Infof("Opened voting on %d disputes", started)
+	}
+
+	ended, err := s.disputeRepo.GetVotingEnded(now)
 	if err != nil {
 		return err
 	}
+
+	for i := range ended {
+		dispute := &ended[i]
+
+		moved, err := s.disputeRepo.TransitionStatus(dispute.ID,
+			[]models.DisputeStatus{models.DisputeStatusOpen, models.DisputeStatusVoting},
+			models.DisputeStatusAwaitingFinalization)
+		if err != nil {
+			return err
+		}
+		if !moved {
+			continue
+		}
+
+		s.logger.Infof("Dispute %s voting ended, awaiting finalization", dispute.ID)
+		s.feedService.Publish(dispute.ID, DisputeFeedVotingEnded)
+
+		data := map[string]interface{}{
+			"project_id": dispute.ProjectID,
+			"dispute_id": dispute.ID,
+		}
+		if payload, err := s.finalizePayload(dispute); err != nil {
+			s.logger.Errorf("Failed to build finalize payload for dispute %s: %v", dispute.ID, err)
+		} else {
+			data["transaction"] = payload
+		}
+
+		s.notifyParties(dispute, NotificationEvent{
+			Type:  models.NotificationDisputeVotingEnded,
+			Title: "Dispute voting ended",
+			Body:  fmt.Sprintf("Voting on %s has closed and the dispute can now be finalized.", dispute.Title),
+			Data:  data,
+		})
+	}
+
+	// Latest reminder first, so a dispute that is already close to its
+	// deadline gets one reminder rather than both.
+	for i := len(disputeDeadlineWarnings) - 1; i >= 0; i-- {
+		if err := s.sendDeadlineWarnings(now, disputeDeadlineWarnings[i], i+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *DisputeService) sendDeadlineWarnings(now time.Time, within time.Duration, level int) error {
+	disputes, err := s.disputeRepo.GetDeadlineApproaching(now, now.Add(within), level)
+	if err != nil {
+		return err
+	}
+
+	for i := range disputes {
+		dispute := &disputes[i]
+
+		marked, err := s.disputeRepo.MarkDeadlineWarning(dispute.ID, level)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			continue
+		}
+
+		remaining := dispute.VotingEndsAt.Sub(now).Round(time.Minute)
+		s.notifyDispute(dispute, NotificationEvent{
+			Type:  models.NotificationDisputeDeadline,
+			Title: "Dispute voting closes soon",
+			Body:  fmt.Sprintf("Voting on %s closes in %s.", dispute.Title, remaining),
+			Data: map[string]interface{}{
+				"project_id":     dispute.ProjectID,
+				"dispute_id":     dispute.ID,
+				"voting_ends_at": dispute.VotingEndsAt,
+			},
+		})
+	}
+
+	return nil
+}
+
+func (s *DisputeService) notifyParties(dispute *models.Dispute, event NotificationEvent) {
+	s.notificationService.Notify(dispute.Project.ClientID, event)
+	if dispute.Project.FreelancerID != nil {
+		s.notificationService.Notify(*dispute.Project.FreelancerID, event)
+	}
+}
+
+// notifyDispute sends the event to both parties and every juror who has not
+// voted on the dispute yet.
+func (s *DisputeService) notifyDispute(dispute *models.Dispute, event NotificationEvent) {
+	s.notifyParties(dispute, event)
+
+	jurorIDs, err := s.disputeRepo.GetPendingJurorIDs(dispute)
+	if err != nil {
+		s.logger.Errorf("Failed to load jurors for dispute %s: %v", dispute.ID, err)
+		return
+	}
+	s.notificationService.NotifyMany(jurorIDs, event)
+}
+
+// SubmitEvidence stores the file as an evidence upload, which checks its
+// type and the submitter's quota, screens it and encrypts it, and records
+// its SHA-256 so the content can be verified whenever it is read back. Only
+// the parties may submit evidence, and only until voting starts. The
+// parties and the jurors who accepted can read it. Files the malware scanner
+// flags are recorded as quarantined evidence, unreadable until a moderator
+// releases the upload.
+func (s *DisputeService) SubmitEvidence(disputeID, userID uuid.UUID, input EvidenceUpload) (*models.Evidence, error) {
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if !isProjectParty(&dispute.Project, userID) {
+		return nil, fmt.Errorf("%w: only parties to the dispute can submit evidence", ErrForbidden)
+	}
+
+	if dispute.Status != models.DisputeStatusOpen || dispute.VotingEndsAt != nil {
+		return nil, fmt.Errorf("%w: evidence is locked once voting starts", ErrConflict)
+	}
+
+	upload, err := s.uploadService.Upload(context.Background(), userID, UploadInput{
+		Purpose:   models.UploadPurposeEvidence,
+		FileName:  input.FileName,
+		ProjectID: &dispute.ProjectID,
+		DisputeID: &dispute.ID,
+	}, input.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence := &models.Evidence{
+		DisputeID:   dispute.ID,
+		SubmitterID: userID,
+		Title:       input.Title,
+		Description: input.Description,
+		FileType:    evidenceFileType(upload.MimeType),
+		FileName:    upload.FileName,
+		MimeType:    upload.MimeType,
+		FileSize:    upload.Size,
+		IPFSHash:    upload.CID,
+		FileURL:     s.privateFileService.FileURL(upload.CID),
+		ContentHash: upload.ContentHash,
+		UploadID:    &upload.ID,
+		Quarantined: upload.ScanStatus == models.ScanStatusQuarantined,
+	}
+
+	if err := s.disputeRepo.CreateEvidence(evidence); err != nil {
+		return nil, err
+	}
+
+	return evidence, nil
+}
+
+// GetEvidence lists a dispute's evidence to the people who can read it: the
+// project's client and freelancer and the jurors who accepted the dispute.
+func (s *DisputeService) GetEvidence(disputeID, userID uuid.UUID) ([]models.Evidence, error) {
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if !isProjectParty(&dispute.Project, userID) {
+		assignment, err := s.jurorRepo.GetAssignment(dispute.ID, userID)
+		if err != nil || assignment.Status != models.JurorAssignmentAccepted {
+			return nil, fmt.Errorf("%w: only the parties and the dispute's jurors can see its evidence", ErrForbidden)
+		}
+	}
+
+	return s.disputeRepo.GetEvidenceByDisputeID(dispute.ID)
+}
+
+// GetEvidenceContent fetches an evidence file from IPFS and checks it against
+// the SHA-256 recorded at submission. Encrypted evidence is only served to
+// users with access to it.
+func (s *DisputeService) GetEvidenceContent(disputeID, evidenceID, userID uuid.UUID) (*models.Evidence, []byte, error) {
+	evidence, err := s.disputeRepo.GetEvidenceByID(disputeID, evidenceID)
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+
+	file, err := s.privateFileService.Open(context.Background(), userID, evidence.IPFSHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, MaxEvidenceSize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(content) > MaxEvidenceSize {
+		return nil, nil, fmt.Errorf("evidence %s exceeds %d bytes", evidence.ID, MaxEvidenceSize)
+	}
+
+	if evidence.ContentHash != "" {
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != evidence.ContentHash {
+			s.logger.Errorf("Evidence %s failed integrity check (ipfs %s)", evidence.ID, evidence.IPFSHash)
+			return nil, nil, fmt.Errorf("%w: evidence %s does not match its recorded hash", ErrIntegrity, evidence.ID)
+		}
+	}
+
+	return evidence, content, nil
+}
+
+func evidenceFileType(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "image"
+	case strings.HasPrefix(mimeType, "video/"):
+		return "video"
+	default:
+		return "document"
+	}
+}
+
+// JurorEligibility explains whether a user may vote on a dispute.
+type JurorEligibility struct {
+	Eligible     bool   `json:"eligible"`
+	Reason       string `json:"reason,omitempty"`
+	Address      string `json:"address"`
+	StakedAmount string `json:"staked_amount"`
+	MinStake     string `json:"min_stake"`
+	VotingPower  string `json:"voting_power"`
+	HasVoted     bool   `json:"has_voted"`
+	// Assignment is the caller's juror assignment status, empty if not drawn.
+	Assignment models.JurorAssignmentStatus `json:"assignment,omitempty"`
+}
+
+// CheckEligibility reports whether the user can vote on the dispute now,
+// using their current FARI stake.
+func (s *DisputeService) CheckEligibility(disputeID, userID uuid.UUID) (*JurorEligibility, error) {
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	result := &JurorEligibility{
+		Address:      user.Address,
+		StakedAmount: "0",
+		MinStake:     MinJurorStake.String(),
+		VotingPower:  "0",
+	}
+
+	if result.HasVoted, err = s.disputeRepo.HasUserVoted(dispute.ID, user.ID); err != nil {
+		return nil, err
+	}
+
+	if assignment, err := s.jurorRepo.GetAssignment(dispute.ID, user.ID); err == nil {
+		result.Assignment = assignment.Status
+	}
+
+	staked := new(big.Int)
+	if user.Address != "" {
+		var power *big.Int
+		if staked, power, err = s.blockchainService.GetFARIStake(common.HexToAddress(user.Address), nil); err != nil {
+			return nil, err
+		}
+		result.StakedAmount = staked.String()
+		result.VotingPower = power.String()
+	}
+
+	switch {
+	case isDisputeParty(dispute, user):
+		result.Reason = "Parties to a dispute cannot vote on it"
+	case user.Address == "":
+		result.Reason = "No wallet address linked to your account"
+	case result.HasVoted:
+		result.Reason = "You have already voted on this dispute"
+	case result.Assignment == "" || result.Assignment == models.JurorAssignmentDeclined:
+		result.Reason = "You are not an assigned juror on this dispute"
+	case dispute.Status != models.DisputeStatusVoting:
+		result.Reason = fmt.Sprintf("Dispute is %s", dispute.Status)
+	case staked.Cmp(MinJurorStake) < 0:
+		result.Reason = "At least 5,000 FARI must be staked to serve as a juror"
+	default:
+		result.Eligible = true
+	}
+
+	return result, nil
+}
+
+// CastVote records a vote the user already submitted to the DAO. The vote,
+// side and weight are read from the DisputeVoted event in the transaction
+// receipt rather than trusted from the client.
+func (s *DisputeService) CastVote(disputeID, userID uuid.UUID, txHash string) (*models.Vote, error) {
+	if hash, err := hexutil.Decode(txHash); err != nil || len(hash) != common.HashLength {
+		return nil, fmt.Errorf("%w: invalid transaction hash", ErrInvalidInput)
+	}
+
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if isDisputeParty(dispute, user) {
+		return nil, fmt.Errorf("%w: parties to a dispute cannot vote on it", ErrForbidden)
+	}
+
+	hasVoted, err := s.disputeRepo.HasUserVoted(dispute.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
 	if hasVoted {
+		return nil, fmt.Errorf("%w: you have already voted on this dispute", ErrConflict)
+	}
+
+	assignment, err := s.activeAssignment(dispute.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.blockchainService.GetTransactionReceipt(common.HexToHash(txHash))
+	if err != nil {
+		return nil, fmt.Errorf("%w: transaction not found or not yet mined", ErrInvalidInput)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w: transaction reverted", ErrInvalidInput)
+	}
+
+	daoAddr, err := s.blockchainService.GetContractAddress("dao")
+	if err != nil {
+		return nil, err
+	}
+
+	if dispute.OnChainID == nil {
+		return nil, fmt.Errorf("%w: dispute has not been initiated on-chain", ErrConflict)
+	}
+
+	for _, log := range receipt.Logs {
+		if log.Address != daoAddr {
+			continue
+		}
+
+		event, err := decodeDisputeVoted(*log)
+		if err != nil || event == nil {
+			continue
+		}
+		if event.DisputeID != *dispute.OnChainID || !strings.EqualFold(event.Juror.Hex(), user.Address) {
+			continue
+		}
+
+		return s.recordVote(dispute, user, user.Address, assignment, event, log.BlockNumber, log.TxHash.Hex())
+	}
+
+	return nil, fmt.Errorf("%w: transaction contains no vote by %s on dispute #%d",
+		ErrInvalidInput, user.Address, *dispute.OnChainID)
+}
+
+// RecordChainVote indexes a DisputeVoted log. The DAO counts every vote from
+// a staked wallet, so every one is recorded to keep the tallies in step with
+// finalizeDispute. Votes from wallets without an account are stored by
+// address, and votes from anyone without an active juror assignment,
+// including dispute parties, are marked unassigned.
+func (s *DisputeService) RecordChainVote(log types.Log) error {
+	event, err := decodeDisputeVoted(log)
+	if err != nil || event == nil {
+		return err
+	}
+
+	dispute, err := s.disputeRepo.GetByOnChainID(event.DisputeID)
+	if err != nil {
 		return err
 	}
 
-	return s.disputeRepo.CreateVote(vote)
+	address := strings.ToLower(event.Juror.Hex())
+	hasVoted, err := s.disputeRepo.HasAddressVoted(dispute.ID, address)
+	if err != nil || hasVoted {
+		return err
+	}
+
+	user, err := s.userRepo.GetByAddress(address)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user = nil
+	} else if err != nil {
+		return err
+	}
+
+	var assignment *models.JurorAssignment
+	if user != nil {
+		if isDisputeParty(dispute, user) {
+			s.logger.Warnf("Dispute %d: party %s voted on their own dispute", event.DisputeID, event.Juror.Hex())
+		} else {
+			assignment, _ = s.activeAssignment(dispute.ID, user.ID)
+		}
+	}
+	if assignment == nil {
+		s.logger.Warnf("Dispute %d: recording unassigned vote from %s", event.DisputeID, event.Juror.Hex())
+	}
+
+	_, err = s.recordVote(dispute, user, address, assignment, event, log.BlockNumber, log.TxHash.Hex())
+	if errors.Is(err, ErrConflict) {
+		return nil // Recorded concurrently through the API
+	}
+	if errors.Is(err, ErrForbidden) {
+		s.logger.Warnf("Dispute %d: ignoring vote from %s: %v", event.DisputeID, event.Juror.Hex(), err)
+		return nil
+	}
+	return err
+}
+
+// recordVote stores a DisputeVoted vote from address. user is nil when the
+// wallet has no account, and assignment is nil when the voter holds no
+// active juror assignment.
+func (s *DisputeService) recordVote(dispute *models.Dispute, user *models.User, address string, assignment *models.JurorAssignment, event *disputeVotedEvent, blockNumber uint64, txHash string) (*models.Vote, error) {
+	var voterID *uuid.UUID
+	if user != nil {
+		voterID = &user.ID
+	}
+
+	weight := event.VotingPower
+
+	staked, power, err := s.blockchainService.GetFARIStake(common.HexToAddress(address), new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		// Historical calls need an archive node. The DAO enforced
+		// MIN_JUROR_STAKE when it accepted the vote and the event carries
+		// getVotingPower from that moment, so fall back to it.
+		s.logger.Warnf("Dispute %s: stake lookup at block %d failed, using event voting power: %v",
+			dispute.ID, blockNumber, err)
+	} else {
+		if staked.Cmp(MinJurorStake) < 0 {
+			return nil, fmt.Errorf("%w: juror stake below the 5,000 FARI minimum", ErrForbidden)
+		}
+		weight = power
+	}
+
+	voteFor := models.VoteForClient
+	if event.VoteForFreelancer {
+		voteFor = models.VoteForFreelancer
+	}
+
+	vote := &models.Vote{
+		DisputeID:   dispute.ID,
+		VoterID:     voterID,
+		VoterAddr:   strings.ToLower(address),
+		Unassigned:  assignment == nil,
+		VoteFor:     voteFor,
+		Weight:      weight.String(),
+		TxHash:      txHash,
+		BlockNumber: blockNumber,
+	}
+
+	if err := s.disputeRepo.RecordVote(vote); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fmt.Errorf("%w: vote already recorded", ErrConflict)
+		}
+		return nil, err
+	}
+	s.feedService.Publish(dispute.ID, DisputeFeedVoted)
+
+	// Voting implies accepting the assignment.
+	if assignment != nil && assignment.Status == models.JurorAssignmentPending {
+		if _, err := s.jurorRepo.RespondToAssignment(assignment.ID, models.JurorAssignmentAccepted); err != nil {
+			s.logger.Errorf("Failed to accept juror assignment %s: %v", assignment.ID, err)
+		}
+	}
+
+	return vote, nil
+}
+
+// activeAssignment returns the user's pending or accepted juror assignment
+// on the dispute.
+func (s *DisputeService) activeAssignment(disputeID, userID uuid.UUID) (*models.JurorAssignment, error) {
+	assignment, err := s.jurorRepo.GetAssignment(disputeID, userID)
+	if err != nil || assignment.Status == models.JurorAssignmentDeclined {
+		return nil, fmt.Errorf("%w: you are not an assigned juror on this dispute", ErrForbidden)
+	}
+	return assignment, nil
+}
+
+func isDisputeParty(dispute *models.Dispute, user *models.User) bool {
+	return isProjectParty(&dispute.Project, user.ID)
+}
+
+func isProjectParty(project *models.Project, userID uuid.UUID) bool {
+	if userID == project.ClientID {
+		return true
+	}
+	return project.FreelancerID != nil && userID == *project.FreelancerID
+}
+
+type disputeVotedEvent struct {
+	DisputeID         int64
+	Juror             common.Address
+	VoteForFreelancer bool
+	VotingPower       *big.Int
+}
+
+// decodeDisputeVoted returns nil if the log is not a DisputeVoted event.
+func decodeDisputeVoted(log types.Log) (*disputeVotedEvent, error) {
+	// DisputeVoted(uint256 indexed disputeId, address indexed juror, bool voteForFreelancer, uint256 votingPower)
+	if len(log.Topics) < 3 || log.Topics[0] != daoABI.Events["DisputeVoted"].ID {
+		return nil, nil
+	}
+
+	var data struct {
+		VoteForFreelancer bool
+		VotingPower       *big.Int
+	}
+	if err := daoABI.UnpackIntoInterface(&data, "DisputeVoted", log.Data); err != nil {
+		return nil, err
+	}
+
+	return &disputeVotedEvent{
+		DisputeID:         new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64(),
+		Juror:             common.BytesToAddress(log.Topics[2].Bytes()),
+		VoteForFreelancer: data.VoteForFreelancer,
+		VotingPower:       data.VotingPower,
+	}, nil
 }
 
 func (s *DisputeService) GetVotes(disputeID uuid.UUID) ([]models.Vote, error) {
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/mailer"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/hkdf"
+	"gorm.io/gorm"
+)
+
+// unsubscribeKeyInfo labels the key that signs unsubscribe links, derived
+// from JWT_SECRET so that neither key can be used in place of the other.
+const unsubscribeKeyInfo = "fariima email unsubscribe v1"
+
+const (
+	emailBatchSize      = 20
+	emailLease          = 5 * time.Minute
+	emailRetryBase      = time.Minute
+	emailTokenTTL       = 24 * time.Hour
+	suppressBounce      = "bounce"
+	suppressComplaint   = "complaint"
+	suppressUnsubscribe = "unsubscribe"
+)
+
+// EmailService renders templated email into a Postgres-backed outbox and a
+// background worker drains it through the configured Mailer with retries.
+type EmailService struct {
+	cfg            *config.Config
+	mailer         mailer.Mailer
+	renderer       *mailer.Renderer
+	emailRepo      *repositories.EmailRepository
+	userRepo       *repositories.UserRepository
+	unsubscribeKey []byte
+	logger         *logrus.Logger
+}
+
+func NewEmailService(
+	cfg *config.Config,
+	m mailer.Mailer,
+	renderer *mailer.Renderer,
+	emailRepo *repositories.EmailRepository,
+	userRepo *repositories.UserRepository,
+	logger *logrus.Logger,
+) *EmailService {
+	return &EmailService{
+		cfg:            cfg,
+		mailer:         m,
+		renderer:       renderer,
+		emailRepo:      emailRepo,
+		userRepo:       userRepo,
+		unsubscribeKey: deriveKey(cfg.JWTSecret, unsubscribeKeyInfo),
+		logger:         logger,
+	}
+}
+
+// deriveKey derives a 32-byte key for one purpose from a secret with HKDF.
+func deriveKey(secret, info string) []byte {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(info)), key); err != nil {
+		panic(err) // Only fails past 255 blocks of output
+	}
+	return key
+}
+
+// Enqueue renders a template for the user and stores it in the outbox.
+// Suppressed addresses are recorded but never sent.
+func (s *EmailService) Enqueue(user *models.User, template string, category models.EmailCategory, data map[string]interface{}) error {
+	if user.Email == nil {
+		return fmt.Errorf("%w: user has no email address", ErrInvalidInput)
+	}
+	email := *user.Email
+
+	locale := mailer.NormalizeLocale(user.Locale)
+
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	if _, ok := data["Name"]; !ok {
+		data["Name"] = displayName(user)
+	}
+	if _, ok := data["UnsubscribeURL"]; !ok {
+		data["UnsubscribeURL"] = ""
+		if category == models.EmailCategoryNotification {
+			data["UnsubscribeURL"] = s.UnsubscribeURL(email)
+		}
+	}
+
+	subject, html, text, err := s.renderer.Render(template, locale, data)
+	if err != nil {
+		return err
+	}
+
+	message := &models.EmailMessage{
+		UserID:        &user.ID,
+		To:            email,
+		Template:      template,
+		Locale:        locale,
+		Category:      category,
+		Subject:       subject,
+		HTML:          html,
+		Text:          text,
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+
+	if s.isSuppressed(email, category) {
+		message.Status = models.EmailStatusSuppressed
+	}
+
+	return s.emailRepo.CreateMessage(message)
+}
+
+// ProcessOutbox sends due messages in batches until the outbox is drained.
+// It runs as the JobProcessEmails job every few seconds.
+func (s *EmailService) ProcessOutbox(ctx context.Context, job *models.Job) error {
+	for ctx.Err() == nil {
+		messages, err := s.emailRepo.ClaimDue(emailBatchSize, emailLease)
+		if err != nil {
+			return err
+		}
+
+		for i := range messages {
+			s.deliver(ctx, &messages[i])
+		}
+
+		if len(messages) < emailBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *EmailService) deliver(ctx context.Context, message *models.EmailMessage) {
+	if s.isSuppressed(message.To, message.Category) {
+		message.Status = models.EmailStatusSuppressed
+		s.saveMessage(message)
+		return
+	}
+
+	headers := map[string]string{}
+	if message.Category == models.EmailCategoryNotification {
+		headers["List-Unsubscribe"] = "<" + s.UnsubscribeURL(message.To) + ">"
+		headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	err := s.mailer.Send(sendCtx, &mailer.Message{
+		From:    s.cfg.MailFrom,
+		To:      message.To,
+		Subject: message.Subject,
+		HTML:    message.HTML,
+		Text:    message.Text,
+		Headers: headers,
+	})
+
+	if err == nil {
+		now := time.Now()
+		message.Status = models.EmailStatusSent
+		message.SentAt = &now
+		message.LastError = ""
+		s.saveMessage(message)
+		return
+	}
+
+	message.LastError = err.Error()
+	if message.Attempts >= s.cfg.MailMaxAttempts {
+		message.Status = models.EmailStatusFailed
+		s.logger.Errorf("Giving up on email %s to %s after %d attempts: %v",
+			message.ID, message.To, message.Attempts, err)
+	} else {
+		// Exponential backoff: 1m, 2m, 4m, ...
+		message.NextAttemptAt = time.Now().Add(emailRetryBase << (message.Attempts - 1))
+		s.logger.Warnf("Email %s to %s failed (attempt %d), retrying at %s: %v",
+			message.ID, message.To, message.Attempts, message.NextAttemptAt.Format(time.RFC3339), err)
+	}
+	s.saveMessage(message)
+}
+
+func (s *EmailService) saveMessage(message *models.EmailMessage) {
+	if err := s.emailRepo.UpdateMessage(message); err != nil {
+		s.logger.Errorf("Failed to update email %s: %v", message.ID, err)
+	}
+}
+
+func (s *EmailService) isSuppressed(email string, category models.EmailCategory) bool {
+	suppression, err := s.emailRepo.GetSuppression(email)
+	if err != nil {
+		return false
+	}
+	if suppression.Reason == suppressUnsubscribe {
+		return category == models.EmailCategoryNotification
+	}
+	return true
+}
+
+// Verification
+
+// ChangeEmail sets the user's address, unverified, and sends a verification
+// link to it. Setting the current address again resends the link.
+func (s *EmailService) ChangeEmail(userID uuid.UUID, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	if user.Email == nil || *user.Email != email {
+		existing, err := s.userRepo.GetByEmail(email)
+		if err == nil && existing.ID != user.ID {
+			return fmt.Errorf("%w: email address is used by another account", ErrConflict)
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		user.Email = &email
+		user.EmailVerifiedAt = nil
+		if err := s.userRepo.Update(user); err != nil {
+			return err
+		}
+	}
+
+	return s.sendVerification(user)
+}
+
+func (s *EmailService) SendVerification(userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return ErrNotFound
+	}
+	return s.sendVerification(user)
+}
+
+func (s *EmailService) sendVerification(user *models.User) error {
+	if user.Email == nil {
+		return fmt.Errorf("%w: add an email address first", ErrInvalidInput)
+	}
+	if user.EmailVerifiedAt != nil {
+		return fmt.Errorf("%w: email already verified", ErrConflict)
+	}
+
+	token, err := s.issueToken(user, models.EmailTokenVerifyEmail, emailTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.Enqueue(user, mailer.TemplateVerifyEmail, models.EmailCategoryAccount, map[string]interface{}{
+		"URL":            s.cfg.AppBaseURL + "/verify-email?token=" + token,
+		"ExpiresInHours": int(emailTokenTTL.Hours()),
+	})
+}
+
+// VerifyEmail marks the user's address verified. Links sent to an address
+// the user has since replaced are refused.
+func (s *EmailService) VerifyEmail(token string) (*models.User, error) {
+	emailToken, err := s.consumeToken(token, models.EmailTokenVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(emailToken.UserID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if user.Email == nil || *user.Email != emailToken.Email {
+		return nil, fmt.Errorf("%w: the email address was changed after this link was sent", ErrInvalidInput)
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *EmailService) issueToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	err := s.emailRepo.CreateToken(&models.EmailToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     *user.Email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	return token, err
+}
+
+func (s *EmailService) consumeToken(token, purpose string) (*models.EmailToken, error) {
+	emailToken, err := s.emailRepo.GetTokenByHash(hashToken(token))
+	if err != nil || emailToken.Purpose != purpose {
+		return nil, fmt.Errorf("%w: invalid token", ErrInvalidInput)
+	}
+	if time.Now().After(emailToken.ExpiresAt) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidInput)
+	}
+
+	ok, err := s.emailRepo.UseToken(emailToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: token already used", ErrInvalidInput)
+	}
+
+	return emailToken, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Bounces and unsubscribes
+
+// UnsubscribeURL returns a signed one-click link that stops notification
+// emails to the address.
+func (s *EmailService) UnsubscribeURL(email string) string {
+	query := url.Values{}
+	query.Set("email", strings.ToLower(email))
+	query.Set("token", s.unsubscribeToken(email))
+	return s.cfg.PublicAPIURL + "/api/" + s.cfg.APIVersion + "/email/unsubscribe?" + query.Encode()
+}
+
+func (s *EmailService) unsubscribeToken(email string) string {
+	mac := hmac.New(sha256.New, s.unsubscribeKey)
+	mac.Write([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *EmailService) Unsubscribe(email, token string) error {
+	if !hmac.Equal([]byte(token), []byte(s.unsubscribeToken(email))) {
+		return fmt.Errorf("%w: invalid unsubscribe link", ErrInvalidInput)
+	}
+
+	// Never downgrade a bounce or complaint to a plain unsubscribe.
+	if existing, err := s.emailRepo.GetSuppression(email); err == nil && existing.Reason != suppressUnsubscribe {
+		return nil
+	}
+
+	if err := s.emailRepo.UpsertSuppression(&models.EmailSuppression{
+		Email:  email,
+		Reason: suppressUnsubscribe,
+	}); err != nil {
+		return err
+	}
+
+	return s.emailRepo.SuppressPending(email, models.EmailCategoryNotification)
+}
+
+// HandleBounce records a delivery failure reported by the mail provider. Hard
+// bounces and complaints suppress all mail; soft bounces are only logged.
+func (s *EmailService) HandleBounce(email, kind string) error {
+	var reason string
+	switch kind {
+	case "hard", "bounce":
+		reason = suppressBounce
+	case "complaint", "spam":
+		reason = suppressComplaint
+	case "soft":
+		s.logger.Warnf("Soft bounce reported for %s", email)
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown bounce type %q", ErrInvalidInput, kind)
+	}
+
+	if err := s.emailRepo.UpsertSuppression(&models.EmailSuppression{
+		Email:  email,
+		Reason: reason,
+	}); err != nil {
+		return err
+	}
+
+	return s.emailRepo.SuppressPending(email, "")
+}
+
+// ValidWebhookSecret checks the shared secret sent by the mail provider.
+func (s *EmailService) ValidWebhookSecret(secret string) bool {
+	if s.cfg.MailWebhookSecret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(secret), []byte(s.cfg.MailWebhookSecret))
+}
+
+func displayName(user *models.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	if user.Email != nil {
+		return *user.Email
+	}
+	return ""
+}
@@ -0,0 +1,15 @@
+package services
+
+import "errors"
+
+// Sentinel errors returned by services so handlers can map them to HTTP
+// status codes with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrConflict     = errors.New("conflict")
+	ErrTooLarge     = errors.New("too large")
+	// ErrIntegrity means stored content does not match its recorded hash.
+	ErrIntegrity = errors.New("integrity check failed")
+)
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// governanceParamsTTL is how long quorum and approval thresholds read from
+// the DAO are cached.
+const governanceParamsTTL = time.Minute
+
+// ProposalTally is a proposal's live vote count measured against the DAO's
+// thresholds. Quorum is taken as a share of all staked FARI; the contract
+// itself currently only requires at least one vote.
+type ProposalTally struct {
+	VotesFor     string `json:"votes_for"`
+	VotesAgainst string `json:"votes_against"`
+	TotalVotes   string `json:"total_votes"`
+	VoterCount   int    `json:"voter_count"`
+	ApprovalBps  int64  `json:"approval_bps"` // votesFor / totalVotes
+
+	// Thresholds are omitted when the DAO cannot be reached.
+	QuorumBps            *int64 `json:"quorum_bps,omitempty"`
+	ApprovalThresholdBps *int64 `json:"approval_threshold_bps,omitempty"`
+	TotalStaked          string `json:"total_staked,omitempty"`
+	QuorumRequired       string `json:"quorum_required,omitempty"`
+	QuorumReached        *bool  `json:"quorum_reached,omitempty"`
+	Approved             *bool  `json:"approved,omitempty"`
+}
+
+// ProposalView is a proposal with its tally and decoded callData.
+type ProposalView struct {
+	models.Proposal
+	Tally *ProposalTally `json:"tally"`
+	Call  *DecodedCall   `json:"call"`
+}
+
+type governanceParams struct {
+	quorumBps   map[models.ProposalType]int64
+	approvalBps map[models.ProposalType]int64
+	totalStaked *big.Int
+	fetchedAt   time.Time
+}
+
+type GovernanceService struct {
+	proposalRepo      *repositories.ProposalRepository
+	userRepo          *repositories.UserRepository
+	blockchainService *BlockchainService
+	logger            *logrus.Logger
+
+	paramsMu sync.Mutex
+	params   *governanceParams
+}
+
+func NewGovernanceService(
+	proposalRepo *repositories.ProposalRepository,
+	userRepo *repositories.UserRepository,
+	blockchainService *BlockchainService,
+	logger *logrus.Logger,
+) *GovernanceService {
+	return &GovernanceService{
+		proposalRepo:      proposalRepo,
+		userRepo:          userRepo,
+		blockchainService: blockchainService,
+		logger:            logger,
+	}
+}
+
+func (s *GovernanceService) ListProposals(state, proposalType string, limit, offset int) ([]ProposalView, int64, error) {
+	proposals, total, err := s.proposalRepo.List(state, proposalType, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	params := s.governanceParams()
+
+	views := make([]ProposalView, len(proposals))
+	for i := range proposals {
+		views[i] = s.view(&proposals[i], params)
+	}
+	return views, total, nil
+}
+
+func (s *GovernanceService) GetProposal(id uuid.UUID) (*ProposalView, error) {
+	proposal, err := s.proposalRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	view := s.view(proposal, s.governanceParams())
+	return &view, nil
+}
+
+func (s *GovernanceService) GetProposalVotes(id uuid.UUID, limit, offset int) ([]models.ProposalVote, int64, error) {
+	if _, err := s.proposalRepo.GetByID(id); err != nil {
+		return nil, 0, ErrNotFound
+	}
+	return s.proposalRepo.GetVotes(id, limit, offset)
+}
+
+// GetVoterHistory returns an address's proposal votes, newest first.
+func (s *GovernanceService) GetVoterHistory(address string, limit, offset int) ([]models.ProposalVote, int64, error) {
+	if !common.IsHexAddress(address) {
+		return nil, 0, ErrInvalidInput
+	}
+	return s.proposalRepo.GetVotesByVoter(strings.ToLower(address), limit, offset)
+}
+
+func (s *GovernanceService) view(proposal *models.Proposal, params *governanceParams) ProposalView {
+	return ProposalView{
+		Proposal: *proposal,
+		Tally:    proposalTally(proposal, params),
+		Call:     DecodeProposalCall(proposal.Type, proposal.CallData),
+	}
+}
+
+func proposalTally(proposal *models.Proposal, params *governanceParams) *ProposalTally {
+	votesFor, _ := new(big.Int).SetString(proposal.VotesFor, 10)
+	votesAgainst, _ := new(big.Int).SetString(proposal.VotesAgainst, 10)
+	if votesFor == nil {
+		votesFor = new(big.Int)
+	}
+	if votesAgainst == nil {
+		votesAgainst = new(big.Int)
+	}
+	total := new(big.Int).Add(votesFor, votesAgainst)
+
+	tally := &ProposalTally{
+		VotesFor:     votesFor.String(),
+		VotesAgainst: votesAgainst.String(),
+		TotalVotes:   total.String(),
+		VoterCount:   proposal.VoterCount,
+	}
+	if total.Sign() > 0 {
+		tally.ApprovalBps = new(big.Int).Div(new(big.Int).Mul(votesFor, big.NewInt(10000)), total).Int64()
+	}
+
+	if params == nil {
+		return tally
+	}
+
+	quorumBps := params.quorumBps[proposal.Type]
+	approvalBps := params.approvalBps[proposal.Type]
+	required := new(big.Int).Div(new(big.Int).Mul(params.totalStaked, big.NewInt(quorumBps)), big.NewInt(10000))
+	quorumReached := total.Sign() > 0 && total.Cmp(required) >= 0
+	approved := total.Sign() > 0 && tally.ApprovalBps >= approvalBps
+
+	tally.QuorumBps = &quorumBps
+	tally.ApprovalThresholdBps = &approvalBps
+	tally.TotalStaked = params.totalStaked.String()
+	tally.QuorumRequired = required.String()
+	tally.QuorumReached = &quorumReached
+	tally.Approved = &approved
+
+	return tally
+}
+
+// governanceParams returns the DAO's thresholds and the FARI total stake,
+// cached for governanceParamsTTL. It returns nil if they cannot be read.
+func (s *GovernanceService) governanceParams() *governanceParams {
+	s.paramsMu.Lock()
+	defer s.paramsMu.Unlock()
+
+	if s.params != nil && time.Since(s.params.fetchedAt) < governanceParamsTTL {
+		return s.params
+	}
+
+	params, err := s.fetchGovernanceParams()
+	if err != nil {
+		s.logger.Warnf("Failed to read governance parameters: %v", err)
+		return s.params // Stale values beat none
+	}
+
+	s.params = params
+	return params
+}
+
+func (s *GovernanceService) fetchGovernanceParams() (*governanceParams, error) {
+	params := &governanceParams{
+		quorumBps:   make(map[models.ProposalType]int64, len(models.ProposalTypes)),
+		approvalBps: make(map[models.ProposalType]int64, len(models.ProposalTypes)),
+		fetchedAt:   time.Now(),
+	}
+
+	for i, proposalType := range models.ProposalTypes {
+		quorum, err := s.blockchainService.CallContract("dao", daoABI, "quorumBps", nil, uint8(i))
+		if err != nil {
+			return nil, err
+		}
+		approval, err := s.blockchainService.CallContract("dao", daoABI, "approvalThresholdBps", nil, uint8(i))
+		if err != nil {
+			return nil, err
+		}
+		params.quorumBps[proposalType] = quorum[0].(*big.Int).Int64()
+		params.approvalBps[proposalType] = approval[0].(*big.Int).Int64()
+	}
+
+	totalStaked, err := s.blockchainService.CallContract("fari_token", fariTokenABI, "totalStaked", nil)
+	if err != nil {
+		return nil, err
+	}
+	params.totalStaked = totalStaked[0].(*big.Int)
+
+	return params, nil
+}
+
+// Indexing
+
+// RecordProposalCreated indexes a DAO ProposalCreated event. Description,
+// callData and deadline are not in the event and are read from the
+// proposals getter.
+func (s *GovernanceService) RecordProposalCreated(log types.Log) error {
+	// ProposalCreated(uint256 indexed proposalId, address indexed proposer, ProposalType proposalType, string title)
+	if len(log.Topics) < 3 {
+		return nil
+	}
+
+	proposalID := new(big.Int).SetBytes(log.Topics[1].Bytes())
+	proposer := strings.ToLower(common.BytesToAddress(log.Topics[2].Bytes()).Hex())
+
+	if _, err := s.proposalRepo.GetByOnChainID(proposalID.Int64()); err == nil {
+		return nil // Already indexed
+	}
+
+	out, err := s.blockchainService.CallContract("dao", daoABI, "proposals", nil, proposalID)
+	if err != nil {
+		return err
+	}
+
+	proposal := &models.Proposal{
+		OnChainID:    proposalID.Int64(),
+		ProposerAddr: proposer,
+		Type:         proposalTypeFromChain(out[2].(uint8)),
+		Title:        out[3].(string),
+		Description:  out[4].(string),
+		CallData:     hexutil.Encode(out[5].([]byte)),
+		VotingEndsAt: time.Unix(out[6].(*big.Int).Int64(), 0),
+		VotesFor:     "0",
+		VotesAgainst: "0",
+		State:        models.ProposalStateActive,
+		TxHash:       log.TxHash.Hex(),
+		BlockNumber:  log.BlockNumber,
+	}
+	if user, err := s.userRepo.GetByAddress(proposer); err == nil {
+		proposal.ProposerID = &user.ID
+	}
+
+	if err := s.proposalRepo.Create(proposal); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil
+		}
+		return err
+	}
+
+	s.logger.Infof("Proposal created: ID=%d, Type=%s, Proposer=%s", proposal.OnChainID, proposal.Type, proposer)
+	return nil
+}
+
+// RecordProposalVote indexes a DAO ProposalVoted event.
+func (s *GovernanceService) RecordProposalVote(log types.Log) error {
+	// ProposalVoted(uint256 indexed proposalId, address indexed voter, bool support, uint256 votingPower)
+	if len(log.Topics) < 3 {
+		return nil
+	}
+
+	proposalID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
+	voter := strings.ToLower(common.BytesToAddress(log.Topics[2].Bytes()).Hex())
+
+	var event struct {
+		Support     bool
+		VotingPower *big.Int
+	}
+	if err := daoABI.UnpackIntoInterface(&event, "ProposalVoted", log.Data); err != nil {
+		return err
+	}
+
+	proposal, err := s.proposalRepo.GetByOnChainID(proposalID)
+	if err != nil {
+		return err
+	}
+
+	vote := &models.ProposalVote{
+		ProposalID:  proposal.ID,
+		VoterAddr:   voter,
+		Support:     event.Support,
+		VotingPower: event.VotingPower.String(),
+		TxHash:      log.TxHash.Hex(),
+		BlockNumber: log.BlockNumber,
+	}
+	if user, err := s.userRepo.GetByAddress(voter); err == nil {
+		vote.VoterID = &user.ID
+	}
+
+	if err := s.proposalRepo.RecordVote(vote); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil // Already indexed
+		}
+		return err
+	}
+
+	s.logger.Infof("Proposal vote: ID=%d, Voter=%s, Support=%t, Power=%s", proposalID, voter, event.Support, vote.VotingPower)
+	return nil
+}
+
+// RecordProposalExecuted indexes a DAO ProposalExecuted event.
+func (s *GovernanceService) RecordProposalExecuted(log types.Log) error {
+	// ProposalExecuted(uint256 indexed proposalId)
+	if len(log.Topics) < 2 {
+		return nil
+	}
+
+	proposalID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
+
+	proposal, err := s.proposalRepo.GetByOnChainID(proposalID)
+	if err != nil {
+		return err
+	}
+	if proposal.State == models.ProposalStateExecuted {
+		return nil
+	}
+
+	s.logger.Infof("Proposal executed: ID=%d", proposalID)
+
+	return s.proposalRepo.MarkExecuted(proposal.ID, log.TxHash.Hex(), time.Now())
+}
+
+// SyncProposals is the JobSyncProposals handler. executeProposal can mark a
+// proposal Defeated without emitting an event, so proposals whose voting has
+// ended are reconciled with getProposalVotes.
+func (s *GovernanceService) SyncProposals(ctx context.Context, job *models.Job) error {
+	proposals, err := s.proposalRepo.GetEndedActive(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range proposals {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		proposal := &proposals[i]
+		out, err := s.blockchainService.CallContract("dao", daoABI, "getProposalVotes", nil, big.NewInt(proposal.OnChainID))
+		if err != nil {
+			return err
+		}
+
+		state := proposalStateFromChain(out[2].(uint8))
+		if state == proposal.State {
+			continue
+		}
+
+		s.logger.Infof("Proposal %d is now %s", proposal.OnChainID, state)
+		if err := s.proposalRepo.SetState(proposal.ID, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func proposalTypeFromChain(index uint8) models.ProposalType {
+	if int(index) < len(models.ProposalTypes) {
+		return models.ProposalTypes[index]
+	}
+	return models.ProposalType(proposalTypeName(index))
+}
+
+func proposalStateFromChain(index uint8) models.ProposalState {
+	if int(index) < len(models.ProposalStates) {
+		return models.ProposalStates[index]
+	}
+	return models.ProposalStateActive
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/database"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// healthCheckTimeout bounds each readiness check, so a hung dependency fails
+// the probe instead of stalling it.
+const healthCheckTimeout = 3 * time.Second
+
+// readinessCacheTTL is how long a readiness result is served before the
+// dependencies are checked again, so frequent probes do not each spend an
+// RPC request.
+const readinessCacheTTL = 5 * time.Second
+
+// Health check statuses.
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthService checks the dependencies the API needs to serve requests.
+type HealthService struct {
+	cfg               *config.Config
+	db                *gorm.DB
+	redisClient       *redis.Client
+	blockchainService *BlockchainService
+	logger            *logrus.Logger
+
+	mu        sync.Mutex // Held while checking, so concurrent probes share one check
+	readiness *Readiness
+	checkedAt time.Time
+}
+
+// checkError is a check failure whose message is safe to show to anyone.
+// Other errors may carry connection strings, e.g. an RPC URL with an API key,
+// and are only logged.
+type checkError string
+
+func (e checkError) Error() string { return string(e) }
+
+type HealthCheck struct {
+	Status    string                 `json:"status"`
+	LatencyMS int64                  `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Readiness reports whether every dependency is healthy.
+type Readiness struct {
+	Ready  bool                   `json:"ready"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+func NewHealthService(cfg *config.Config, db *gorm.DB, redisClient *redis.Client, blockchainService *BlockchainService, logger *logrus.Logger) *HealthService {
+	return &HealthService{
+		cfg:               cfg,
+		db:                db,
+		redisClient:       redisClient,
+		blockchainService: blockchainService,
+		logger:            logger,
+	}
+}
+
+// Readiness pings Postgres and Redis and checks that the RPC node's latest
+// block is recent, concurrently. Results are reused for readinessCacheTTL.
+func (s *HealthService) Readiness(ctx context.Context) *Readiness {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readiness == nil || time.Since(s.checkedAt) > readinessCacheTTL {
+		// Shared with other probes, so not cut short by this one going away
+		s.readiness = s.check(context.WithoutCancel(ctx))
+		s.checkedAt = time.Now()
+	}
+	return s.readiness
+}
+
+func (s *HealthService) check(ctx context.Context) *Readiness {
+	checks := map[string]func(ctx context.Context, details map[string]interface{}) error{
+		"database": s.checkDatabase,
+		"redis":    s.checkRedis,
+		"rpc":      s.checkRPC,
+	}
+
+	type result struct {
+		name  string
+		check HealthCheck
+		err   error
+	}
+	results := make(chan result, len(checks))
+	for name, check := range checks {
+		go func(name string, check func(context.Context, map[string]interface{}) error) {
+			healthCheck, err := s.run(ctx, check)
+			results <- result{name, healthCheck, err}
+		}(name, check)
+	}
+
+	readiness := &Readiness{Ready: true, Checks: make(map[string]HealthCheck, len(checks))}
+	for range checks {
+		r := <-results
+		readiness.Checks[r.name] = r.check
+		if r.err != nil {
+			readiness.Ready = false
+			s.logger.Warnf("Readiness check %s failed: %v", r.name, r.err)
+		}
+	}
+	return readiness
+}
+
+// run times a check, failing it once healthCheckTimeout passes even if the
+// check itself does not honor the context.
+func (s *HealthService) run(ctx context.Context, check func(context.Context, map[string]interface{}) error) (HealthCheck, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details := make(map[string]interface{})
+	done := make(chan error, 1)
+	go func() { done <- check(ctx, details) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		details = nil // Still owned by the check
+	}
+
+	result := HealthCheck{Status: HealthStatusOK, LatencyMS: time.Since(start).Milliseconds()}
+	if len(details) > 0 {
+		result.Details = details
+	}
+	if err != nil {
+		var public checkError
+		switch {
+		case errors.As(err, &public):
+			result.Error = public.Error()
+		case errors.Is(err, context.DeadlineExceeded):
+			result.Error = "timed out"
+		default:
+			result.Error = "unavailable"
+		}
+		result.Status = HealthStatusFail
+	}
+	return result, err
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context, details map[string]interface{}) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (s *HealthService) checkRedis(ctx context.Context, details map[string]interface{}) error {
+	return database.PingRedis(ctx, s.redisClient)
+}
+
+// checkRPC fails when the node cannot be reached or has fallen behind the
+// chain, which would leave the indexer and transaction building stale.
+func (s *HealthService) checkRPC(ctx context.Context, details map[string]interface{}) error {
+	header, err := s.blockchainService.GetClient().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	age := time.Since(time.Unix(int64(header.Time), 0))
+	details["head_block"] = header.Number.Uint64()
+	details["head_age_seconds"] = int64(age / time.Second)
+
+	maxAge := time.Duration(s.cfg.RPCMaxHeadAgeSeconds) * time.Second
+	if age > maxAge {
+		return checkError(fmt.Sprintf("latest block %d is %s old, more than %s", header.Number.Uint64(), age.Round(time.Second), maxAge))
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/pdf"
+)
+
+// Invoice layout, in points on an A4 page.
+const (
+	invoiceMargin = 50.0
+	invoiceRight  = pdf.PageWidth - invoiceMargin
+	invoiceColumn = pdf.PageWidth / 2
+)
+
+type invoiceParty struct {
+	Name     string
+	Company  string
+	Location string
+	Email    string
+	Address  string // Wallet
+}
+
+type invoiceData struct {
+	Invoice      *models.Invoice
+	Escrow       *models.Escrow
+	ProjectTitle string
+	Payment      LedgerLine
+	Client       invoiceParty
+	Freelancer   invoiceParty
+	ChainID      int64
+}
+
+// renderInvoice lays out the freelancer's invoice to the client for a
+// completion or milestone payment, itemizing the platform fee taken from it.
+func renderInvoice(data invoiceData) *pdf.Document {
+	doc := pdf.New("Invoice " + data.Invoice.Number)
+	page := doc.AddPage()
+	payment := data.Payment
+
+	y := 80.0
+	page.Text(invoiceMargin, y, pdf.Bold, 24, "INVOICE")
+	page.TextRight(invoiceRight, y-8, pdf.Bold, 14, "FARIIMA")
+	page.TextRight(invoiceRight, y+6, pdf.Regular, 9, "Decentralized freelance marketplace")
+
+	y += 30
+	for _, row := range [][2]string{
+		{"Invoice number", data.Invoice.Number},
+		{"Date", data.Invoice.IssuedAt.UTC().Format("2006-01-02")},
+		{"Status", "Paid from escrow"},
+	} {
+		page.Text(invoiceMargin, y, pdf.Regular, 10, row[0])
+		page.Text(invoiceMargin+100, y, pdf.Bold, 10, row[1])
+		y += 15
+	}
+
+	y += 20
+	y = max(
+		drawParty(page, invoiceMargin, y, "FROM (FREELANCER)", data.Freelancer),
+		drawParty(page, invoiceColumn, y, "BILL TO (CLIENT)", data.Client),
+	)
+
+	y += 25
+	description := "Project: " + data.ProjectTitle
+	if payment.MilestoneIndex != nil {
+		description = fmt.Sprintf("Milestone %d: %s", *payment.MilestoneIndex, data.ProjectTitle)
+	}
+	unit := payment.Symbol
+	if unit == "" {
+		unit = "base units"
+	}
+
+	page.FillRect(invoiceMargin, y-13, invoiceRight-invoiceMargin, 20, 0.92)
+	page.Text(invoiceMargin+6, y, pdf.Bold, 10, "Description")
+	page.TextRight(invoiceRight-6, y, pdf.Bold, 10, "Amount ("+unit+")")
+	y += 24
+
+	page.Text(invoiceMargin+6, y, pdf.Regular, 10, fit(description, pdf.Regular, 10, invoiceRight-invoiceMargin-150))
+	page.TextRight(invoiceRight-6, y, pdf.Regular, 10, invoiceAmount(payment.Gross, payment.GrossUnits))
+	y += 12
+	page.Line(invoiceMargin, y, invoiceRight, y, 0.5, 0.7)
+	y += 18
+
+	page.Text(invoiceMargin+6, y, pdf.Bold, 11, "Total paid by client")
+	page.TextRight(invoiceRight-6, y, pdf.Bold, 11, invoiceAmount(payment.Gross, payment.GrossUnits))
+	y += 18
+	page.Text(invoiceMargin+6, y, pdf.Regular, 10, fmt.Sprintf("Platform fee (%s%%) paid to the FARIIMA treasury", strconv.FormatFloat(platformFeeBps/100.0, 'f', -1, 64)))
+	page.TextRight(invoiceRight-6, y, pdf.Regular, 10, "-"+invoiceAmount(payment.Fee, payment.FeeUnits))
+	y += 18
+	page.Text(invoiceMargin+6, y, pdf.Regular, 10, "Net received by freelancer")
+	page.TextRight(invoiceRight-6, y, pdf.Regular, 10, invoiceAmount(payment.Net, payment.NetUnits))
+	y += 12
+	page.Line(invoiceMargin, y, invoiceRight, y, 0.5, 0.7)
+
+	y += 30
+	page.Text(invoiceMargin, y, pdf.Bold, 11, "Payment details")
+	y += 18
+	rows := [][2]string{
+		{"Token", fmt.Sprintf("%s %s", payment.Symbol, payment.Token)},
+		{"Chain ID", strconv.FormatInt(data.ChainID, 10)},
+		{"Escrow", fmt.Sprintf("#%d", data.Escrow.OnChainID)},
+		{"Payment transaction", payment.TxHash},
+		{"Payment block", strconv.FormatUint(payment.BlockNumber, 10)},
+	}
+	if data.Escrow.DepositTxHash != "" {
+		rows = append(rows, [2]string{"Deposit transaction", data.Escrow.DepositTxHash})
+	}
+	for _, row := range rows {
+		page.Text(invoiceMargin, y, pdf.Regular, 9, row[0])
+		page.Text(invoiceMargin+110, y, pdf.Regular, 8, row[1])
+		y += 14
+	}
+
+	footer := pdf.PageHeight - 50
+	page.Line(invoiceMargin, footer-14, invoiceRight, footer-14, 0.5, 0.7)
+	page.Text(invoiceMargin, footer, pdf.Regular, 8,
+		"Generated from on-chain escrow records. The payment was settled by the escrow contract.")
+
+	return doc
+}
+
+// drawParty draws a party's details in a column and returns the y below
+// them.
+func drawParty(page *pdf.Page, x, y float64, heading string, party invoiceParty) float64 {
+	width := invoiceColumn - invoiceMargin - 10
+
+	page.Text(x, y, pdf.Bold, 9, heading)
+	y += 16
+	name := party.Name
+	if name == "" {
+		name = "Unknown"
+	}
+	page.Text(x, y, pdf.Bold, 11, fit(name, pdf.Bold, 11, width))
+	y += 14
+
+	for _, line := range []string{party.Company, party.Location, party.Email} {
+		if line == "" || !pdf.Encodable(line) {
+			continue
+		}
+		page.Text(x, y, pdf.Regular, 9, fit(line, pdf.Regular, 9, width))
+		y += 12
+	}
+	page.Text(x, y, pdf.Regular, 8, party.Address)
+	return y + 12
+}
+
+// invoiceAmount prefers whole tokens with at least two decimals, falling
+// back to base units for unknown tokens.
+func invoiceAmount(baseUnits, units string) string {
+	if units == "" {
+		return baseUnits
+	}
+
+	dot := strings.IndexByte(units, '.')
+	switch {
+	case dot < 0:
+		return units + ".00"
+	case len(units)-dot == 2:
+		return units + "0"
+	}
+	return units
+}
+
+// fit shortens s with an ellipsis to fit in width.
+func fit(s string, font pdf.Font, size, width float64) string {
+	if pdf.Width(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.Width(font, size, string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
@@ -2,80 +2,130 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"net/http"
+	"os"
+	"time"
 
 	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/imagemeta"
+	"github.com/fariima/backend/internal/metrics"
+	"github.com/fariima/backend/internal/scanner"
+	"github.com/fariima/backend/internal/storage"
 	"github.com/sirupsen/logrus"
 )
 
-type IPFSService struct {
-	cfg    *config.Config
-	logger *logrus.Logger
-}
+// maxServedSize caps content served through the cache. It leaves room for
+// the encryption overhead of private files.
+const maxServedSize = MaxUploadSize + 1<<20
+
+// ipfsUploadDuration times writes to the storage backends, including
+// retries and replication.
+var ipfsUploadDuration = metrics.NewHistogramVec(
+	"fariima_ipfs_upload_duration_seconds",
+	"Latency of uploads to IPFS storage by outcome.",
+	[]float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	"status",
+)
 
-type PinataResponse struct {
-	IpfsHash  string `json:"IpfsHash"`
-	PinSize   int    `json:"PinSize"`
-	Timestamp string `json:"Timestamp"`
+type IPFSService struct {
+	cfg     *config.Config
+	store   storage.Storage
+	cache   *storage.Cache
+	scanner scanner.Scanner
+	logger  *logrus.Logger
 }
 
-func NewIPFSService(cfg *config.Config, logger *logrus.Logger) *IPFSService {
+func NewIPFSService(cfg *config.Config, store storage.Storage, cache *storage.Cache, scanner scanner.Scanner, logger *logrus.Logger) *IPFSService {
 	return &IPFSService{
-		cfg:    cfg,
-		logger: logger,
+		cfg:     cfg,
+		store:   store,
+		cache:   cache,
+		scanner: scanner,
+		logger:  logger,
 	}
 }
 
-func (s *IPFSService) UploadFile(filename string, content []byte) (string, error) {
-	url := s.cfg.IPFSAPIUrl + "/pinning/pinFileToIPFS"
+// ScreenedFile is user content that went through Screen, held in a
+// temporary file positioned at the start. Close removes the file.
+type ScreenedFile struct {
+	*os.File
+	Size        int64
+	ContentHash string // hex SHA-256 of the screened content
+	Scan        *scanner.Result
+}
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+func (f *ScreenedFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
 
-	part, err := writer.CreateFormFile("file", filename)
+// Screen runs the hooks every user upload goes through before it is stored:
+// metadata is stripped from images, then the content is scanned for malware.
+// The caller decides what to do with infected content and closes the file.
+func (s *IPFSService) Screen(ctx context.Context, r io.Reader) (*ScreenedFile, error) {
+	tmp, err := os.CreateTemp("", "screen-*")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	screened := &ScreenedFile{File: tmp}
 
-	if _, err := part.Write(content); err != nil {
-		return "", err
+	if err := s.screen(ctx, screened, r); err != nil {
+		screened.Close()
+		return nil, err
 	}
+	return screened, nil
+}
 
-	if err := writer.Close(); err != nil {
-		return "", err
+func (s *IPFSService) screen(ctx context.Context, screened *ScreenedFile, r io.Reader) error {
+	if err := imagemeta.Strip(screened.File, r); err != nil {
+		if errors.Is(err, imagemeta.ErrMalformed) {
+			return fmt.Errorf("%w: image is malformed", ErrInvalidInput)
+		}
+		return err
 	}
 
-	req, err := http.NewRequest("POST", url, body)
+	size, err := screened.Seek(0, io.SeekEnd)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if _, err := screened.Seek(0, io.SeekStart); err != nil {
+		return err
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("pinata_api_key", s.cfg.PinataAPIKey)
-	req.Header.Set("pinata_secret_api_key", s.cfg.PinataSecretKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// The content is hashed as the scanner reads it
+	hash := sha256.New()
+	tee := io.TeeReader(screened.File, hash)
+	result, err := s.scanner.Scan(ctx, tee)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to scan content with %s: %w", s.scanner.Name(), err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("pinata error: %s", string(bodyBytes))
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+	if _, err := screened.Seek(0, io.SeekStart); err != nil {
+		return err
 	}
 
-	var result PinataResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+	screened.Size = size
+	screened.ContentHash = hex.EncodeToString(hash.Sum(nil))
+	screened.Scan = result
+	if result.Infected {
+		s.logger.Warnf("%s flagged content %s as %s", s.scanner.Name(), screened.ContentHash, result.Signature)
 	}
+	return nil
+}
 
-	return result.IpfsHash, nil
+// UploadReader stores content read from r. r is rewound if the storage
+// backend retries or replicates the write.
+func (s *IPFSService) UploadReader(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	return s.put(ctx, filename, r)
 }
 
 func (s *IPFSService) UploadJSON(data interface{}) (string, error) {
@@ -84,35 +134,47 @@ func (s *IPFSService) UploadJSON(data interface{}) (string, error) {
 		return "", err
 	}
 
-	url := s.cfg.IPFSAPIUrl + "/pinning/pinJSONToIPFS"
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
+	return s.put(context.Background(), "data.json", bytes.NewReader(jsonData))
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("pinata_api_key", s.cfg.PinataAPIKey)
-	req.Header.Set("pinata_secret_api_key", s.cfg.PinataSecretKey)
+func (s *IPFSService) put(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	start := time.Now()
+	hash, err := s.store.Put(ctx, filename, r)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	status := "ok"
 	if err != nil {
-		return "", err
+		status = "error"
 	}
-	defer resp.Body.Close()
+	ipfsUploadDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+	return hash, err
+}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("pinata error: %s", string(bodyBytes))
-	}
+// Unpin releases content from every storage backend.
+func (s *IPFSService) Unpin(ctx context.Context, hash string) error {
+	return s.store.Unpin(ctx, hash)
+}
 
-	var result PinataResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+// Open streams content from the configured storage backends. The caller
+// closes the reader.
+func (s *IPFSService) Open(ctx context.Context, hash string) (io.ReadCloser, error) {
+	body, err := s.store.Get(ctx, hash)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("%w: content %s is not stored", ErrNotFound, hash)
 	}
+	return body, err
+}
 
-	return result.IpfsHash, nil
+// OpenCached returns the content from the local disk cache, fetching it
+// from storage on a miss. The caller closes the file.
+func (s *IPFSService) OpenCached(ctx context.Context, hash string) (*os.File, error) {
+	file, err := s.cache.Open(ctx, hash, maxServedSize, s.Open)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil, fmt.Errorf("%w: content %s is not stored", ErrNotFound, hash)
+	case errors.Is(err, storage.ErrTooLarge):
+		return nil, fmt.Errorf("%w: content %s exceeds %d bytes", ErrTooLarge, hash, maxServedSize)
+	}
+	return file, err
 }
 
 func (s *IPFSService) GetFileURL(hash string) string {
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Job types
+const (
+	JobPruneJobs        = "jobs.prune"
+	JobDisputeDeadlines = "disputes.deadlines"
+	JobRefreshJurorPool = "jurors.refresh_pool"
+	JobSyncProposals    = "proposals.sync"
+	JobRefreshRanking   = "ranking.refresh"
+	JobVerifyPins       = "storage.verify_pins"
+	JobUnpinOrphans     = "storage.unpin_orphans"
+	JobRollupMetrics    = "analytics.rollup"
+	JobReconcileLedger  = "ledger.reconcile"
+	JobIndexChain       = "chain.index"
+	JobProcessEmails    = "email.outbox"
+	JobSendDigests      = "notifications.digest"
+)
+
+const (
+	jobPollInterval     = 2 * time.Second
+	jobDefaultAttempts  = 5
+	jobDefaultTimeout   = 5 * time.Minute
+	jobRetryBase        = 30 * time.Second
+	jobRetryMax         = time.Hour
+	jobRetention        = 7 * 24 * time.Hour
+	jobScheduleInterval = time.Second
+	jobReclaimInterval  = time.Minute
+	jobLeaseGrace       = time.Minute // Time to record the outcome after the timeout
+)
+
+// JobHandler runs one job. Returning an error schedules a retry with
+// exponential backoff until MaxAttempts is reached, after which the job is
+// moved to the dead letter state for inspection.
+type JobHandler func(ctx context.Context, job *models.Job) error
+
+// JobOptions configure how jobs of one type are run.
+type JobOptions struct {
+	MaxAttempts int
+	Timeout     time.Duration
+}
+
+type registeredJob struct {
+	handler JobHandler
+	options JobOptions
+}
+
+// JobQueue is a durable Postgres-backed job queue with cron schedules.
+//
+// Claimed jobs are leased for their type's timeout and marked with the
+// instance's owner name, the host name, so a restarted instance takes back
+// the jobs it was running when it stopped instead of waiting out the lease.
+type JobQueue struct {
+	cfg      *config.Config
+	jobRepo  *repositories.JobRepository
+	owner    string
+	handlers map[string]registeredJob
+	crons    map[string]*CronSchedule
+	mu       sync.RWMutex
+	logger   *logrus.Logger
+}
+
+func NewJobQueue(cfg *config.Config, jobRepo *repositories.JobRepository, logger *logrus.Logger) *JobQueue {
+	owner, err := os.Hostname()
+	if err != nil || owner == "" {
+		owner = uuid.NewString()
+	}
+
+	q := &JobQueue{
+		cfg:      cfg,
+		jobRepo:  jobRepo,
+		owner:    owner,
+		handlers: make(map[string]registeredJob),
+		crons:    make(map[string]*CronSchedule),
+		logger:   logger,
+	}
+
+	q.Register(JobPruneJobs, q.pruneJobs, JobOptions{})
+
+	return q
+}
+
+// Register installs the handler for a job type. It must be called before
+// Start.
+func (q *JobQueue) Register(jobType string, handler JobHandler, options JobOptions) {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = jobDefaultAttempts
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = jobDefaultTimeout
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = registeredJob{handler: handler, options: options}
+}
+
+// Schedule registers a cron schedule that enqueues jobType. The schedule row
+// is created on first start; changing the expression or type in code updates
+// it.
+func (q *JobQueue) Schedule(name, cron, jobType string) error {
+	parsed, err := ParseCron(cron)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	q.crons[name] = parsed
+	q.mu.Unlock()
+
+	existing, err := q.jobRepo.GetScheduleByName(name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return q.jobRepo.CreateSchedule(&models.JobSchedule{
+			Name:      name,
+			Cron:      cron,
+			JobType:   jobType,
+			Enabled:   true,
+			NextRunAt: parsed.Next(time.Now().UTC()),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing.Cron == cron && existing.JobType == jobType {
+		return nil
+	}
+
+	existing.Cron = cron
+	existing.JobType = jobType
+	existing.NextRunAt = parsed.Next(time.Now().UTC())
+	return q.jobRepo.UpdateSchedule(existing)
+}
+
+// Start runs the scheduler and the worker pool until ctx is cancelled, and
+// returns once every worker has stopped. Jobs interrupted by the
+// cancellation go back to pending without using up an attempt.
+func (q *JobQueue) Start(ctx context.Context) {
+	q.logger.Infof("Starting job queue with %d workers as %s...", q.cfg.JobWorkers, q.owner)
+
+	// Jobs this instance was running when it last stopped
+	q.reclaim(q.owner)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q.runScheduler(ctx)
+	}()
+	for i := 0; i < q.cfg.JobWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.runWorker(ctx)
+		}()
+	}
+	wg.Wait()
+
+	q.logger.Info("Stopping job queue...")
+}
+
+func (q *JobQueue) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(jobScheduleInterval)
+	defer ticker.Stop()
+	reclaimTicker := time.NewTicker(jobReclaimInterval)
+	defer reclaimTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := q.fireSchedules(); err != nil {
+				q.logger.Errorf("Error firing job schedules: %v", err)
+			}
+		case <-reclaimTicker.C:
+			// Jobs of workers that died, on any instance
+			q.reclaim("")
+		}
+	}
+}
+
+// reclaim returns running jobs whose lease expired, or that the given owner
+// holds, to the queue.
+func (q *JobQueue) reclaim(owner string) {
+	now := time.Now()
+	reclaimed, err := q.jobRepo.ReclaimRunning(now, owner, func(job *models.Job) bool {
+		return reclaimJob(job, now, owner)
+	})
+	if err != nil {
+		q.logger.Errorf("Error reclaiming jobs: %v", err)
+		return
+	}
+	if reclaimed > 0 {
+		q.logger.Warnf("Reclaimed %d jobs whose worker stopped", reclaimed)
+	}
+}
+
+// reclaimJob puts a running job whose lease expired, or that owner holds,
+// back to pending, or to dead when that was its last attempt. It reports
+// whether the job was changed.
+func reclaimJob(job *models.Job, now time.Time, owner string) bool {
+	if job.Status != models.JobStatusRunning {
+		return false
+	}
+	expired := job.LockedUntil == nil || job.LockedUntil.Before(now)
+	if !expired && (owner == "" || job.LockedBy != owner) {
+		return false
+	}
+
+	job.LockedUntil = nil
+	job.LockedBy = ""
+	job.LastError = "worker stopped before the job finished"
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = models.JobStatusDead
+	} else {
+		job.Status = models.JobStatusPending
+		job.RunAt = now
+	}
+	return true
+}
+
+func (q *JobQueue) fireSchedules() error {
+	now := time.Now().UTC()
+
+	fired, err := q.jobRepo.FireDueSchedules(now,
+		func(schedule *models.JobSchedule) (time.Time, error) {
+			q.mu.RLock()
+			parsed, ok := q.crons[schedule.Name]
+			q.mu.RUnlock()
+			if !ok {
+				var err error
+				if parsed, err = ParseCron(schedule.Cron); err != nil {
+					return time.Time{}, err
+				}
+			}
+			return parsed.Next(now), nil
+		},
+		func(schedule *models.JobSchedule) *models.Job {
+			q.mu.RLock()
+			registered := q.handlers[schedule.JobType]
+			q.mu.RUnlock()
+
+			maxAttempts := registered.options.MaxAttempts
+			if maxAttempts <= 0 {
+				maxAttempts = jobDefaultAttempts
+			}
+
+			// One active run per schedule: a slow job is not stacked up.
+			key := "schedule:" + schedule.Name
+			return &models.Job{
+				Type:        schedule.JobType,
+				UniqueKey:   &key,
+				Status:      models.JobStatusPending,
+				MaxAttempts: maxAttempts,
+				RunAt:       now,
+			}
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range fired {
+		q.logger.Debugf("Schedule %s fired, next run at %s", schedule.Name, schedule.NextRunAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (q *JobQueue) runWorker(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep draining while there is work before waiting again.
+			for ctx.Err() == nil {
+				ran, err := q.runNext(ctx)
+				if err != nil {
+					q.logger.Errorf("Error claiming jobs: %v", err)
+				}
+				if !ran {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (q *JobQueue) runNext(ctx context.Context) (bool, error) {
+	jobs, err := q.jobRepo.ClaimDue(q.leases(), q.owner, 1)
+	if err != nil || len(jobs) == 0 {
+		return false, err
+	}
+
+	q.run(ctx, &jobs[0])
+	return true, nil
+}
+
+// leases returns how long a claimed job of each registered type is held:
+// its timeout, plus the time to record the outcome.
+func (q *JobQueue) leases() map[string]time.Duration {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	leases := make(map[string]time.Duration, len(q.handlers))
+	for jobType, registered := range q.handlers {
+		leases[jobType] = registered.options.Timeout + jobLeaseGrace
+	}
+	return leases
+}
+
+func (q *JobQueue) run(ctx context.Context, job *models.Job) {
+	q.mu.RLock()
+	registered := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	jobCtx, cancel := context.WithTimeout(ctx, registered.options.Timeout)
+	defer cancel()
+
+	err := q.safeRun(jobCtx, registered.handler, job)
+
+	now := time.Now()
+	job.LockedUntil = nil
+	job.LockedBy = ""
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// The queue is stopping; the attempt does not count.
+		job.Status = models.JobStatusPending
+		job.Attempts--
+		job.RunAt = now
+		job.LastError = err.Error()
+		q.logger.Infof("Job %s (%s) interrupted by shutdown, released", job.ID, job.Type)
+	case err == nil:
+		job.Status = models.JobStatusCompleted
+		job.CompletedAt = &now
+		job.LastError = ""
+	default:
+		job.LastError = err.Error()
+		if job.Attempts >= job.MaxAttempts {
+			job.Status = models.JobStatusDead
+			q.logger.Errorf("Job %s (%s) moved to dead letter after %d attempts: %v",
+				job.ID, job.Type, job.Attempts, err)
+		} else {
+			job.Status = models.JobStatusPending
+			job.RunAt = now.Add(jobBackoff(job.Attempts))
+			q.logger.Warnf("Job %s (%s) failed on attempt %d, retrying at %s: %v",
+				job.ID, job.Type, job.Attempts, job.RunAt.Format(time.RFC3339), err)
+		}
+	}
+
+	if err := q.jobRepo.Update(job); err != nil {
+		q.logger.Errorf("Failed to update job %s: %v", job.ID, err)
+	}
+}
+
+func (q *JobQueue) safeRun(ctx context.Context, handler JobHandler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// jobBackoff doubles the delay per attempt with ±20% jitter, capped at an hour.
+func jobBackoff(attempt int) time.Duration {
+	delay := jobRetryBase << uint(attempt-1)
+	if delay > jobRetryMax || delay <= 0 {
+		delay = jobRetryMax
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5*2+1)) - delay/5
+	return delay + jitter
+}
+
+// Admin
+
+func (q *JobQueue) ListJobs(status, jobType string, limit, offset int) ([]models.Job, int64, error) {
+	return q.jobRepo.List(status, jobType, limit, offset)
+}
+
+func (q *JobQueue) GetJob(id uuid.UUID) (*models.Job, error) {
+	job, err := q.jobRepo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+func (q *JobQueue) Stats() ([]repositories.JobCount, error) {
+	return q.jobRepo.CountByStatus()
+}
+
+func (q *JobQueue) ListSchedules() ([]models.JobSchedule, error) {
+	return q.jobRepo.ListSchedules()
+}
+
+// RetryJob requeues a dead job, or pulls a pending retry forward to now.
+func (q *JobQueue) RetryJob(id uuid.UUID) (*models.Job, error) {
+	job, err := q.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case models.JobStatusDead:
+		job.Attempts = 0
+	case models.JobStatusPending:
+	default:
+		return nil, fmt.Errorf("%w: job is %s", ErrConflict, job.Status)
+	}
+
+	job.Status = models.JobStatusPending
+	job.RunAt = time.Now()
+	job.LockedUntil = nil
+	job.LockedBy = ""
+
+	if err := q.jobRepo.Update(job); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fmt.Errorf("%w: another active job holds key %s", ErrConflict, *job.UniqueKey)
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (q *JobQueue) pruneJobs(ctx context.Context, job *models.Job) error {
+	deleted, err := q.jobRepo.DeleteFinishedBefore(time.Now().Add(-jobRetention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		q.logger.Infof("Pruned %d finished jobs", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestJobLeases(t *testing.T) {
+	q := NewJobQueue(&config.Config{}, nil, logrus.New())
+	noop := func(context.Context, *models.Job) error { return nil }
+	q.Register(JobVerifyPins, noop, JobOptions{Timeout: 6 * time.Hour})
+	q.Register(JobProcessEmails, noop, JobOptions{MaxAttempts: 1})
+	q.Register(JobRollupMetrics, noop, JobOptions{Timeout: time.Hour})
+
+	tests := []struct {
+		jobType string
+		want    time.Duration
+	}{
+		{JobVerifyPins, 6*time.Hour + jobLeaseGrace},
+		{JobProcessEmails, jobDefaultTimeout + jobLeaseGrace},
+		{JobRollupMetrics, time.Hour + jobLeaseGrace},
+		{JobPruneJobs, jobDefaultTimeout + jobLeaseGrace},
+	}
+
+	leases := q.leases()
+	if len(leases) != len(tests) {
+		t.Errorf("got leases for %d types, want %d", len(leases), len(tests))
+	}
+	for _, tt := range tests {
+		if got := leases[tt.jobType]; got != tt.want {
+			t.Errorf("lease of %s = %s, want %s", tt.jobType, got, tt.want)
+		}
+	}
+}
+
+func TestReclaimJob(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Second), now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		status      models.JobStatus
+		lockedUntil *time.Time
+		lockedBy    string
+		attempts    int
+		owner       string
+		reclaimed   bool
+		want        models.JobStatus
+	}{
+		{"lease expired", models.JobStatusRunning, &past, "api-2", 1, "", true, models.JobStatusPending},
+		{"lease expired on last attempt", models.JobStatusRunning, &past, "api-2", 3, "", true, models.JobStatusDead},
+		{"no lease", models.JobStatusRunning, nil, "", 1, "", true, models.JobStatusPending},
+		{"lease held by another worker", models.JobStatusRunning, &future, "api-2", 1, "", false, models.JobStatusRunning},
+		{"lease held by another instance at startup", models.JobStatusRunning, &future, "api-2", 1, "api-1", false, models.JobStatusRunning},
+		{"lease held by this instance at startup", models.JobStatusRunning, &future, "api-1", 1, "api-1", true, models.JobStatusPending},
+		{"owned on last attempt", models.JobStatusRunning, &future, "api-1", 3, "api-1", true, models.JobStatusDead},
+		{"pending", models.JobStatusPending, nil, "", 0, "api-1", false, models.JobStatusPending},
+		{"completed", models.JobStatusCompleted, nil, "", 1, "api-1", false, models.JobStatusCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.Job{
+				Status:      tt.status,
+				LockedUntil: tt.lockedUntil,
+				LockedBy:    tt.lockedBy,
+				Attempts:    tt.attempts,
+				MaxAttempts: 3,
+				RunAt:       now.Add(-time.Hour),
+			}
+
+			if got := reclaimJob(job, now, tt.owner); got != tt.reclaimed {
+				t.Fatalf("reclaimJob = %v, want %v", got, tt.reclaimed)
+			}
+			if job.Status != tt.want {
+				t.Errorf("status = %s, want %s", job.Status, tt.want)
+			}
+			if !tt.reclaimed {
+				return
+			}
+			if job.LockedUntil != nil || job.LockedBy != "" {
+				t.Errorf("lease not cleared: until %v, by %q", job.LockedUntil, job.LockedBy)
+			}
+			if job.Status == models.JobStatusPending && !job.RunAt.Equal(now) {
+				t.Errorf("run_at = %s, want now", job.RunAt)
+			}
+			if job.Attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", job.Attempts, tt.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,623 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxParticipationBonus caps the weight bonus from past participation at
+// +100% (10% per dispute voted on).
+const maxParticipationBonus = 10
+
+// JurorCandidate is one entry of a draw's published candidate list.
+type JurorCandidate struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Address       string    `json:"address"`
+	StakedAmount  string    `json:"staked_amount"`
+	Participation int       `json:"participation"`
+	Weight        string    `json:"weight"`
+}
+
+// Jury is everything needed to audit a dispute's juror selection.
+type Jury struct {
+	Assignments []models.JurorAssignment `json:"assignments"`
+	Draws       []models.JurorDraw       `json:"draws"`
+}
+
+type JurorService struct {
+	cfg                 *config.Config
+	jurorRepo           *repositories.JurorRepository
+	disputeRepo         *repositories.DisputeRepository
+	userRepo            *repositories.UserRepository
+	blockchainService   *BlockchainService
+	privateFileService  *PrivateFileService
+	notificationService *NotificationService
+	logger              *logrus.Logger
+}
+
+func NewJurorService(
+	cfg *config.Config,
+	jurorRepo *repositories.JurorRepository,
+	disputeRepo *repositories.DisputeRepository,
+	userRepo *repositories.UserRepository,
+	blockchainService *BlockchainService,
+	privateFileService *PrivateFileService,
+	notificationService *NotificationService,
+	logger *logrus.Logger,
+) *JurorService {
+	return &JurorService{
+		cfg:                 cfg,
+		jurorRepo:           jurorRepo,
+		disputeRepo:         disputeRepo,
+		userRepo:            userRepo,
+		blockchainService:   blockchainService,
+		privateFileService:  privateFileService,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// RefreshPool is the JobRefreshJurorPool handler. It rebuilds the pool from
+// the current FARI stakes of every user with a wallet, then draws jurors for
+// disputes that missed out because the pool was empty.
+func (s *JurorService) RefreshPool(ctx context.Context, job *models.Job) error {
+	users, err := s.userRepo.ListWithAddress()
+	if err != nil {
+		return err
+	}
+
+	participation, err := s.jurorRepo.ParticipationCounts()
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Abort on RPC errors so members are not dropped for a flaky node.
+		staked, _, err := s.blockchainService.GetFARIStake(common.HexToAddress(user.Address), nil)
+		if err != nil {
+			return err
+		}
+		if staked.Cmp(MinJurorStake) < 0 {
+			continue
+		}
+
+		if err := s.jurorRepo.UpsertPoolMember(&models.JurorPoolMember{
+			UserID:        user.ID,
+			Address:       user.Address,
+			StakedAmount:  staked.String(),
+			Participation: participation[user.ID],
+			RefreshedAt:   time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	removed, err := s.jurorRepo.DeletePoolMembersRefreshedBefore(started)
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		s.logger.Infof("Removed %d jurors from the pool", removed)
+	}
+
+	disputes, err := s.jurorRepo.GetDisputesWithoutDraw()
+	if err != nil {
+		return err
+	}
+	for _, dispute := range disputes {
+		if err := s.AssignJurors(dispute.ID); err != nil {
+			s.logger.Errorf("Failed to assign jurors to dispute %s: %v", dispute.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// AssignJurors draws the dispute's jurors from the pool. The draw is seeded
+// with keccak256(blockhash ++ disputeId) of the block the dispute was created
+// in, and is stored with its candidate list so anyone can recompute it. It
+// is a no-op once jurors have been drawn.
+func (s *JurorService) AssignJurors(disputeID uuid.UUID) error {
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return err
+	}
+	if dispute.OnChainID == nil {
+		return fmt.Errorf("dispute %s has not been initiated on-chain", dispute.ID)
+	}
+
+	draws, err := s.jurorRepo.CountDraws(dispute.ID)
+	if err != nil || draws > 0 {
+		return err
+	}
+
+	blockHash, err := s.blockchainService.GetBlockHash(dispute.BlockNumber)
+	if err != nil {
+		return err
+	}
+
+	seed := crypto.Keccak256(blockHash.Bytes(), common.LeftPadBytes(big.NewInt(*dispute.OnChainID).Bytes(), 32))
+	source := fmt.Sprintf("keccak256(blockhash(%d) ++ uint256(%d))", dispute.BlockNumber, *dispute.OnChainID)
+
+	candidates, err := s.candidates(dispute, nil)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		s.logger.Warnf("No eligible jurors for dispute %s", dispute.ID)
+		return nil
+	}
+
+	return s.saveDraw(dispute, seed, source, candidates, drawJurors(seed, candidates, s.cfg.JurorsPerDispute), nil)
+}
+
+// Respond records an assigned juror accepting or declining. A juror who
+// accepts can read the dispute's encrypted evidence; a declined seat is
+// refilled with a new draw.
+func (s *JurorService) Respond(disputeID, jurorID uuid.UUID, accept bool) (*models.JurorAssignment, error) {
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if dispute.Status != models.DisputeStatusOpen && dispute.Status != models.DisputeStatusVoting {
+		return nil, fmt.Errorf("%w: dispute is %s", ErrConflict, dispute.Status)
+	}
+
+	assignment, err := s.jurorRepo.GetAssignment(dispute.ID, jurorID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: you are not a juror on this dispute", ErrNotFound)
+	}
+	if assignment.Status != models.JurorAssignmentPending {
+		return nil, fmt.Errorf("%w: assignment already %s", ErrConflict, assignment.Status)
+	}
+
+	status := models.JurorAssignmentDeclined
+	if accept {
+		status = models.JurorAssignmentAccepted
+	}
+
+	updated, err := s.jurorRepo.RespondToAssignment(assignment.ID, status)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: assignment already answered", ErrConflict)
+	}
+
+	now := time.Now()
+	assignment.Status = status
+	assignment.RespondedAt = &now
+
+	if accept {
+		if err := s.privateFileService.GrantDispute(dispute.ID, jurorID, models.FileGrantJuror); err != nil {
+			s.logger.Errorf("Failed to grant juror %s access to dispute %s evidence: %v", jurorID, dispute.ID, err)
+		}
+	} else if err := s.replace(dispute, assignment); err != nil {
+		s.logger.Errorf("Failed to replace juror on dispute %s: %v", dispute.ID, err)
+	}
+
+	return assignment, nil
+}
+
+func (s *JurorService) GetJury(disputeID uuid.UUID) (*Jury, error) {
+	if _, err := s.disputeRepo.GetByID(disputeID); err != nil {
+		return nil, ErrNotFound
+	}
+
+	assignments, err := s.jurorRepo.GetAssignments(disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	draws, err := s.jurorRepo.GetDraws(disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Jury{Assignments: assignments, Draws: draws}, nil
+}
+
+func (s *JurorService) ListAssignments(jurorID uuid.UUID, status string, limit, offset int) ([]models.JurorAssignment, int64, error) {
+	return s.jurorRepo.ListAssignmentsByJuror(jurorID, status, limit, offset)
+}
+
+// minLeaderboardVotes keeps jurors with too few decided votes off the
+// accuracy ranking.
+const minLeaderboardVotes = 3
+
+// leaderboardOrders maps the leaderboard sort options to ORDER BY clauses.
+var leaderboardOrders = map[string]string{
+	"accuracy":      "accuracy DESC, decided_votes DESC",
+	"participation": "participation DESC, accuracy DESC",
+	"rewards":       "rewards_earned::numeric DESC, accuracy DESC",
+}
+
+// JurorProfile is a juror's public reputation.
+type JurorProfile struct {
+	repositories.JurorStats
+	InPool        bool                 `json:"in_pool"`
+	StakedAmount  string               `json:"staked_amount"` // Wei string, as of the last pool refresh
+	RecentRewards []models.JurorReward `json:"recent_rewards"`
+}
+
+// RewardClaim is a claimJurorRewards call for every finalized dispute the
+// juror has not been paid for yet.
+type RewardClaim struct {
+	DisputeIDs  []int64             `json:"dispute_ids"` // On-chain IDs
+	Amount      string              `json:"amount"`      // Expected reward, wei
+	Transaction *TransactionPayload `json:"transaction"`
+}
+
+func (s *JurorService) GetProfile(address string) (*JurorProfile, error) {
+	user, err := s.userRepo.GetByAddress(strings.ToLower(address))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	stats, err := s.jurorRepo.GetJurorStats(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Address = user.Address
+	stats.Username = user.Username
+	stats.Avatar = user.Avatar
+
+	rewards, _, err := s.jurorRepo.ListRewards(user.Address, 10, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &JurorProfile{JurorStats: *stats, StakedAmount: "0", RecentRewards: rewards}
+	if member, err := s.jurorRepo.GetPoolMember(user.ID); err == nil {
+		profile.InPool = true
+		profile.StakedAmount = member.StakedAmount
+	}
+
+	return profile, nil
+}
+
+// Leaderboard ranks jurors by accuracy, participation or rewards earned.
+// The accuracy ranking only includes jurors with minLeaderboardVotes
+// decided votes.
+func (s *JurorService) Leaderboard(sort string, limit, offset int) ([]repositories.JurorStats, int64, error) {
+	orderBy, ok := leaderboardOrders[sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: sort must be accuracy, participation or rewards", ErrInvalidInput)
+	}
+
+	minDecided := 0
+	if sort == "accuracy" {
+		minDecided = minLeaderboardVotes
+	}
+
+	return s.jurorRepo.Leaderboard(orderBy, minDecided, limit, offset)
+}
+
+// ClaimRewardsPayload builds the claimJurorRewards call for the juror's
+// unclaimed disputes. The DAO does not track claims itself, so only
+// disputes without an indexed JurorRewarded claim are included.
+func (s *JurorService) ClaimRewardsPayload(jurorID uuid.UUID) (*RewardClaim, error) {
+	disputeIDs, err := s.jurorRepo.UnclaimedRewardDisputes(jurorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(disputeIDs) == 0 {
+		return nil, fmt.Errorf("%w: no unclaimed juror rewards", ErrConflict)
+	}
+
+	args := make([]*big.Int, len(disputeIDs))
+	for i, id := range disputeIDs {
+		args[i] = big.NewInt(id)
+	}
+
+	tx, err := s.blockchainService.BuildContractCall("dao", daoABI, "claimJurorRewards", args)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := new(big.Int).Mul(BaseJurorReward, big.NewInt(int64(len(disputeIDs))))
+
+	return &RewardClaim{DisputeIDs: disputeIDs, Amount: amount.String(), Transaction: tx}, nil
+}
+
+// RecordReward indexes a DAO JurorRewarded event.
+func (s *JurorService) RecordReward(log types.Log) error {
+	// JurorRewarded(address indexed juror, uint256 amount)
+	if len(log.Topics) < 2 {
+		return nil
+	}
+
+	address := strings.ToLower(common.BytesToAddress(log.Topics[1].Bytes()).Hex())
+
+	var event struct {
+		Amount *big.Int
+	}
+	if err := daoABI.UnpackIntoInterface(&event, "JurorRewarded", log.Data); err != nil {
+		return err
+	}
+
+	disputeIDs, err := s.claimedDisputes(log.TxHash)
+	if err != nil {
+		return err
+	}
+	idsJSON, _ := json.Marshal(disputeIDs)
+
+	reward := &models.JurorReward{
+		Address:     address,
+		Amount:      event.Amount.String(),
+		DisputeIDs:  idsJSON,
+		TxHash:      log.TxHash.Hex(),
+		LogIndex:    log.Index,
+		BlockNumber: log.BlockNumber,
+	}
+	if user, err := s.userRepo.GetByAddress(address); err == nil {
+		reward.JurorID = &user.ID
+	}
+
+	if err := s.jurorRepo.RecordReward(reward, disputeIDs, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil // Already indexed
+		}
+		return err
+	}
+
+	s.logger.Infof("Juror rewarded: Juror=%s, Amount=%s, Disputes=%v", address, reward.Amount, disputeIDs)
+	return nil
+}
+
+// claimedDisputes decodes the dispute IDs from the claimJurorRewards call
+// that emitted a JurorRewarded event.
+func (s *JurorService) claimedDisputes(txHash common.Hash) ([]int64, error) {
+	tx, _, err := s.blockchainService.GetTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	method := daoABI.Methods["claimJurorRewards"]
+	data := tx.Data()
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		// Claimed through an intermediary contract; the disputes cannot be
+		// attributed from the calldata.
+		s.logger.Warnf("Juror reward in tx %s was not a direct claimJurorRewards call", txHash.Hex())
+		return []int64{}, nil
+	}
+
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	raw := args[0].([]*big.Int)
+	ids := make([]int64, len(raw))
+	for i, id := range raw {
+		ids[i] = id.Int64()
+	}
+	return ids, nil
+}
+
+// replace draws one juror for a declined seat. The n-th draw on a dispute is
+// seeded with keccak256(firstSeed ++ uint256(n)).
+func (s *JurorService) replace(dispute *models.Dispute, declined *models.JurorAssignment) error {
+	first, err := s.jurorRepo.GetFirstDraw(dispute.ID)
+	if err != nil {
+		return err
+	}
+
+	draws, err := s.jurorRepo.CountDraws(dispute.ID)
+	if err != nil {
+		return err
+	}
+
+	firstSeed, err := hexutil.Decode(first.Seed)
+	if err != nil {
+		return err
+	}
+	seed := replacementSeed(firstSeed, draws)
+	source := fmt.Sprintf("keccak256(%s ++ uint256(%d))", first.Seed, draws)
+
+	assigned, err := s.jurorRepo.GetAssignments(dispute.ID)
+	if err != nil {
+		return err
+	}
+	exclude := make([]uuid.UUID, 0, len(assigned))
+	for _, assignment := range assigned {
+		exclude = append(exclude, assignment.JurorID)
+	}
+
+	candidates, err := s.candidates(dispute, exclude)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		s.logger.Warnf("No replacement juror available for dispute %s", dispute.ID)
+		return nil
+	}
+
+	return s.saveDraw(dispute, seed, source, candidates, drawJurors(seed, candidates, 1), declined)
+}
+
+// candidates returns the pool minus the parties, anyone with a conflict of
+// interest and the excluded users, ordered by address with their weights.
+func (s *JurorService) candidates(dispute *models.Dispute, exclude []uuid.UUID) ([]JurorCandidate, error) {
+	pool, err := s.jurorRepo.ListPool()
+	if err != nil {
+		return nil, err
+	}
+
+	parties := []uuid.UUID{dispute.Project.ClientID}
+	if dispute.Project.FreelancerID != nil {
+		parties = append(parties, *dispute.Project.FreelancerID)
+	}
+
+	conflicted, err := s.jurorRepo.ConflictedUserIDs(parties)
+	if err != nil {
+		return nil, err
+	}
+
+	return poolCandidates(pool, parties, conflicted, exclude), nil
+}
+
+// poolCandidates turns the pool into a weighted candidate list, leaving out
+// every user in the excluded lists and members with an unreadable stake.
+func poolCandidates(pool []models.JurorPoolMember, excludedLists ...[]uuid.UUID) []JurorCandidate {
+	excluded := make(map[uuid.UUID]bool)
+	for _, ids := range excludedLists {
+		for _, id := range ids {
+			excluded[id] = true
+		}
+	}
+
+	var candidates []JurorCandidate
+	for _, member := range pool {
+		if excluded[member.UserID] {
+			continue
+		}
+
+		staked, ok := new(big.Int).SetString(member.StakedAmount, 10)
+		if !ok {
+			continue
+		}
+
+		candidates = append(candidates, JurorCandidate{
+			UserID:        member.UserID,
+			Address:       strings.ToLower(member.Address),
+			StakedAmount:  member.StakedAmount,
+			Participation: member.Participation,
+			Weight:        jurorWeight(staked, member.Participation).String(),
+		})
+	}
+
+	return candidates
+}
+
+func (s *JurorService) saveDraw(dispute *models.Dispute, seed []byte, source string, candidates []JurorCandidate, picked []int, replaced *models.JurorAssignment) error {
+	selected := make([]string, 0, len(picked))
+	assignments := make([]models.JurorAssignment, 0, len(picked))
+	for _, idx := range picked {
+		candidate := candidates[idx]
+		selected = append(selected, candidate.Address)
+		assignments = append(assignments, models.JurorAssignment{
+			DisputeID: dispute.ID,
+			JurorID:   candidate.UserID,
+			Address:   candidate.Address,
+			Weight:    candidate.Weight,
+			Status:    models.JurorAssignmentPending,
+		})
+	}
+
+	candidatesJSON, err := json.Marshal(candidates)
+	if err != nil {
+		return err
+	}
+	selectedJSON, err := json.Marshal(selected)
+	if err != nil {
+		return err
+	}
+
+	draw := &models.JurorDraw{
+		DisputeID:  dispute.ID,
+		Seed:       hexutil.Encode(seed),
+		SeedSource: source,
+		Candidates: candidatesJSON,
+		Selected:   selectedJSON,
+	}
+
+	if err := s.jurorRepo.CreateDraw(draw, assignments, replaced); err != nil {
+		return err
+	}
+
+	s.logger.Infof("Drew %d jurors for dispute %s (seed %s)", len(assignments), dispute.ID, draw.Seed)
+
+	for _, assignment := range assignments {
+		s.notificationService.Notify(assignment.JurorID, NotificationEvent{
+			Type:  models.NotificationDisputeVoteNeeded,
+			Title: "You were selected as a juror",
+			Body:  fmt.Sprintf("You were drawn as a juror for %s. Please accept or decline the assignment.", dispute.Title),
+			Data: map[string]interface{}{
+				"project_id":    dispute.ProjectID,
+				"dispute_id":    dispute.ID,
+				"assignment_id": assignment.ID,
+			},
+		})
+	}
+
+	return nil
+}
+
+// replacementSeed is the seed of a dispute's n-th draw:
+// keccak256(firstSeed ++ uint256(n)).
+func replacementSeed(firstSeed []byte, n int64) []byte {
+	return crypto.Keccak256(firstSeed, common.LeftPadBytes(big.NewInt(n).Bytes(), 32))
+}
+
+// jurorWeight is the whole FARI staked, boosted 10% for every dispute the
+// juror has voted on, up to maxParticipationBonus disputes.
+func jurorWeight(staked *big.Int, participation int) *big.Int {
+	if participation > maxParticipationBonus {
+		participation = maxParticipationBonus
+	}
+
+	weight := new(big.Int).Div(staked, big.NewInt(1e18))
+	weight.Mul(weight, big.NewInt(int64(100+10*participation)))
+	return weight.Div(weight, big.NewInt(100))
+}
+
+// drawJurors picks up to n candidates without replacement, with probability
+// proportional to weight. Pick k uses r = uint256(keccak256(seed ++
+// uint256(k))) mod the remaining total weight, and takes the first remaining
+// candidate (in address order) whose cumulative weight exceeds r.
+func drawJurors(seed []byte, candidates []JurorCandidate, n int) []int {
+	weights := make([]*big.Int, len(candidates))
+	remaining := make([]int, 0, len(candidates))
+	total := new(big.Int)
+
+	for i, candidate := range candidates {
+		weight, ok := new(big.Int).SetString(candidate.Weight, 10)
+		if !ok || weight.Sign() <= 0 {
+			continue
+		}
+		weights[i] = weight
+		remaining = append(remaining, i)
+		total.Add(total, weight)
+	}
+
+	var picked []int
+	for k := 0; k < n && total.Sign() > 0; k++ {
+		hash := crypto.Keccak256(seed, common.LeftPadBytes(big.NewInt(int64(k)).Bytes(), 32))
+		r := new(big.Int).Mod(new(big.Int).SetBytes(hash), total)
+
+		for pos, idx := range remaining {
+			if r.Cmp(weights[idx]) < 0 {
+				picked = append(picked, idx)
+				total.Sub(total, weights[idx])
+				remaining = append(remaining[:pos], remaining[pos+1:]...)
+				break
+			}
+			r.Sub(r, weights[idx])
+		}
+	}
+
+	return picked
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func fari(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+func TestJurorWeight(t *testing.T) {
+	tests := []struct {
+		name          string
+		staked        *big.Int
+		participation int
+		want          int64
+	}{
+		{"no participation", fari(5000), 0, 5000},
+		{"three disputes", fari(5000), 3, 6500},
+		{"bonus capped", fari(5000), maxParticipationBonus + 5, 10000},
+		{"whole FARI only", new(big.Int).Add(fari(5000), big.NewInt(9e17)), 0, 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jurorWeight(tt.staked, tt.participation); got.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("jurorWeight = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrawJurors(t *testing.T) {
+	seed := crypto.Keccak256([]byte("fariima"))
+	candidates := []JurorCandidate{
+		{Address: "0x01", Weight: "5000"},
+		{Address: "0x02", Weight: "0"},
+		{Address: "0x03", Weight: "7500"},
+		{Address: "0x04", Weight: "not a number"},
+		{Address: "0x05", Weight: "10000"},
+		{Address: "0x06", Weight: "5000"},
+		{Address: "0x07", Weight: "6000"},
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []int
+	}{
+		{"one", 1, []int{6}},
+		{"three", 3, []int{6, 5, 0}},
+		{"more than the weighted candidates", 10, []int{6, 5, 0, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := drawJurors(seed, candidates, tt.n)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("drawJurors = %v, want %v", got, tt.want)
+			}
+			if again := drawJurors(seed, candidates, tt.n); !reflect.DeepEqual(again, got) {
+				t.Errorf("second draw = %v, want the same %v", again, got)
+			}
+		})
+	}
+
+	// The first pick is keccak256(seed ++ uint256(0)) mod the total weight,
+	// walked over the cumulative weights in address order.
+	r := new(big.Int).SetBytes(crypto.Keccak256(seed, make([]byte, 32)))
+	r.Mod(r, big.NewInt(33500))
+	first := -1
+	for _, c := range []struct {
+		idx    int
+		weight int64
+	}{{0, 5000}, {2, 7500}, {4, 10000}, {5, 5000}, {6, 6000}} {
+		if r.Cmp(big.NewInt(c.weight)) < 0 {
+			first = c.idx
+			break
+		}
+		r.Sub(r, big.NewInt(c.weight))
+	}
+	if got := drawJurors(seed, candidates, 1); !reflect.DeepEqual(got, []int{first}) {
+		t.Errorf("first pick = %v, want [%d]", got, first)
+	}
+
+	if got := drawJurors(seed, nil, 3); len(got) != 0 {
+		t.Errorf("draw from no candidates = %v, want none", got)
+	}
+}
+
+func TestReplacementSeed(t *testing.T) {
+	first := crypto.Keccak256([]byte("fariima"))
+
+	n := make([]byte, 32)
+	n[31] = 1
+	if got, want := replacementSeed(first, 1), crypto.Keccak256(append(append([]byte{}, first...), n...)); !bytes.Equal(got, want) {
+		t.Errorf("replacementSeed(first, 1) = %x, want %x", got, want)
+	}
+
+	seen := map[string]bool{string(first): true}
+	for draw := int64(1); draw <= 4; draw++ {
+		seed := replacementSeed(first, draw)
+		if !bytes.Equal(seed, replacementSeed(first, draw)) {
+			t.Errorf("draw %d: seed is not deterministic", draw)
+		}
+		if seen[string(seed)] {
+			t.Errorf("draw %d: seed %x repeats an earlier one", draw, seed)
+		}
+		seen[string(seed)] = true
+	}
+}
+
+func TestPoolCandidatesForReplacement(t *testing.T) {
+	client, freelancer, conflicted, declined, assigned, free := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	pool := []models.JurorPoolMember{
+		{UserID: client, Address: "0xA1", StakedAmount: fari(9000).String()},
+		{UserID: declined, Address: "0xA2", StakedAmount: fari(6000).String()},
+		{UserID: free, Address: "0xA3", StakedAmount: fari(5000).String(), Participation: 2},
+		{UserID: conflicted, Address: "0xA4", StakedAmount: fari(8000).String()},
+		{UserID: uuid.New(), Address: "0xA5", StakedAmount: "bad"},
+		{UserID: assigned, Address: "0xA6", StakedAmount: fari(7000).String()},
+		{UserID: freelancer, Address: "0xA7", StakedAmount: fari(7000).String()},
+	}
+
+	// A replacement excludes the parties, conflicted users and everyone
+	// already assigned, including the juror who declined.
+	got := poolCandidates(pool, []uuid.UUID{client, freelancer}, []uuid.UUID{conflicted}, []uuid.UUID{declined, assigned})
+	want := []JurorCandidate{
+		{UserID: free, Address: "0xa3", StakedAmount: fari(5000).String(), Participation: 2, Weight: "6000"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("poolCandidates = %+v, want %+v", got, want)
+	}
+
+	seed := replacementSeed(crypto.Keccak256([]byte("fariima")), 1)
+	if picked := drawJurors(seed, got, 1); !reflect.DeepEqual(picked, []int{0}) {
+		t.Errorf("replacement draw = %v, want the only eligible candidate", picked)
+	}
+}
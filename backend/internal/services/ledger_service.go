@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// platformFeeBps is the escrow contract's PLATFORM_FEE_BPS (5%).
+const platformFeeBps = 500
+
+// ledgerReconcileBatch bounds the events recorded per reconcile run.
+const ledgerReconcileBatch = 500
+
+// earningKinds are the entries that pay a freelancer.
+var earningKinds = []models.LedgerKind{models.LedgerKindPayment, models.LedgerKindMilestone, models.LedgerKindDisputePayout}
+
+// invoiceKinds are the entries invoiced to the client: completion and
+// milestone payments. Dispute payouts are not invoiced.
+var invoiceKinds = []models.LedgerKind{models.LedgerKindPayment, models.LedgerKindMilestone}
+
+// platformFee is the fee the escrow contract takes from amount, rounded down.
+func platformFee(amount *big.Int) *big.Int {
+	fee := new(big.Int).Mul(amount, big.NewInt(platformFeeBps))
+	return fee.Div(fee, big.NewInt(10000))
+}
+
+// LedgerService keeps the ledger of escrow payouts and reports freelancer
+// earnings and client spend from it. Amounts are exact integers in each
+// token's base units.
+type LedgerService struct {
+	cfg         *config.Config
+	ledgerRepo  *repositories.LedgerRepository
+	invoiceRepo *repositories.InvoiceRepository
+	escrowRepo  *repositories.EscrowRepository
+	tokens      *TokenRegistry
+	logger      *logrus.Logger
+}
+
+// LedgerQuery selects entries that occurred in [From, To). Nil bounds are
+// open.
+type LedgerQuery struct {
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// LedgerLine is a ledger entry with its amounts in whole tokens.
+type LedgerLine struct {
+	models.LedgerEntry
+	Symbol     string `json:"symbol,omitempty"`
+	GrossUnits string `json:"gross_units,omitempty"`
+	FeeUnits   string `json:"fee_units,omitempty"`
+	NetUnits   string `json:"net_units,omitempty"`
+}
+
+// LedgerTotals are per-token sums of a statement. For earnings Net is what
+// the freelancer received. For spend Gross is what the client paid out of
+// escrow and Refunded what came back to them.
+type LedgerTotals struct {
+	Gross    []TokenAmount `json:"gross"`
+	Fees     []TokenAmount `json:"fees"`
+	Net      []TokenAmount `json:"net"`
+	Refunded []TokenAmount `json:"refunded,omitempty"`
+}
+
+type LedgerStatement struct {
+	Items  []LedgerLine `json:"items"`
+	Totals LedgerTotals `json:"totals"`
+	Total  int64        `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+func NewLedgerService(cfg *config.Config, ledgerRepo *repositories.LedgerRepository, invoiceRepo *repositories.InvoiceRepository, escrowRepo *repositories.EscrowRepository, tokens *TokenRegistry, logger *logrus.Logger) *LedgerService {
+	return &LedgerService{
+		cfg:         cfg,
+		ledgerRepo:  ledgerRepo,
+		invoiceRepo: invoiceRepo,
+		escrowRepo:  escrowRepo,
+		tokens:      tokens,
+		logger:      logger,
+	}
+}
+
+// Record adds the payouts of an indexed escrow event to the ledger and
+// issues invoices for payments. Other events are ignored.
+func (s *LedgerService) Record(event *models.EscrowEvent) error {
+	switch event.EventType {
+	case "release", "milestone_release", "dispute_resolved", "cancelled":
+	default:
+		return nil
+	}
+
+	escrow, err := s.escrowRepo.GetByID(event.EscrowID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := ledgerEntries(escrow, event)
+	if err != nil {
+		return err
+	}
+	if err := s.ledgerRepo.Create(entries); err != nil {
+		return err
+	}
+	return s.issueInvoices()
+}
+
+func (s *LedgerService) issueInvoices() error {
+	issued, err := s.invoiceRepo.IssuePending(s.cfg.InvoicePrefix, invoiceKinds)
+	if err != nil {
+		return fmt.Errorf("failed to issue invoices: %w", err)
+	}
+	if issued > 0 {
+		s.logger.Infof("Issued %d invoices", issued)
+	}
+	return nil
+}
+
+// ReconcileLedger is the JobReconcileLedger handler. It records payout
+// events the indexer stored without ledger entries, including those indexed
+// before the ledger existed, and issues any missing invoices.
+func (s *LedgerService) ReconcileLedger(ctx context.Context, job *models.Job) error {
+	events, err := s.ledgerRepo.UnrecordedEvents(ledgerReconcileBatch)
+	if err != nil {
+		return err
+	}
+
+	recorded := 0
+	for i := range events {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := s.Record(&events[i]); err != nil {
+			s.logger.Errorf("Failed to record escrow event %s in the ledger: %v", events[i].ID, err)
+			if retry, err := s.ledgerRepo.RecordFailure(events[i].ID); err != nil {
+				s.logger.Errorf("Failed to count the ledger failure of escrow event %s: %v", events[i].ID, err)
+			} else if !retry {
+				s.logger.Errorf("Giving up on recording escrow event %s in the ledger", events[i].ID)
+			}
+			continue
+		}
+		recorded++
+	}
+
+	if recorded > 0 {
+		s.logger.Infof("Recorded %d escrow events in the ledger", recorded)
+	}
+	return s.issueInvoices()
+}
+
+// ledgerEntries derives the entries of a payout event from its amounts.
+// escrow must have its project loaded.
+// ProjectCompleted reports the payment and fee separately. Milestone
+// releases report the amount leaving escrow, from which the contract takes
+// its fee as on completion. Dispute resolutions carry no fee; the client's
+// share is recorded as a refund when there is one. Cancellations after
+// funding refund the whole deposit, also without a fee.
+func ledgerEntries(escrow *models.Escrow, event *models.EscrowEvent) ([]models.LedgerEntry, error) {
+	base := models.LedgerEntry{
+		EventID:        event.ID,
+		EscrowID:       escrow.ID,
+		ProjectID:      escrow.ProjectID,
+		ClientID:       escrow.Project.ClientID,
+		FreelancerID:   escrow.Project.FreelancerID,
+		ClientAddr:     escrow.ClientAddr,
+		FreelancerAddr: escrow.FreelancerAddr,
+		Token:          escrow.Token,
+		TxHash:         event.TxHash,
+		BlockNumber:    event.BlockNumber,
+		OccurredAt:     event.CreatedAt,
+	}
+	if event.BlockTime != nil {
+		base.OccurredAt = *event.BlockTime
+	}
+
+	entry := func(kind models.LedgerKind, gross, fee *big.Int) models.LedgerEntry {
+		e := base
+		e.Kind = kind
+		e.Gross = gross.String()
+		e.Fee = fee.String()
+		e.Net = new(big.Int).Sub(gross, fee).String()
+		return e
+	}
+
+	switch event.EventType {
+	case "release":
+		payment, err := eventAmount(event, "freelancer_payment")
+		if err != nil {
+			return nil, err
+		}
+		fee, err := eventAmount(event, "platform_fee")
+		if err != nil {
+			return nil, err
+		}
+		return []models.LedgerEntry{entry(models.LedgerKindPayment, new(big.Int).Add(payment, fee), fee)}, nil
+
+	case "milestone_release":
+		amount, err := eventAmount(event, "amount")
+		if err != nil {
+			return nil, err
+		}
+		index, err := eventAmount(event, "milestone_index")
+		if err != nil {
+			return nil, err
+		}
+		e := entry(models.LedgerKindMilestone, amount, platformFee(amount))
+		milestone := int(index.Int64())
+		e.MilestoneIndex = &milestone
+		return []models.LedgerEntry{e}, nil
+
+	case "dispute_resolved":
+		payout, err := eventAmount(event, "freelancer_amount")
+		if err != nil {
+			return nil, err
+		}
+		refund, err := eventAmount(event, "client_refund")
+		if err != nil {
+			return nil, err
+		}
+		// The payout is kept even when zero so that every resolution has an
+		// entry and is not picked up again by the reconcile job
+		entries := []models.LedgerEntry{entry(models.LedgerKindDisputePayout, payout, new(big.Int))}
+		if refund.Sign() > 0 {
+			entries = append(entries, entry(models.LedgerKindRefund, refund, new(big.Int)))
+		}
+		return entries, nil
+
+	case "cancelled":
+		refund, err := eventAmount(event, "refund_amount")
+		if err != nil {
+			return nil, err
+		}
+		// Cancellations before funding move nothing; the reconcile job
+		// skips them
+		if refund.Sign() == 0 {
+			return nil, nil
+		}
+		return []models.LedgerEntry{entry(models.LedgerKindRefund, refund, new(big.Int))}, nil
+	}
+
+	return nil, nil
+}
+
+func eventAmount(event *models.EscrowEvent, key string) (*big.Int, error) {
+	value, _ := event.Data[key].(string)
+	amount, ok := parseAmount(value)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("escrow event %s has no valid %s", event.ID, key)
+	}
+	return amount, nil
+}
+
+// GetEarnings returns what the user was paid as a freelancer.
+func (s *LedgerService) GetEarnings(userID uuid.UUID, query LedgerQuery) (*LedgerStatement, error) {
+	return s.statement(repositories.LedgerFilter{FreelancerID: &userID, Kinds: earningKinds}, query)
+}
+
+// GetSpend returns what the user paid out of escrow as a client, and what
+// was refunded to them.
+func (s *LedgerService) GetSpend(userID uuid.UUID, query LedgerQuery) (*LedgerStatement, error) {
+	return s.statement(repositories.LedgerFilter{ClientID: &userID}, query)
+}
+
+// EarnedValue is the USD value at par of everything the user was paid as a
+// freelancer in known stablecoins.
+func (s *LedgerService) EarnedValue(userID uuid.UUID) (float64, error) {
+	totals, err := s.ledgerRepo.Totals(repositories.LedgerFilter{FreelancerID: &userID, Kinds: earningKinds})
+	if err != nil {
+		return 0, err
+	}
+
+	net := map[string]*big.Int{}
+	for _, total := range totals {
+		addAmount(net, total.Token, total.Net)
+	}
+	return s.tokens.USDValue(net), nil
+}
+
+func (s *LedgerService) statement(filter repositories.LedgerFilter, query LedgerQuery) (*LedgerStatement, error) {
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidInput)
+	}
+	filter.From, filter.To = query.From, query.To
+
+	entries, total, err := s.ledgerRepo.List(filter, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.ledgerRepo.Totals(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &LedgerStatement{
+		Items:  make([]LedgerLine, len(entries)),
+		Totals: s.sumTotals(totals),
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	for i, entry := range entries {
+		statement.Items[i] = s.line(entry)
+	}
+	return statement, nil
+}
+
+func (s *LedgerService) line(entry models.LedgerEntry) LedgerLine {
+	line := LedgerLine{LedgerEntry: entry}
+	token, ok := s.tokens.Lookup(entry.Token)
+	if !ok {
+		return line
+	}
+
+	line.Symbol = token.Symbol
+	for _, amount := range []struct {
+		value string
+		units *string
+	}{
+		{entry.Gross, &line.GrossUnits},
+		{entry.Fee, &line.FeeUnits},
+		{entry.Net, &line.NetUnits},
+	} {
+		if parsed, ok := parseAmount(amount.value); ok {
+			*amount.units = formatUnits(parsed, token.Decimals)
+		}
+	}
+	return line
+}
+
+func (s *LedgerService) sumTotals(totals []repositories.LedgerTotal) LedgerTotals {
+	gross, fees, net, refunded := map[string]*big.Int{}, map[string]*big.Int{}, map[string]*big.Int{}, map[string]*big.Int{}
+	for _, total := range totals {
+		if total.Kind == models.LedgerKindRefund {
+			addAmount(refunded, total.Token, total.Net)
+			continue
+		}
+		addAmount(gross, total.Token, total.Gross)
+		addAmount(fees, total.Token, total.Fee)
+		addAmount(net, total.Token, total.Net)
+	}
+
+	result := LedgerTotals{
+		Gross: s.tokens.Amounts(gross),
+		Fees:  s.tokens.Amounts(fees),
+		Net:   s.tokens.Amounts(net),
+	}
+	if len(refunded) > 0 {
+		result.Refunded = s.tokens.Amounts(refunded)
+	}
+	return result
+}
+
+// addAmount adds a base-unit amount to the token's total.
+func addAmount(totals map[string]*big.Int, token, value string) {
+	amount, ok := parseAmount(value)
+	if !ok {
+		return
+	}
+	if total, ok := totals[token]; ok {
+		total.Add(total, amount)
+		return
+	}
+	totals[token] = amount
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestPlatformFee(t *testing.T) {
+	tests := []struct{ amount, want int64 }{
+		{0, 0},
+		{19, 0}, // Rounded down like the contract
+		{20, 1},
+		{1000000, 50000},
+		{1000019, 50000},
+	}
+	for _, tt := range tests {
+		if got := platformFee(big.NewInt(tt.amount)); got.Int64() != tt.want {
+			t.Errorf("platformFee(%d) = %s, want %d", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestLedgerEntries(t *testing.T) {
+	freelancerID := uuid.New()
+	escrow := &models.Escrow{
+		ID:             uuid.New(),
+		ProjectID:      uuid.New(),
+		Project:        models.Project{ClientID: uuid.New(), FreelancerID: &freelancerID},
+		ClientAddr:     "0xclient",
+		FreelancerAddr: "0xfreelancer",
+		Token:          "0xtoken",
+	}
+	indexedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	blockTime := time.Date(2026, 3, 1, 11, 59, 0, 0, time.UTC)
+
+	type want struct {
+		kind           models.LedgerKind
+		gross, fee     string
+		net            string
+		milestoneIndex *int
+	}
+	milestone := 2
+
+	tests := []struct {
+		name      string
+		eventType string
+		data      map[string]interface{}
+		want      []want
+		wantErr   bool
+	}{
+		{
+			name:      "completion",
+			eventType: "release",
+			data:      map[string]interface{}{"freelancer_payment": "950000", "platform_fee": "50000"},
+			want:      []want{{models.LedgerKindPayment, "1000000", "50000", "950000", nil}},
+		},
+		{
+			name:      "milestone",
+			eventType: "milestone_release",
+			data:      map[string]interface{}{"amount": "1000019", "milestone_index": "2"},
+			want:      []want{{models.LedgerKindMilestone, "1000019", "50000", "950019", &milestone}},
+		},
+		{
+			name:      "dispute split",
+			eventType: "dispute_resolved",
+			data:      map[string]interface{}{"freelancer_amount": "700000", "client_refund": "300000"},
+			want: []want{
+				{models.LedgerKindDisputePayout, "700000", "0", "700000", nil},
+				{models.LedgerKindRefund, "300000", "0", "300000", nil},
+			},
+		},
+		{
+			name:      "dispute won by the freelancer",
+			eventType: "dispute_resolved",
+			data:      map[string]interface{}{"freelancer_amount": "1000000", "client_refund": "0"},
+			want:      []want{{models.LedgerKindDisputePayout, "1000000", "0", "1000000", nil}},
+		},
+		{
+			name:      "dispute won by the client",
+			eventType: "dispute_resolved",
+			data:      map[string]interface{}{"freelancer_amount": "0", "client_refund": "1000000"},
+			want: []want{
+				{models.LedgerKindDisputePayout, "0", "0", "0", nil},
+				{models.LedgerKindRefund, "1000000", "0", "1000000", nil},
+			},
+		},
+		{
+			name:      "cancelled with a refund",
+			eventType: "cancelled",
+			data:      map[string]interface{}{"refund_amount": "1000000"},
+			want:      []want{{models.LedgerKindRefund, "1000000", "0", "1000000", nil}},
+		},
+		{
+			name:      "cancelled before funding",
+			eventType: "cancelled",
+			data:      map[string]interface{}{"refund_amount": "0"},
+		},
+		{
+			name:      "cancelled without an amount",
+			eventType: "cancelled",
+			data:      map[string]interface{}{},
+			wantErr:   true,
+		},
+		{
+			name:      "not a payout",
+			eventType: "funded",
+			data:      map[string]interface{}{"amount": "1000000"},
+		},
+		{
+			name:      "missing amount",
+			eventType: "release",
+			data:      map[string]interface{}{"freelancer_payment": "950000"},
+			wantErr:   true,
+		},
+		{
+			name:      "negative amount",
+			eventType: "milestone_release",
+			data:      map[string]interface{}{"amount": "-1", "milestone_index": "0"},
+			wantErr:   true,
+		},
+		{
+			name:      "amount not a string",
+			eventType: "dispute_resolved",
+			data:      map[string]interface{}{"freelancer_amount": 700000.0, "client_refund": "0"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &models.EscrowEvent{
+				ID:          uuid.New(),
+				EventType:   tt.eventType,
+				TxHash:      "0xtx",
+				BlockNumber: 123,
+				BlockTime:   &blockTime,
+				Data:        tt.data,
+				CreatedAt:   indexedAt,
+			}
+
+			entries, err := ledgerEntries(escrow, event)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %d entries, want an error", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(tt.want))
+			}
+
+			for i, w := range tt.want {
+				e := entries[i]
+				if e.Kind != w.kind || e.Gross != w.gross || e.Fee != w.fee || e.Net != w.net {
+					t.Errorf("entry %d is %s %s/%s/%s, want %s %s/%s/%s",
+						i, e.Kind, e.Gross, e.Fee, e.Net, w.kind, w.gross, w.fee, w.net)
+				}
+				if (e.MilestoneIndex == nil) != (w.milestoneIndex == nil) ||
+					e.MilestoneIndex != nil && *e.MilestoneIndex != *w.milestoneIndex {
+					t.Errorf("entry %d has milestone index %v, want %v", i, e.MilestoneIndex, w.milestoneIndex)
+				}
+
+				if e.EventID != event.ID || e.EscrowID != escrow.ID || e.ProjectID != escrow.ProjectID {
+					t.Errorf("entry %d is not linked to its event, escrow and project", i)
+				}
+				if e.ClientID != escrow.Project.ClientID || e.FreelancerID != escrow.Project.FreelancerID {
+					t.Errorf("entry %d has the wrong parties", i)
+				}
+				if e.Token != escrow.Token || e.TxHash != event.TxHash || e.BlockNumber != event.BlockNumber {
+					t.Errorf("entry %d has the wrong token or transaction", i)
+				}
+				if !e.OccurredAt.Equal(blockTime) {
+					t.Errorf("entry %d occurred at %v, want the block time %v", i, e.OccurredAt, blockTime)
+				}
+			}
+		})
+	}
+}
+
+func TestLedgerEntriesWithoutBlockTime(t *testing.T) {
+	indexedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := &models.EscrowEvent{
+		ID:        uuid.New(),
+		EventType: "release",
+		Data:      map[string]interface{}{"freelancer_payment": "95", "platform_fee": "5"},
+		CreatedAt: indexedAt,
+	}
+
+	entries, err := ledgerEntries(&models.Escrow{ID: uuid.New()}, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].OccurredAt.Equal(indexedAt) {
+		t.Errorf("got %+v, want one entry at the indexing time", entries)
+	}
+}
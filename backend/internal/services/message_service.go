@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	maxMessageLength      = 10000
+	maxMessageAttachments = 10
+)
+
+// MaxAttachmentSize caps a single message attachment.
+const MaxAttachmentSize = 10 << 20 // 10 MB
+
+type MessageService struct {
+	messageRepo   *repositories.MessageRepository
+	projectRepo   *repositories.ProjectRepository
+	disputeRepo   *repositories.DisputeRepository
+	ipfsService   *IPFSService
+	uploadService *UploadService
+	wsService     *WebSocketService
+	logger        *logrus.Logger
+}
+
+// AttachmentUpload is a file received with a message before it is pinned to IPFS.
+type AttachmentUpload struct {
+	FileName string
+	Content  io.Reader
+}
+
+type ConversationSummary struct {
+	models.Conversation
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func NewMessageService(
+	messageRepo *repositories.MessageRepository,
+	projectRepo *repositories.ProjectRepository,
+	disputeRepo *repositories.DisputeRepository,
+	ipfsService *IPFSService,
+	uploadService *UploadService,
+	wsService *WebSocketService,
+	logger *logrus.Logger,
+) *MessageService {
+	return &MessageService{
+		messageRepo:   messageRepo,
+		projectRepo:   projectRepo,
+		disputeRepo:   disputeRepo,
+		ipfsService:   ipfsService,
+		uploadService: uploadService,
+		wsService:     wsService,
+		logger:        logger,
+	}
+}
+
+// OpenConversation returns the conversation for a project or application,
+// creating it on first use. Application chats only open once the
+// application has been accepted; project chats need a hired freelancer.
+func (s *MessageService) OpenConversation(userID, projectID uuid.UUID, applicationID *uuid.UUID) (*models.Conversation, error) {
+	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if applicationID != nil {
+		app, err := s.projectRepo.GetApplicationByID(*applicationID)
+		if err != nil || app.ProjectID != project.ID {
+			return nil, ErrNotFound
+		}
+		if userID != project.ClientID && userID != app.FreelancerID {
+			return nil, ErrForbidden
+		}
+		if app.Status != "accepted" {
+			return nil, fmt.Errorf("%w: chat opens after the application is accepted", ErrInvalidInput)
+		}
+
+		conversation, err := s.messageRepo.GetApplicationConversation(app.ID)
+		if err == nil {
+			return conversation, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		conversation = &models.Conversation{
+			ProjectID:     project.ID,
+			ApplicationID: &app.ID,
+			Scope:         models.ConversationScopeApplication,
+		}
+		if err := s.messageRepo.CreateConversation(conversation); err != nil {
+			return nil, err
+		}
+		return s.messageRepo.GetApplicationConversation(app.ID)
+	}
+
+	if project.FreelancerID == nil {
+		return nil, fmt.Errorf("%w: project has no hired freelancer yet", ErrInvalidInput)
+	}
+	if userID != project.ClientID && userID != *project.FreelancerID {
+		return nil, ErrForbidden
+	}
+
+	conversation, err := s.messageRepo.GetProjectConversation(project.ID)
+	if err == nil {
+		return conversation, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	conversation = &models.Conversation{
+		ProjectID: project.ID,
+		Scope:     models.ConversationScopeProject,
+	}
+	if err := s.messageRepo.CreateConversation(conversation); err != nil {
+		return nil, err
+	}
+	return s.messageRepo.GetProjectConversation(project.ID)
+}
+
+func (s *MessageService) GetConversation(userID, conversationID uuid.UUID) (*models.Conversation, error) {
+	conversation, err := s.messageRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if err := s.authorizeRead(userID, conversation); err != nil {
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+func (s *MessageService) ListConversations(userID uuid.UUID, limit, offset int) ([]ConversationSummary, int64, error) {
+	conversations, total, err := s.messageRepo.ListUserConversations(userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	summaries := make([]ConversationSummary, 0, len(conversations))
+	for _, conversation := range conversations {
+		unread, err := s.messageRepo.CountUnread(conversation.ID, userID)
+		if err != nil {
+			return nil, 0, err
+		}
+		summaries = append(summaries, ConversationSummary{
+			Conversation: conversation,
+			UnreadCount:  unread,
+		})
+	}
+
+	return summaries, total, nil
+}
+
+// ListMessages returns a page of messages, newest first.
+func (s *MessageService) ListMessages(userID, conversationID uuid.UUID, limit, offset int) ([]models.Message, int64, error) {
+	if _, err := s.GetConversation(userID, conversationID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.messageRepo.ListMessages(conversationID, limit, offset)
+}
+
+// uploadAttachment stores an attachment as an upload of the sender, which
+// checks its type and the sender's quota and screens it. Flagged attachments
+// are sent quarantined, without a link, until a moderator releases them.
+func (s *MessageService) uploadAttachment(userID uuid.UUID, input AttachmentUpload) (*models.MessageAttachment, error) {
+	upload, err := s.uploadService.Upload(context.Background(), userID, UploadInput{
+		Purpose:  models.UploadPurposeAttachment,
+		FileName: input.FileName,
+	}, input.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment %s: %w", input.FileName, err)
+	}
+
+	attachment := &models.MessageAttachment{
+		FileName: upload.FileName,
+		MimeType: upload.MimeType,
+		Size:     upload.Size,
+		IPFSHash: upload.CID,
+		UploadID: &upload.ID,
+	}
+	if upload.ScanStatus == models.ScanStatusQuarantined {
+		attachment.Quarantined = true
+	} else {
+		attachment.FileURL = s.ipfsService.GetFileURL(upload.CID)
+	}
+	return attachment, nil
+}
+
+func (s *MessageService) SendMessage(userID, conversationID uuid.UUID, body string, uploads []AttachmentUpload) (*models.Message, error) {
+	conversation, err := s.messageRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	participants := s.participants(conversation)
+	if !containsUUID(participants, userID) {
+		return nil, ErrForbidden
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" && len(uploads) == 0 {
+		return nil, fmt.Errorf("%w: message is empty", ErrInvalidInput)
+	}
+	if len(body) > maxMessageLength {
+		return nil, fmt.Errorf("%w: message is too long", ErrInvalidInput)
+	}
+	if len(uploads) > maxMessageAttachments {
+		return nil, fmt.Errorf("%w: too many attachments", ErrInvalidInput)
+	}
+
+	message := &models.Message{
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		Body:           body,
+	}
+
+	for _, upload := range uploads {
+		attachment, err := s.uploadAttachment(userID, upload)
+		if err != nil {
+			return nil, err
+		}
+		message.Attachments = append(message.Attachments, *attachment)
+	}
+
+	if err := s.messageRepo.CreateMessage(message); err != nil {
+		return nil, err
+	}
+
+	if err := s.messageRepo.TouchConversation(conversation.ID, message.CreatedAt); err != nil {
+		s.logger.Errorf("Failed to update conversation %s: %v", conversation.ID, err)
+	}
+
+	// The sender has read everything up to their own message.
+	if _, err := s.markRead(conversation.ID, userID, message.ID, message.CreatedAt); err != nil {
+		s.logger.Errorf("Failed to record read receipt for %s: %v", userID, err)
+	}
+
+	s.notify(participants, userID, WSMessage{
+		Type:    "message_new",
+		Payload: message,
+	})
+
+	return message, nil
+}
+
+// MarkRead records a read receipt up to and including the given message.
+// Marking an older message than the last one read changes nothing.
+func (s *MessageService) MarkRead(userID, conversationID, messageID uuid.UUID) error {
+	conversation, err := s.GetConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	message, err := s.messageRepo.GetMessageByID(messageID)
+	if err != nil || message.ConversationID != conversation.ID {
+		return ErrNotFound
+	}
+
+	advanced, err := s.markRead(conversation.ID, userID, message.ID, message.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return nil
+	}
+
+	s.notify(s.participants(conversation), userID, WSMessage{
+		Type: "message_read",
+		Payload: map[string]interface{}{
+			"conversation_id": conversation.ID,
+			"user_id":         userID,
+			"message_id":      message.ID,
+			"read_at":         message.CreatedAt,
+		},
+	})
+
+	return nil
+}
+
+func (s *MessageService) GetReadReceipts(userID, conversationID uuid.UUID) ([]models.ConversationRead, error) {
+	if _, err := s.GetConversation(userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	return s.messageRepo.GetReads(conversationID)
+}
+
+// SendTyping relays a typing indicator to the other participants. Nothing is persisted.
+func (s *MessageService) SendTyping(userID, conversationID uuid.UUID) error {
+	conversation, err := s.messageRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	participants := s.participants(conversation)
+	if !containsUUID(participants, userID) {
+		return ErrForbidden
+	}
+
+	s.notify(participants, userID, WSMessage{
+		Type: "typing",
+		Payload: map[string]interface{}{
+			"conversation_id": conversation.ID,
+			"user_id":         userID,
+		},
+	})
+
+	return nil
+}
+
+func (s *MessageService) markRead(conversationID, userID, messageID uuid.UUID, readAt time.Time) (bool, error) {
+	return s.messageRepo.UpsertRead(&models.ConversationRead{
+		ConversationID:    conversationID,
+		UserID:            userID,
+		LastReadMessageID: messageID,
+		LastReadAt:        readAt,
+	})
+}
+
+// participants returns the users who can write to the conversation.
+func (s *MessageService) participants(conversation *models.Conversation) []uuid.UUID {
+	participants := []uuid.UUID{conversation.Project.ClientID}
+
+	switch conversation.Scope {
+	case models.ConversationScopeApplication:
+		if conversation.Application != nil {
+			participants = append(participants, conversation.Application.FreelancerID)
+		}
+	case models.ConversationScopeProject:
+		if conversation.Project.FreelancerID != nil {
+			participants = append(participants, *conversation.Project.FreelancerID)
+		}
+	}
+
+	return participants
+}
+
+// authorizeRead allows participants, and jurors assigned to an unresolved
+// dispute raised for the project, to read the conversation.
+func (s *MessageService) authorizeRead(userID uuid.UUID, conversation *models.Conversation) error {
+	if containsUUID(s.participants(conversation), userID) {
+		return nil
+	}
+
+	isJuror, err := s.disputeRepo.IsProjectJuror(conversation.ProjectID, userID)
+	if err != nil {
+		return err
+	}
+	if !isJuror {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (s *MessageService) notify(recipients []uuid.UUID, senderID uuid.UUID, message WSMessage) {
+	for _, recipient := range recipients {
+		if recipient == senderID {
+			continue
+		}
+		if err := s.wsService.SendToUser(recipient.String(), message); err != nil {
+			s.logger.Errorf("Failed to deliver %s to %s: %v", message.Type, recipient, err)
+		}
+	}
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -1,37 +1,390 @@
 package services
 
 import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fariima/backend/internal/config"
 	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/repositories"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+// nftAmountDecimals mirrors ProofOfWorkNFT._formatAmount, which assumes a
+// 6-decimal stablecoin.
+const nftAmountDecimals = 6
+
+// NFTMetadata is ERC-721 metadata in the format marketplaces such as OpenSea
+// read from a tokenURI.
+type NFTMetadata struct {
+	Name            string         `json:"name"`
+	Description     string         `json:"description"`
+	Image           string         `json:"image"`
+	ExternalURL     string         `json:"external_url"`
+	BackgroundColor string         `json:"background_color"`
+	Attributes      []NFTAttribute `json:"attributes"`
+	Links           NFTLinks       `json:"links"`
+}
+
+type NFTAttribute struct {
+	DisplayType string      `json:"display_type,omitempty"`
+	TraitType   string      `json:"trait_type"`
+	Value       interface{} `json:"value"`
+	MaxValue    int         `json:"max_value,omitempty"`
+}
+
+// NFTLinks point back to the platform pages behind a certificate.
+type NFTLinks struct {
+	Project    string `json:"project"`
+	Freelancer string `json:"freelancer"`
+	Client     string `json:"client,omitempty"`
+	Review     string `json:"review,omitempty"`
+}
+
+// nftMetadata is ProofOfWorkNFT.ProjectMetadata.
+type nftMetadata struct {
+	ProjectId      *big.Int
+	ProjectTitle   string
+	Amount         *big.Int
+	Client         common.Address
+	Freelancer     common.Address
+	CompletionDate *big.Int
+	Category       string
+}
+
 type NFTService struct {
-	nftRepo           *repositories.NFTRepository
-	blockchainService *BlockchainService
-	logger            *logrus.Logger
+	cfg                 *config.Config
+	nftRepo             *repositories.NFTRepository
+	projectRepo         *repositories.ProjectRepository
+	userRepo            *repositories.UserRepository
+	blockchainService   *BlockchainService
+	notificationService *NotificationService
+	logger              *logrus.Logger
 }
 
 func NewNFTService(
+	cfg *config.Config,
 	nftRepo *repositories.NFTRepository,
+	projectRepo *repositories.ProjectRepository,
+	userRepo *repositories.UserRepository,
 	blockchainService *BlockchainService,
+	notificationService *NotificationService,
 	logger *logrus.Logger,
 ) *NFTService {
 	return &NFTService{
-		nftRepo:           nftRepo,
-		blockchainService: blockchainService,
-		logger:            logger,
+		cfg:                 cfg,
+		nftRepo:             nftRepo,
+		projectRepo:         projectRepo,
+		userRepo:            userRepo,
+		blockchainService:   blockchainService,
+		notificationService: notificationService,
+		logger:              logger,
 	}
 }
 
+// GetNFTByTokenID returns an NFT, enriching it from the contract first if it
+// was indexed before enrichment existed.
 func (s *NFTService) GetNFTByTokenID(tokenID int64) (*models.NFT, error) {
-	return s.nftRepo.GetByTokenID(tokenID)
+	nft, err := s.nftRepo.GetByTokenID(tokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if nft.CompletedAt == nil {
+		if err := s.enrich(nft); err != nil {
+			s.logger.Warnf("Failed to enrich NFT #%d: %v", tokenID, err)
+		} else if err := s.nftRepo.Update(nft); err != nil {
+			s.logger.Errorf("Failed to save enriched NFT #%d: %v", tokenID, err)
+		}
+	}
+
+	return nft, nil
 }
 
 func (s *NFTService) GetUserNFTs(ownerAddress string) ([]models.NFT, error) {
+	if !common.IsHexAddress(ownerAddress) {
+		return nil, ErrInvalidInput
+	}
 	return s.nftRepo.GetByOwnerAddress(ownerAddress)
 }
 
 func (s *NFTService) CreateNFT(nft *models.NFT) error {
 	return s.nftRepo.Create(nft)
 }
+
+// TokenURI is the tokenURI of an NFT served by this API. TokenURIBase plus
+// the token ID gives the same URL, for use as an ERC-721 base URI.
+func (s *NFTService) TokenURI(tokenID int64) string {
+	return fmt.Sprintf("%s%d", s.TokenURIBase(), tokenID)
+}
+
+func (s *NFTService) TokenURIBase() string {
+	return s.cfg.PublicAPIURL + "/api/v1/public/nfts/"
+}
+
+func (s *NFTService) imageURL(tokenID int64) string {
+	return fmt.Sprintf("%s/image.svg", s.TokenURI(tokenID))
+}
+
+// Metadata returns the NFT's tokenURI metadata.
+func (s *NFTService) Metadata(tokenID int64) (*NFTMetadata, error) {
+	nft, err := s.GetNFTByTokenID(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &NFTMetadata{
+		Name:            nft.Name,
+		Description:     nft.Description,
+		Image:           s.imageURL(tokenID),
+		ExternalURL:     s.cfg.AppBaseURL + "/projects/" + nft.ProjectID.String(),
+		BackgroundColor: "667eea",
+		Attributes: []NFTAttribute{
+			{TraitType: "Project ID", Value: fmt.Sprint(nft.OnChainProjectID)},
+			{TraitType: "Category", Value: nft.Category},
+			{TraitType: "Amount", Value: formatNFTAmount(nft.Amount, nft.Project.Currency)},
+			{TraitType: "Soulbound", Value: "Yes"},
+		},
+		Links: NFTLinks{
+			Project:    s.cfg.AppBaseURL + "/projects/" + nft.ProjectID.String(),
+			Freelancer: s.cfg.AppBaseURL + "/users/" + strings.ToLower(nft.OwnerAddr),
+		},
+	}
+
+	if nft.CompletedAt != nil {
+		metadata.Attributes = append(metadata.Attributes, NFTAttribute{
+			DisplayType: "date",
+			TraitType:   "Completion Date",
+			Value:       nft.CompletedAt.Unix(),
+		})
+	}
+	if nft.ClientAddr != "" {
+		metadata.Links.Client = s.cfg.AppBaseURL + "/users/" + nft.ClientAddr
+	}
+
+	review, err := s.projectRepo.GetReview(nft.ProjectID, nft.OwnerID)
+	if err == nil {
+		metadata.Attributes = append(metadata.Attributes, NFTAttribute{
+			DisplayType: "number",
+			TraitType:   "Client Rating",
+			Value:       review.Rating,
+			MaxValue:    5,
+		})
+		metadata.Links.Review = fmt.Sprintf("%s/projects/%s#review-%s", s.cfg.AppBaseURL, nft.ProjectID, review.ID)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// Image renders the NFT's certificate as SVG, following
+// ProofOfWorkNFT._generateSVG.
+func (s *NFTService) Image(tokenID int64) (string, error) {
+	nft, err := s.GetNFTByTokenID(tokenID)
+	if err != nil {
+		return "", err
+	}
+
+	title := []rune(nft.Project.Title)
+	if len(title) > 30 {
+		title = append(title[:27], []rune("...")...)
+	}
+
+	completed := ""
+	if nft.CompletedAt != nil {
+		completed = "Completed on Polygon | " + nft.CompletedAt.UTC().Format("2 Jan 2006")
+	}
+
+	return fmt.Sprintf(nftSVG,
+		tokenID,
+		xmlEscape(string(title)),
+		xmlEscape(formatNFTAmount(nft.Amount, nft.Project.Currency)),
+		shortenAddress(nft.OwnerAddr),
+		shortenAddress(nft.ClientAddr),
+		completed,
+	), nil
+}
+
+const nftSVG = `<svg width="500" height="700" xmlns="http://www.w3.org/2000/svg">` +
+	`<defs><linearGradient id="grad" x1="0%%" y1="0%%" x2="100%%" y2="100%%">` +
+	`<stop offset="0%%" style="stop-color:#667eea;stop-opacity:1" />` +
+	`<stop offset="100%%" style="stop-color:#764ba2;stop-opacity:1" />` +
+	`</linearGradient></defs>` +
+	`<rect width="500" height="700" fill="url(#grad)"/>` +
+	`<rect x="20" y="20" width="460" height="660" fill="none" stroke="white" stroke-width="3" rx="15"/>` +
+	`<text x="250" y="80" font-family="Arial, sans-serif" font-size="36" font-weight="bold" fill="white" text-anchor="middle">FARIIMA</text>` +
+	`<text x="250" y="120" font-family="Arial, sans-serif" font-size="18" fill="white" text-anchor="middle" opacity="0.9">Proof of Work Certificate</text>` +
+	`<line x1="60" y1="150" x2="440" y2="150" stroke="white" stroke-width="2" opacity="0.5"/>` +
+	`<text x="250" y="200" font-family="Arial, sans-serif" font-size="16" fill="white" text-anchor="middle">Certificate #%d</text>` +
+	`<text x="250" y="260" font-family="Arial, sans-serif" font-size="14" fill="white" text-anchor="middle" opacity="0.7">PROJECT</text>` +
+	`<text x="250" y="290" font-family="Arial, sans-serif" font-size="20" font-weight="bold" fill="white" text-anchor="middle">%s</text>` +
+	`<text x="250" y="360" font-family="Arial, sans-serif" font-size="14" fill="white" text-anchor="middle" opacity="0.7">VALUE</text>` +
+	`<text x="250" y="390" font-family="Arial, sans-serif" font-size="24" font-weight="bold" fill="white" text-anchor="middle">%s</text>` +
+	`<text x="250" y="460" font-family="Arial, sans-serif" font-size="14" fill="white" text-anchor="middle" opacity="0.7">EARNED BY</text>` +
+	`<text x="250" y="490" font-family="monospace" font-size="12" fill="white" text-anchor="middle">%s</text>` +
+	`<text x="250" y="540" font-family="Arial, sans-serif" font-size="14" fill="white" text-anchor="middle" opacity="0.7">CLIENT</text>` +
+	`<text x="250" y="570" font-family="monospace" font-size="12" fill="white" text-anchor="middle">%s</text>` +
+	`<text x="250" y="630" font-family="Arial, sans-serif" font-size="12" fill="white" text-anchor="middle" opacity="0.7">%s</text>` +
+	`</svg>`
+
+// Indexing
+
+// RecordMint indexes an NFTMinted event and enriches it with the token's
+// on-chain metadata.
+func (s *NFTService) RecordMint(log types.Log) error {
+	// NFTMinted(uint256 indexed tokenId, address indexed freelancer, uint256 indexed projectId, uint256 amount)
+	if len(log.Topics) < 4 {
+		return nil
+	}
+
+	tokenID := new(big.Int).SetBytes(log.Topics[1].Bytes()).Int64()
+	to := common.BytesToAddress(log.Topics[2].Bytes())
+	projectID := new(big.Int).SetBytes(log.Topics[3].Bytes()).Int64()
+
+	s.logger.Infof("NFT minted: TokenID=%d, Owner=%s", tokenID, to.Hex())
+
+	if _, err := s.nftRepo.GetByTokenID(tokenID); err == nil {
+		return nil // Already indexed
+	}
+
+	project, err := s.projectRepo.GetByOnChainID(projectID)
+	if err != nil || project.FreelancerID == nil {
+		s.logger.Warnf("NFT minted: on-chain project %d is not linked to a project", projectID)
+		return nil
+	}
+
+	var data struct {
+		Amount *big.Int
+	}
+	if err := nftABI.UnpackIntoInterface(&data, "NFTMinted", log.Data); err != nil {
+		return err
+	}
+
+	mintedAt, err := s.blockchainService.GetBlockTime(log.BlockNumber)
+	if err != nil {
+		return err
+	}
+
+	nft := &models.NFT{
+		TokenID:          tokenID,
+		OwnerID:          *project.FreelancerID,
+		ProjectID:        project.ID,
+		OwnerAddr:        strings.ToLower(to.Hex()),
+		TokenURI:         s.TokenURI(tokenID),
+		Name:             fmt.Sprintf("FARIIMA Proof of Work #%d", tokenID),
+		Description:      "Verified completion certificate for project: " + project.Title,
+		Image:            s.imageURL(tokenID),
+		OnChainProjectID: projectID,
+		Amount:           data.Amount.String(),
+		Category:         project.Category,
+		MintTxHash:       log.TxHash.Hex(),
+		BlockNumber:      log.BlockNumber,
+		MintedAt:         mintedAt,
+	}
+	if project.Client.Address != "" {
+		nft.ClientID = &project.ClientID
+		nft.ClientAddr = strings.ToLower(project.Client.Address)
+	}
+
+	if err := s.enrich(nft); err != nil {
+		// Keep the event data; the NFT is enriched again when it is read
+		s.logger.Warnf("Failed to read metadata of NFT #%d: %v", tokenID, err)
+	}
+
+	if err := s.nftRepo.Create(nft); err != nil {
+		return err
+	}
+
+	s.notificationService.Notify(nft.OwnerID, NotificationEvent{
+		Type:  models.NotificationNFTMinted,
+		Title: "Proof-of-work NFT minted",
+		Body:  fmt.Sprintf("You received NFT #%d for %q.", tokenID, project.Title),
+		Data: map[string]interface{}{
+			"project_id": project.ID,
+			"token_id":   tokenID,
+		},
+	})
+
+	return nil
+}
+
+// enrich fills the NFT from ProofOfWorkNFT.getMetadata.
+func (s *NFTService) enrich(nft *models.NFT) error {
+	out, err := s.blockchainService.CallContract("nft", nftABI, "getMetadata", nil, big.NewInt(nft.TokenID))
+	if err != nil {
+		return err
+	}
+	metadata := *abi.ConvertType(out[0], new(nftMetadata)).(*nftMetadata)
+
+	completedAt := time.Unix(metadata.CompletionDate.Int64(), 0)
+	nft.OnChainProjectID = metadata.ProjectId.Int64()
+	nft.Amount = metadata.Amount.String()
+	nft.Category = metadata.Category
+	nft.CompletedAt = &completedAt
+	nft.Name = fmt.Sprintf("FARIIMA Proof of Work #%d", nft.TokenID)
+	nft.Description = "Verified completion certificate for project: " + metadata.ProjectTitle
+	nft.TokenURI = s.TokenURI(nft.TokenID)
+	nft.Image = s.imageURL(nft.TokenID)
+
+	if metadata.Client != (common.Address{}) {
+		nft.ClientAddr = strings.ToLower(metadata.Client.Hex())
+		if client, err := s.userRepo.GetByAddress(nft.ClientAddr); err == nil {
+			nft.ClientID = &client.ID
+		}
+	}
+
+	return nil
+}
+
+// formatNFTAmount renders a payment amount, e.g. "1,250.50 USDC".
+func formatNFTAmount(amount, currency string) string {
+	if currency == "" {
+		currency = "USDC"
+	}
+
+	units := parseWei(amount)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(nftAmountDecimals), nil)
+	whole, frac := new(big.Int).QuoRem(units, scale, new(big.Int))
+
+	digits := whole.String()
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(d)
+	}
+
+	cents := new(big.Int).Div(frac, new(big.Int).Div(scale, big.NewInt(100)))
+	if cents.Sign() == 0 {
+		return fmt.Sprintf("%s %s", grouped.String(), currency)
+	}
+	return fmt.Sprintf("%s.%02d %s", grouped.String(), cents.Int64(), currency)
+}
+
+// shortenAddress follows ProofOfWorkNFT._shortenAddress, e.g. 0x1234...abcd.
+func shortenAddress(address string) string {
+	if len(address) < 42 {
+		return address
+	}
+	address = strings.ToLower(address)
+	return address[:6] + "..." + address[38:]
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/mailer"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// InAppChannel pushes notifications to connected clients over the WebSocket
+// hub. Storage in the inbox is handled by the NotificationService itself.
+type InAppChannel struct {
+	wsService *WebSocketService
+}
+
+func NewInAppChannel(wsService *WebSocketService) *InAppChannel {
+	return &InAppChannel{wsService: wsService}
+}
+
+func (c *InAppChannel) Name() models.NotificationChannelName {
+	return models.NotificationChannelInApp
+}
+
+func (c *InAppChannel) Send(user *models.User, notification *models.Notification) error {
+	return c.wsService.SendToUser(user.ID.String(), WSMessage{
+		Type:    "notification",
+		Payload: notification,
+	})
+}
+
+const (
+	// maxConcurrentWebhooks bounds the webhook requests in flight, so a burst
+	// of notifications cannot open unbounded outbound connections.
+	maxConcurrentWebhooks = 16
+	// webhookSlotWait is how long a send waits for a free slot before it is
+	// given up.
+	webhookSlotWait = 30 * time.Second
+)
+
+// blockedWebhookNets are ranges outside the private, loopback and link-local
+// ones that net.IP can tell about, which a webhook must not reach either.
+var blockedWebhookNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "This" network
+	"100.64.0.0/10", // Carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // Benchmarking
+	"64:ff9b::/96",  // NAT64, which can map to any IPv4 address
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// checkWebhookIP rejects addresses of this host and of internal networks,
+// cloud metadata endpoints such as 169.254.169.254 included.
+func checkWebhookIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: webhook address %s is not publicly routable", ErrInvalidInput, ip)
+	}
+	for _, blocked := range blockedWebhookNets {
+		if blocked.Contains(ip) {
+			return fmt.Errorf("%w: webhook address %s is not publicly routable", ErrInvalidInput, ip)
+		}
+	}
+	return nil
+}
+
+// checkWebhookDial runs checkWebhookIP on the resolved address of every
+// connection a webhook makes.
+func checkWebhookDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("webhook address %s is not an IP address", host)
+	}
+	return checkWebhookIP(ip)
+}
+
+// validateWebhookURL checks the URL's scheme and that every address its host
+// resolves to may be called. https is required in release mode.
+func validateWebhookURL(ctx context.Context, cfg *config.Config, rawURL string) (*url.URL, error) {
+	parsed, err := parseWebhookURL(cfg, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return nil, fmt.Errorf("%w: webhook host %s does not resolve", ErrInvalidInput, parsed.Hostname())
+	}
+	for _, addr := range addrs {
+		if err := checkWebhookIP(addr.IP); err != nil {
+			return nil, err
+		}
+	}
+
+	return parsed, nil
+}
+
+func parseWebhookURL(cfg *config.Config, rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("%w: webhook URL must be an absolute http(s) URL", ErrInvalidInput)
+	}
+	if parsed.Scheme != "https" && cfg.GinMode == "release" {
+		return nil, fmt.Errorf("%w: webhook URL must use https", ErrInvalidInput)
+	}
+	if parsed.User != nil {
+		return nil, fmt.Errorf("%w: webhook URL must not contain credentials", ErrInvalidInput)
+	}
+	return parsed, nil
+}
+
+// WebhookChannel posts notifications as JSON to the user's registered URL,
+// signed with HMAC-SHA256 in the X-Fariima-Signature header.
+//
+// Addresses are checked again on every connection, after resolution, so a
+// host that resolved to a public address when the webhook was saved cannot
+// later be pointed at an internal one.
+type WebhookChannel struct {
+	cfg              *config.Config
+	notificationRepo *repositories.NotificationRepository
+	client           *http.Client
+	slots            chan struct{}
+}
+
+func NewWebhookChannel(cfg *config.Config, notificationRepo *repositories.NotificationRepository) *WebhookChannel {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: checkWebhookDial,
+	}
+
+	return &WebhookChannel{
+		cfg:              cfg,
+		notificationRepo: notificationRepo,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				Proxy:                 nil, // A proxy would make the dial checks moot
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   5 * time.Second,
+				ResponseHeaderTimeout: 10 * time.Second,
+				MaxIdleConns:          maxConcurrentWebhooks,
+				IdleConnTimeout:       90 * time.Second,
+			},
+			// Redirects are not followed; a 3xx fails the delivery.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		slots: make(chan struct{}, maxConcurrentWebhooks),
+	}
+}
+
+func (c *WebhookChannel) Name() models.NotificationChannelName {
+	return models.NotificationChannelWebhook
+}
+
+func (c *WebhookChannel) Send(user *models.User, notification *models.Notification) error {
+	webhook, err := c.notificationRepo.GetWebhook(user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // No webhook configured
+	}
+	if err != nil {
+		return err
+	}
+
+	// Webhooks saved before https was required are not called over http.
+	if _, err := parseWebhookURL(c.cfg, webhook.URL); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		defer func() { <-c.slots }()
+	case <-time.After(webhookSlotWait):
+		return errors.New("too many webhook deliveries in flight")
+	}
+
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write(body)
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Fariima-Event", string(notification.Type))
+	req.Header.Set("X-Fariima-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// EmailChannel queues notification emails through the EmailService. Only
+// verified addresses receive them.
+type EmailChannel struct {
+	cfg          *config.Config
+	emailService *EmailService
+}
+
+func NewEmailChannel(cfg *config.Config, emailService *EmailService) *EmailChannel {
+	return &EmailChannel{cfg: cfg, emailService: emailService}
+}
+
+func (c *EmailChannel) Name() models.NotificationChannelName {
+	return models.NotificationChannelEmail
+}
+
+func (c *EmailChannel) Send(user *models.User, notification *models.Notification) error {
+	if user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	return c.emailService.Enqueue(user, mailer.TemplateNotification, models.EmailCategoryNotification, map[string]interface{}{
+		"Title": notification.Title,
+		"Body":  notification.Body,
+		"URL":   c.cfg.AppBaseURL + "/notifications",
+	})
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/mailer"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// NotificationChannel delivers a notification over one transport. Channels
+// are registered on the NotificationService at startup.
+type NotificationChannel interface {
+	Name() models.NotificationChannelName
+	Send(user *models.User, notification *models.Notification) error
+}
+
+// NotificationEvent describes something a user should hear about.
+type NotificationEvent struct {
+	Type  models.NotificationType
+	Title string
+	Body  string
+	Data  map[string]interface{}
+}
+
+const (
+	digestWindow   = 24 * time.Hour
+	maxDigestItems = 20
+)
+
+// channelDefaults apply when a user has no preference row for a channel.
+var channelDefaults = map[models.NotificationChannelName]bool{
+	models.NotificationChannelInApp:   true,
+	models.NotificationChannelEmail:   true,
+	models.NotificationChannelWebhook: true,
+}
+
+type NotificationService struct {
+	cfg              *config.Config
+	notificationRepo *repositories.NotificationRepository
+	userRepo         *repositories.UserRepository
+	emailService     *EmailService
+	channels         []NotificationChannel
+	logger           *logrus.Logger
+}
+
+func NewNotificationService(
+	cfg *config.Config,
+	notificationRepo *repositories.NotificationRepository,
+	userRepo *repositories.UserRepository,
+	emailService *EmailService,
+	logger *logrus.Logger,
+) *NotificationService {
+	return &NotificationService{
+		cfg:              cfg,
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		emailService:     emailService,
+		logger:           logger,
+	}
+}
+
+func (s *NotificationService) RegisterChannel(channel NotificationChannel) {
+	s.channels = append(s.channels, channel)
+}
+
+// Notify stores the notification in the user's inbox (when in-app delivery is
+// enabled) and fans it out to the other enabled channels. Failures are logged
+// rather than returned so callers' own work is never rolled back.
+func (s *NotificationService) Notify(userID uuid.UUID, event NotificationEvent) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Errorf("Notification %s: user %s not found: %v", event.Type, userID, err)
+		return
+	}
+
+	s.deliver(user, event)
+}
+
+// NotifyAddress resolves a wallet address to a user before notifying. Unknown
+// addresses are ignored since not every on-chain party has an account.
+func (s *NotificationService) NotifyAddress(address string, event NotificationEvent) {
+	if address == "" {
+		return
+	}
+
+	user, err := s.userRepo.GetByAddress(strings.ToLower(address))
+	if err != nil {
+		return
+	}
+
+	s.deliver(user, event)
+}
+
+func (s *NotificationService) NotifyMany(userIDs []uuid.UUID, event NotificationEvent) {
+	for _, userID := range userIDs {
+		s.Notify(userID, event)
+	}
+}
+
+func (s *NotificationService) deliver(user *models.User, event NotificationEvent) {
+	enabled, err := s.enabledChannels(user.ID, event.Type)
+	if err != nil {
+		s.logger.Errorf("Failed to load notification preferences for %s: %v", user.ID, err)
+		return
+	}
+
+	notification := &models.Notification{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Type:      event.Type,
+		Title:     event.Title,
+		Body:      event.Body,
+		Data:      event.Data,
+		CreatedAt: time.Now(),
+	}
+
+	if enabled[models.NotificationChannelInApp] {
+		if err := s.notificationRepo.Create(notification); err != nil {
+			s.logger.Errorf("Failed to store notification for %s: %v", user.ID, err)
+			return
+		}
+	}
+
+	for _, channel := range s.channels {
+		if !enabled[channel.Name()] {
+			continue
+		}
+
+		if channel.Name() == models.NotificationChannelInApp {
+			s.send(channel, user, notification)
+			continue
+		}
+
+		go s.send(channel, user, notification)
+	}
+}
+
+func (s *NotificationService) send(channel NotificationChannel, user *models.User, notification *models.Notification) {
+	if err := channel.Send(user, notification); err != nil {
+		s.logger.Errorf("Failed to send %s notification %s to %s: %v",
+			channel.Name(), notification.ID, user.ID, err)
+	}
+}
+
+func (s *NotificationService) enabledChannels(userID uuid.UUID, eventType models.NotificationType) (map[models.NotificationChannelName]bool, error) {
+	enabled := make(map[models.NotificationChannelName]bool, len(channelDefaults))
+	for channel, on := range channelDefaults {
+		enabled[channel] = on
+	}
+
+	preferences, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, preference := range preferences {
+		if preference.EventType == eventType {
+			enabled[preference.Channel] = preference.Enabled
+		}
+	}
+
+	return enabled, nil
+}
+
+// Digests
+
+// SendDigests emails each user a summary of their unread notifications of the
+// last day that were not emailed to them one by one, because they turned off
+// email for that event type. It runs daily as the JobSendDigests job. Users
+// turn the digest itself off with the email channel of the "digest" type.
+func (s *NotificationService) SendDigests(ctx context.Context, job *models.Job) error {
+	notifications, err := s.notificationRepo.ListUnreadSince(time.Now().Add(-digestWindow))
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for start := 0; start < len(notifications); {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		userID := notifications[start].UserID
+		end := start
+		for end < len(notifications) && notifications[end].UserID == userID {
+			end++
+		}
+
+		ok, err := s.sendDigest(userID, notifications[start:end])
+		if err != nil {
+			s.logger.Errorf("Failed to send the notification digest to %s: %v", userID, err)
+		}
+		if ok {
+			sent++
+		}
+		start = end
+	}
+
+	s.logger.Infof("Queued %d notification digests", sent)
+	return nil
+}
+
+func (s *NotificationService) sendDigest(userID uuid.UUID, notifications []models.Notification) (bool, error) {
+	preferences, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return false, err
+	}
+
+	emailed := make(map[models.NotificationType]bool)
+	for _, preference := range preferences {
+		if preference.Channel == models.NotificationChannelEmail {
+			emailed[preference.EventType] = preference.Enabled
+		}
+	}
+	emailEnabled := func(eventType models.NotificationType) bool {
+		if enabled, ok := emailed[eventType]; ok {
+			return enabled
+		}
+		return channelDefaults[models.NotificationChannelEmail]
+	}
+
+	if !emailEnabled(models.NotificationDigest) {
+		return false, nil
+	}
+
+	var items []models.Notification
+	for _, notification := range notifications {
+		if !emailEnabled(notification.Type) {
+			items = append(items, notification)
+		}
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if user.EmailVerifiedAt == nil {
+		return false, nil
+	}
+
+	count := len(items)
+	if len(items) > maxDigestItems {
+		items = items[:maxDigestItems]
+	}
+
+	err = s.emailService.Enqueue(user, mailer.TemplateDigest, models.EmailCategoryNotification, map[string]interface{}{
+		"Count": count,
+		"Items": items,
+		"More":  count - len(items),
+		"URL":   s.cfg.AppBaseURL + "/notifications",
+	})
+	return err == nil, err
+}
+
+// Inbox
+func (s *NotificationService) ListNotifications(userID uuid.UUID, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error) {
+	return s.notificationRepo.List(userID, unreadOnly, limit, offset)
+}
+
+func (s *NotificationService) CountUnread(userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+func (s *NotificationService) SetRead(userID, notificationID uuid.UUID, read bool) error {
+	var readAt *time.Time
+	if read {
+		now := time.Now()
+		readAt = &now
+	}
+
+	affected, err := s.notificationRepo.SetReadAt(notificationID, userID, readAt)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) error {
+	return s.notificationRepo.MarkAllRead(userID, time.Now())
+}
+
+// Preferences
+
+// GetPreferences returns the full event type × channel matrix with defaults
+// filled in for anything the user has not set.
+func (s *NotificationService) GetPreferences(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	stored, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]models.NotificationPreference, len(stored))
+	for _, preference := range stored {
+		overrides[string(preference.EventType)+"/"+string(preference.Channel)] = preference
+	}
+
+	var preferences []models.NotificationPreference
+	for _, eventType := range models.NotificationTypes {
+		for _, channel := range models.NotificationChannels {
+			if preference, ok := overrides[string(eventType)+"/"+string(channel)]; ok {
+				preferences = append(preferences, preference)
+				continue
+			}
+			preferences = append(preferences, models.NotificationPreference{
+				UserID:    userID,
+				EventType: eventType,
+				Channel:   channel,
+				Enabled:   channelDefaults[channel],
+			})
+		}
+	}
+
+	return preferences, nil
+}
+
+func (s *NotificationService) UpdatePreferences(userID uuid.UUID, preferences []models.NotificationPreference) error {
+	for _, preference := range preferences {
+		if !validNotificationType(preference.EventType) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidInput, preference.EventType)
+		}
+		if _, ok := channelDefaults[preference.Channel]; !ok {
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalidInput, preference.Channel)
+		}
+	}
+
+	for _, preference := range preferences {
+		preference.ID = uuid.Nil
+		preference.UserID = userID
+		if err := s.notificationRepo.UpsertPreference(&preference); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *NotificationService) GetWebhook(userID uuid.UUID) (*models.NotificationWebhook, error) {
+	webhook, err := s.notificationRepo.GetWebhook(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return webhook, err
+}
+
+// SetWebhook registers the webhook URL and rotates its signing secret. The
+// secret is only returned here, so callers must show it to the user once.
+// URLs of internal hosts are refused.
+func (s *NotificationService) SetWebhook(userID uuid.UUID, rawURL string) (*models.NotificationWebhook, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	parsed, err := validateWebhookURL(ctx, s.cfg, rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, "", err
+	}
+	secret := hex.EncodeToString(secretBytes)
+
+	webhook := &models.NotificationWebhook{
+		UserID: userID,
+		URL:    parsed.String(),
+		Secret: secret,
+	}
+	if err := s.notificationRepo.UpsertWebhook(webhook); err != nil {
+		return nil, "", err
+	}
+
+	return webhook, secret, nil
+}
+
+func (s *NotificationService) DeleteWebhook(userID uuid.UUID) error {
+	return s.notificationRepo.DeleteWebhook(userID)
+}
+
+func validNotificationType(eventType models.NotificationType) bool {
+	for _, known := range models.NotificationTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/fariima/backend/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// pinTimeout bounds checking and re-pinning a single CID, which may mean
+// fetching it from the IPFS network.
+const pinTimeout = 2 * time.Minute
+
+// PinService keeps referenced content pinned and releases uploads nothing
+// refers to.
+type PinService struct {
+	cfg     *config.Config
+	pinRepo *repositories.PinRepository
+	store   storage.Storage
+	logger  *logrus.Logger
+}
+
+func NewPinService(cfg *config.Config, pinRepo *repositories.PinRepository, store storage.Storage, logger *logrus.Logger) *PinService {
+	return &PinService{
+		cfg:     cfg,
+		pinRepo: pinRepo,
+		store:   store,
+		logger:  logger,
+	}
+}
+
+// VerifyPins is the JobVerifyPins handler. It checks that evidence, message
+// attachments, NFT token URIs, private files and pinned uploads are still
+// pinned on every storage backend, and re-pins whatever is missing.
+func (s *PinService) VerifyPins(ctx context.Context, job *models.Job) error {
+	cids, err := s.referencedCIDs()
+	if err != nil {
+		return err
+	}
+
+	var repinned, failed int
+	for _, cid := range cids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		ok, err := s.ensurePinned(ctx, cid)
+		switch {
+		case err != nil:
+			s.logger.Errorf("Failed to re-pin %s: %v", cid, err)
+			failed++
+		case !ok:
+			repinned++
+		}
+	}
+
+	s.logger.Infof("Verified %d pins: %d re-pinned, %d failed", len(cids), repinned, failed)
+	return nil
+}
+
+// ensurePinned reports whether cid was already pinned, pinning it if not.
+func (s *PinService) ensurePinned(ctx context.Context, cid string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, pinTimeout)
+	defer cancel()
+
+	pinned, err := s.store.IsPinned(ctx, cid)
+	if err != nil || pinned {
+		return pinned, err
+	}
+
+	s.logger.Warnf("%s is not pinned, re-pinning", cid)
+	return false, s.store.Pin(ctx, cid)
+}
+
+// UnpinOrphans is the JobUnpinOrphans handler. Uploads that nothing has
+// referred to for PIN_RETENTION_DAYS are unpinned and stop counting toward
+// their owners' quotas.
+func (s *PinService) UnpinOrphans(ctx context.Context, job *models.Job) error {
+	before := time.Now().AddDate(0, 0, -s.cfg.PinRetentionDays)
+	cids, err := s.pinRepo.OrphanedCIDs(before)
+	if err != nil {
+		return err
+	}
+
+	unpinned := 0
+	for _, cid := range cids {
+		if err := s.store.Unpin(ctx, cid); err != nil {
+			s.logger.Errorf("Failed to unpin orphaned upload %s: %v", cid, err)
+			continue
+		}
+		if err := s.pinRepo.MarkUnpinned(cid); err != nil {
+			return err
+		}
+		unpinned++
+	}
+
+	if unpinned > 0 {
+		s.logger.Infof("Unpinned %d orphaned uploads", unpinned)
+	}
+	return nil
+}
+
+func (s *PinService) referencedCIDs() ([]string, error) {
+	cids, err := s.pinRepo.ReferencedCIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	uris, err := s.pinRepo.TokenURIs()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(cids))
+	for _, cid := range cids {
+		seen[cid] = true
+	}
+	for _, uri := range uris {
+		if cid := cidFromURI(uri); cid != "" && !seen[cid] {
+			seen[cid] = true
+			cids = append(cids, cid)
+		}
+	}
+	return cids, nil
+}
+
+// cidFromURI extracts the CID from ipfs://<cid>/... or a gateway URL
+// .../ipfs/<cid>/..., or returns "".
+func cidFromURI(uri string) string {
+	var rest string
+	if strings.HasPrefix(uri, "ipfs://") {
+		rest = strings.TrimPrefix(uri, "ipfs://")
+	} else if i := strings.Index(uri, "/ipfs/"); i >= 0 {
+		rest = uri[i+len("/ipfs/"):]
+	} else {
+		return ""
+	}
+
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
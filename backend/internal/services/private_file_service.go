@@ -0,0 +1,454 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/envelope"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// FileScope ties a private file to the project or dispute whose parties may
+// read it.
+type FileScope struct {
+	ProjectID *uuid.UUID
+	DisputeID *uuid.UUID
+}
+
+// FileContent is a file opened by CID, seekable for serving byte ranges.
+// The caller closes it.
+type FileContent struct {
+	io.ReadSeeker
+	Size     int64
+	FileName string
+	MimeType string
+	Private  bool
+	file     *os.File
+}
+
+func (c *FileContent) Close() error {
+	return c.file.Close()
+}
+
+// FileKey is a private file's data key wrapped for the requesting user, for
+// decrypting it client-side.
+type FileKey struct {
+	CID string `json:"cid"`
+	// WrappedKey is the eth_decrypt message as JSON; EthDecryptPayload is the
+	// same message hex-encoded, ready to pass to eth_decrypt.
+	WrappedKey        *envelope.BoxMessage `json:"wrapped_key"`
+	EthDecryptPayload string               `json:"eth_decrypt_payload"`
+}
+
+// PrivateFileService stores deliverables and evidence with envelope
+// encryption and keeps the list of users allowed to read them.
+type PrivateFileService struct {
+	cfg             *config.Config
+	kek             []byte
+	privateFileRepo *repositories.PrivateFileRepository
+	uploadRepo      *repositories.UploadRepository
+	pinRepo         *repositories.PinRepository
+	userRepo        *repositories.UserRepository
+	projectRepo     *repositories.ProjectRepository
+	disputeRepo     *repositories.DisputeRepository
+	jurorRepo       *repositories.JurorRepository
+	ipfsService     *IPFSService
+	logger          *logrus.Logger
+}
+
+func NewPrivateFileService(
+	cfg *config.Config,
+	privateFileRepo *repositories.PrivateFileRepository,
+	uploadRepo *repositories.UploadRepository,
+	pinRepo *repositories.PinRepository,
+	userRepo *repositories.UserRepository,
+	projectRepo *repositories.ProjectRepository,
+	disputeRepo *repositories.DisputeRepository,
+	jurorRepo *repositories.JurorRepository,
+	ipfsService *IPFSService,
+	logger *logrus.Logger,
+) (*PrivateFileService, error) {
+	var kek []byte
+	var err error
+	if cfg.FileEncryptionKey != "" {
+		if kek, err = envelope.ParseKey(cfg.FileEncryptionKey); err != nil {
+			return nil, fmt.Errorf("invalid FILE_ENCRYPTION_KEY: %w", err)
+		}
+	} else {
+		logger.Warn("FILE_ENCRYPTION_KEY is not set; private files are encrypted with a temporary key and cannot be read by the server after a restart")
+		if kek, err = envelope.GenerateKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PrivateFileService{
+		cfg:             cfg,
+		kek:             kek,
+		privateFileRepo: privateFileRepo,
+		uploadRepo:      uploadRepo,
+		pinRepo:         pinRepo,
+		userRepo:        userRepo,
+		projectRepo:     projectRepo,
+		disputeRepo:     disputeRepo,
+		jurorRepo:       jurorRepo,
+		ipfsService:     ipfsService,
+		logger:          logger,
+	}, nil
+}
+
+// FileURL is where authorized users download a file through the API.
+func (s *PrivateFileService) FileURL(cid string) string {
+	return strings.TrimRight(s.cfg.PublicAPIURL, "/") + "/api/v1/ipfs/" + cid
+}
+
+// Grantees checks that the user is a party to the scope's project or dispute
+// and returns who may read files stored under it: the project's client and
+// freelancer, plus the jurors who accepted a dispute.
+func (s *PrivateFileService) Grantees(userID uuid.UUID, scope FileScope) (map[uuid.UUID]models.FileGrantRole, error) {
+	var project *models.Project
+	grantees := map[uuid.UUID]models.FileGrantRole{}
+
+	switch {
+	case scope.DisputeID != nil:
+		dispute, err := s.disputeRepo.GetByID(*scope.DisputeID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: dispute not found", ErrNotFound)
+		}
+		project = &dispute.Project
+
+		assignments, err := s.jurorRepo.GetAssignments(dispute.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, assignment := range assignments {
+			if assignment.Status == models.JurorAssignmentAccepted {
+				grantees[assignment.JurorID] = models.FileGrantJuror
+			}
+		}
+	case scope.ProjectID != nil:
+		var err error
+		if project, err = s.projectRepo.GetByID(*scope.ProjectID); err != nil {
+			return nil, fmt.Errorf("%w: project not found", ErrNotFound)
+		}
+	default:
+		return nil, fmt.Errorf("%w: a project or dispute is required for private files", ErrInvalidInput)
+	}
+
+	if !isProjectParty(project, userID) {
+		return nil, fmt.Errorf("%w: only the project's client or freelancer can share files on it", ErrForbidden)
+	}
+
+	grantees[project.ClientID] = models.FileGrantClient
+	if project.FreelancerID != nil {
+		grantees[*project.FreelancerID] = models.FileGrantFreelancer
+	}
+	return grantees, nil
+}
+
+// Store encrypts r with a new data key, stores the ciphertext and records the
+// file with a grant for each grantee. file.CID is set on success.
+func (s *PrivateFileService) Store(ctx context.Context, file *models.PrivateFile, r io.Reader, grantees map[uuid.UUID]models.FileGrantRole) error {
+	dataKey, err := envelope.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "private-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	writer, err := envelope.NewWriter(tmp, dataKey)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, r); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if file.WrappedKey, err = envelope.WrapKey(s.kek, dataKey); err != nil {
+		return err
+	}
+
+	file.Grants = nil
+	for userID, role := range grantees {
+		if userID == file.OwnerID {
+			role = models.FileGrantOwner
+		}
+		file.Grants = append(file.Grants, models.FileGrant{
+			UserID:     userID,
+			Role:       role,
+			WrappedKey: s.wrapFor(userID, dataKey),
+		})
+	}
+
+	if file.CID, err = s.ipfsService.UploadReader(ctx, file.FileName, tmp); err != nil {
+		return err
+	}
+	return s.privateFileRepo.Create(file)
+}
+
+// GrantDispute gives a user access to every private file of a dispute, e.g.
+// when a juror accepts a seat.
+func (s *PrivateFileService) GrantDispute(disputeID, userID uuid.UUID, role models.FileGrantRole) error {
+	files, err := s.privateFileRepo.ListByDispute(disputeID)
+	if err != nil {
+		return err
+	}
+
+	for i := range files {
+		if err := s.grant(&files[i], userID, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Share grants access to a stored file, e.g. once a moderator releases it
+// from quarantine.
+func (s *PrivateFileService) Share(file *models.PrivateFile, grantees map[uuid.UUID]models.FileGrantRole) error {
+	for userID, role := range grantees {
+		if userID == file.OwnerID {
+			role = models.FileGrantOwner
+		}
+		if err := s.grant(file, userID, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PrivateFileService) grant(file *models.PrivateFile, userID uuid.UUID, role models.FileGrantRole) error {
+	grant := &models.FileGrant{FileID: file.ID, UserID: userID, Role: role}
+	if dataKey, err := envelope.UnwrapKey(s.kek, file.WrappedKey); err == nil {
+		grant.WrappedKey = s.wrapFor(userID, dataKey)
+	} else {
+		s.logger.Errorf("Failed to unwrap the key of private file %s: %v", file.CID, err)
+	}
+	return s.privateFileRepo.AddGrant(grant)
+}
+
+func (s *PrivateFileService) Get(cid string) (*models.PrivateFile, error) {
+	file, err := s.privateFileRepo.GetByCID(cid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: private file not found", ErrNotFound)
+	}
+	return file, nil
+}
+
+// Delete unpins a file and removes it with its grants.
+func (s *PrivateFileService) Delete(ctx context.Context, file *models.PrivateFile) error {
+	if err := s.ipfsService.Unpin(ctx, file.CID); err != nil {
+		return err
+	}
+	return s.privateFileRepo.Delete(file)
+}
+
+// Open opens a file by CID through the local cache. Private files are
+// decrypted for users with a grant. Other content is served as stored, with
+// its type detected from its magic bytes unless it was uploaded here, and
+// only if the platform knows the CID, so the API is not an open gateway.
+func (s *PrivateFileService) Open(ctx context.Context, userID uuid.UUID, cid string) (*FileContent, error) {
+	file, err := s.privateFileRepo.GetByCID(cid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.openPublic(ctx, cid)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNotQuarantined(cid); err != nil {
+		return nil, err
+	}
+	if _, err := s.privateFileRepo.GetGrant(file.ID, userID); err != nil {
+		return nil, fmt.Errorf("%w: you do not have access to this file", ErrForbidden)
+	}
+
+	return s.decrypt(ctx, file)
+}
+
+// OpenForReview decrypts a file for a moderator, without checking grants.
+func (s *PrivateFileService) OpenForReview(ctx context.Context, cid string) (*FileContent, error) {
+	file, err := s.Get(cid)
+	if err != nil {
+		return nil, err
+	}
+	return s.decrypt(ctx, file)
+}
+
+func (s *PrivateFileService) decrypt(ctx context.Context, file *models.PrivateFile) (*FileContent, error) {
+	dataKey, err := envelope.UnwrapKey(s.kek, file.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap the key of private file %s: %w", file.CID, err)
+	}
+
+	cached, info, err := s.openCached(ctx, file.CID)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, size, err := envelope.NewReadSeeker(cached, info.Size(), dataKey)
+	if err != nil {
+		cached.Close()
+		return nil, fmt.Errorf("failed to decrypt private file %s: %w", file.CID, err)
+	}
+
+	return &FileContent{
+		ReadSeeker: plaintext,
+		Size:       size,
+		FileName:   file.FileName,
+		MimeType:   file.MimeType,
+		Private:    true,
+		file:       cached,
+	}, nil
+}
+
+// checkNotQuarantined refuses access to uploads flagged by the malware
+// scanner until a moderator releases them.
+func (s *PrivateFileService) checkNotQuarantined(cid string) error {
+	upload, err := s.uploadRepo.GetByCID(cid)
+	if err == nil && upload.ScanStatus == models.ScanStatusQuarantined {
+		return fmt.Errorf("%w: this file is quarantined pending moderator review", ErrForbidden)
+	}
+	return nil
+}
+
+func (s *PrivateFileService) openPublic(ctx context.Context, cid string) (*FileContent, error) {
+	known, err := s.pinRepo.IsKnownCID(cid)
+	if err != nil {
+		return nil, err
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: file not found", ErrNotFound)
+	}
+
+	cached, info, err := s.openCached(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &FileContent{ReadSeeker: cached, Size: info.Size(), file: cached}
+	if upload, err := s.uploadRepo.GetByCID(cid); err == nil {
+		content.FileName = upload.FileName
+		content.MimeType = upload.MimeType
+		return content, nil
+	}
+
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(cached, head)
+	if _, err := cached.Seek(0, io.SeekStart); err != nil {
+		cached.Close()
+		return nil, err
+	}
+	content.MimeType = servableContentType(head[:n])
+	return content, nil
+}
+
+func (s *PrivateFileService) openCached(ctx context.Context, cid string) (*os.File, os.FileInfo, error) {
+	cached, err := s.ipfsService.OpenCached(ctx, cid)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := cached.Stat()
+	if err != nil {
+		cached.Close()
+		return nil, nil, err
+	}
+	return cached, info, nil
+}
+
+// GetKey returns a private file's data key wrapped for the user.
+func (s *PrivateFileService) GetKey(userID uuid.UUID, cid string) (*FileKey, error) {
+	file, err := s.privateFileRepo.GetByCID(cid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: private file not found", ErrNotFound)
+	}
+
+	if err := s.checkNotQuarantined(cid); err != nil {
+		return nil, err
+	}
+	grant, err := s.privateFileRepo.GetGrant(file.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: you do not have access to this file", ErrForbidden)
+	}
+	if grant.WrappedKey == "" {
+		return nil, fmt.Errorf("%w: register an encryption public key to decrypt files client-side", ErrConflict)
+	}
+
+	var message envelope.BoxMessage
+	if err := json.Unmarshal([]byte(grant.WrappedKey), &message); err != nil {
+		return nil, err
+	}
+	return &FileKey{CID: cid, WrappedKey: &message, EthDecryptPayload: message.EthDecryptPayload()}, nil
+}
+
+// SetEncryptionKey registers the user's encryption public key and wraps the
+// keys of every file they can read for it.
+func (s *PrivateFileService) SetEncryptionKey(userID uuid.UUID, publicKey string) error {
+	if _, err := envelope.ParsePublicKey(publicKey); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return ErrNotFound
+	}
+	user.EncryptionPublicKey = publicKey
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	files, err := s.privateFileRepo.ListByGrantee(userID)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		dataKey, err := envelope.UnwrapKey(s.kek, file.WrappedKey)
+		if err != nil {
+			s.logger.Errorf("Failed to unwrap the key of private file %s: %v", file.CID, err)
+			continue
+		}
+		for _, grant := range file.Grants {
+			if err := s.privateFileRepo.SetGrantKey(grant.ID, wrapKeyFor(publicKey, dataKey)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// wrapFor wraps the data key for the user's encryption public key, or
+// returns "" if they have not registered one.
+func (s *PrivateFileService) wrapFor(userID uuid.UUID, dataKey []byte) string {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || user.EncryptionPublicKey == "" {
+		return ""
+	}
+	return wrapKeyFor(user.EncryptionPublicKey, dataKey)
+}
+
+func wrapKeyFor(publicKey string, dataKey []byte) string {
+	message, err := envelope.WrapKeyFor(publicKey, dataKey)
+	if err != nil {
+		return ""
+	}
+	data, _ := json.Marshal(message)
+	return string(data)
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/repositories"
 	"github.com/google/uuid"
@@ -8,20 +11,26 @@ import (
 )
 
 type ProjectService struct {
-	projectRepo       *repositories.ProjectRepository
-	blockchainService *BlockchainService
-	logger            *logrus.Logger
+	projectRepo         *repositories.ProjectRepository
+	blockchainService   *BlockchainService
+	notificationService *NotificationService
+	rankingService      *RankingService
+	logger              *logrus.Logger
 }
 
 func NewProjectService(
 	projectRepo *repositories.ProjectRepository,
 	blockchainService *BlockchainService,
+	notificationService *NotificationService,
+	rankingService *RankingService,
 	logger *logrus.Logger,
 ) *ProjectService {
 	return &ProjectService{
-		projectRepo:       projectRepo,
-		blockchainService: blockchainService,
-		logger:            logger,
+		projectRepo:         projectRepo,
+		blockchainService:   blockchainService,
+		notificationService: notificationService,
+		rankingService:      rankingService,
+		logger:              logger,
 	}
 }
 
@@ -85,14 +94,96 @@ func (s *ProjectService) GetUserProjects(userID uuid.UUID, role string) ([]model
 
 // Applications
 func (s *ProjectService) ApplyToProject(app *models.Application) error {
+	project, err := s.projectRepo.GetByID(app.ProjectID)
+	if err != nil {
+		return ErrNotFound
+	}
+
 	app.Status = "pending"
-	return s.projectRepo.CreateApplication(app)
+	if err := s.projectRepo.CreateApplication(app); err != nil {
+		return err
+	}
+
+	s.notificationService.Notify(project.ClientID, NotificationEvent{
+		Type:  models.NotificationApplicationReceived,
+		Title: "New application",
+		Body:  fmt.Sprintf("A freelancer applied to %q.", project.Title),
+		Data: map[string]interface{}{
+			"project_id":     project.ID,
+			"application_id": app.ID,
+		},
+	})
+
+	return nil
 }
 
-func (s *ProjectService) GetApplications(projectID uuid.UUID) ([]models.Application, error) {
-	return s.projectRepo.GetApplicationsByProjectID(projectID)
+// GetApplications returns the project's applications, freelancers with a
+// stake boost first.
+func (s *ProjectService) GetApplications(projectID uuid.UUID) ([]RankedApplication, error) {
+	applications, err := s.projectRepo.GetApplicationsByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.rankingService.RankApplications(applications)
 }
 
 func (s *ProjectService) UpdateApplication(app *models.Application) error {
 	return s.projectRepo.UpdateApplication(app)
 }
+
+// UpdateApplicationStatus lets the project's client accept or reject a
+// pending application. Accepting hires the freelancer and starts the project.
+func (s *ProjectService) UpdateApplicationStatus(clientID, projectID, appID uuid.UUID, status string) (*models.Application, error) {
+	if status != "accepted" && status != "rejected" {
+		return nil, fmt.Errorf("%w: status must be accepted or rejected", ErrInvalidInput)
+	}
+
+	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if project.ClientID != clientID {
+		return nil, ErrForbidden
+	}
+
+	app, err := s.projectRepo.GetApplicationByID(appID)
+	if err != nil || app.ProjectID != project.ID {
+		return nil, ErrNotFound
+	}
+	if app.Status != "pending" {
+		return nil, fmt.Errorf("%w: application is already %s", ErrConflict, app.Status)
+	}
+	if status == "accepted" && project.FreelancerID != nil {
+		return nil, fmt.Errorf("%w: project already has a freelancer", ErrConflict)
+	}
+
+	app.Status = status
+	if err := s.projectRepo.UpdateApplication(app); err != nil {
+		return nil, err
+	}
+
+	if status != "accepted" {
+		return app, nil
+	}
+
+	now := time.Now()
+	project.FreelancerID = &app.FreelancerID
+	project.Freelancer = nil
+	project.Status = models.ProjectStatusInProgress
+	project.StartDate = &now
+	if err := s.projectRepo.Update(project); err != nil {
+		return nil, err
+	}
+
+	s.notificationService.Notify(app.FreelancerID, NotificationEvent{
+		Type:  models.NotificationApplicationAccepted,
+		Title: "Application accepted",
+		Body:  fmt.Sprintf("Your application to %q was accepted.", project.Title),
+		Data: map[string]interface{}{
+			"project_id":     project.ID,
+			"application_id": app.ID,
+		},
+	})
+
+	return app, nil
+}
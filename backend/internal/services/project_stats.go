@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fariima/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// projectViewsKey counts the views of a project's page by anyone but its
+// client.
+const projectViewsKey = "analytics:project:%s:views"
+
+// ProjectStats is a project's funnel from posting to hire, and its escrow
+// and dispute once funded.
+type ProjectStats struct {
+	ProjectID    uuid.UUID               `json:"project_id"`
+	Status       models.ProjectStatus    `json:"status"`
+	PostedAt     time.Time               `json:"posted_at"`
+	Views        int64                   `json:"views"`
+	Applications ProjectApplicationStats `json:"applications"`
+	Rates        ProjectRateStats        `json:"rates"`
+	Hiring       ProjectHiringStats      `json:"hiring"`
+	Escrow       *ProjectEscrowStats     `json:"escrow"`  // Nil until an escrow is created
+	Dispute      *ProjectDisputeStats    `json:"dispute"` // The latest dispute, if any
+}
+
+type ProjectApplicationStats struct {
+	Total    int64                   `json:"total"`
+	Pending  int64                   `json:"pending"`
+	Accepted int64                   `json:"accepted"`
+	Rejected int64                   `json:"rejected"`
+	Daily    []ProjectApplicationDay `json:"daily"` // Days with applications, oldest first
+}
+
+// ProjectApplicationDay is the number of applications received on a UTC day
+// and up to the end of it.
+type ProjectApplicationDay struct {
+	Day        string `json:"day"`
+	Count      int64  `json:"count"`
+	Cumulative int64  `json:"cumulative"`
+}
+
+// ProjectRateStats compares the freelancers' proposed rates with the
+// project's budget. Median fields are nil without applications, and the
+// ratio also without a budget.
+type ProjectRateStats struct {
+	Budget              float64  `json:"budget"`
+	Currency            string   `json:"currency"`
+	MedianProposedRate  *float64 `json:"median_proposed_rate"`
+	MedianRateToBudget  *float64 `json:"median_rate_to_budget"`
+	LowestProposedRate  *float64 `json:"lowest_proposed_rate"`
+	HighestProposedRate *float64 `json:"highest_proposed_rate"`
+}
+
+// ProjectHiringStats times the first application and the hire from posting.
+type ProjectHiringStats struct {
+	FirstApplicationAt            *time.Time `json:"first_application_at"`
+	TimeToFirstApplicationSeconds *int64     `json:"time_to_first_application_seconds"`
+	HiredAt                       *time.Time `json:"hired_at"`
+	TimeToHireSeconds             *int64     `json:"time_to_hire_seconds"`
+}
+
+type ProjectEscrowStats struct {
+	ID        uuid.UUID             `json:"id"`
+	OnChainID int64                 `json:"on_chain_id"`
+	Status    models.EscrowStatus   `json:"status"`
+	Amount    TokenAmount           `json:"amount"`
+	Timeline  []EscrowTimelineEvent `json:"timeline"` // Oldest first
+}
+
+// EscrowTimelineEvent is an on-chain escrow event, at its block time or,
+// if unknown, when it was indexed.
+type EscrowTimelineEvent struct {
+	Type        string                 `json:"type"`
+	At          time.Time              `json:"at"`
+	TxHash      string                 `json:"tx_hash"`
+	BlockNumber uint64                 `json:"block_number"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+type ProjectDisputeStats struct {
+	ID               uuid.UUID            `json:"id"`
+	Status           models.DisputeStatus `json:"status"`
+	Category         string               `json:"category"`
+	OpenedAt         time.Time            `json:"opened_at"`
+	VotingEndsAt     *time.Time           `json:"voting_ends_at"`
+	ResolvedAt       *time.Time           `json:"resolved_at"`
+	TotalVotes       int                  `json:"total_votes"`
+	ClientVotes      int                  `json:"client_votes"`
+	FreelancerVotes  int                  `json:"freelancer_votes"`
+	ClientWeight     string               `json:"client_weight"`     // Voting power, wei
+	FreelancerWeight string               `json:"freelancer_weight"` // Voting power, wei
+	ClientSplit      int                  `json:"client_split"`
+	FreelancerSplit  int                  `json:"freelancer_split"`
+}
+
+// RecordProjectView counts a view of the project's page. The project's own
+// client is not counted.
+func (s *AnalyticsService) RecordProjectView(project *models.Project, viewerID uuid.UUID) {
+	if project.ClientID == viewerID {
+		return
+	}
+	key := fmt.Sprintf(projectViewsKey, project.ID)
+	if err := s.redisClient.Incr(context.Background(), key).Err(); err != nil {
+		s.logger.Warnf("Failed to count a view of project %s: %v", project.ID, err)
+	}
+}
+
+// GetProjectStats returns a project's analytics to its client or an admin.
+func (s *AnalyticsService) GetProjectStats(projectID, userID uuid.UUID, address string) (*ProjectStats, error) {
+	var project models.Project
+	if err := s.db.First(&project, "id = ?", projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if project.ClientID != userID && !s.cfg.IsAdmin(address) {
+		return nil, fmt.Errorf("%w: only the project's client can see its analytics", ErrForbidden)
+	}
+
+	stats := &ProjectStats{
+		ProjectID: project.ID,
+		Status:    project.Status,
+		PostedAt:  project.CreatedAt,
+		Views:     s.projectViews(project.ID),
+		Rates: ProjectRateStats{
+			Budget:   project.Budget,
+			Currency: project.Currency,
+		},
+	}
+
+	if err := s.applicationStats(&project, stats); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if stats.Escrow, err = s.escrowStats(project.ID); err != nil {
+		return nil, err
+	}
+	if stats.Dispute, err = s.disputeStats(project.ID); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *AnalyticsService) projectViews(projectID uuid.UUID) int64 {
+	views, err := s.redisClient.Get(context.Background(), fmt.Sprintf(projectViewsKey, projectID)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		s.logger.Warnf("Failed to read the views of project %s: %v", projectID, err)
+	}
+	return views
+}
+
+// applicationStats fills in the application funnel, proposed rates and
+// hiring times.
+func (s *AnalyticsService) applicationStats(project *models.Project, stats *ProjectStats) error {
+	var applications []models.Application
+	err := s.db.Select("id, status, proposed_rate, created_at, updated_at").
+		Where("project_id = ?", project.ID).
+		Order("created_at ASC").
+		Find(&applications).Error
+	if err != nil {
+		return err
+	}
+	if len(applications) == 0 {
+		stats.Applications.Daily = []ProjectApplicationDay{}
+		return nil
+	}
+
+	rates := make([]float64, 0, len(applications))
+	daily := stats.Applications.Daily
+	for _, app := range applications {
+		stats.Applications.Total++
+		switch app.Status {
+		case "pending":
+			stats.Applications.Pending++
+		case "accepted":
+			stats.Applications.Accepted++
+			if stats.Hiring.HiredAt == nil {
+				hiredAt := app.UpdatedAt
+				stats.Hiring.HiredAt = &hiredAt
+				stats.Hiring.TimeToHireSeconds = secondsBetween(project.CreatedAt, hiredAt)
+			}
+		case "rejected":
+			stats.Applications.Rejected++
+		}
+
+		day := truncateDay(app.CreatedAt).Format(dayLayout)
+		if n := len(daily); n == 0 || daily[n-1].Day != day {
+			daily = append(daily, ProjectApplicationDay{Day: day, Cumulative: stats.Applications.Total - 1})
+		}
+		daily[len(daily)-1].Count++
+		daily[len(daily)-1].Cumulative++
+
+		rates = append(rates, app.ProposedRate)
+	}
+	stats.Applications.Daily = daily
+
+	first := applications[0].CreatedAt
+	stats.Hiring.FirstApplicationAt = &first
+	stats.Hiring.TimeToFirstApplicationSeconds = secondsBetween(project.CreatedAt, first)
+
+	sort.Float64s(rates)
+	median := rates[len(rates)/2]
+	if len(rates)%2 == 0 {
+		median = (rates[len(rates)/2-1] + median) / 2
+	}
+	lowest, highest := rates[0], rates[len(rates)-1]
+	stats.Rates.MedianProposedRate = &median
+	stats.Rates.LowestProposedRate = &lowest
+	stats.Rates.HighestProposedRate = &highest
+	if project.Budget > 0 {
+		ratio := median / project.Budget
+		stats.Rates.MedianRateToBudget = &ratio
+	}
+	return nil
+}
+
+func (s *AnalyticsService) escrowStats(projectID uuid.UUID) (*ProjectEscrowStats, error) {
+	var escrow models.Escrow
+	if err := s.db.Where("project_id = ?", projectID).Order("created_at DESC").First(&escrow).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var events []models.EscrowEvent
+	err := s.db.Where("escrow_id = ?", escrow.ID).
+		Order("block_number ASC, created_at ASC").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ProjectEscrowStats{
+		ID:        escrow.ID,
+		OnChainID: escrow.OnChainID,
+		Status:    escrow.Status,
+		Amount:    TokenAmount{Token: escrow.Token, Amount: escrow.Amount},
+		Timeline:  make([]EscrowTimelineEvent, len(events)),
+	}
+	if amount, ok := parseAmount(escrow.Amount); ok {
+		stats.Amount = s.tokens.Amount(escrow.Token, amount)
+	}
+	for i, event := range events {
+		at := event.CreatedAt
+		if event.BlockTime != nil {
+			at = *event.BlockTime
+		}
+		stats.Timeline[i] = EscrowTimelineEvent{
+			Type:        event.EventType,
+			At:          at,
+			TxHash:      event.TxHash,
+			BlockNumber: event.BlockNumber,
+			Data:        event.Data,
+		}
+	}
+	return stats, nil
+}
+
+func (s *AnalyticsService) disputeStats(projectID uuid.UUID) (*ProjectDisputeStats, error) {
+	var dispute models.Dispute
+	if err := s.db.Where("project_id = ?", projectID).Order("created_at DESC").First(&dispute).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &ProjectDisputeStats{
+		ID:               dispute.ID,
+		Status:           dispute.Status,
+		Category:         dispute.Category,
+		OpenedAt:         dispute.CreatedAt,
+		VotingEndsAt:     dispute.VotingEndsAt,
+		ResolvedAt:       dispute.ResolvedAt,
+		TotalVotes:       dispute.TotalVotes,
+		ClientVotes:      dispute.ClientVotes,
+		FreelancerVotes:  dispute.FreelancerVotes,
+		ClientWeight:     dispute.ClientWeight,
+		FreelancerWeight: dispute.FreelancerWeight,
+		ClientSplit:      dispute.ClientSplit,
+		FreelancerSplit:  dispute.FreelancerSplit,
+	}, nil
+}
+
+func secondsBetween(from, to time.Time) *int64 {
+	seconds := int64(to.Sub(from) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	return &seconds
+}
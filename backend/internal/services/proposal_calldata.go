@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fariima/backend/internal/models"
+)
+
+// DecodedCallArg is one decoded argument of a proposal call.
+type DecodedCallArg struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// DecodedCall is a readable form of a proposal's callData. executeProposal
+// runs callData against the DAO itself, so it is decoded with the DAO ABI.
+type DecodedCall struct {
+	Selector string           `json:"selector,omitempty"`
+	Method   string           `json:"method,omitempty"` // Signature, e.g. updateQuorum(uint8,uint256)
+	Args     []DecodedCallArg `json:"args,omitempty"`
+	Summary  string           `json:"summary"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// proposalTypeMethods are the DAO functions each proposal type is expected
+// to call. The DAO has no fee or treasury setters yet, so FeeChange and
+// TreasurySpend proposals can only be signalling proposals.
+var proposalTypeMethods = map[models.ProposalType][]string{
+	models.ProposalTypeFeeChange:       {},
+	models.ProposalTypeTreasurySpend:   {},
+	models.ProposalTypeContractUpgrade: {"upgradeTo", "upgradeToAndCall"},
+	models.ProposalTypeParameterChange: {"updateQuorum", "updateApprovalThreshold", "grantRole", "revokeRole"},
+}
+
+// daoRoles names the DAO's AccessControl roles.
+var daoRoles = map[common.Hash]string{
+	common.Hash{}: "DEFAULT_ADMIN_ROLE",
+	crypto.Keccak256Hash([]byte("UPGRADER_ROLE")): "UPGRADER_ROLE",
+}
+
+// DecodeProposalCall decodes a proposal's hex callData.
+func DecodeProposalCall(proposalType models.ProposalType, callData string) *DecodedCall {
+	data, err := hexutil.Decode(callData)
+	if err != nil || len(data) == 0 {
+		return &DecodedCall{Summary: "No on-chain action (signalling proposal)"}
+	}
+
+	if len(data) < 4 {
+		return &DecodedCall{
+			Summary:  "Malformed call",
+			Warnings: []string{"callData is shorter than a function selector; execution will revert"},
+		}
+	}
+
+	decoded := &DecodedCall{Selector: hexutil.Encode(data[:4])}
+
+	method, err := daoABI.MethodById(data[:4])
+	if err != nil {
+		decoded.Summary = "Unknown function " + decoded.Selector
+		decoded.Warnings = append(decoded.Warnings, "The DAO has no function with this selector; execution will revert")
+		return decoded
+	}
+	decoded.Method = method.Sig
+
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		decoded.Summary = "Call to " + method.Sig
+		decoded.Warnings = append(decoded.Warnings, "Arguments do not match the function signature: "+err.Error())
+		return decoded
+	}
+
+	for i, input := range method.Inputs {
+		decoded.Args = append(decoded.Args, DecodedCallArg{
+			Name:  input.Name,
+			Type:  input.Type.String(),
+			Value: formatCallArg(values[i]),
+		})
+	}
+
+	decoded.Summary = summarizeProposalCall(method.Name, values)
+
+	if !containsString(proposalTypeMethods[proposalType], method.Name) {
+		decoded.Warnings = append(decoded.Warnings,
+			fmt.Sprintf("%s is not a %s action", method.Name, proposalType))
+	}
+
+	return decoded
+}
+
+func summarizeProposalCall(method string, values []interface{}) string {
+	switch method {
+	case "updateQuorum":
+		return fmt.Sprintf("Set the quorum for %s proposals to %s",
+			proposalTypeName(values[0].(uint8)), formatBps(values[1].(*big.Int)))
+	case "updateApprovalThreshold":
+		return fmt.Sprintf("Set the approval threshold for %s proposals to %s",
+			proposalTypeName(values[0].(uint8)), formatBps(values[1].(*big.Int)))
+	case "grantRole":
+		return fmt.Sprintf("Grant %s to %s", roleName(values[0].([32]byte)), values[1].(common.Address).Hex())
+	case "revokeRole":
+		return fmt.Sprintf("Revoke %s from %s", roleName(values[0].([32]byte)), values[1].(common.Address).Hex())
+	case "upgradeTo":
+		return "Upgrade the DAO implementation to " + values[0].(common.Address).Hex()
+	case "upgradeToAndCall":
+		return fmt.Sprintf("Upgrade the DAO implementation to %s and call it with %d bytes of data",
+			values[0].(common.Address).Hex(), len(values[1].([]byte)))
+	default:
+		return "Call " + method
+	}
+}
+
+func proposalTypeName(index uint8) string {
+	if int(index) < len(models.ProposalTypes) {
+		return string(models.ProposalTypes[index])
+	}
+	return fmt.Sprintf("unknown type %d", index)
+}
+
+func roleName(role [32]byte) string {
+	if name, ok := daoRoles[common.Hash(role)]; ok {
+		return name
+	}
+	return hexutil.Encode(role[:])
+}
+
+// formatBps renders basis points as a percentage, e.g. 1500 -> "15.00%".
+func formatBps(bps *big.Int) string {
+	return fmt.Sprintf("%d.%02d%%", bps.Int64()/100, bps.Int64()%100)
+}
+
+func formatCallArg(value interface{}) string {
+	switch v := value.(type) {
+	case common.Address:
+		return v.Hex()
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	case []byte:
+		return hexutil.Encode(v)
+	case *big.Int:
+		return v.String()
+	case []*big.Int:
+		parts := make([]string, len(v))
+		for i, n := range v {
+			parts[i] = n.String()
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
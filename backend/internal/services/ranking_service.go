@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+// RankedUser is a search result with the freelancer's stake boost.
+type RankedUser struct {
+	models.User
+	Boosted bool    `json:"boosted"`
+	Boost   float64 `json:"boost"` // Percent
+}
+
+// RankedApplication is an application with its freelancer's stake boost.
+type RankedApplication struct {
+	models.Application
+	Boosted bool    `json:"boosted"`
+	Boost   float64 `json:"boost"` // Percent
+}
+
+// RankingService computes the visibility boost freelancers get from staking
+// FARI. The boost grows with the stake weighted by FARIToken's time
+// multiplier, logarithmically so that returns diminish, and is capped at
+// RANKING_MAX_BOOST once the weighted stake reaches RANKING_SATURATION_STAKE.
+//
+// Boosts are stored on stake_accounts so that search can order by them; they
+// are recomputed on every staking event and hourly as multipliers step up.
+type RankingService struct {
+	cfg         *config.Config
+	stakingRepo *repositories.StakingRepository
+	logger      *logrus.Logger
+}
+
+func NewRankingService(
+	cfg *config.Config,
+	stakingRepo *repositories.StakingRepository,
+	logger *logrus.Logger,
+) *RankingService {
+	return &RankingService{
+		cfg:         cfg,
+		stakingRepo: stakingRepo,
+		logger:      logger,
+	}
+}
+
+// Boost returns the account's ranking boost in percent.
+func (s *RankingService) Boost(account *models.StakeAccount, now time.Time) float64 {
+	maxBoost := float64(s.cfg.RankingMaxBoost)
+	if maxBoost <= 0 || account.StakedSince == nil {
+		return 0
+	}
+
+	wei, _ := new(big.Float).SetInt(parseWei(account.StakedAmount)).Float64()
+	staked := wei / 1e18
+	minStake := math.Max(float64(s.cfg.RankingMinStake), 1)
+	if staked < minStake {
+		return 0
+	}
+
+	multiplier := StakeMultipliers[stakeTier(now.Sub(*account.StakedSince))].Multiplier
+	weighted := staked * float64(multiplier) / 100
+
+	saturation := float64(s.cfg.RankingSaturationStake)
+	if saturation <= minStake {
+		return maxBoost
+	}
+
+	boost := maxBoost * math.Log1p(weighted/minStake) / math.Log1p(saturation/minStake)
+	return math.Round(math.Min(boost, maxBoost)*100) / 100
+}
+
+// RefreshAccount recomputes the stored boost of one address.
+func (s *RankingService) RefreshAccount(address string) {
+	account, err := s.stakingRepo.GetAccount(address)
+	if err != nil {
+		s.logger.Errorf("Failed to load stake account %s: %v", address, err)
+		return
+	}
+
+	boost := s.Boost(account, time.Now())
+	if boost == account.RankingBoost {
+		return
+	}
+	if err := s.stakingRepo.SetRankingBoost(address, boost); err != nil {
+		s.logger.Errorf("Failed to update ranking boost of %s: %v", address, err)
+	}
+}
+
+// RefreshBoosts recomputes all stored boosts. Runs as a periodic job, since
+// time multipliers and configuration change without a staking event.
+func (s *RankingService) RefreshBoosts(ctx context.Context, job *models.Job) error {
+	accounts, err := s.stakingRepo.GetRankedAccounts()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updated := 0
+	for i := range accounts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		boost := s.Boost(&accounts[i], now)
+		if boost == accounts[i].RankingBoost {
+			continue
+		}
+		if err := s.stakingRepo.SetRankingBoost(accounts[i].Address, boost); err != nil {
+			return err
+		}
+		updated++
+	}
+
+	s.logger.Infof("Refreshed ranking boosts: %d accounts, %d updated", len(accounts), updated)
+	return nil
+}
+
+// RankUsers attaches boosts to users already ordered by the repository.
+// Only freelancers are boosted.
+func (s *RankingService) RankUsers(users []models.User) ([]RankedUser, error) {
+	addresses := make([]string, 0, len(users))
+	for _, user := range users {
+		if user.Role == "freelancer" && user.Address != "" {
+			addresses = append(addresses, strings.ToLower(user.Address))
+		}
+	}
+
+	boosts, err := s.boosts(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]RankedUser, len(users))
+	for i, user := range users {
+		ranked[i] = RankedUser{User: user}
+		if user.Role == "freelancer" {
+			ranked[i].Boost = boosts[strings.ToLower(user.Address)]
+			ranked[i].Boosted = ranked[i].Boost > 0
+		}
+	}
+	return ranked, nil
+}
+
+// RankApplications orders applications by their freelancer's boost. The
+// sort is stable, so equally boosted applications keep their order.
+func (s *RankingService) RankApplications(applications []models.Application) ([]RankedApplication, error) {
+	addresses := make([]string, 0, len(applications))
+	for _, app := range applications {
+		if app.Freelancer.Address != "" {
+			addresses = append(addresses, strings.ToLower(app.Freelancer.Address))
+		}
+	}
+
+	boosts, err := s.boosts(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]RankedApplication, len(applications))
+	for i, app := range applications {
+		boost := boosts[strings.ToLower(app.Freelancer.Address)]
+		ranked[i] = RankedApplication{Application: app, Boosted: boost > 0, Boost: boost}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Boost > ranked[j].Boost
+	})
+	return ranked, nil
+}
+
+func (s *RankingService) boosts(addresses []string) (map[string]float64, error) {
+	accounts, err := s.stakingRepo.GetAccounts(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	boosts := make(map[string]float64, len(accounts))
+	for _, account := range accounts {
+		boosts[account.Address] = account.RankingBoost
+	}
+	return boosts, nil
+}
+
+// stakeTier mirrors FARIToken._getTimeMultiplier, returning the index of the
+// StakeMultipliers tier reached after staking for the given duration.
+func stakeTier(stakedFor time.Duration) int {
+	for i, tier := range StakeMultipliers {
+		if stakedFor >= tier.After {
+			return i
+		}
+	}
+	return len(StakeMultipliers) - 1
+}
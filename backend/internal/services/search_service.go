@@ -8,23 +8,26 @@ import (
 )
 
 type SearchService struct {
-	projectRepo *repositories.ProjectRepository
-	userRepo    *repositories.UserRepository
-	redisClient *redis.Client
-	logger      *logrus.Logger
+	projectRepo    *repositories.ProjectRepository
+	userRepo       *repositories.UserRepository
+	rankingService *RankingService
+	redisClient    *redis.Client
+	logger         *logrus.Logger
 }
 
 func NewSearchService(
 	projectRepo *repositories.ProjectRepository,
 	userRepo *repositories.UserRepository,
+	rankingService *RankingService,
 	redisClient *redis.Client,
 	logger *logrus.Logger,
 ) *SearchService {
 	return &SearchService{
-		projectRepo: projectRepo,
-		userRepo:    userRepo,
-		redisClient: redisClient,
-		logger:      logger,
+		projectRepo:    projectRepo,
+		userRepo:       userRepo,
+		rankingService: rankingService,
+		redisClient:    redisClient,
+		logger:         logger,
 	}
 }
 
@@ -32,6 +35,14 @@ func (s *SearchService) SearchProjects(query, category string, limit, offset int
 	return s.projectRepo.Search(query, category, limit, offset)
 }
 
-func (s *SearchService) SearchUsers(query string, limit, offset int) ([]models.User, int64, error) {
-	return s.userRepo.Search(query, limit, offset)
+// SearchUsers ranks users by rating, with freelancers raised by their stake
+// boost.
+func (s *SearchService) SearchUsers(query string, limit, offset int) ([]RankedUser, int64, error) {
+	users, total, err := s.userRepo.Search(query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ranked, err := s.rankingService.RankUsers(users)
+	return ranked, total, err
 }
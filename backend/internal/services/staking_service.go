@@ -0,0 +1,319 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// StakingOverview is an address's FARI stake and vesting position, derived
+// from the indexed ledger with the same formulas as FARIToken.
+type StakingOverview struct {
+	Address            string     `json:"address"`
+	StakedAmount       string     `json:"staked_amount"` // Wei
+	StakedSince        *time.Time `json:"staked_since"`
+	Multiplier         int64      `json:"multiplier"`   // Percent, 100 = 1.0x
+	VotingPower        string     `json:"voting_power"` // Wei
+	NextMultiplier     *int64     `json:"next_multiplier,omitempty"`
+	NextMultiplierAt   *time.Time `json:"next_multiplier_at,omitempty"`
+	PendingUnstake     string     `json:"pending_unstake"` // Wei
+	UnstakeAvailableAt *time.Time `json:"unstake_available_at"`
+	CanUnstake         bool       `json:"can_unstake"`
+
+	Vesting *VestingOverview    `json:"vesting"`
+	History []models.StakeEvent `json:"history"`
+}
+
+// VestingOverview is a vesting schedule with its amounts as of now.
+type VestingOverview struct {
+	TotalAmount string    `json:"total_amount"`
+	Released    string    `json:"released"`
+	Vested      string    `json:"vested"`
+	Releasable  string    `json:"releasable"`
+	StartTime   time.Time `json:"start_time"`
+	CliffEndsAt time.Time `json:"cliff_ends_at"`
+	EndsAt      time.Time `json:"ends_at"`
+}
+
+type StakingService struct {
+	stakingRepo       *repositories.StakingRepository
+	rankingService    *RankingService
+	blockchainService *BlockchainService
+	logger            *logrus.Logger
+}
+
+func NewStakingService(
+	stakingRepo *repositories.StakingRepository,
+	rankingService *RankingService,
+	blockchainService *BlockchainService,
+	logger *logrus.Logger,
+) *StakingService {
+	return &StakingService{
+		stakingRepo:       stakingRepo,
+		rankingService:    rankingService,
+		blockchainService: blockchainService,
+		logger:            logger,
+	}
+}
+
+// GetOverview returns the address's staking position. Addresses that never
+// staked or vested get a zero-valued overview.
+func (s *StakingService) GetOverview(address string) (*StakingOverview, error) {
+	if !common.IsHexAddress(address) {
+		return nil, ErrInvalidInput
+	}
+	address = strings.ToLower(address)
+	now := time.Now()
+
+	overview := &StakingOverview{
+		Address:        address,
+		StakedAmount:   "0",
+		Multiplier:     100,
+		VotingPower:    "0",
+		PendingUnstake: "0",
+	}
+
+	account, err := s.stakingRepo.GetAccount(address)
+	if err == nil {
+		s.reconcilePendingUnstake(account)
+		s.applyAccount(overview, account, now)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	schedule, err := s.stakingRepo.GetVestingSchedule(address)
+	if err == nil {
+		overview.Vesting = vestingOverview(schedule, now)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	history, _, err := s.stakingRepo.ListEvents(address, 20, 0)
+	if err != nil {
+		return nil, err
+	}
+	overview.History = history
+
+	return overview, nil
+}
+
+func (s *StakingService) applyAccount(overview *StakingOverview, account *models.StakeAccount, now time.Time) {
+	staked := parseWei(account.StakedAmount)
+
+	overview.StakedAmount = staked.String()
+	overview.StakedSince = account.StakedSince
+	overview.PendingUnstake = parseWei(account.PendingUnstake).String()
+	overview.UnstakeAvailableAt = account.UnstakeAvailableAt
+	overview.CanUnstake = overview.PendingUnstake != "0" &&
+		account.UnstakeAvailableAt != nil && !now.Before(*account.UnstakeAvailableAt)
+
+	if staked.Sign() == 0 || account.StakedSince == nil {
+		return
+	}
+
+	// getVotingPower: amount * multiplier(block.timestamp - firstStake) / 100
+	tier := stakeTier(now.Sub(*account.StakedSince))
+	overview.Multiplier = StakeMultipliers[tier].Multiplier
+	if tier > 0 {
+		next := StakeMultipliers[tier-1]
+		at := account.StakedSince.Add(next.After)
+		overview.NextMultiplier = &next.Multiplier
+		overview.NextMultiplierAt = &at
+	}
+
+	power := new(big.Int).Mul(staked, big.NewInt(overview.Multiplier))
+	overview.VotingPower = power.Div(power, big.NewInt(100)).String()
+}
+
+// reconcilePendingUnstake clears a pending unstake that was cancelled
+// on-chain; cancelUnstake emits no event. The ledger is left untouched if
+// the token contract cannot be reached.
+func (s *StakingService) reconcilePendingUnstake(account *models.StakeAccount) {
+	if parseWei(account.PendingUnstake).Sign() == 0 {
+		return
+	}
+
+	out, err := s.blockchainService.CallContract("fari_token", fariTokenABI, "stakes", nil, common.HexToAddress(account.Address))
+	if err != nil {
+		s.logger.Warnf("Failed to read stake of %s: %v", account.Address, err)
+		return
+	}
+	if out[2].(*big.Int).Sign() != 0 {
+		return
+	}
+
+	if err := s.stakingRepo.ClearPendingUnstake(account.Address); err != nil {
+		s.logger.Errorf("Failed to clear cancelled unstake of %s: %v", account.Address, err)
+		return
+	}
+	account.PendingUnstake = "0"
+	account.UnstakeAvailableAt = nil
+}
+
+// vestingOverview mirrors FARIToken._releasableAmount.
+func vestingOverview(schedule *models.VestingSchedule, now time.Time) *VestingOverview {
+	total := parseWei(schedule.TotalAmount)
+	released := parseWei(schedule.Released)
+	duration := time.Duration(schedule.Duration) * time.Second
+
+	view := &VestingOverview{
+		TotalAmount: total.String(),
+		Released:    released.String(),
+		Vested:      "0",
+		Releasable:  "0",
+		StartTime:   schedule.StartTime,
+		CliffEndsAt: schedule.StartTime.Add(time.Duration(schedule.Cliff) * time.Second),
+		EndsAt:      schedule.StartTime.Add(duration),
+	}
+
+	if now.Before(view.CliffEndsAt) {
+		return view
+	}
+
+	vested := new(big.Int).Set(total)
+	if elapsed := int64(now.Sub(schedule.StartTime) / time.Second); elapsed < schedule.Duration {
+		vested.Mul(total, big.NewInt(elapsed))
+		vested.Div(vested, big.NewInt(schedule.Duration))
+	}
+
+	view.Vested = vested.String()
+	if releasable := new(big.Int).Sub(vested, released); releasable.Sign() > 0 {
+		view.Releasable = releasable.String()
+	}
+	return view
+}
+
+// Indexing
+
+// RecordEvent indexes a FARIToken staking or vesting event.
+func (s *StakingService) RecordEvent(log types.Log) error {
+	if len(log.Topics) < 2 {
+		return nil
+	}
+
+	blockTime, err := s.blockchainService.GetBlockTime(log.BlockNumber)
+	if err != nil {
+		return err
+	}
+
+	event := &models.StakeEvent{
+		Address:     strings.ToLower(common.BytesToAddress(log.Topics[1].Bytes()).Hex()),
+		TxHash:      log.TxHash.Hex(),
+		LogIndex:    log.Index,
+		BlockNumber: log.BlockNumber,
+		BlockTime:   blockTime,
+	}
+
+	switch log.Topics[0] {
+	case fariTokenABI.Events["Staked"].ID:
+		// Staked(address indexed user, uint256 amount, uint256 totalStaked)
+		var data struct {
+			Amount      *big.Int
+			TotalStaked *big.Int
+		}
+		if err := fariTokenABI.UnpackIntoInterface(&data, "Staked", log.Data); err != nil {
+			return err
+		}
+		event.Type = models.StakeEventStaked
+		event.Amount = data.Amount.String()
+		event.Balance = data.TotalStaked.String()
+		err = s.stakingRepo.RecordStaked(event)
+
+	case fariTokenABI.Events["UnstakeRequested"].ID:
+		// UnstakeRequested(address indexed user, uint256 amount, uint256 availableAt)
+		var data struct {
+			Amount      *big.Int
+			AvailableAt *big.Int
+		}
+		if err := fariTokenABI.UnpackIntoInterface(&data, "UnstakeRequested", log.Data); err != nil {
+			return err
+		}
+		availableAt := time.Unix(data.AvailableAt.Int64(), 0)
+		event.Type = models.StakeEventUnstakeRequested
+		event.Amount = data.Amount.String()
+		event.AvailableAt = &availableAt
+		err = s.stakingRepo.RecordUnstakeRequested(event)
+
+	case fariTokenABI.Events["Unstaked"].ID:
+		// Unstaked(address indexed user, uint256 amount, uint256 totalStaked)
+		var data struct {
+			Amount      *big.Int
+			TotalStaked *big.Int
+		}
+		if err := fariTokenABI.UnpackIntoInterface(&data, "Unstaked", log.Data); err != nil {
+			return err
+		}
+		event.Type = models.StakeEventUnstaked
+		event.Amount = data.Amount.String()
+		event.Balance = data.TotalStaked.String()
+		err = s.stakingRepo.RecordUnstaked(event)
+
+	case fariTokenABI.Events["VestingScheduleCreated"].ID:
+		// VestingScheduleCreated(address indexed beneficiary, uint256 amount, uint256 duration, uint256 cliff)
+		var data struct {
+			Amount   *big.Int
+			Duration *big.Int
+			Cliff    *big.Int
+		}
+		if err := fariTokenABI.UnpackIntoInterface(&data, "VestingScheduleCreated", log.Data); err != nil {
+			return err
+		}
+		event.Type = models.StakeEventVestingCreated
+		event.Amount = data.Amount.String()
+		err = s.stakingRepo.RecordVestingCreated(event, &models.VestingSchedule{
+			Beneficiary: event.Address,
+			TotalAmount: data.Amount.String(),
+			Released:    "0",
+			StartTime:   blockTime,
+			Duration:    data.Duration.Int64(),
+			Cliff:       data.Cliff.Int64(),
+			TxHash:      event.TxHash,
+			BlockNumber: event.BlockNumber,
+		})
+
+	case fariTokenABI.Events["TokensReleased"].ID:
+		// TokensReleased(address indexed beneficiary, uint256 amount)
+		var data struct {
+			Amount *big.Int
+		}
+		if err := fariTokenABI.UnpackIntoInterface(&data, "TokensReleased", log.Data); err != nil {
+			return err
+		}
+		event.Type = models.StakeEventTokensReleased
+		event.Amount = data.Amount.String()
+		err = s.stakingRepo.RecordTokensReleased(event)
+
+	default:
+		return nil
+	}
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil // Already indexed
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("FARI %s: Address=%s, Amount=%s", event.Type, event.Address, event.Amount)
+	if event.Type != models.StakeEventVestingCreated && event.Type != models.StakeEventTokensReleased {
+		s.rankingService.RefreshAccount(event.Address)
+	}
+	return nil
+}
+
+// parseWei parses a wei string, treating malformed values as zero.
+func parseWei(value string) *big.Int {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return n
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fariima/backend/internal/config"
+)
+
+// Token is a stablecoin escrows can be paid in. Amounts are stored in its
+// base units (the "wei string" of an escrow).
+type Token struct {
+	Symbol   string `json:"symbol"`
+	Address  string `json:"address"`
+	Decimals int    `json:"decimals"`
+}
+
+// TokenAmount is an amount of one token, exact in base units and formatted
+// in whole tokens.
+type TokenAmount struct {
+	Token  string `json:"token"`  // Address
+	Symbol string `json:"symbol"` // Empty for unknown tokens
+	Amount string `json:"amount"` // Base units
+	Value  string `json:"value"`  // Whole tokens, e.g. "1250.5"; empty for unknown tokens
+}
+
+// TokenRegistry resolves payment token addresses from STABLECOINS.
+type TokenRegistry struct {
+	tokens map[string]Token // By lowercase address
+}
+
+func NewTokenRegistry(cfg *config.Config) (*TokenRegistry, error) {
+	r := &TokenRegistry{tokens: map[string]Token{}}
+	for _, entry := range cfg.Stablecoins {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 || !common.IsHexAddress(parts[1]) {
+			return nil, fmt.Errorf("invalid STABLECOINS entry %q, expected SYMBOL:address:decimals", entry)
+		}
+		decimals, err := strconv.Atoi(parts[2])
+		if err != nil || decimals < 0 || decimals > 36 {
+			return nil, fmt.Errorf("invalid decimals in STABLECOINS entry %q", entry)
+		}
+
+		address := common.HexToAddress(parts[1]).Hex()
+		r.tokens[strings.ToLower(address)] = Token{Symbol: parts[0], Address: address, Decimals: decimals}
+	}
+	return r, nil
+}
+
+func (r *TokenRegistry) Lookup(address string) (Token, bool) {
+	token, ok := r.tokens[strings.ToLower(address)]
+	return token, ok
+}
+
+// Amount describes amount of the token at address.
+func (r *TokenRegistry) Amount(address string, amount *big.Int) TokenAmount {
+	result := TokenAmount{Token: address, Amount: amount.String()}
+	if token, ok := r.Lookup(address); ok {
+		result.Token = token.Address
+		result.Symbol = token.Symbol
+		result.Value = formatUnits(amount, token.Decimals)
+	}
+	return result
+}
+
+// Amounts describes per-token totals, sorted by symbol.
+func (r *TokenRegistry) Amounts(totals map[string]*big.Int) []TokenAmount {
+	amounts := make([]TokenAmount, 0, len(totals))
+	for address, amount := range totals {
+		amounts = append(amounts, r.Amount(address, amount))
+	}
+	sort.Slice(amounts, func(i, j int) bool {
+		if amounts[i].Symbol != amounts[j].Symbol {
+			return amounts[i].Symbol < amounts[j].Symbol
+		}
+		return amounts[i].Token < amounts[j].Token
+	})
+	return amounts
+}
+
+// USDValue sums amounts of known stablecoins at par. Unknown tokens are
+// left out.
+func (r *TokenRegistry) USDValue(totals map[string]*big.Int) float64 {
+	sum := new(big.Rat)
+	for address, amount := range totals {
+		token, ok := r.Lookup(address)
+		if !ok {
+			continue
+		}
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(token.Decimals)), nil)
+		sum.Add(sum, new(big.Rat).SetFrac(amount, scale))
+	}
+	value, _ := sum.Float64()
+	return value
+}
+
+// formatUnits renders base units as a decimal number of whole tokens,
+// without trailing zeros.
+func formatUnits(amount *big.Int, decimals int) string {
+	if decimals == 0 {
+		return amount.String()
+	}
+
+	digits := new(big.Int).Abs(amount).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+
+	result := whole
+	if fraction != "" {
+		result += "." + fraction
+	}
+	if amount.Sign() < 0 {
+		result = "-" + result
+	}
+	return result
+}
+
+// parseAmount parses a base-unit amount as stored in escrows and events.
+func parseAmount(s string) (*big.Int, bool) {
+	return new(big.Int).SetString(strings.TrimSpace(s), 10)
+}
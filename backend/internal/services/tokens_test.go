@@ -0,0 +1,64 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     string
+	}{
+		{"0", 6, "0"},
+		{"1", 6, "0.000001"},
+		{"999999", 6, "0.999999"},
+		{"1000000", 6, "1"},
+		{"1500000", 6, "1.5"},
+		{"1234567890", 6, "1234.56789"},
+		{"100000000000000000000", 18, "100"},
+		{"123456789012345678901234567890", 18, "123456789012.34567890123456789"},
+		{"-1500000", 6, "-1.5"},
+		{"-1", 6, "-0.000001"},
+		{"42", 0, "42"},
+		{"-42", 0, "-42"},
+		{"5", 1, "0.5"},
+	}
+
+	for _, tt := range tests {
+		amount, ok := new(big.Int).SetString(tt.amount, 10)
+		if !ok {
+			t.Fatalf("bad amount %q", tt.amount)
+		}
+		if got := formatUnits(amount, tt.decimals); got != tt.want {
+			t.Errorf("formatUnits(%s, %d) = %q, want %q", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"1000000", "1000000", true},
+		{" 42 ", "42", true},
+		{"0", "0", true},
+		{"1.5", "", false},
+		{"0x10", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseAmount(tt.input)
+		if ok != tt.ok {
+			t.Errorf("parseAmount(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && got.String() != tt.want {
+			t.Errorf("parseAmount(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/fariima/backend/internal/models"
+	"github.com/fariima/backend/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxUploadSize is the largest file any upload purpose accepts.
+const MaxUploadSize = 100 << 20 // 100 MB
+
+// sniffLen is how much content http.DetectContentType looks at.
+const sniffLen = 512
+
+// uploadPolicy limits the size and sniffed content type of an upload.
+// Private uploads are encrypted and only readable by the parties of the
+// project or dispute they belong to.
+type uploadPolicy struct {
+	maxSize int64
+	types   []string
+	private bool
+}
+
+var (
+	imageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+	mediaTypes = []string{"video/mp4", "video/webm", "audio/mpeg", "audio/wave"}
+)
+
+var uploadPolicies = map[models.UploadPurpose]uploadPolicy{
+	models.UploadPurposeAvatar: {
+		maxSize: 5 << 20,
+		types:   imageTypes,
+	},
+	models.UploadPurposeResume: {
+		maxSize: 10 << 20,
+		types:   []string{"application/pdf", "text/plain"},
+	},
+	models.UploadPurposeEvidence: {
+		maxSize: MaxEvidenceSize,
+		types:   concat(imageTypes, mediaTypes, []string{"application/pdf", "text/plain", "application/zip"}),
+		private: true,
+	},
+	models.UploadPurposeAttachment: {
+		maxSize: MaxAttachmentSize,
+		types:   concat(imageTypes, mediaTypes, []string{"application/pdf", "text/plain", "application/zip"}),
+	},
+	models.UploadPurposeDeliverable: {
+		maxSize: MaxUploadSize,
+		types: concat(imageTypes, mediaTypes, []string{
+			"application/pdf", "text/plain", "application/zip", "application/x-gzip", "application/x-rar-compressed",
+		}),
+		private: true,
+	},
+}
+
+// UploadInput describes a file being uploaded. Evidence belongs to a dispute
+// and deliverables to a project. Evidence and message attachments are
+// uploaded through DisputeService and MessageService, which record where
+// they are used.
+type UploadInput struct {
+	Purpose   models.UploadPurpose
+	FileName  string
+	ProjectID *uuid.UUID
+	DisputeID *uuid.UUID
+}
+
+// UploadUsage is a user's storage use against their quota.
+type UploadUsage struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+	Uploads    int64 `json:"uploads"`
+}
+
+// UploadService checks uploads against their purpose's policy and the
+// owner's quota before storing them.
+type UploadService struct {
+	cfg                *config.Config
+	uploadRepo         *repositories.UploadRepository
+	ipfsService        *IPFSService
+	privateFileService *PrivateFileService
+	logger             *logrus.Logger
+}
+
+func NewUploadService(
+	cfg *config.Config,
+	uploadRepo *repositories.UploadRepository,
+	ipfsService *IPFSService,
+	privateFileService *PrivateFileService,
+	logger *logrus.Logger,
+) *UploadService {
+	return &UploadService{
+		cfg:                cfg,
+		uploadRepo:         uploadRepo,
+		ipfsService:        ipfsService,
+		privateFileService: privateFileService,
+		logger:             logger,
+	}
+}
+
+func (s *UploadService) quota() int64 {
+	return int64(s.cfg.UploadQuotaMB) << 20
+}
+
+// Upload checks r's type and size and screens it to a temporary file, then
+// stores it. The content is spooled to disk rather than memory so the
+// storage backend can rewind it for retries and replication. Files the
+// malware scanner flags are stored quarantined for a moderator to review.
+func (s *UploadService) Upload(ctx context.Context, ownerID uuid.UUID, input UploadInput, r io.Reader) (*models.Upload, error) {
+	purpose := input.Purpose
+	policy, ok := uploadPolicies[purpose]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown upload purpose %q", ErrInvalidInput, purpose)
+	}
+
+	if purpose == models.UploadPurposeEvidence && input.DisputeID == nil {
+		return nil, fmt.Errorf("%w: evidence uploads require a dispute", ErrInvalidInput)
+	}
+	if purpose == models.UploadPurposeDeliverable && input.ProjectID == nil {
+		return nil, fmt.Errorf("%w: deliverable uploads require a project", ErrInvalidInput)
+	}
+
+	var grantees map[uuid.UUID]models.FileGrantRole
+	if policy.private {
+		var err error
+		grantees, err = s.privateFileService.Grantees(ownerID, FileScope{ProjectID: input.ProjectID, DisputeID: input.DisputeID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	used, _, err := s.uploadRepo.Usage(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := s.quota() - used
+	if remaining <= 0 {
+		return nil, fmt.Errorf("%w: storage quota of %d MB exceeded", ErrTooLarge, s.cfg.UploadQuotaMB)
+	}
+	limit := policy.maxSize
+	if remaining < limit {
+		limit = remaining
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidInput)
+	}
+
+	mimeType := sniffContentType(head)
+	if !containsString(policy.types, mimeType) {
+		return nil, fmt.Errorf("%w: %s files are not allowed for %s uploads", ErrInvalidInput, mimeType, purpose)
+	}
+
+	// The content is cut off one byte past the limit, before being screened,
+	// so oversized files are told apart from malformed ones
+	limited := &io.LimitedReader{R: io.MultiReader(bytes.NewReader(head), r), N: limit + 1}
+	screened, err := s.ipfsService.Screen(ctx, limited)
+	if limited.N == 0 {
+		if err == nil {
+			screened.Close()
+		}
+		if limit < policy.maxSize {
+			return nil, fmt.Errorf("%w: storage quota of %d MB exceeded", ErrTooLarge, s.cfg.UploadQuotaMB)
+		}
+		return nil, fmt.Errorf("%w: %s uploads are limited to %d bytes", ErrTooLarge, purpose, policy.maxSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer screened.Close()
+
+	upload := &models.Upload{
+		OwnerID:     ownerID,
+		FileName:    input.FileName,
+		MimeType:    mimeType,
+		Size:        screened.Size,
+		ContentHash: screened.ContentHash,
+		Purpose:     purpose,
+		Private:     policy.private,
+		ScanStatus:  models.ScanStatusClean,
+	}
+	if screened.Scan.Infected {
+		upload.ScanStatus = models.ScanStatusQuarantined
+		upload.ScanSignature = screened.Scan.Signature
+	}
+
+	// The quota is checked again while reserving, since other uploads by the
+	// same user may have finished in the meantime
+	reserved, err := s.uploadRepo.Reserve(upload, s.quota())
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return nil, fmt.Errorf("%w: storage quota of %d MB exceeded", ErrTooLarge, s.cfg.UploadQuotaMB)
+	}
+
+	cid, err := s.store(ctx, upload, input, screened, grantees)
+	if err != nil {
+		upload.PinStatus = models.PinStatusFailed
+		if updateErr := s.uploadRepo.Update(upload); updateErr != nil {
+			s.logger.Errorf("Failed to mark upload %s as failed: %v", upload.ID, updateErr)
+		}
+		return nil, err
+	}
+
+	upload.CID = cid
+	upload.PinStatus = models.PinStatusPinned
+	if err := s.uploadRepo.Update(upload); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// store stores the upload, encrypted if it is private or quarantined.
+// Quarantined files get no grants, so only moderators can read them.
+func (s *UploadService) store(ctx context.Context, upload *models.Upload, input UploadInput, r io.ReadSeeker, grantees map[uuid.UUID]models.FileGrantRole) (string, error) {
+	quarantined := upload.ScanStatus == models.ScanStatusQuarantined
+	if !upload.Private && !quarantined {
+		return s.ipfsService.UploadReader(ctx, upload.FileName, r)
+	}
+	if quarantined {
+		grantees = nil
+	}
+
+	file := &models.PrivateFile{
+		OwnerID:   upload.OwnerID,
+		ProjectID: input.ProjectID,
+		DisputeID: input.DisputeID,
+		FileName:  upload.FileName,
+		MimeType:  upload.MimeType,
+		Size:      upload.Size,
+	}
+	if err := s.privateFileService.Store(ctx, file, r, grantees); err != nil {
+		return "", err
+	}
+	return file.CID, nil
+}
+
+func (s *UploadService) ListUploads(ownerID uuid.UUID, limit, offset int) ([]models.Upload, int64, error) {
+	return s.uploadRepo.ListByOwner(ownerID, limit, offset)
+}
+
+func (s *UploadService) GetUsage(ownerID uuid.UUID) (*UploadUsage, error) {
+	used, count, err := s.uploadRepo.Usage(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadUsage{UsedBytes: used, QuotaBytes: s.quota(), Uploads: count}, nil
+}
+
+// ReviewDecision is a moderator's verdict on a quarantined upload.
+type ReviewDecision string
+
+const (
+	ReviewRelease ReviewDecision = "release"
+	ReviewReject  ReviewDecision = "reject"
+)
+
+// ListQuarantined returns the uploads awaiting review, oldest first.
+func (s *UploadService) ListQuarantined(limit, offset int) ([]models.Upload, int64, error) {
+	return s.uploadRepo.ListQuarantined(limit, offset)
+}
+
+// OpenQuarantined decrypts a quarantined upload for a moderator to inspect.
+// The caller closes the content.
+func (s *UploadService) OpenQuarantined(ctx context.Context, uploadID uuid.UUID) (*FileContent, error) {
+	upload, err := s.getQuarantined(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.privateFileService.OpenForReview(ctx, upload.CID)
+}
+
+// ReviewUpload records a moderator's decision on a quarantined upload.
+// Released files are shared as if they had passed the scan: private ones
+// with the parties of their project or dispute, public ones stored again in
+// the clear under a new CID. Evidence and message attachments stored by the
+// upload become readable. Rejected files are unpinned and stop counting
+// toward the owner's quota.
+func (s *UploadService) ReviewUpload(ctx context.Context, uploadID, moderatorID uuid.UUID, decision ReviewDecision, note string) (*models.Upload, error) {
+	upload, err := s.getQuarantined(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.privateFileService.Get(upload.CID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch decision {
+	case ReviewRelease:
+		if err := s.release(ctx, upload, file); err != nil {
+			return nil, err
+		}
+		upload.ScanStatus = models.ScanStatusReleased
+		if err := s.uploadRepo.ReleaseReferences(upload, s.ipfsService.GetFileURL(upload.CID)); err != nil {
+			return nil, err
+		}
+	case ReviewReject:
+		if err := s.privateFileService.Delete(ctx, file); err != nil {
+			return nil, err
+		}
+		upload.ScanStatus = models.ScanStatusRejected
+		upload.PinStatus = models.PinStatusUnpinned
+	default:
+		return nil, fmt.Errorf("%w: decision must be %q or %q", ErrInvalidInput, ReviewRelease, ReviewReject)
+	}
+
+	now := time.Now()
+	upload.ReviewedByID = &moderatorID
+	upload.ReviewedAt = &now
+	upload.ReviewNote = note
+	if err := s.uploadRepo.Update(upload); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Moderator %s marked upload %s as %s", moderatorID, upload.ID, upload.ScanStatus)
+	return upload, nil
+}
+
+func (s *UploadService) release(ctx context.Context, upload *models.Upload, file *models.PrivateFile) error {
+	if upload.Private {
+		grantees, err := s.privateFileService.Grantees(upload.OwnerID, FileScope{ProjectID: file.ProjectID, DisputeID: file.DisputeID})
+		if err != nil {
+			return err
+		}
+		return s.privateFileService.Share(file, grantees)
+	}
+
+	content, err := s.privateFileService.OpenForReview(ctx, file.CID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	cid, err := s.ipfsService.UploadReader(ctx, upload.FileName, content)
+	if err != nil {
+		return err
+	}
+	if err := s.privateFileService.Delete(ctx, file); err != nil {
+		s.logger.Errorf("Failed to delete the quarantined copy of upload %s: %v", upload.ID, err)
+	}
+	upload.CID = cid
+	return nil
+}
+
+func (s *UploadService) getQuarantined(uploadID uuid.UUID) (*models.Upload, error) {
+	upload, err := s.uploadRepo.GetByID(uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: upload not found", ErrNotFound)
+	}
+	if upload.ScanStatus != models.ScanStatusQuarantined || upload.PinStatus != models.PinStatusPinned {
+		return nil, fmt.Errorf("%w: upload is not quarantined", ErrConflict)
+	}
+	return upload, nil
+}
+
+// sniffContentType returns the media type detected from the content's magic
+// bytes, without parameters such as charset.
+func sniffContentType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// servableContentType is the sniffed type of content served back to
+// browsers. Types no upload purpose accepts, such as HTML or SVG that could
+// run scripts, are served as opaque binary.
+func servableContentType(head []byte) string {
+	mimeType := sniffContentType(head)
+	for _, policy := range uploadPolicies {
+		if containsString(policy.types, mimeType) {
+			return mimeType
+		}
+	}
+	return "application/octet-stream"
+}
+
+func concat(lists ...[]string) []string {
+	var result []string
+	for _, list := range lists {
+		result = append(result, list...)
+	}
+	return result
+}
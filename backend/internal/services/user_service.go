@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/fariima/backend/internal/mailer"
 	"github.com/fariima/backend/internal/models"
 	"github.com/fariima/backend/internal/repositories"
 	"github.com/google/uuid"
@@ -87,6 +88,9 @@ func (s *UserService) UpdateProfile(id uuid.UUID, updates map[string]interface{}
 	if website, ok := updates["website"].(string); ok {
 		user.Website = website
 	}
+	if locale, ok := updates["locale"].(string); ok {
+		user.Locale = mailer.NormalizeLocale(locale)
+	}
 
 	if err := s.userRepo.Update(user); err != nil {
 		return nil, err
@@ -2,16 +2,35 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
 	"sync"
+	"time"
 
+	"github.com/fariima/backend/internal/metrics"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
+// TopicDisputes streams redacted dispute updates to anonymous subscribers.
+const TopicDisputes = "disputes"
+
+// PublicTopics are the topics anyone may subscribe to without logging in.
+var PublicTopics = map[string]bool{
+	TopicDisputes: true,
+}
+
+// wsWriteWait bounds a single write, so a stalled client cannot hold up
+// anything else.
+const (
+	wsWriteWait  = 10 * time.Second
+	wsSendBuffer = 64
+)
+
 type WebSocketService struct {
-	clients map[string]*websocket.Conn
-	mu      sync.RWMutex
-	logger  *logrus.Logger
+	clients     map[string]*wsConn
+	subscribers map[string]map[*websocket.Conn]*wsConn // Topic -> connections
+	mu          sync.RWMutex
+	logger      *logrus.Logger
 }
 
 type WSMessage struct {
@@ -19,60 +38,184 @@ type WSMessage struct {
 	Payload interface{} `json:"payload"`
 }
 
+// wsConn owns the writes to one connection. A connection may not be written
+// to concurrently, so messages are queued and written by one goroutine. A
+// client that falls wsSendBuffer messages behind is disconnected instead of
+// slowing down the sender.
+type wsConn struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+func newWSConn(conn *websocket.Conn, logger *logrus.Logger) *wsConn {
+	c := &wsConn{conn: conn, send: make(chan []byte, wsSendBuffer)}
+	go c.writeLoop(logger)
+	return c
+}
+
+func (c *wsConn) writeLoop(logger *logrus.Logger) {
+	for data := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			logger.Debugf("Closing WebSocket connection after a failed write: %v", err)
+			// The handler's read loop sees the close and removes the
+			// connection, which ends this loop.
+			c.conn.Close()
+			for range c.send {
+			}
+			return
+		}
+	}
+}
+
+// queue hands a message to the writer without blocking, disconnecting the
+// client if its queue is full. The caller must hold the service's lock, as
+// the queue is closed under it.
+func (c *wsConn) queue(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	default:
+		c.conn.Close()
+		return false
+	}
+}
+
 func NewWebSocketService(logger *logrus.Logger) *WebSocketService {
 	return &WebSocketService{
-		clients: make(map[string]*websocket.Conn),
-		logger:  logger,
+		clients:     make(map[string]*wsConn),
+		subscribers: make(map[string]map[*websocket.Conn]*wsConn),
+		logger:      logger,
 	}
 }
 
+// AddClient registers the user's connection, replacing and closing any
+// previous one.
 func (s *WebSocketService) AddClient(userID string, conn *websocket.Conn) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.clients[userID] = conn
+	if previous, ok := s.clients[userID]; ok {
+		close(previous.send)
+		previous.conn.Close()
+	}
+	s.clients[userID] = newWSConn(conn, s.logger)
 	s.logger.Infof("WebSocket client added: %s", userID)
 }
 
-func (s *WebSocketService) RemoveClient(userID string) {
+// RemoveClient removes the user's connection if it is still conn.
+func (s *WebSocketService) RemoveClient(userID string, conn *websocket.Conn) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if conn, ok := s.clients[userID]; ok {
-		conn.Close()
+	if client, ok := s.clients[userID]; ok && client.conn == conn {
+		close(client.send)
+		client.conn.Close()
 		delete(s.clients, userID)
 		s.logger.Infof("WebSocket client removed: %s", userID)
 	}
 }
 
 func (s *WebSocketService) SendToUser(userID string, message WSMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
 	s.mu.RLock()
-	conn, ok := s.clients[userID]
-	s.mu.RUnlock()
+	defer s.mu.RUnlock()
 
+	client, ok := s.clients[userID]
 	if !ok {
 		return nil // User not connected
 	}
+	if !client.queue(data) {
+		return fmt.Errorf("client %s is not keeping up and was disconnected", userID)
+	}
+	return nil
+}
 
+func (s *WebSocketService) Broadcast(message WSMessage) {
 	data, err := json.Marshal(message)
 	if err != nil {
-		return err
+		s.logger.Errorf("Failed to marshal broadcast message: %v", err)
+		return
 	}
 
-	return conn.WriteMessage(websocket.TextMessage, data)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for userID, client := range s.clients {
+		if !client.queue(data) {
+			s.logger.Warnf("Disconnected WebSocket client %s: not keeping up", userID)
+		}
+	}
 }
 
-func (s *WebSocketService) Broadcast(message WSMessage) {
+// RegisterMetrics exports the number of user connections and of subscribers
+// to each public topic.
+func (s *WebSocketService) RegisterMetrics() {
+	const name, help = "fariima_websocket_connections", "Open WebSocket connections, of logged-in users or by public topic."
+
+	metrics.NewGaugeFunc(name, help, metrics.Labels{"kind": "user"}, func() float64 {
+		return float64(s.ClientCount())
+	})
+	for topic := range PublicTopics {
+		topic := topic
+		metrics.NewGaugeFunc(name, help, metrics.Labels{"kind": "topic", "topic": topic}, func() float64 {
+			return float64(s.SubscriberCount(topic))
+		})
+	}
+}
+
+// ClientCount returns the number of connected users.
+func (s *WebSocketService) ClientCount() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return len(s.clients)
+}
 
+// SubscriberCount returns the number of connections subscribed to a topic.
+func (s *WebSocketService) SubscriberCount(topic string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subscribers[topic])
+}
+
+func (s *WebSocketService) Subscribe(topic string, conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[topic] == nil {
+		s.subscribers[topic] = make(map[*websocket.Conn]*wsConn)
+	}
+	if _, ok := s.subscribers[topic][conn]; !ok {
+		s.subscribers[topic][conn] = newWSConn(conn, s.logger)
+	}
+}
+
+func (s *WebSocketService) Unsubscribe(topic string, conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if subscriber, ok := s.subscribers[topic][conn]; ok {
+		close(subscriber.send)
+		delete(s.subscribers[topic], conn)
+	}
+}
+
+// Publish queues a message for every subscriber of a topic and returns
+// without waiting for the writes. Subscribers that are not keeping up are
+// disconnected.
+func (s *WebSocketService) Publish(topic string, message WSMessage) {
 	data, err := json.Marshal(message)
 	if err != nil {
-		s.logger.Errorf("Failed to marshal broadcast message: %v", err)
+		s.logger.Errorf("Failed to marshal %s message: %v", topic, err)
 		return
 	}
 
-	for userID, conn := range s.clients {
-		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-			s.logger.Errorf("Failed to send message to %s: %v", userID, err)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, subscriber := range s.subscribers[topic] {
+		if !subscriber.queue(data) {
+			s.logger.Debugf("Dropping %s subscriber: not keeping up", topic)
 		}
 	}
 }
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Cache keeps fetched content in a local directory and evicts the least
+// recently used files once it grows past its size limit. Content is
+// immutable, so cached files never need revalidating.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List // Most recently used first
+	entries map[string]*list.Element
+	size    int64
+}
+
+type cacheEntry struct {
+	cid  string
+	size int64
+}
+
+// NewCache opens the cache in dir, picking up files left by a previous run
+// in order of modification time.
+func NewCache(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  map[string]*list.Element{},
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []os.FileInfo
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if !validCID.MatchString(info.Name()) {
+			// Leftover temporary file
+			os.Remove(filepath.Join(dir, info.Name()))
+			continue
+		}
+		existing = append(existing, info)
+	}
+	sort.Slice(existing, func(i, j int) bool {
+		return existing[i].ModTime().After(existing[j].ModTime())
+	})
+	for _, info := range existing {
+		c.entries[info.Name()] = c.lru.PushBack(&cacheEntry{cid: info.Name(), size: info.Size()})
+		c.size += info.Size()
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+
+	return c, nil
+}
+
+// Open returns the cached content of cid, fetching it first on a miss.
+// Content larger than maxSize is not fetched past the limit and fails with
+// ErrTooLarge. The caller closes the file.
+func (c *Cache) Open(ctx context.Context, cid string, maxSize int64, fetch func(ctx context.Context, cid string) (io.ReadCloser, error)) (*os.File, error) {
+	if !validCID.MatchString(cid) {
+		return nil, ErrNotFound
+	}
+	path := filepath.Join(c.dir, cid)
+
+	c.mu.Lock()
+	if elem, ok := c.entries[cid]; ok {
+		file, err := os.Open(path)
+		if err == nil {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			return file, nil
+		}
+		c.remove(elem)
+	}
+	c.mu.Unlock()
+
+	body, err := fetch(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(c.dir, ".fetch-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSize {
+		return nil, ErrTooLarge
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	// Opened before evicting, so the file stays readable even if it alone
+	// exceeds the cache
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if elem, ok := c.entries[cid]; ok {
+		// Fetched concurrently by another request
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[cid] = c.lru.PushFront(&cacheEntry{cid: cid, size: size})
+		c.size += size
+	}
+	c.evict()
+
+	return file, nil
+}
+
+// evict removes the least recently used files until the cache fits. The
+// caller holds c.mu.
+func (c *Cache) evict() {
+	for c.size > c.maxBytes && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.cid)
+	c.size -= entry.size
+	os.Remove(filepath.Join(c.dir, entry.cid))
+}
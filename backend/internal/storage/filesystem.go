@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// validCID guards filesystem paths; CIDs are base32 (v1) or base58 (v0).
+var validCID = regexp.MustCompile(`^[a-zA-Z0-9]{32,128}$`)
+
+var cidEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// FilesystemStorage keeps content in a local directory under CIDs computed
+// locally, for development and tests. CIDs are CIDv1 with the raw codec and
+// a SHA-256 multihash. They match what IPFS assigns only to content that
+// fits in a single block (256 KiB), since larger files are chunked into a
+// DAG.
+type FilesystemStorage struct {
+	dir string
+}
+
+func NewFilesystemStorage(dir string) (*FilesystemStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FilesystemStorage{dir: dir}, nil
+}
+
+func (s *FilesystemStorage) Name() string {
+	return "filesystem"
+}
+
+func (s *FilesystemStorage) Put(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), r); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	cid := RawCIDv1(hash.Sum(nil))
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, cid)); err != nil {
+		return "", err
+	}
+	return cid, nil
+}
+
+func (s *FilesystemStorage) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	if !validCID.MatchString(cid) {
+		return nil, ErrNotFound
+	}
+
+	file, err := os.Open(filepath.Join(s.dir, cid))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *FilesystemStorage) IsPinned(ctx context.Context, cid string) (bool, error) {
+	if !validCID.MatchString(cid) {
+		return false, nil
+	}
+
+	_, err := os.Stat(filepath.Join(s.dir, cid))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Pin only succeeds for content already stored, since the filesystem
+// backend cannot fetch from the network.
+func (s *FilesystemStorage) Pin(ctx context.Context, cid string) error {
+	pinned, err := s.IsPinned(ctx, cid)
+	if err != nil {
+		return err
+	}
+	if !pinned {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *FilesystemStorage) Unpin(ctx context.Context, cid string) error {
+	if !validCID.MatchString(cid) {
+		return nil
+	}
+
+	err := os.Remove(filepath.Join(s.dir, cid))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RawCIDv1 returns the base32 CIDv1 of a SHA-256 digest of raw content:
+// multibase "b", version 1, codec raw (0x55), multihash sha2-256 (0x12, 32
+// bytes).
+func RawCIDv1(digest []byte) string {
+	cid := append([]byte{0x01, 0x55, 0x12, 0x20}, digest...)
+	return "b" + strings.ToLower(cidEncoding.EncodeToString(cid))
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KuboStorage adds and pins content on a Kubo (go-ipfs) node through its
+// HTTP RPC API.
+type KuboStorage struct {
+	apiURL string
+	client *http.Client
+}
+
+func NewKuboStorage(apiURL string, client *http.Client) *KuboStorage {
+	return &KuboStorage{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		client: client,
+	}
+}
+
+func (s *KuboStorage) Name() string {
+	return "kubo"
+}
+
+func (s *KuboStorage) Put(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	body, contentType := multipartBody(filename, r, nil)
+	defer body.Close()
+
+	resp, err := s.call(ctx, "add", url.Values{"cid-version": {"1"}, "pin": {"true"}}, body, contentType)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Hash string `json:"Hash"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Hash, nil
+}
+
+func (s *KuboStorage) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	resp, err := s.call(ctx, "cat", url.Values{"arg": {cid}}, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *KuboStorage) IsPinned(ctx context.Context, cid string) (bool, error) {
+	resp, err := s.call(ctx, "pin/ls", url.Values{"arg": {cid}, "type": {"recursive"}}, nil, "")
+	var rpcErr *KuboError
+	if errors.As(err, &rpcErr) && strings.Contains(rpcErr.Message, "not pinned") {
+		return false, nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// Pin fetches the content from the IPFS network if the node does not have
+// it, which can take as long as ctx allows.
+func (s *KuboStorage) Pin(ctx context.Context, cid string) error {
+	resp, err := s.call(ctx, "pin/add", url.Values{"arg": {cid}}, nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *KuboStorage) Unpin(ctx context.Context, cid string) error {
+	resp, err := s.call(ctx, "pin/rm", url.Values{"arg": {cid}}, nil, "")
+	var rpcErr *KuboError
+	if errors.As(err, &rpcErr) && strings.Contains(rpcErr.Message, "not pinned") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// KuboError is an error reported by the Kubo RPC API.
+type KuboError struct {
+	Command string
+	Message string
+}
+
+func (e *KuboError) Error() string {
+	return fmt.Sprintf("kubo %s: %s", e.Command, e.Message)
+}
+
+// call invokes an RPC command. Kubo answers every call with POST and reports
+// errors as 500 with a JSON message.
+func (s *KuboStorage) call(ctx context.Context, command string, params url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL+"/api/v0/"+command+"?"+params.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var rpcErr struct {
+		Message string `json:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if json.Unmarshal(data, &rpcErr) != nil || rpcErr.Message == "" {
+		// Not from Kubo itself, e.g. a proxy in front of it
+		return nil, &StatusError{Backend: s.Name(), StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if isKuboNotFound(rpcErr.Message) {
+		return nil, ErrNotFound
+	}
+	return nil, &KuboError{Command: command, Message: rpcErr.Message}
+}
+
+func isKuboNotFound(message string) bool {
+	return strings.Contains(message, "not found") || strings.Contains(message, "invalid path") || strings.Contains(message, "invalid cid")
+}
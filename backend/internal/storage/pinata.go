@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PinataStorage pins content with Pinata and reads it back through the
+// gateway.
+type PinataStorage struct {
+	apiURL     string
+	gatewayURL string
+	apiKey     string
+	secretKey  string
+	client     *http.Client
+}
+
+func NewPinataStorage(apiURL, gatewayURL, apiKey, secretKey string, client *http.Client) *PinataStorage {
+	return &PinataStorage{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		gatewayURL: gatewayURL,
+		apiKey:     apiKey,
+		secretKey:  secretKey,
+		client:     client,
+	}
+}
+
+func (s *PinataStorage) Name() string {
+	return "pinata"
+}
+
+func (s *PinataStorage) Put(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	body, contentType := multipartBody(filename, r, map[string]string{
+		"pinataOptions":  `{"cidVersion":1}`,
+		"pinataMetadata": fmt.Sprintf(`{"name":%q}`, filename),
+	})
+	defer body.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL+"/pinning/pinFileToIPFS", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("pinata_api_key", s.apiKey)
+	req.Header.Set("pinata_secret_api_key", s.secretKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(s.Name(), resp)
+	}
+
+	var result struct {
+		IpfsHash string `json:"IpfsHash"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.IpfsHash, nil
+}
+
+func (s *PinataStorage) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.gatewayURL+cid, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, statusError(s.Name(), resp)
+	}
+}
+
+func (s *PinataStorage) IsPinned(ctx context.Context, cid string) (bool, error) {
+	query := url.Values{"hashContains": {cid}, "status": {"pinned"}, "pageLimit": {"1"}}
+	resp, err := s.do(ctx, http.MethodGet, "/data/pinList?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, statusError(s.Name(), resp)
+	}
+
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+	return result.Count > 0, nil
+}
+
+// Pin queues a pin by CID. Pinata searches the IPFS network for the content
+// in the background, so the pin may not be complete when Pin returns.
+func (s *PinataStorage) Pin(ctx context.Context, cid string) error {
+	body, _ := json.Marshal(map[string]string{"hashToPin": cid})
+	resp, err := s.do(ctx, http.MethodPost, "/pinning/pinByHash", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(s.Name(), resp)
+	}
+	return nil
+}
+
+func (s *PinataStorage) Unpin(ctx context.Context, cid string) error {
+	resp, err := s.do(ctx, http.MethodDelete, "/pinning/unpin/"+url.PathEscape(cid), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return statusError(s.Name(), resp)
+	}
+	return nil
+}
+
+// do sends an authenticated JSON request to the Pinata API.
+func (s *PinataStorage) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.apiURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("pinata_api_key", s.apiKey)
+	req.Header.Set("pinata_secret_api_key", s.secretKey)
+
+	return s.client.Do(req)
+}
+
+// multipartBody streams r as the "file" part of a multipart form, after the
+// given fields, without buffering it in memory. Closing the body stops the
+// goroutine writing it and waits for it, so r is no longer read once Close
+// returns and may be rewound for a retry.
+func multipartBody(filename string, r io.Reader, fields map[string]string) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	body := &multipartReader{PipeReader: pr, done: make(chan struct{})}
+
+	go func() {
+		defer close(body.done)
+		for name, value := range fields {
+			if err := writer.WriteField(name, value); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+
+		part, err := writer.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return body, writer.FormDataContentType()
+}
+
+type multipartReader struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+// Close fails the writer's pending and future writes and waits for it to
+// return. The transport may close the body too, so Close can be called more
+// than once.
+func (r *multipartReader) Close() error {
+	err := r.PipeReader.Close()
+	<-r.done
+	return err
+}
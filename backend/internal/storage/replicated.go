@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCIDMismatch is returned when backends store the same content under
+// different CIDs, e.g. because they chunk files differently.
+var ErrCIDMismatch = errors.New("backends disagree on the CID")
+
+// replicated writes to a primary backend and copies every write to the
+// replicas. A replica that is unavailable does not fail a write, but one that
+// stores the content under another CID does: reads fall back to the replicas
+// in order by the primary's CID, so they must agree on it.
+type replicated struct {
+	primary  Storage
+	replicas []Storage
+	logger   *logrus.Logger
+}
+
+func NewReplicated(primary Storage, replicas []Storage, logger *logrus.Logger) Storage {
+	return &replicated{
+		primary:  primary,
+		replicas: replicas,
+		logger:   logger,
+	}
+}
+
+func (s *replicated) Name() string {
+	return s.primary.Name()
+}
+
+func (s *replicated) Put(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	cid, err := s.primary.Put(ctx, filename, r)
+	if err != nil {
+		return "", err
+	}
+
+	for _, replica := range s.replicas {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+
+		replicaCID, err := replica.Put(ctx, filename, r)
+		if err != nil {
+			s.logger.Warnf("Failed to replicate %s to %s: %v", cid, replica.Name(), err)
+			continue
+		}
+		if replicaCID != cid {
+			// The copies are not unpinned: the same content may already be
+			// stored for another upload. Unreferenced pins are collected
+			// later.
+			return "", fmt.Errorf("%w: %s stored the content as %s, %s as %s",
+				ErrCIDMismatch, s.primary.Name(), cid, replica.Name(), replicaCID)
+		}
+	}
+
+	return cid, nil
+}
+
+func (s *replicated) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	body, err := s.primary.Get(ctx, cid)
+	if err == nil {
+		return body, nil
+	}
+
+	for _, replica := range s.replicas {
+		body, replicaErr := replica.Get(ctx, cid)
+		if replicaErr == nil {
+			return body, nil
+		}
+		if !errors.Is(replicaErr, ErrNotFound) {
+			s.logger.Warnf("Failed to read %s from %s: %v", cid, replica.Name(), replicaErr)
+		}
+	}
+
+	return nil, err
+}
+
+// IsPinned reports whether every backend keeps cid pinned.
+func (s *replicated) IsPinned(ctx context.Context, cid string) (bool, error) {
+	for _, backend := range s.backends() {
+		pinned, err := backend.IsPinned(ctx, cid)
+		if err != nil || !pinned {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Pin pins cid on every backend missing it. A backend that cannot find the
+// content by itself is given a copy from one that has it.
+func (s *replicated) Pin(ctx context.Context, cid string) error {
+	var missing, holders []Storage
+	for _, backend := range s.backends() {
+		pinned, err := backend.IsPinned(ctx, cid)
+		if err != nil {
+			return err
+		}
+		if pinned {
+			holders = append(holders, backend)
+		} else {
+			missing = append(missing, backend)
+		}
+	}
+
+	for _, backend := range missing {
+		err := backend.Pin(ctx, cid)
+		if err == nil {
+			continue
+		}
+		if len(holders) == 0 {
+			return err
+		}
+
+		s.logger.Warnf("Failed to pin %s on %s, copying it from %s: %v", cid, backend.Name(), holders[0].Name(), err)
+		if err := s.copy(ctx, cid, holders[0], backend); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *replicated) Unpin(ctx context.Context, cid string) error {
+	var firstErr error
+	for _, backend := range s.backends() {
+		if err := backend.Unpin(ctx, cid); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *replicated) backends() []Storage {
+	return append([]Storage{s.primary}, s.replicas...)
+}
+
+// copy stores cid from one backend on another, spooling it to a temporary
+// file so the destination can rewind it.
+func (s *replicated) copy(ctx context.Context, cid string, from, to Storage) error {
+	body, err := from.Get(ctx, cid)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "replica-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	copied, err := to.Put(ctx, cid, tmp)
+	if err != nil {
+		return err
+	}
+	if copied != cid {
+		return fmt.Errorf("%s stored %s as %s", to.Name(), cid, copied)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+)
+
+// retrying retries a backend's transient failures with exponential backoff.
+type retrying struct {
+	Storage
+	maxRetries int
+}
+
+// WithRetry wraps backend so that network errors, 429s and 5xx responses are
+// retried up to maxRetries times.
+func WithRetry(backend Storage, maxRetries int) Storage {
+	if maxRetries <= 0 {
+		return backend
+	}
+	return &retrying{Storage: backend, maxRetries: maxRetries}
+}
+
+func (s *retrying) Put(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	var cid string
+	err := s.retry(ctx, func() error {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		var err error
+		cid, err = s.Storage.Put(ctx, filename, r)
+		return err
+	})
+	return cid, err
+}
+
+func (s *retrying) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := s.retry(ctx, func() error {
+		var err error
+		body, err = s.Storage.Get(ctx, cid)
+		return err
+	})
+	return body, err
+}
+
+func (s *retrying) IsPinned(ctx context.Context, cid string) (bool, error) {
+	var pinned bool
+	err := s.retry(ctx, func() error {
+		var err error
+		pinned, err = s.Storage.IsPinned(ctx, cid)
+		return err
+	})
+	return pinned, err
+}
+
+func (s *retrying) Pin(ctx context.Context, cid string) error {
+	return s.retry(ctx, func() error {
+		return s.Storage.Pin(ctx, cid)
+	})
+}
+
+func (s *retrying) Unpin(ctx context.Context, cid string) error {
+	return s.retry(ctx, func() error {
+		return s.Storage.Unpin(ctx, cid)
+	})
+}
+
+func (s *retrying) retry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= s.maxRetries || !isTemporary(err) {
+			return err
+		}
+
+		delay := retryBaseDelay << attempt
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+		delay += time.Duration(rand.Int63n(int64(delay) / 2))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isTemporary(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fariima/backend/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrNotFound is returned when a backend does not have the content.
+	ErrNotFound = errors.New("content not found")
+
+	// ErrTooLarge is returned when content exceeds a size limit.
+	ErrTooLarge = errors.New("content too large")
+)
+
+// Storage stores immutable content under its IPFS CID. Implementations must
+// be safe for concurrent use.
+type Storage interface {
+	// Name identifies the backend in logs and errors.
+	Name() string
+
+	// Put stores content read from the start of r and returns its CID. r is
+	// rewound on retries and replication, so it must not be shared.
+	Put(ctx context.Context, filename string, r io.ReadSeeker) (string, error)
+
+	// Get streams the content of cid. The caller closes the reader.
+	Get(ctx context.Context, cid string) (io.ReadCloser, error)
+
+	// IsPinned reports whether the backend keeps cid pinned.
+	IsPinned(ctx context.Context, cid string) (bool, error)
+
+	// Pin pins content the backend can fetch by CID, e.g. from the IPFS
+	// network. It returns ErrNotFound if the content cannot be found.
+	Pin(ctx context.Context, cid string) error
+
+	// Unpin releases cid so the backend may garbage-collect it. Unpinning
+	// content that is not pinned is not an error.
+	Unpin(ctx context.Context, cid string) error
+}
+
+// New returns the storage selected by STORAGE_BACKENDS. The first backend is
+// the primary; writes are replicated to the others and reads fall back to
+// them. Every backend times out and retries each request on its own.
+func New(cfg *config.Config, logger *logrus.Logger) (Storage, error) {
+	// No client timeout: it would also cut off a download being streamed to
+	// a slow client. WithTimeout bounds each request instead.
+	client := &http.Client{}
+	timeout := time.Duration(cfg.StorageTimeoutSeconds) * time.Second
+
+	var backends []Storage
+	for _, name := range cfg.StorageBackends {
+		backend, err := newBackend(strings.TrimSpace(name), cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, WithRetry(WithTimeout(backend, timeout), cfg.StorageMaxRetries))
+	}
+
+	switch len(backends) {
+	case 0:
+		return nil, fmt.Errorf("no storage backend configured")
+	case 1:
+		return backends[0], nil
+	default:
+		return NewReplicated(backends[0], backends[1:], logger), nil
+	}
+}
+
+func newBackend(name string, cfg *config.Config, client *http.Client) (Storage, error) {
+	switch name {
+	case "pinata":
+		return NewPinataStorage(cfg.IPFSAPIUrl, cfg.IPFSGatewayURL, cfg.PinataAPIKey, cfg.PinataSecretKey, client), nil
+	case "kubo":
+		return NewKuboStorage(cfg.KuboAPIURL, client), nil
+	case "filesystem":
+		return NewFilesystemStorage(cfg.StorageDir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", name)
+	}
+}
+
+// StatusError is an unexpected HTTP response from a storage API.
+type StatusError struct {
+	Backend    string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Backend, e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the request may succeed if retried.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func statusError(backend string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &StatusError{Backend: backend, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+}
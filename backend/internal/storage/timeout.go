@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// timeouts bounds each request to a backend. Downloads are only bounded
+// until the backend starts sending the content; streaming it is bounded by
+// the caller's context, as a large file may take longer to reach a slow
+// client.
+type timeouts struct {
+	Storage
+	timeout time.Duration
+}
+
+// WithTimeout wraps backend so that every call fails after timeout. Wrap it
+// before WithRetry so each attempt gets the full timeout.
+func WithTimeout(backend Storage, timeout time.Duration) Storage {
+	if timeout <= 0 {
+		return backend
+	}
+	return &timeouts{Storage: backend, timeout: timeout}
+}
+
+func (s *timeouts) Put(ctx context.Context, filename string, r io.ReadSeeker) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Storage.Put(ctx, filename, r)
+}
+
+func (s *timeouts) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(s.timeout, cancel)
+
+	body, err := s.Storage.Get(ctx, cid)
+	if err != nil {
+		timer.Stop()
+		cancel()
+		return nil, err
+	}
+	if !timer.Stop() {
+		// Timed out just as the content arrived
+		body.Close()
+		return nil, context.DeadlineExceeded
+	}
+	return &cancelOnClose{ReadCloser: body, cancel: cancel}, nil
+}
+
+func (s *timeouts) IsPinned(ctx context.Context, cid string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Storage.IsPinned(ctx, cid)
+}
+
+func (s *timeouts) Pin(ctx context.Context, cid string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Storage.Pin(ctx, cid)
+}
+
+func (s *timeouts) Unpin(ctx context.Context, cid string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Storage.Unpin(ctx, cid)
+}
+
+// cancelOnClose releases a download's context once it has been read.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}